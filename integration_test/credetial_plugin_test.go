@@ -55,7 +55,7 @@ func TestCredentialPlugin(t *testing.T) {
 				svc := oidcserver.New(t, tc.keyPair, testconfig.Config{
 					Want: testconfig.Want{
 						Scope:               "openid",
-						RedirectURIPrefix:   "http://localhost:",
+						RedirectURIPrefix:   "http://127.0.0.1:",
 						CodeChallengeMethod: "S256",
 					},
 					Response: testconfig.Response{
@@ -81,7 +81,7 @@ func TestCredentialPlugin(t *testing.T) {
 				svc := oidcserver.New(t, tc.keyPair, testconfig.Config{
 					Want: testconfig.Want{
 						Scope:             "openid",
-						RedirectURIPrefix: "http://localhost:",
+						RedirectURIPrefix: "http://127.0.0.1:",
 						Username:          "USER1",
 						Password:          "PASS1",
 					},
@@ -114,7 +114,7 @@ func TestCredentialPlugin(t *testing.T) {
 					svc.SetConfig(testconfig.Config{
 						Want: testconfig.Want{
 							Scope:               "openid",
-							RedirectURIPrefix:   "http://localhost:",
+							RedirectURIPrefix:   "http://127.0.0.1:",
 							CodeChallengeMethod: "S256",
 						},
 						Response: testconfig.Response{
@@ -151,7 +151,7 @@ func TestCredentialPlugin(t *testing.T) {
 					svc.SetConfig(testconfig.Config{
 						Want: testconfig.Want{
 							Scope:             "openid",
-							RedirectURIPrefix: "http://localhost:",
+							RedirectURIPrefix: "http://127.0.0.1:",
 							RefreshToken:      "REFRESH_TOKEN_1",
 						},
 						Response: testconfig.Response{
@@ -175,7 +175,7 @@ func TestCredentialPlugin(t *testing.T) {
 					svc.SetConfig(testconfig.Config{
 						Want: testconfig.Want{
 							Scope:             "openid",
-							RedirectURIPrefix: "http://localhost:",
+							RedirectURIPrefix: "http://127.0.0.1:",
 							RefreshToken:      "REFRESH_TOKEN_2",
 						},
 						Response: testconfig.Response{
@@ -205,7 +205,7 @@ func TestCredentialPlugin(t *testing.T) {
 			svc := oidcserver.New(t, keypair.None, testconfig.Config{
 				Want: testconfig.Want{
 					Scope:               "openid",
-					RedirectURIPrefix:   "http://localhost:",
+					RedirectURIPrefix:   "http://127.0.0.1:",
 					CodeChallengeMethod: "",
 				},
 				Response: testconfig.Response{
@@ -230,7 +230,7 @@ func TestCredentialPlugin(t *testing.T) {
 			svc := oidcserver.New(t, keypair.None, testconfig.Config{
 				Want: testconfig.Want{
 					Scope:               "openid",
-					RedirectURIPrefix:   "http://localhost:",
+					RedirectURIPrefix:   "http://127.0.0.1:",
 					CodeChallengeMethod: "S256",
 				},
 				Response: testconfig.Response{
@@ -257,7 +257,7 @@ func TestCredentialPlugin(t *testing.T) {
 		svc := oidcserver.New(t, keypair.Server, testconfig.Config{
 			Want: testconfig.Want{
 				Scope:               "openid",
-				RedirectURIPrefix:   "http://localhost:",
+				RedirectURIPrefix:   "http://127.0.0.1:",
 				CodeChallengeMethod: "S256",
 			},
 			Response: testconfig.Response{
@@ -283,7 +283,7 @@ func TestCredentialPlugin(t *testing.T) {
 		svc := oidcserver.New(t, keypair.None, testconfig.Config{
 			Want: testconfig.Want{
 				Scope:               "email profile openid",
-				RedirectURIPrefix:   "http://localhost:",
+				RedirectURIPrefix:   "http://127.0.0.1:",
 				CodeChallengeMethod: "S256",
 			},
 			Response: testconfig.Response{
@@ -312,7 +312,7 @@ func TestCredentialPlugin(t *testing.T) {
 		svc := oidcserver.New(t, keypair.None, testconfig.Config{
 			Want: testconfig.Want{
 				Scope:               "openid",
-				RedirectURIPrefix:   "http://localhost:",
+				RedirectURIPrefix:   "http://127.0.0.1:",
 				CodeChallengeMethod: "S256",
 			},
 			Response: testconfig.Response{
@@ -370,7 +370,7 @@ func TestCredentialPlugin(t *testing.T) {
 		svc := oidcserver.New(t, keypair.None, testconfig.Config{
 			Want: testconfig.Want{
 				Scope:               "openid",
-				RedirectURIPrefix:   "http://localhost:",
+				RedirectURIPrefix:   "http://127.0.0.1:",
 				CodeChallengeMethod: "S256",
 				ExtraParams: map[string]string{
 					"ttl":    "86400",
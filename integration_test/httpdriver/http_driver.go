@@ -68,6 +68,8 @@ func (c *client) OpenCommand(_ context.Context, url, _ string) error {
 	return c.Open(url)
 }
 
+func (c *client) IsAvailable() bool { return true }
+
 type zeroClient struct {
 	t *testing.T
 }
@@ -80,3 +82,5 @@ func (c *zeroClient) Open(url string) error {
 func (c *zeroClient) OpenCommand(_ context.Context, url, _ string) error {
 	return c.Open(url)
 }
+
+func (c *zeroClient) IsAvailable() bool { return true }
@@ -49,7 +49,7 @@ func TestStandalone(t *testing.T) {
 				sv := oidcserver.New(t, tc.keyPair, testconfig.Config{
 					Want: testconfig.Want{
 						Scope:             "openid",
-						RedirectURIPrefix: "http://localhost:",
+						RedirectURIPrefix: "http://127.0.0.1:",
 					},
 					Response: testconfig.Response{
 						IDTokenExpiry: now.Add(time.Hour),
@@ -78,7 +78,7 @@ func TestStandalone(t *testing.T) {
 				sv := oidcserver.New(t, tc.keyPair, testconfig.Config{
 					Want: testconfig.Want{
 						Scope:             "openid",
-						RedirectURIPrefix: "http://localhost:",
+						RedirectURIPrefix: "http://127.0.0.1:",
 						Username:          "USER1",
 						Password:          "PASS1",
 					},
@@ -120,7 +120,7 @@ func TestStandalone(t *testing.T) {
 					sv.SetConfig(testconfig.Config{
 						Want: testconfig.Want{
 							Scope:             "openid",
-							RedirectURIPrefix: "http://localhost:",
+							RedirectURIPrefix: "http://127.0.0.1:",
 						},
 						Response: testconfig.Response{
 							IDTokenExpiry: now.Add(time.Hour),
@@ -155,7 +155,7 @@ func TestStandalone(t *testing.T) {
 					sv.SetConfig(testconfig.Config{
 						Want: testconfig.Want{
 							Scope:             "openid",
-							RedirectURIPrefix: "http://localhost:",
+							RedirectURIPrefix: "http://127.0.0.1:",
 							RefreshToken:      "REFRESH_TOKEN_1",
 						},
 						Response: testconfig.Response{
@@ -178,7 +178,7 @@ func TestStandalone(t *testing.T) {
 					sv.SetConfig(testconfig.Config{
 						Want: testconfig.Want{
 							Scope:             "openid",
-							RedirectURIPrefix: "http://localhost:",
+							RedirectURIPrefix: "http://127.0.0.1:",
 							RefreshToken:      "REFRESH_TOKEN_2",
 						},
 						Response: testconfig.Response{
@@ -207,7 +207,7 @@ func TestStandalone(t *testing.T) {
 		sv := oidcserver.New(t, keypair.Server, testconfig.Config{
 			Want: testconfig.Want{
 				Scope:             "openid",
-				RedirectURIPrefix: "http://localhost:",
+				RedirectURIPrefix: "http://127.0.0.1:",
 			},
 			Response: testconfig.Response{
 				IDTokenExpiry: now.Add(time.Hour),
@@ -235,7 +235,7 @@ func TestStandalone(t *testing.T) {
 		sv := oidcserver.New(t, keypair.None, testconfig.Config{
 			Want: testconfig.Want{
 				Scope:             "openid",
-				RedirectURIPrefix: "http://localhost:",
+				RedirectURIPrefix: "http://127.0.0.1:",
 			},
 			Response: testconfig.Response{
 				IDTokenExpiry: now.Add(time.Hour),
@@ -263,7 +263,7 @@ func TestStandalone(t *testing.T) {
 		sv := oidcserver.New(t, keypair.None, testconfig.Config{
 			Want: testconfig.Want{
 				Scope:             "profile groups openid",
-				RedirectURIPrefix: "http://localhost:",
+				RedirectURIPrefix: "http://127.0.0.1:",
 			},
 			Response: testconfig.Response{
 				IDTokenExpiry: now.Add(time.Hour),
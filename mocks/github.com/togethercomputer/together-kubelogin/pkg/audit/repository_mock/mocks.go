@@ -0,0 +1,156 @@
+// Code generated by mockery; DO NOT EDIT.
+// github.com/vektra/mockery
+// template: testify
+
+package repository_mock
+
+import (
+	mock "github.com/stretchr/testify/mock"
+	"github.com/togethercomputer/together-kubelogin/pkg/audit"
+)
+
+// NewMockInterface creates a new instance of MockInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockInterface(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockInterface {
+	mock := &MockInterface{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockInterface is an autogenerated mock type for the Interface type
+type MockInterface struct {
+	mock.Mock
+}
+
+type MockInterface_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockInterface) EXPECT() *MockInterface_Expecter {
+	return &MockInterface_Expecter{mock: &_m.Mock}
+}
+
+// Append provides a mock function for the type MockInterface
+func (_mock *MockInterface) Append(config audit.Config, record audit.Record) error {
+	ret := _mock.Called(config, record)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Append")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(audit.Config, audit.Record) error); ok {
+		r0 = returnFunc(config, record)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockInterface_Append_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Append'
+type MockInterface_Append_Call struct {
+	*mock.Call
+}
+
+// Append is a helper method to define mock.On call
+//   - config audit.Config
+//   - record audit.Record
+func (_e *MockInterface_Expecter) Append(config interface{}, record interface{}) *MockInterface_Append_Call {
+	return &MockInterface_Append_Call{Call: _e.mock.On("Append", config, record)}
+}
+
+func (_c *MockInterface_Append_Call) Run(run func(config audit.Config, record audit.Record)) *MockInterface_Append_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 audit.Config
+		if args[0] != nil {
+			arg0 = args[0].(audit.Config)
+		}
+		var arg1 audit.Record
+		if args[1] != nil {
+			arg1 = args[1].(audit.Record)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockInterface_Append_Call) Return(err error) *MockInterface_Append_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockInterface_Append_Call) RunAndReturn(run func(config audit.Config, record audit.Record) error) *MockInterface_Append_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// FindAll provides a mock function for the type MockInterface
+func (_mock *MockInterface) FindAll(config audit.Config) ([]audit.Record, error) {
+	ret := _mock.Called(config)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindAll")
+	}
+
+	var r0 []audit.Record
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(audit.Config) ([]audit.Record, error)); ok {
+		return returnFunc(config)
+	}
+	if returnFunc, ok := ret.Get(0).(func(audit.Config) []audit.Record); ok {
+		r0 = returnFunc(config)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]audit.Record)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(audit.Config) error); ok {
+		r1 = returnFunc(config)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockInterface_FindAll_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindAll'
+type MockInterface_FindAll_Call struct {
+	*mock.Call
+}
+
+// FindAll is a helper method to define mock.On call
+//   - config audit.Config
+func (_e *MockInterface_Expecter) FindAll(config interface{}) *MockInterface_FindAll_Call {
+	return &MockInterface_FindAll_Call{Call: _e.mock.On("FindAll", config)}
+}
+
+func (_c *MockInterface_FindAll_Call) Run(run func(config audit.Config)) *MockInterface_FindAll_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 audit.Config
+		if args[0] != nil {
+			arg0 = args[0].(audit.Config)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockInterface_FindAll_Call) Return(records []audit.Record, err error) *MockInterface_FindAll_Call {
+	_c.Call.Return(records, err)
+	return _c
+}
+
+func (_c *MockInterface_FindAll_Call) RunAndReturn(run func(config audit.Config) ([]audit.Record, error)) *MockInterface_FindAll_Call {
+	_c.Call.Return(run)
+	return _c
+}
@@ -37,6 +37,50 @@ func (_m *MockInterface) EXPECT() *MockInterface_Expecter {
 	return &MockInterface_Expecter{mock: &_m.Mock}
 }
 
+// IsAvailable provides a mock function for the type MockInterface
+func (_mock *MockInterface) IsAvailable() bool {
+	ret := _mock.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for IsAvailable")
+	}
+
+	var r0 bool
+	if returnFunc, ok := ret.Get(0).(func() bool); ok {
+		r0 = returnFunc()
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+	return r0
+}
+
+// MockInterface_IsAvailable_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'IsAvailable'
+type MockInterface_IsAvailable_Call struct {
+	*mock.Call
+}
+
+// IsAvailable is a helper method to define mock.On call
+func (_e *MockInterface_Expecter) IsAvailable() *MockInterface_IsAvailable_Call {
+	return &MockInterface_IsAvailable_Call{Call: _e.mock.On("IsAvailable")}
+}
+
+func (_c *MockInterface_IsAvailable_Call) Run(run func()) *MockInterface_IsAvailable_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockInterface_IsAvailable_Call) Return(b bool) *MockInterface_IsAvailable_Call {
+	_c.Call.Return(b)
+	return _c
+}
+
+func (_c *MockInterface_IsAvailable_Call) RunAndReturn(run func() bool) *MockInterface_IsAvailable_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Open provides a mock function for the type MockInterface
 func (_mock *MockInterface) Open(url string) error {
 	ret := _mock.Called(url)
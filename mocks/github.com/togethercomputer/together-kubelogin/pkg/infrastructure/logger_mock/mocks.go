@@ -77,6 +77,52 @@ func (_c *MockInterface_AddFlags_Call) RunAndReturn(run func(f *pflag.FlagSet))
 	return _c
 }
 
+// Event provides a mock function for the type MockInterface
+func (_mock *MockInterface) Event(event string, fields logger.Fields) {
+	_mock.Called(event, fields)
+	return
+}
+
+// MockInterface_Event_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Event'
+type MockInterface_Event_Call struct {
+	*mock.Call
+}
+
+// Event is a helper method to define mock.On call
+//   - event string
+//   - fields logger.Fields
+func (_e *MockInterface_Expecter) Event(event interface{}, fields interface{}) *MockInterface_Event_Call {
+	return &MockInterface_Event_Call{Call: _e.mock.On("Event", event, fields)}
+}
+
+func (_c *MockInterface_Event_Call) Run(run func(event string, fields logger.Fields)) *MockInterface_Event_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		var arg1 logger.Fields
+		if args[1] != nil {
+			arg1 = args[1].(logger.Fields)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockInterface_Event_Call) Return() *MockInterface_Event_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockInterface_Event_Call) RunAndReturn(run func(event string, fields logger.Fields)) *MockInterface_Event_Call {
+	_c.Run(run)
+	return _c
+}
+
 // IsEnabled provides a mock function for the type MockInterface
 func (_mock *MockInterface) IsEnabled(level int) bool {
 	ret := _mock.Called(level)
@@ -128,6 +174,50 @@ func (_c *MockInterface_IsEnabled_Call) RunAndReturn(run func(level int) bool) *
 	return _c
 }
 
+// IsUnredacted provides a mock function for the type MockInterface
+func (_mock *MockInterface) IsUnredacted() bool {
+	ret := _mock.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for IsUnredacted")
+	}
+
+	var r0 bool
+	if returnFunc, ok := ret.Get(0).(func() bool); ok {
+		r0 = returnFunc()
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+	return r0
+}
+
+// MockInterface_IsUnredacted_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'IsUnredacted'
+type MockInterface_IsUnredacted_Call struct {
+	*mock.Call
+}
+
+// IsUnredacted is a helper method to define mock.On call
+func (_e *MockInterface_Expecter) IsUnredacted() *MockInterface_IsUnredacted_Call {
+	return &MockInterface_IsUnredacted_Call{Call: _e.mock.On("IsUnredacted")}
+}
+
+func (_c *MockInterface_IsUnredacted_Call) Run(run func()) *MockInterface_IsUnredacted_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockInterface_IsUnredacted_Call) Return(b bool) *MockInterface_IsUnredacted_Call {
+	_c.Call.Return(b)
+	return _c
+}
+
+func (_c *MockInterface_IsUnredacted_Call) RunAndReturn(run func() bool) *MockInterface_IsUnredacted_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Printf provides a mock function for the type MockInterface
 func (_mock *MockInterface) Printf(format string, args ...interface{}) {
 	if len(args) > 0 {
@@ -0,0 +1,140 @@
+// Code generated by mockery; DO NOT EDIT.
+// github.com/vektra/mockery
+// template: testify
+
+package terminal_mock
+
+import (
+	mock "github.com/stretchr/testify/mock"
+)
+
+// NewMockInterface creates a new instance of MockInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockInterface(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockInterface {
+	mock := &MockInterface{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockInterface is an autogenerated mock type for the Interface type
+type MockInterface struct {
+	mock.Mock
+}
+
+type MockInterface_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockInterface) EXPECT() *MockInterface_Expecter {
+	return &MockInterface_Expecter{mock: &_m.Mock}
+}
+
+// IsTerminal provides a mock function for the type MockInterface
+func (_mock *MockInterface) IsTerminal() bool {
+	ret := _mock.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for IsTerminal")
+	}
+
+	var r0 bool
+	if returnFunc, ok := ret.Get(0).(func() bool); ok {
+		r0 = returnFunc()
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+	return r0
+}
+
+// MockInterface_IsTerminal_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'IsTerminal'
+type MockInterface_IsTerminal_Call struct {
+	*mock.Call
+}
+
+// IsTerminal is a helper method to define mock.On call
+func (_e *MockInterface_Expecter) IsTerminal() *MockInterface_IsTerminal_Call {
+	return &MockInterface_IsTerminal_Call{Call: _e.mock.On("IsTerminal")}
+}
+
+func (_c *MockInterface_IsTerminal_Call) Run(run func()) *MockInterface_IsTerminal_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockInterface_IsTerminal_Call) Return(b bool) *MockInterface_IsTerminal_Call {
+	_c.Call.Return(b)
+	return _c
+}
+
+func (_c *MockInterface_IsTerminal_Call) RunAndReturn(run func() bool) *MockInterface_IsTerminal_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Write provides a mock function for the type MockInterface
+func (_mock *MockInterface) Write(p []byte) (int, error) {
+	ret := _mock.Called(p)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Write")
+	}
+
+	var r0 int
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func([]byte) (int, error)); ok {
+		return returnFunc(p)
+	}
+	if returnFunc, ok := ret.Get(0).(func([]byte) int); ok {
+		r0 = returnFunc(p)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+	if returnFunc, ok := ret.Get(1).(func([]byte) error); ok {
+		r1 = returnFunc(p)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockInterface_Write_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Write'
+type MockInterface_Write_Call struct {
+	*mock.Call
+}
+
+// Write is a helper method to define mock.On call
+//   - p []byte
+func (_e *MockInterface_Expecter) Write(p interface{}) *MockInterface_Write_Call {
+	return &MockInterface_Write_Call{Call: _e.mock.On("Write", p)}
+}
+
+func (_c *MockInterface_Write_Call) Run(run func(p []byte)) *MockInterface_Write_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 []byte
+		if args[0] != nil {
+			arg0 = args[0].([]byte)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockInterface_Write_Call) Return(n int, err error) *MockInterface_Write_Call {
+	_c.Call.Return(n, err)
+	return _c
+}
+
+func (_c *MockInterface_Write_Call) RunAndReturn(run func(p []byte) (int, error)) *MockInterface_Write_Call {
+	_c.Call.Return(run)
+	return _c
+}
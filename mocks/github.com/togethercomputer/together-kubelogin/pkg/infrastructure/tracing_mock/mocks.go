@@ -0,0 +1,155 @@
+// Code generated by mockery; DO NOT EDIT.
+// github.com/vektra/mockery
+// template: testify
+
+package tracing_mock
+
+import (
+	"context"
+
+	"github.com/spf13/pflag"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// NewMockInterface creates a new instance of MockInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockInterface(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockInterface {
+	mock := &MockInterface{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockInterface is an autogenerated mock type for the Interface type
+type MockInterface struct {
+	mock.Mock
+}
+
+type MockInterface_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockInterface) EXPECT() *MockInterface_Expecter {
+	return &MockInterface_Expecter{mock: &_m.Mock}
+}
+
+// AddFlags provides a mock function for the type MockInterface
+func (_mock *MockInterface) AddFlags(f *pflag.FlagSet) {
+	_mock.Called(f)
+	return
+}
+
+// MockInterface_AddFlags_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AddFlags'
+type MockInterface_AddFlags_Call struct {
+	*mock.Call
+}
+
+// AddFlags is a helper method to define mock.On call
+//   - f *pflag.FlagSet
+func (_e *MockInterface_Expecter) AddFlags(f interface{}) *MockInterface_AddFlags_Call {
+	return &MockInterface_AddFlags_Call{Call: _e.mock.On("AddFlags", f)}
+}
+
+func (_c *MockInterface_AddFlags_Call) Run(run func(f *pflag.FlagSet)) *MockInterface_AddFlags_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 *pflag.FlagSet
+		if args[0] != nil {
+			arg0 = args[0].(*pflag.FlagSet)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockInterface_AddFlags_Call) Return() *MockInterface_AddFlags_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockInterface_AddFlags_Call) RunAndReturn(run func(f *pflag.FlagSet)) *MockInterface_AddFlags_Call {
+	_c.Run(run)
+	return _c
+}
+
+// Setup provides a mock function for the type MockInterface
+func (_mock *MockInterface) Setup(ctx context.Context, version string) (context.Context, func(context.Context) error, error) {
+	ret := _mock.Called(ctx, version)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Setup")
+	}
+
+	var r0 context.Context
+	var r1 func(context.Context) error
+	var r2 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (context.Context, func(context.Context) error, error)); ok {
+		return returnFunc(ctx, version)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) context.Context); ok {
+		r0 = returnFunc(ctx, version)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(context.Context)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) func(context.Context) error); ok {
+		r1 = returnFunc(ctx, version)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(func(context.Context) error)
+		}
+	}
+	if returnFunc, ok := ret.Get(2).(func(context.Context, string) error); ok {
+		r2 = returnFunc(ctx, version)
+	} else {
+		r2 = ret.Error(2)
+	}
+	return r0, r1, r2
+}
+
+// MockInterface_Setup_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Setup'
+type MockInterface_Setup_Call struct {
+	*mock.Call
+}
+
+// Setup is a helper method to define mock.On call
+//   - ctx context.Context
+//   - version string
+func (_e *MockInterface_Expecter) Setup(ctx interface{}, version interface{}) *MockInterface_Setup_Call {
+	return &MockInterface_Setup_Call{Call: _e.mock.On("Setup", ctx, version)}
+}
+
+func (_c *MockInterface_Setup_Call) Run(run func(ctx context.Context, version string)) *MockInterface_Setup_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockInterface_Setup_Call) Return(context1 context.Context, fn func(context.Context) error, err error) *MockInterface_Setup_Call {
+	_c.Call.Return(context1, fn, err)
+	return _c
+}
+
+func (_c *MockInterface_Setup_Call) RunAndReturn(run func(ctx context.Context, version string) (context.Context, func(context.Context) error, error)) *MockInterface_Setup_Call {
+	_c.Call.Return(run)
+	return _c
+}
@@ -109,3 +109,71 @@ func (_c *MockInterface_GetCurrentAuthProvider_Call) RunAndReturn(run func(expli
 	_c.Call.Return(run)
 	return _c
 }
+
+// ListExecContexts provides a mock function for the type MockInterface
+func (_mock *MockInterface) ListExecContexts(explicitFilename string, contextNames []kubeconfig.ContextName) ([]kubeconfig.ExecContext, error) {
+	ret := _mock.Called(explicitFilename, contextNames)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListExecContexts")
+	}
+
+	var r0 []kubeconfig.ExecContext
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(string, []kubeconfig.ContextName) ([]kubeconfig.ExecContext, error)); ok {
+		return returnFunc(explicitFilename, contextNames)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string, []kubeconfig.ContextName) []kubeconfig.ExecContext); ok {
+		r0 = returnFunc(explicitFilename, contextNames)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]kubeconfig.ExecContext)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(string, []kubeconfig.ContextName) error); ok {
+		r1 = returnFunc(explicitFilename, contextNames)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockInterface_ListExecContexts_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListExecContexts'
+type MockInterface_ListExecContexts_Call struct {
+	*mock.Call
+}
+
+// ListExecContexts is a helper method to define mock.On call
+//   - explicitFilename string
+//   - contextNames []kubeconfig.ContextName
+func (_e *MockInterface_Expecter) ListExecContexts(explicitFilename interface{}, contextNames interface{}) *MockInterface_ListExecContexts_Call {
+	return &MockInterface_ListExecContexts_Call{Call: _e.mock.On("ListExecContexts", explicitFilename, contextNames)}
+}
+
+func (_c *MockInterface_ListExecContexts_Call) Run(run func(explicitFilename string, contextNames []kubeconfig.ContextName)) *MockInterface_ListExecContexts_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		var arg1 []kubeconfig.ContextName
+		if args[1] != nil {
+			arg1 = args[1].([]kubeconfig.ContextName)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockInterface_ListExecContexts_Call) Return(execContexts []kubeconfig.ExecContext, err error) *MockInterface_ListExecContexts_Call {
+	_c.Call.Return(execContexts, err)
+	return _c
+}
+
+func (_c *MockInterface_ListExecContexts_Call) RunAndReturn(run func(explicitFilename string, contextNames []kubeconfig.ContextName) ([]kubeconfig.ExecContext, error)) *MockInterface_ListExecContexts_Call {
+	_c.Call.Return(run)
+	return _c
+}
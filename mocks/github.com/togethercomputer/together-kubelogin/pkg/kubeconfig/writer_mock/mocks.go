@@ -36,6 +36,140 @@ func (_m *MockInterface) EXPECT() *MockInterface_Expecter {
 	return &MockInterface_Expecter{mock: &_m.Mock}
 }
 
+// FindExecConfigConflicts provides a mock function for the type MockInterface
+func (_mock *MockInterface) FindExecConfigConflicts(filename string, c kubeconfig.ExecConfig) ([]string, error) {
+	ret := _mock.Called(filename, c)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindExecConfigConflicts")
+	}
+
+	var r0 []string
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(string, kubeconfig.ExecConfig) ([]string, error)); ok {
+		return returnFunc(filename, c)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string, kubeconfig.ExecConfig) []string); ok {
+		r0 = returnFunc(filename, c)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(string, kubeconfig.ExecConfig) error); ok {
+		r1 = returnFunc(filename, c)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockInterface_FindExecConfigConflicts_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindExecConfigConflicts'
+type MockInterface_FindExecConfigConflicts_Call struct {
+	*mock.Call
+}
+
+// FindExecConfigConflicts is a helper method to define mock.On call
+//   - filename string
+//   - c kubeconfig.ExecConfig
+func (_e *MockInterface_Expecter) FindExecConfigConflicts(filename interface{}, c interface{}) *MockInterface_FindExecConfigConflicts_Call {
+	return &MockInterface_FindExecConfigConflicts_Call{Call: _e.mock.On("FindExecConfigConflicts", filename, c)}
+}
+
+func (_c *MockInterface_FindExecConfigConflicts_Call) Run(run func(filename string, c kubeconfig.ExecConfig)) *MockInterface_FindExecConfigConflicts_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		var arg1 kubeconfig.ExecConfig
+		if args[1] != nil {
+			arg1 = args[1].(kubeconfig.ExecConfig)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockInterface_FindExecConfigConflicts_Call) Return(strings []string, err error) *MockInterface_FindExecConfigConflicts_Call {
+	_c.Call.Return(strings, err)
+	return _c
+}
+
+func (_c *MockInterface_FindExecConfigConflicts_Call) RunAndReturn(run func(filename string, c kubeconfig.ExecConfig) ([]string, error)) *MockInterface_FindExecConfigConflicts_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// MergeExecConfig provides a mock function for the type MockInterface
+func (_mock *MockInterface) MergeExecConfig(filename string, c kubeconfig.ExecConfig) (string, error) {
+	ret := _mock.Called(filename, c)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MergeExecConfig")
+	}
+
+	var r0 string
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(string, kubeconfig.ExecConfig) (string, error)); ok {
+		return returnFunc(filename, c)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string, kubeconfig.ExecConfig) string); ok {
+		r0 = returnFunc(filename, c)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+	if returnFunc, ok := ret.Get(1).(func(string, kubeconfig.ExecConfig) error); ok {
+		r1 = returnFunc(filename, c)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockInterface_MergeExecConfig_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'MergeExecConfig'
+type MockInterface_MergeExecConfig_Call struct {
+	*mock.Call
+}
+
+// MergeExecConfig is a helper method to define mock.On call
+//   - filename string
+//   - c kubeconfig.ExecConfig
+func (_e *MockInterface_Expecter) MergeExecConfig(filename interface{}, c interface{}) *MockInterface_MergeExecConfig_Call {
+	return &MockInterface_MergeExecConfig_Call{Call: _e.mock.On("MergeExecConfig", filename, c)}
+}
+
+func (_c *MockInterface_MergeExecConfig_Call) Run(run func(filename string, c kubeconfig.ExecConfig)) *MockInterface_MergeExecConfig_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		var arg1 kubeconfig.ExecConfig
+		if args[1] != nil {
+			arg1 = args[1].(kubeconfig.ExecConfig)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockInterface_MergeExecConfig_Call) Return(s string, err error) *MockInterface_MergeExecConfig_Call {
+	_c.Call.Return(s, err)
+	return _c
+}
+
+func (_c *MockInterface_MergeExecConfig_Call) RunAndReturn(run func(filename string, c kubeconfig.ExecConfig) (string, error)) *MockInterface_MergeExecConfig_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // UpdateAuthProvider provides a mock function for the type MockInterface
 func (_mock *MockInterface) UpdateAuthProvider(p kubeconfig.AuthProvider) error {
 	ret := _mock.Called(p)
@@ -111,8 +111,8 @@ func (_c *MockInterface_ExchangeAuthCode_Call) RunAndReturn(run func(ctx context
 }
 
 // ExchangeDeviceCode provides a mock function for the type MockInterface
-func (_mock *MockInterface) ExchangeDeviceCode(ctx context.Context, authResponse *oauth2dev.AuthorizationResponse) (*oidc.TokenSet, error) {
-	ret := _mock.Called(ctx, authResponse)
+func (_mock *MockInterface) ExchangeDeviceCode(ctx context.Context, in client.ExchangeDeviceCodeInput) (*oidc.TokenSet, error) {
+	ret := _mock.Called(ctx, in)
 
 	if len(ret) == 0 {
 		panic("no return value specified for ExchangeDeviceCode")
@@ -120,18 +120,18 @@ func (_mock *MockInterface) ExchangeDeviceCode(ctx context.Context, authResponse
 
 	var r0 *oidc.TokenSet
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, *oauth2dev.AuthorizationResponse) (*oidc.TokenSet, error)); ok {
-		return returnFunc(ctx, authResponse)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, client.ExchangeDeviceCodeInput) (*oidc.TokenSet, error)); ok {
+		return returnFunc(ctx, in)
 	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, *oauth2dev.AuthorizationResponse) *oidc.TokenSet); ok {
-		r0 = returnFunc(ctx, authResponse)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, client.ExchangeDeviceCodeInput) *oidc.TokenSet); ok {
+		r0 = returnFunc(ctx, in)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*oidc.TokenSet)
 		}
 	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, *oauth2dev.AuthorizationResponse) error); ok {
-		r1 = returnFunc(ctx, authResponse)
+	if returnFunc, ok := ret.Get(1).(func(context.Context, client.ExchangeDeviceCodeInput) error); ok {
+		r1 = returnFunc(ctx, in)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -145,20 +145,20 @@ type MockInterface_ExchangeDeviceCode_Call struct {
 
 // ExchangeDeviceCode is a helper method to define mock.On call
 //   - ctx context.Context
-//   - authResponse *oauth2dev.AuthorizationResponse
-func (_e *MockInterface_Expecter) ExchangeDeviceCode(ctx interface{}, authResponse interface{}) *MockInterface_ExchangeDeviceCode_Call {
-	return &MockInterface_ExchangeDeviceCode_Call{Call: _e.mock.On("ExchangeDeviceCode", ctx, authResponse)}
+//   - in client.ExchangeDeviceCodeInput
+func (_e *MockInterface_Expecter) ExchangeDeviceCode(ctx interface{}, in interface{}) *MockInterface_ExchangeDeviceCode_Call {
+	return &MockInterface_ExchangeDeviceCode_Call{Call: _e.mock.On("ExchangeDeviceCode", ctx, in)}
 }
 
-func (_c *MockInterface_ExchangeDeviceCode_Call) Run(run func(ctx context.Context, authResponse *oauth2dev.AuthorizationResponse)) *MockInterface_ExchangeDeviceCode_Call {
+func (_c *MockInterface_ExchangeDeviceCode_Call) Run(run func(ctx context.Context, in client.ExchangeDeviceCodeInput)) *MockInterface_ExchangeDeviceCode_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
 			arg0 = args[0].(context.Context)
 		}
-		var arg1 *oauth2dev.AuthorizationResponse
+		var arg1 client.ExchangeDeviceCodeInput
 		if args[1] != nil {
-			arg1 = args[1].(*oauth2dev.AuthorizationResponse)
+			arg1 = args[1].(client.ExchangeDeviceCodeInput)
 		}
 		run(
 			arg0,
@@ -173,7 +173,75 @@ func (_c *MockInterface_ExchangeDeviceCode_Call) Return(tokenSet *oidc.TokenSet,
 	return _c
 }
 
-func (_c *MockInterface_ExchangeDeviceCode_Call) RunAndReturn(run func(ctx context.Context, authResponse *oauth2dev.AuthorizationResponse) (*oidc.TokenSet, error)) *MockInterface_ExchangeDeviceCode_Call {
+func (_c *MockInterface_ExchangeDeviceCode_Call) RunAndReturn(run func(ctx context.Context, in client.ExchangeDeviceCodeInput) (*oidc.TokenSet, error)) *MockInterface_ExchangeDeviceCode_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ExchangeToken provides a mock function for the type MockInterface
+func (_mock *MockInterface) ExchangeToken(ctx context.Context, subjectToken string) (*oidc.TokenSet, error) {
+	ret := _mock.Called(ctx, subjectToken)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ExchangeToken")
+	}
+
+	var r0 *oidc.TokenSet
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (*oidc.TokenSet, error)); ok {
+		return returnFunc(ctx, subjectToken)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) *oidc.TokenSet); ok {
+		r0 = returnFunc(ctx, subjectToken)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*oidc.TokenSet)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, subjectToken)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockInterface_ExchangeToken_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ExchangeToken'
+type MockInterface_ExchangeToken_Call struct {
+	*mock.Call
+}
+
+// ExchangeToken is a helper method to define mock.On call
+//   - ctx context.Context
+//   - subjectToken string
+func (_e *MockInterface_Expecter) ExchangeToken(ctx interface{}, subjectToken interface{}) *MockInterface_ExchangeToken_Call {
+	return &MockInterface_ExchangeToken_Call{Call: _e.mock.On("ExchangeToken", ctx, subjectToken)}
+}
+
+func (_c *MockInterface_ExchangeToken_Call) Run(run func(ctx context.Context, subjectToken string)) *MockInterface_ExchangeToken_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockInterface_ExchangeToken_Call) Return(tokenSet *oidc.TokenSet, err error) *MockInterface_ExchangeToken_Call {
+	_c.Call.Return(tokenSet, err)
+	return _c
+}
+
+func (_c *MockInterface_ExchangeToken_Call) RunAndReturn(run func(ctx context.Context, subjectToken string) (*oidc.TokenSet, error)) *MockInterface_ExchangeToken_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -230,8 +298,8 @@ func (_c *MockInterface_GetAuthCodeURL_Call) RunAndReturn(run func(in client.Aut
 }
 
 // GetDeviceAuthorization provides a mock function for the type MockInterface
-func (_mock *MockInterface) GetDeviceAuthorization(ctx context.Context) (*oauth2dev.AuthorizationResponse, error) {
-	ret := _mock.Called(ctx)
+func (_mock *MockInterface) GetDeviceAuthorization(ctx context.Context, in client.GetDeviceAuthorizationInput) (*oauth2dev.AuthorizationResponse, error) {
+	ret := _mock.Called(ctx, in)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetDeviceAuthorization")
@@ -239,18 +307,18 @@ func (_mock *MockInterface) GetDeviceAuthorization(ctx context.Context) (*oauth2
 
 	var r0 *oauth2dev.AuthorizationResponse
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context) (*oauth2dev.AuthorizationResponse, error)); ok {
-		return returnFunc(ctx)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, client.GetDeviceAuthorizationInput) (*oauth2dev.AuthorizationResponse, error)); ok {
+		return returnFunc(ctx, in)
 	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context) *oauth2dev.AuthorizationResponse); ok {
-		r0 = returnFunc(ctx)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, client.GetDeviceAuthorizationInput) *oauth2dev.AuthorizationResponse); ok {
+		r0 = returnFunc(ctx, in)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*oauth2dev.AuthorizationResponse)
 		}
 	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context) error); ok {
-		r1 = returnFunc(ctx)
+	if returnFunc, ok := ret.Get(1).(func(context.Context, client.GetDeviceAuthorizationInput) error); ok {
+		r1 = returnFunc(ctx, in)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -264,18 +332,24 @@ type MockInterface_GetDeviceAuthorization_Call struct {
 
 // GetDeviceAuthorization is a helper method to define mock.On call
 //   - ctx context.Context
-func (_e *MockInterface_Expecter) GetDeviceAuthorization(ctx interface{}) *MockInterface_GetDeviceAuthorization_Call {
-	return &MockInterface_GetDeviceAuthorization_Call{Call: _e.mock.On("GetDeviceAuthorization", ctx)}
+//   - in client.GetDeviceAuthorizationInput
+func (_e *MockInterface_Expecter) GetDeviceAuthorization(ctx interface{}, in interface{}) *MockInterface_GetDeviceAuthorization_Call {
+	return &MockInterface_GetDeviceAuthorization_Call{Call: _e.mock.On("GetDeviceAuthorization", ctx, in)}
 }
 
-func (_c *MockInterface_GetDeviceAuthorization_Call) Run(run func(ctx context.Context)) *MockInterface_GetDeviceAuthorization_Call {
+func (_c *MockInterface_GetDeviceAuthorization_Call) Run(run func(ctx context.Context, in client.GetDeviceAuthorizationInput)) *MockInterface_GetDeviceAuthorization_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
 			arg0 = args[0].(context.Context)
 		}
+		var arg1 client.GetDeviceAuthorizationInput
+		if args[1] != nil {
+			arg1 = args[1].(client.GetDeviceAuthorizationInput)
+		}
 		run(
 			arg0,
+			arg1,
 		)
 	})
 	return _c
@@ -286,7 +360,7 @@ func (_c *MockInterface_GetDeviceAuthorization_Call) Return(authorizationRespons
 	return _c
 }
 
-func (_c *MockInterface_GetDeviceAuthorization_Call) RunAndReturn(run func(ctx context.Context) (*oauth2dev.AuthorizationResponse, error)) *MockInterface_GetDeviceAuthorization_Call {
+func (_c *MockInterface_GetDeviceAuthorization_Call) RunAndReturn(run func(ctx context.Context, in client.GetDeviceAuthorizationInput) (*oauth2dev.AuthorizationResponse, error)) *MockInterface_GetDeviceAuthorization_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -434,8 +508,8 @@ func (_c *MockInterface_GetTokenByClientCredentials_Call) RunAndReturn(run func(
 }
 
 // GetTokenByROPC provides a mock function for the type MockInterface
-func (_mock *MockInterface) GetTokenByROPC(ctx context.Context, username string, password string) (*oidc.TokenSet, error) {
-	ret := _mock.Called(ctx, username, password)
+func (_mock *MockInterface) GetTokenByROPC(ctx context.Context, in client.GetTokenByROPCInput) (*oidc.TokenSet, error) {
+	ret := _mock.Called(ctx, in)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetTokenByROPC")
@@ -443,18 +517,18 @@ func (_mock *MockInterface) GetTokenByROPC(ctx context.Context, username string,
 
 	var r0 *oidc.TokenSet
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) (*oidc.TokenSet, error)); ok {
-		return returnFunc(ctx, username, password)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, client.GetTokenByROPCInput) (*oidc.TokenSet, error)); ok {
+		return returnFunc(ctx, in)
 	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) *oidc.TokenSet); ok {
-		r0 = returnFunc(ctx, username, password)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, client.GetTokenByROPCInput) *oidc.TokenSet); ok {
+		r0 = returnFunc(ctx, in)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*oidc.TokenSet)
 		}
 	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
-		r1 = returnFunc(ctx, username, password)
+	if returnFunc, ok := ret.Get(1).(func(context.Context, client.GetTokenByROPCInput) error); ok {
+		r1 = returnFunc(ctx, in)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -468,30 +542,24 @@ type MockInterface_GetTokenByROPC_Call struct {
 
 // GetTokenByROPC is a helper method to define mock.On call
 //   - ctx context.Context
-//   - username string
-//   - password string
-func (_e *MockInterface_Expecter) GetTokenByROPC(ctx interface{}, username interface{}, password interface{}) *MockInterface_GetTokenByROPC_Call {
-	return &MockInterface_GetTokenByROPC_Call{Call: _e.mock.On("GetTokenByROPC", ctx, username, password)}
+//   - in client.GetTokenByROPCInput
+func (_e *MockInterface_Expecter) GetTokenByROPC(ctx interface{}, in interface{}) *MockInterface_GetTokenByROPC_Call {
+	return &MockInterface_GetTokenByROPC_Call{Call: _e.mock.On("GetTokenByROPC", ctx, in)}
 }
 
-func (_c *MockInterface_GetTokenByROPC_Call) Run(run func(ctx context.Context, username string, password string)) *MockInterface_GetTokenByROPC_Call {
+func (_c *MockInterface_GetTokenByROPC_Call) Run(run func(ctx context.Context, in client.GetTokenByROPCInput)) *MockInterface_GetTokenByROPC_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
 			arg0 = args[0].(context.Context)
 		}
-		var arg1 string
+		var arg1 client.GetTokenByROPCInput
 		if args[1] != nil {
-			arg1 = args[1].(string)
-		}
-		var arg2 string
-		if args[2] != nil {
-			arg2 = args[2].(string)
+			arg1 = args[1].(client.GetTokenByROPCInput)
 		}
 		run(
 			arg0,
 			arg1,
-			arg2,
 		)
 	})
 	return _c
@@ -502,7 +570,7 @@ func (_c *MockInterface_GetTokenByROPC_Call) Return(tokenSet *oidc.TokenSet, err
 	return _c
 }
 
-func (_c *MockInterface_GetTokenByROPC_Call) RunAndReturn(run func(ctx context.Context, username string, password string) (*oidc.TokenSet, error)) *MockInterface_GetTokenByROPC_Call {
+func (_c *MockInterface_GetTokenByROPC_Call) RunAndReturn(run func(ctx context.Context, in client.GetTokenByROPCInput) (*oidc.TokenSet, error)) *MockInterface_GetTokenByROPC_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -619,6 +687,57 @@ func (_c *MockInterface_Refresh_Call) RunAndReturn(run func(ctx context.Context,
 	return _c
 }
 
+// SupportsGrantType provides a mock function for the type MockInterface
+func (_mock *MockInterface) SupportsGrantType(grantType string) bool {
+	ret := _mock.Called(grantType)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SupportsGrantType")
+	}
+
+	var r0 bool
+	if returnFunc, ok := ret.Get(0).(func(string) bool); ok {
+		r0 = returnFunc(grantType)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+	return r0
+}
+
+// MockInterface_SupportsGrantType_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SupportsGrantType'
+type MockInterface_SupportsGrantType_Call struct {
+	*mock.Call
+}
+
+// SupportsGrantType is a helper method to define mock.On call
+//   - grantType string
+func (_e *MockInterface_Expecter) SupportsGrantType(grantType interface{}) *MockInterface_SupportsGrantType_Call {
+	return &MockInterface_SupportsGrantType_Call{Call: _e.mock.On("SupportsGrantType", grantType)}
+}
+
+func (_c *MockInterface_SupportsGrantType_Call) Run(run func(grantType string)) *MockInterface_SupportsGrantType_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockInterface_SupportsGrantType_Call) Return(b bool) *MockInterface_SupportsGrantType_Call {
+	_c.Call.Return(b)
+	return _c
+}
+
+func (_c *MockInterface_SupportsGrantType_Call) RunAndReturn(run func(grantType string) bool) *MockInterface_SupportsGrantType_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewMockFactoryInterface creates a new instance of MockFactoryInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewMockFactoryInterface(t interface {
@@ -0,0 +1,102 @@
+// Code generated by mockery; DO NOT EDIT.
+// github.com/vektra/mockery
+// template: testify
+
+package applier_mock
+
+import (
+	"context"
+
+	mock "github.com/stretchr/testify/mock"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// NewMockInterface creates a new instance of MockInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockInterface(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockInterface {
+	mock := &MockInterface{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockInterface is an autogenerated mock type for the Interface type
+type MockInterface struct {
+	mock.Mock
+}
+
+type MockInterface_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockInterface) EXPECT() *MockInterface_Expecter {
+	return &MockInterface_Expecter{mock: &_m.Mock}
+}
+
+// Apply provides a mock function for the type MockInterface
+func (_mock *MockInterface) Apply(ctx context.Context, kubeconfigFilename string, obj *unstructured.Unstructured) error {
+	ret := _mock.Called(ctx, kubeconfigFilename, obj)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Apply")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, *unstructured.Unstructured) error); ok {
+		r0 = returnFunc(ctx, kubeconfigFilename, obj)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockInterface_Apply_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Apply'
+type MockInterface_Apply_Call struct {
+	*mock.Call
+}
+
+// Apply is a helper method to define mock.On call
+//   - ctx context.Context
+//   - kubeconfigFilename string
+//   - obj *unstructured.Unstructured
+func (_e *MockInterface_Expecter) Apply(ctx interface{}, kubeconfigFilename interface{}, obj interface{}) *MockInterface_Apply_Call {
+	return &MockInterface_Apply_Call{Call: _e.mock.On("Apply", ctx, kubeconfigFilename, obj)}
+}
+
+func (_c *MockInterface_Apply_Call) Run(run func(ctx context.Context, kubeconfigFilename string, obj *unstructured.Unstructured)) *MockInterface_Apply_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 *unstructured.Unstructured
+		if args[2] != nil {
+			arg2 = args[2].(*unstructured.Unstructured)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockInterface_Apply_Call) Return(err error) *MockInterface_Apply_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockInterface_Apply_Call) RunAndReturn(run func(ctx context.Context, kubeconfigFilename string, obj *unstructured.Unstructured) error) *MockInterface_Apply_Call {
+	_c.Call.Return(run)
+	return _c
+}
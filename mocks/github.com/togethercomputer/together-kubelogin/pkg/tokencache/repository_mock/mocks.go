@@ -6,6 +6,7 @@ package repository_mock
 
 import (
 	"io"
+	"time"
 
 	mock "github.com/stretchr/testify/mock"
 	"github.com/togethercomputer/together-kubelogin/pkg/oidc"
@@ -90,6 +91,80 @@ func (_c *MockInterface_DeleteAll_Call) RunAndReturn(run func(config tokencache.
 	return _c
 }
 
+// FindAuthenticationMarker provides a mock function for the type MockInterface
+func (_mock *MockInterface) FindAuthenticationMarker(config tokencache.Config, issuerURL string, clientID string) (*tokencache.AuthenticationMarker, error) {
+	ret := _mock.Called(config, issuerURL, clientID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindAuthenticationMarker")
+	}
+
+	var r0 *tokencache.AuthenticationMarker
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(tokencache.Config, string, string) (*tokencache.AuthenticationMarker, error)); ok {
+		return returnFunc(config, issuerURL, clientID)
+	}
+	if returnFunc, ok := ret.Get(0).(func(tokencache.Config, string, string) *tokencache.AuthenticationMarker); ok {
+		r0 = returnFunc(config, issuerURL, clientID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*tokencache.AuthenticationMarker)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(tokencache.Config, string, string) error); ok {
+		r1 = returnFunc(config, issuerURL, clientID)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockInterface_FindAuthenticationMarker_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindAuthenticationMarker'
+type MockInterface_FindAuthenticationMarker_Call struct {
+	*mock.Call
+}
+
+// FindAuthenticationMarker is a helper method to define mock.On call
+//   - config tokencache.Config
+//   - issuerURL string
+//   - clientID string
+func (_e *MockInterface_Expecter) FindAuthenticationMarker(config interface{}, issuerURL interface{}, clientID interface{}) *MockInterface_FindAuthenticationMarker_Call {
+	return &MockInterface_FindAuthenticationMarker_Call{Call: _e.mock.On("FindAuthenticationMarker", config, issuerURL, clientID)}
+}
+
+func (_c *MockInterface_FindAuthenticationMarker_Call) Run(run func(config tokencache.Config, issuerURL string, clientID string)) *MockInterface_FindAuthenticationMarker_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 tokencache.Config
+		if args[0] != nil {
+			arg0 = args[0].(tokencache.Config)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockInterface_FindAuthenticationMarker_Call) Return(authenticationMarker *tokencache.AuthenticationMarker, err error) *MockInterface_FindAuthenticationMarker_Call {
+	_c.Call.Return(authenticationMarker, err)
+	return _c
+}
+
+func (_c *MockInterface_FindAuthenticationMarker_Call) RunAndReturn(run func(config tokencache.Config, issuerURL string, clientID string) (*tokencache.AuthenticationMarker, error)) *MockInterface_FindAuthenticationMarker_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // FindByKey provides a mock function for the type MockInterface
 func (_mock *MockInterface) FindByKey(config tokencache.Config, key tokencache.Key) (*oidc.TokenSet, error) {
 	ret := _mock.Called(config, key)
@@ -158,6 +233,80 @@ func (_c *MockInterface_FindByKey_Call) RunAndReturn(run func(config tokencache.
 	return _c
 }
 
+// FindLatestByProvider provides a mock function for the type MockInterface
+func (_mock *MockInterface) FindLatestByProvider(config tokencache.Config, key tokencache.Key, since time.Time) (*tokencache.LatestTokenSet, error) {
+	ret := _mock.Called(config, key, since)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindLatestByProvider")
+	}
+
+	var r0 *tokencache.LatestTokenSet
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(tokencache.Config, tokencache.Key, time.Time) (*tokencache.LatestTokenSet, error)); ok {
+		return returnFunc(config, key, since)
+	}
+	if returnFunc, ok := ret.Get(0).(func(tokencache.Config, tokencache.Key, time.Time) *tokencache.LatestTokenSet); ok {
+		r0 = returnFunc(config, key, since)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*tokencache.LatestTokenSet)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(tokencache.Config, tokencache.Key, time.Time) error); ok {
+		r1 = returnFunc(config, key, since)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockInterface_FindLatestByProvider_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindLatestByProvider'
+type MockInterface_FindLatestByProvider_Call struct {
+	*mock.Call
+}
+
+// FindLatestByProvider is a helper method to define mock.On call
+//   - config tokencache.Config
+//   - key tokencache.Key
+//   - since time.Time
+func (_e *MockInterface_Expecter) FindLatestByProvider(config interface{}, key interface{}, since interface{}) *MockInterface_FindLatestByProvider_Call {
+	return &MockInterface_FindLatestByProvider_Call{Call: _e.mock.On("FindLatestByProvider", config, key, since)}
+}
+
+func (_c *MockInterface_FindLatestByProvider_Call) Run(run func(config tokencache.Config, key tokencache.Key, since time.Time)) *MockInterface_FindLatestByProvider_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 tokencache.Config
+		if args[0] != nil {
+			arg0 = args[0].(tokencache.Config)
+		}
+		var arg1 tokencache.Key
+		if args[1] != nil {
+			arg1 = args[1].(tokencache.Key)
+		}
+		var arg2 time.Time
+		if args[2] != nil {
+			arg2 = args[2].(time.Time)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockInterface_FindLatestByProvider_Call) Return(latestTokenSet *tokencache.LatestTokenSet, err error) *MockInterface_FindLatestByProvider_Call {
+	_c.Call.Return(latestTokenSet, err)
+	return _c
+}
+
+func (_c *MockInterface_FindLatestByProvider_Call) RunAndReturn(run func(config tokencache.Config, key tokencache.Key, since time.Time) (*tokencache.LatestTokenSet, error)) *MockInterface_FindLatestByProvider_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Lock provides a mock function for the type MockInterface
 func (_mock *MockInterface) Lock(config tokencache.Config, key tokencache.Key) (io.Closer, error) {
 	ret := _mock.Called(config, key)
@@ -226,6 +375,74 @@ func (_c *MockInterface_Lock_Call) RunAndReturn(run func(config tokencache.Confi
 	return _c
 }
 
+// LockAuthentication provides a mock function for the type MockInterface
+func (_mock *MockInterface) LockAuthentication(config tokencache.Config, marker tokencache.AuthenticationMarker) (io.Closer, error) {
+	ret := _mock.Called(config, marker)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LockAuthentication")
+	}
+
+	var r0 io.Closer
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(tokencache.Config, tokencache.AuthenticationMarker) (io.Closer, error)); ok {
+		return returnFunc(config, marker)
+	}
+	if returnFunc, ok := ret.Get(0).(func(tokencache.Config, tokencache.AuthenticationMarker) io.Closer); ok {
+		r0 = returnFunc(config, marker)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(io.Closer)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(tokencache.Config, tokencache.AuthenticationMarker) error); ok {
+		r1 = returnFunc(config, marker)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockInterface_LockAuthentication_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'LockAuthentication'
+type MockInterface_LockAuthentication_Call struct {
+	*mock.Call
+}
+
+// LockAuthentication is a helper method to define mock.On call
+//   - config tokencache.Config
+//   - marker tokencache.AuthenticationMarker
+func (_e *MockInterface_Expecter) LockAuthentication(config interface{}, marker interface{}) *MockInterface_LockAuthentication_Call {
+	return &MockInterface_LockAuthentication_Call{Call: _e.mock.On("LockAuthentication", config, marker)}
+}
+
+func (_c *MockInterface_LockAuthentication_Call) Run(run func(config tokencache.Config, marker tokencache.AuthenticationMarker)) *MockInterface_LockAuthentication_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 tokencache.Config
+		if args[0] != nil {
+			arg0 = args[0].(tokencache.Config)
+		}
+		var arg1 tokencache.AuthenticationMarker
+		if args[1] != nil {
+			arg1 = args[1].(tokencache.AuthenticationMarker)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockInterface_LockAuthentication_Call) Return(closer io.Closer, err error) *MockInterface_LockAuthentication_Call {
+	_c.Call.Return(closer, err)
+	return _c
+}
+
+func (_c *MockInterface_LockAuthentication_Call) RunAndReturn(run func(config tokencache.Config, marker tokencache.AuthenticationMarker) (io.Closer, error)) *MockInterface_LockAuthentication_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Save provides a mock function for the type MockInterface
 func (_mock *MockInterface) Save(config tokencache.Config, key tokencache.Key, tokenSet oidc.TokenSet) error {
 	ret := _mock.Called(config, key, tokenSet)
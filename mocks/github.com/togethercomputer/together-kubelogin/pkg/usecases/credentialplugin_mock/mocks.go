@@ -8,6 +8,7 @@ import (
 	"context"
 
 	mock "github.com/stretchr/testify/mock"
+	credentialplugin0 "github.com/togethercomputer/together-kubelogin/pkg/credentialplugin"
 	"github.com/togethercomputer/together-kubelogin/pkg/usecases/credentialplugin"
 )
 
@@ -94,3 +95,71 @@ func (_c *MockInterface_Do_Call) RunAndReturn(run func(ctx context.Context, in c
 	_c.Call.Return(run)
 	return _c
 }
+
+// Token provides a mock function for the type MockInterface
+func (_mock *MockInterface) Token(ctx context.Context, in credentialplugin.Input) (*credentialplugin0.Output, error) {
+	ret := _mock.Called(ctx, in)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Token")
+	}
+
+	var r0 *credentialplugin0.Output
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, credentialplugin.Input) (*credentialplugin0.Output, error)); ok {
+		return returnFunc(ctx, in)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, credentialplugin.Input) *credentialplugin0.Output); ok {
+		r0 = returnFunc(ctx, in)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*credentialplugin0.Output)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, credentialplugin.Input) error); ok {
+		r1 = returnFunc(ctx, in)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockInterface_Token_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Token'
+type MockInterface_Token_Call struct {
+	*mock.Call
+}
+
+// Token is a helper method to define mock.On call
+//   - ctx context.Context
+//   - in credentialplugin.Input
+func (_e *MockInterface_Expecter) Token(ctx interface{}, in interface{}) *MockInterface_Token_Call {
+	return &MockInterface_Token_Call{Call: _e.mock.On("Token", ctx, in)}
+}
+
+func (_c *MockInterface_Token_Call) Run(run func(ctx context.Context, in credentialplugin.Input)) *MockInterface_Token_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 credentialplugin.Input
+		if args[1] != nil {
+			arg1 = args[1].(credentialplugin.Input)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockInterface_Token_Call) Return(output *credentialplugin0.Output, err error) *MockInterface_Token_Call {
+	_c.Call.Return(output, err)
+	return _c
+}
+
+func (_c *MockInterface_Token_Call) RunAndReturn(run func(ctx context.Context, in credentialplugin.Input) (*credentialplugin0.Output, error)) *MockInterface_Token_Call {
+	_c.Call.Return(run)
+	return _c
+}
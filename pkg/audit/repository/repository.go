@@ -0,0 +1,138 @@
+// Package repository provides access to the audit log.
+package repository
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/gofrs/flock"
+	"github.com/google/wire"
+	"github.com/togethercomputer/together-kubelogin/pkg/audit"
+)
+
+var Set = wire.NewSet(
+	wire.Struct(new(Repository), "*"),
+	wire.Bind(new(Interface), new(*Repository)),
+)
+
+type Interface interface {
+	Append(config audit.Config, record audit.Record) error
+	FindAll(config audit.Config) ([]audit.Record, error)
+}
+
+// Repository provides access to the audit log on the local filesystem.
+// The audit log is a file of JSON lines.
+// When the file exceeds the max size, it is renamed to FILE.1 and the older files are shifted.
+type Repository struct{}
+
+// Append writes the record to the end of the audit log.
+// It is safe to call from multiple processes.
+func (r *Repository) Append(config audit.Config, record audit.Record) error {
+	if !config.Enabled() {
+		return nil
+	}
+	b, err := json.Marshal(&record)
+	if err != nil {
+		return fmt.Errorf("could not encode the record: %w", err)
+	}
+	b = append(b, '\n')
+	if err := os.MkdirAll(filepath.Dir(config.Filename), 0700); err != nil {
+		return fmt.Errorf("could not create directory: %w", err)
+	}
+	lockFile := flock.New(config.Filename + ".lock")
+	if err := lockFile.Lock(); err != nil {
+		return fmt.Errorf("could not lock the audit log: %w", err)
+	}
+	defer func() { _ = lockFile.Unlock() }()
+	if err := rotate(config, int64(len(b))); err != nil {
+		return fmt.Errorf("could not rotate the audit log: %w", err)
+	}
+	f, err := os.OpenFile(config.Filename, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("could not open the audit log: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(b); err != nil {
+		return fmt.Errorf("could not write the audit log: %w", err)
+	}
+	return nil
+}
+
+// rotate renames the file if it will exceed the max size.
+func rotate(config audit.Config, size int64) error {
+	if config.MaxSize <= 0 {
+		return nil
+	}
+	st, err := os.Stat(config.Filename)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	if st.Size() == 0 || st.Size()+size <= config.MaxSize {
+		return nil
+	}
+	if config.MaxBackups <= 0 {
+		return os.Remove(config.Filename)
+	}
+	for i := config.MaxBackups - 1; i >= 1; i-- {
+		if err := os.Rename(backupFilename(config, i), backupFilename(config, i+1)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return os.Rename(config.Filename, backupFilename(config, 1))
+}
+
+func backupFilename(config audit.Config, i int) string {
+	return fmt.Sprintf("%s.%d", config.Filename, i)
+}
+
+// FindAll returns the records in the audit log and the rotated files, oldest first.
+func (r *Repository) FindAll(config audit.Config) ([]audit.Record, error) {
+	var records []audit.Record
+	filenames := []string{config.Filename}
+	for i := 1; i <= config.MaxBackups; i++ {
+		filenames = append([]string{backupFilename(config, i)}, filenames...)
+	}
+	for _, filename := range filenames {
+		r, err := readRecords(filename)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, r...)
+	}
+	return records, nil
+}
+
+func readRecords(filename string) ([]audit.Record, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("could not open the audit log: %w", err)
+	}
+	defer f.Close()
+	var records []audit.Record
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var record audit.Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("invalid record at %s:%d: %w", filename, line, err)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read the audit log: %w", err)
+	}
+	return records, nil
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/togethercomputer/together-kubelogin/pkg/audit"
+)
+
+func TestRepository(t *testing.T) {
+	var r Repository
+	newRecord := func(i int) audit.Record {
+		expiry := time.Date(2020, 1, 2, 4, 0, 0, 0, time.UTC)
+		return audit.Record{
+			Time:     time.Date(2020, 1, 2, 3, i, 0, 0, time.UTC),
+			Event:    audit.EventInteractiveLogin,
+			Grant:    "authcode",
+			Issuer:   "https://issuer.example.com",
+			ClientID: "YOUR_CLIENT_ID",
+			Subject:  "YOUR_SUBJECT",
+			Expiry:   &expiry,
+		}
+	}
+
+	t.Run("Disabled", func(t *testing.T) {
+		if err := r.Append(audit.Config{}, newRecord(0)); err != nil {
+			t.Errorf("Append error: %s", err)
+		}
+	})
+
+	t.Run("AppendAndFindAll", func(t *testing.T) {
+		config := audit.Config{Filename: filepath.Join(t.TempDir(), "audit", "audit.log")}
+		want := []audit.Record{newRecord(0), newRecord(1)}
+		for _, record := range want {
+			if err := r.Append(config, record); err != nil {
+				t.Fatalf("Append error: %s", err)
+			}
+		}
+		got, err := r.FindAll(config)
+		if err != nil {
+			t.Fatalf("FindAll error: %s", err)
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+		st, err := os.Stat(config.Filename)
+		if err != nil {
+			t.Fatalf("Stat error: %s", err)
+		}
+		if st.Mode().Perm() != 0600 {
+			t.Errorf("mode wants 0600 but %o", st.Mode().Perm())
+		}
+	})
+
+	t.Run("Rotation", func(t *testing.T) {
+		dir := t.TempDir()
+		// a record is about 200 bytes, so each file contains one record
+		config := audit.Config{Filename: filepath.Join(dir, "audit.log"), MaxSize: 300, MaxBackups: 2}
+		for i := range 5 {
+			if err := r.Append(config, newRecord(i)); err != nil {
+				t.Fatalf("Append error: %s", err)
+			}
+		}
+		got, err := r.FindAll(config)
+		if err != nil {
+			t.Fatalf("FindAll error: %s", err)
+		}
+		want := []audit.Record{newRecord(2), newRecord(3), newRecord(4)}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "audit.log.3")); !os.IsNotExist(err) {
+			t.Errorf("audit.log.3 wants not exist but %v", err)
+		}
+	})
+}
@@ -0,0 +1,81 @@
+// Package audit provides the types for the audit log of authentication.
+package audit
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/togethercomputer/together-kubelogin/pkg/claimpolicy"
+	"github.com/int128/oauth2dev"
+	"golang.org/x/oauth2"
+)
+
+// Event represents a kind of the audit record.
+type Event string
+
+const (
+	EventCacheHit         Event = "cache-hit"
+	EventRefresh          Event = "refresh"
+	EventInteractiveLogin Event = "interactive-login"
+	EventFailure          Event = "failure"
+)
+
+// Record represents a record of the audit log.
+// It must not contain any token.
+type Record struct {
+	Time       time.Time  `json:"time"`
+	Event      Event      `json:"event"`
+	Grant      string     `json:"grant,omitempty"`
+	Issuer     string     `json:"issuer"`
+	ClientID   string     `json:"client_id"`
+	Subject    string     `json:"subject,omitempty"`
+	Context    string     `json:"context,omitempty"` // kubeconfig context if known
+	Cluster    string     `json:"cluster,omitempty"` // server of the cluster if given by client-go
+	Expiry     *time.Time `json:"expiry,omitempty"`
+	ErrorClass string     `json:"error_class,omitempty"`
+}
+
+// Config represents the configuration of the audit log.
+// If Filename is empty, the audit log is disabled.
+type Config struct {
+	Filename   string
+	MaxSize    int64 // rotate the file when it exceeds this size in bytes
+	MaxBackups int   // number of the rotated files to keep
+}
+
+// Enabled returns true if the audit log is enabled.
+func (c Config) Enabled() bool {
+	return c.Filename != ""
+}
+
+// ClassifyError returns the class of the error without any detail,
+// such as timeout, canceled, network or the error code of OAuth 2.0.
+func ClassifyError(err error) string {
+	if err == nil {
+		return ""
+	}
+	var retrieveError *oauth2.RetrieveError
+	var tokenError oauth2dev.TokenErrorResponse
+	var netError net.Error
+	var ruleError *claimpolicy.RuleError
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.As(err, &retrieveError):
+		if retrieveError.ErrorCode != "" {
+			return "oauth2:" + retrieveError.ErrorCode
+		}
+		return "oauth2"
+	case errors.As(err, &tokenError):
+		return "oauth2:" + tokenError.ErrorCode
+	case errors.As(err, &netError):
+		return "network"
+	case errors.As(err, &ruleError):
+		return "claim-policy"
+	}
+	return "other"
+}
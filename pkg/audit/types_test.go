@@ -0,0 +1,28 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/togethercomputer/together-kubelogin/pkg/claimpolicy"
+	"github.com/int128/oauth2dev"
+	"golang.org/x/oauth2"
+)
+
+func TestClassifyError(t *testing.T) {
+	for want, err := range map[string]error{
+		"":                     nil,
+		"timeout":              fmt.Errorf("authentication error: %w", context.DeadlineExceeded),
+		"canceled":             context.Canceled,
+		"oauth2:invalid_grant": fmt.Errorf("token error: %w", &oauth2.RetrieveError{ErrorCode: "invalid_grant"}),
+		"oauth2:access_denied": fmt.Errorf("token error: %w", oauth2dev.TokenErrorResponse{ErrorCode: "access_denied"}),
+		"claim-policy":         fmt.Errorf("rejected: %w", &claimpolicy.RuleError{Rule: "email_verified=true"}),
+		"other":                errors.New("something wrong"),
+	} {
+		if got := ClassifyError(err); got != want {
+			t.Errorf("ClassifyError(%v) wants %q but got %q", err, want, got)
+		}
+	}
+}
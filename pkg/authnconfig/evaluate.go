@@ -0,0 +1,336 @@
+package authnconfig
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/ext"
+)
+
+// UserInfo represents the user which kube-apiserver maps from the claims.
+type UserInfo struct {
+	Username string              `json:"username"`
+	UID      string              `json:"uid,omitempty"`
+	Groups   []string            `json:"groups,omitempty"`
+	Extra    map[string][]string `json:"extra,omitempty"`
+}
+
+// RuleError represents the rule which rejected the token.
+type RuleError struct {
+	Path    string // e.g. jwt[0].claimValidationRules[1]
+	Rule    string // claim or expression of the rule
+	Message string
+}
+
+func (e *RuleError) Error() string {
+	if e.Rule == "" {
+		return fmt.Sprintf("%s: %s", e.Path, e.Message)
+	}
+	return fmt.Sprintf("%s (%s): %s", e.Path, e.Rule, e.Message)
+}
+
+// Evaluate maps the claims to a user in the same way as kube-apiserver.
+// It finds the JWT authenticator of the issuer and applies the rules in order.
+// If a rule rejects the token, it returns a RuleError.
+//
+// Note that this does not verify the signature of the token.
+func Evaluate(c AuthenticationConfiguration, claims map[string]any, now time.Time) (*UserInfo, error) {
+	iss, _ := claims["iss"].(string)
+	for i, a := range c.JWT {
+		if a.Issuer.URL == iss {
+			return evaluateJWTAuthenticator(fmt.Sprintf("jwt[%d]", i), a, claims, now)
+		}
+	}
+	return nil, &RuleError{Path: "jwt[*].issuer.url", Message: fmt.Sprintf("no issuer matches the iss claim %q", iss)}
+}
+
+func evaluateJWTAuthenticator(path string, a JWTAuthenticator, claims map[string]any, now time.Time) (*UserInfo, error) {
+	if err := validateAudiences(path, a.Issuer, claims); err != nil {
+		return nil, err
+	}
+	if exp, ok := claims["exp"].(float64); ok && now.After(time.Unix(int64(exp), 0)) {
+		return nil, &RuleError{Path: path, Rule: "exp", Message: fmt.Sprintf("token has expired at %s", time.Unix(int64(exp), 0))}
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Before(time.Unix(int64(nbf), 0)) {
+		return nil, &RuleError{Path: path, Rule: "nbf", Message: fmt.Sprintf("token is not valid before %s", time.Unix(int64(nbf), 0))}
+	}
+	for i, rule := range a.ClaimValidationRules {
+		rulePath := fmt.Sprintf("%s.claimValidationRules[%d]", path, i)
+		if err := validateClaim(rulePath, rule, claims); err != nil {
+			return nil, err
+		}
+	}
+
+	var userInfo UserInfo
+	mappingsPath := path + ".claimMappings"
+	username, err := mapUsername(mappingsPath+".username", a.ClaimMappings.Username, claims)
+	if err != nil {
+		return nil, err
+	}
+	userInfo.Username = username
+	groups, err := mapGroups(mappingsPath+".groups", a.ClaimMappings.Groups, claims)
+	if err != nil {
+		return nil, err
+	}
+	userInfo.Groups = groups
+	uid, err := mapString(mappingsPath+".uid", a.ClaimMappings.UID.Claim, a.ClaimMappings.UID.Expression, claims)
+	if err != nil {
+		return nil, err
+	}
+	userInfo.UID = uid
+	for i, extra := range a.ClaimMappings.Extra {
+		extraPath := fmt.Sprintf("%s.extra[%d]", mappingsPath, i)
+		values, err := evaluateStringOrList(extraPath, extra.ValueExpression, claimsVariable(claims))
+		if err != nil {
+			return nil, err
+		}
+		if len(values) == 0 {
+			continue
+		}
+		if userInfo.Extra == nil {
+			userInfo.Extra = make(map[string][]string)
+		}
+		userInfo.Extra[extra.Key] = values
+	}
+
+	for i, rule := range a.UserValidationRules {
+		rulePath := fmt.Sprintf("%s.userValidationRules[%d]", path, i)
+		ok, err := evaluateBool(rulePath, rule.Expression, userVariable(userInfo))
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, &RuleError{Path: rulePath, Rule: rule.Expression, Message: messageOrDefault(rule.Message)}
+		}
+	}
+	return &userInfo, nil
+}
+
+func validateAudiences(path string, issuer Issuer, claims map[string]any) error {
+	rulePath := path + ".issuer.audiences"
+	if len(issuer.Audiences) > 1 && issuer.AudienceMatchPolicy != AudienceMatchPolicyMatchAny {
+		return &RuleError{Path: rulePath, Message: "audienceMatchPolicy must be MatchAny when multiple audiences are set"}
+	}
+	var audiences []string
+	switch aud := claims["aud"].(type) {
+	case string:
+		audiences = []string{aud}
+	case []any:
+		for _, v := range aud {
+			if s, ok := v.(string); ok {
+				audiences = append(audiences, s)
+			}
+		}
+	}
+	for _, audience := range issuer.Audiences {
+		if slices.Contains(audiences, audience) {
+			return nil
+		}
+	}
+	return &RuleError{Path: rulePath, Message: fmt.Sprintf("aud claim %v does not contain any of %v", audiences, issuer.Audiences)}
+}
+
+func validateClaim(path string, rule ClaimValidationRule, claims map[string]any) error {
+	if rule.Claim != "" {
+		value, ok := claims[rule.Claim].(string)
+		if !ok || value != rule.RequiredValue {
+			return &RuleError{
+				Path:    path,
+				Rule:    rule.Claim,
+				Message: fmt.Sprintf("claim must be %q but was %v", rule.RequiredValue, claims[rule.Claim]),
+			}
+		}
+		return nil
+	}
+	ok, err := evaluateBool(path, rule.Expression, claimsVariable(claims))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return &RuleError{Path: path, Rule: rule.Expression, Message: messageOrDefault(rule.Message)}
+	}
+	return nil
+}
+
+func mapUsername(path string, m PrefixedClaimOrExpression, claims map[string]any) (string, error) {
+	if m.Claim == "" {
+		username, err := mapString(path, "", m.Expression, claims)
+		if err != nil {
+			return "", err
+		}
+		if username == "" {
+			return "", &RuleError{Path: path, Rule: m.Expression, Message: "username is empty"}
+		}
+		return username, nil
+	}
+	if m.Prefix == nil {
+		return "", &RuleError{Path: path + ".prefix", Message: "prefix is required when claim is set"}
+	}
+	username, ok := claims[m.Claim].(string)
+	if !ok || username == "" {
+		return "", &RuleError{Path: path, Rule: m.Claim, Message: "claim is missing or not a string"}
+	}
+	if m.Claim == "email" {
+		// kube-apiserver requires email_verified to be true if present
+		if emailVerified, ok := claims["email_verified"]; ok && emailVerified != true {
+			return "", &RuleError{Path: path, Rule: m.Claim, Message: "email_verified claim must be true"}
+		}
+	}
+	return *m.Prefix + username, nil
+}
+
+func mapGroups(path string, m PrefixedClaimOrExpression, claims map[string]any) ([]string, error) {
+	if m.Claim == "" && m.Expression == "" {
+		return nil, nil
+	}
+	if m.Claim == "" {
+		return evaluateStringOrList(path, m.Expression, claimsVariable(claims))
+	}
+	if m.Prefix == nil {
+		return nil, &RuleError{Path: path + ".prefix", Message: "prefix is required when claim is set"}
+	}
+	var groups []string
+	switch value := claims[m.Claim].(type) {
+	case nil:
+	case string:
+		groups = []string{*m.Prefix + value}
+	case []any:
+		for _, v := range value {
+			s, ok := v.(string)
+			if !ok {
+				return nil, &RuleError{Path: path, Rule: m.Claim, Message: "claim must be a string or an array of strings"}
+			}
+			groups = append(groups, *m.Prefix+s)
+		}
+	default:
+		return nil, &RuleError{Path: path, Rule: m.Claim, Message: "claim must be a string or an array of strings"}
+	}
+	return groups, nil
+}
+
+func mapString(path, claim, expression string, claims map[string]any) (string, error) {
+	if claim != "" {
+		value, ok := claims[claim].(string)
+		if !ok {
+			return "", &RuleError{Path: path, Rule: claim, Message: "claim is missing or not a string"}
+		}
+		return value, nil
+	}
+	if expression == "" {
+		return "", nil
+	}
+	value, err := evaluate(path, expression, claimsVariable(claims))
+	if err != nil {
+		return "", err
+	}
+	s, ok := value.Value().(string)
+	if !ok {
+		return "", &RuleError{Path: path, Rule: expression, Message: fmt.Sprintf("expression must return a string but returned %s", value.Type().TypeName())}
+	}
+	return s, nil
+}
+
+func evaluateBool(path, expression string, variables map[string]any) (bool, error) {
+	value, err := evaluate(path, expression, variables)
+	if err != nil {
+		return false, err
+	}
+	b, ok := value.Value().(bool)
+	if !ok {
+		return false, &RuleError{Path: path, Rule: expression, Message: fmt.Sprintf("expression must return a bool but returned %s", value.Type().TypeName())}
+	}
+	return b, nil
+}
+
+func evaluateStringOrList(path, expression string, variables map[string]any) ([]string, error) {
+	value, err := evaluate(path, expression, variables)
+	if err != nil {
+		return nil, err
+	}
+	if s, ok := value.Value().(string); ok {
+		if s == "" {
+			return nil, nil
+		}
+		return []string{s}, nil
+	}
+	native, err := value.ConvertToNative(stringSliceType)
+	if err != nil {
+		return nil, &RuleError{Path: path, Rule: expression, Message: fmt.Sprintf("expression must return a string or a list of strings but returned %s", value.Type().TypeName())}
+	}
+	return native.([]string), nil
+}
+
+var stringSliceType = reflect.TypeOf([]string{})
+
+var celEnv = mustNewCELEnv()
+
+func mustNewCELEnv() *cel.Env {
+	env, err := cel.NewEnv(
+		cel.Variable("claims", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("user", cel.MapType(cel.StringType, cel.DynType)),
+		cel.OptionalTypes(),
+		cel.CrossTypeNumericComparisons(true),
+		ext.Strings(),
+		ext.Lists(),
+		ext.Sets(),
+	)
+	if err != nil {
+		panic(err)
+	}
+	return env
+}
+
+func evaluate(path, expression string, variables map[string]any) (ref.Val, error) {
+	ast, issues := celEnv.Compile(expression)
+	if issues.Err() != nil {
+		return nil, &RuleError{Path: path, Rule: expression, Message: fmt.Sprintf("invalid expression: %s", issues.Err())}
+	}
+	program, err := celEnv.Program(ast)
+	if err != nil {
+		return nil, &RuleError{Path: path, Rule: expression, Message: fmt.Sprintf("invalid expression: %s", err)}
+	}
+	value, _, err := program.Eval(variables)
+	if err != nil {
+		return nil, &RuleError{Path: path, Rule: expression, Message: fmt.Sprintf("evaluation error: %s", err)}
+	}
+	if types.IsError(value) {
+		return nil, &RuleError{Path: path, Rule: expression, Message: fmt.Sprintf("evaluation error: %v", value)}
+	}
+	return value, nil
+}
+
+func claimsVariable(claims map[string]any) map[string]any {
+	return map[string]any{"claims": claims, "user": map[string]any{}}
+}
+
+func userVariable(u UserInfo) map[string]any {
+	extra := make(map[string]any, len(u.Extra))
+	for k, v := range u.Extra {
+		extra[k] = v
+	}
+	groups := u.Groups
+	if groups == nil {
+		groups = []string{}
+	}
+	return map[string]any{
+		"claims": map[string]any{},
+		"user": map[string]any{
+			"username": u.Username,
+			"uid":      u.UID,
+			"groups":   groups,
+			"extra":    extra,
+		},
+	}
+}
+
+func messageOrDefault(message string) string {
+	if message == "" {
+		return "rule is not satisfied"
+	}
+	return message
+}
@@ -0,0 +1,156 @@
+package authnconfig
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestEvaluate(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	emptyPrefix := ""
+	issuerPrefix := "oidc:"
+	c := AuthenticationConfiguration{
+		APIVersion: APIVersion,
+		Kind:       Kind,
+		JWT: []JWTAuthenticator{
+			{
+				Issuer: Issuer{
+					URL:       "https://issuer.example.com",
+					Audiences: []string{"YOUR_CLIENT_ID"},
+				},
+				ClaimValidationRules: []ClaimValidationRule{
+					{Claim: "hd", RequiredValue: "example.com"},
+					{Expression: "claims.email_verified == true", Message: "email must be verified"},
+				},
+				ClaimMappings: ClaimMappings{
+					Username: PrefixedClaimOrExpression{Claim: "email", Prefix: &emptyPrefix},
+					Groups:   PrefixedClaimOrExpression{Claim: "groups", Prefix: &issuerPrefix},
+					UID:      ClaimOrExpression{Claim: "sub"},
+					Extra: []ExtraMapping{
+						{Key: "example.com/tenant", ValueExpression: "claims.?tenant.orValue('')"},
+					},
+				},
+				UserValidationRules: []UserValidationRule{
+					{Expression: "!user.username.startsWith('system:')", Message: "username cannot use reserved system: prefix"},
+				},
+			},
+		},
+	}
+	validClaims := func() map[string]any {
+		return map[string]any{
+			"iss":            "https://issuer.example.com",
+			"aud":            "YOUR_CLIENT_ID",
+			"sub":            "YOUR_SUBJECT",
+			"exp":            float64(now.Add(time.Hour).Unix()),
+			"hd":             "example.com",
+			"email":          "alice@example.com",
+			"email_verified": true,
+			"groups":         []any{"admin", "dev"},
+			"tenant":         "acme",
+		}
+	}
+
+	t.Run("Accepted", func(t *testing.T) {
+		got, err := Evaluate(c, validClaims(), now)
+		if err != nil {
+			t.Fatalf("Evaluate error: %s", err)
+		}
+		want := &UserInfo{
+			Username: "alice@example.com",
+			UID:      "YOUR_SUBJECT",
+			Groups:   []string{"oidc:admin", "oidc:dev"},
+			Extra:    map[string][]string{"example.com/tenant": {"acme"}},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	tests := map[string]struct {
+		mutation func(claims map[string]any)
+		want     RuleError
+	}{
+		"UnknownIssuer": {
+			mutation: func(claims map[string]any) { claims["iss"] = "https://evil.example.com" },
+			want:     RuleError{Path: "jwt[*].issuer.url", Message: `no issuer matches the iss claim "https://evil.example.com"`},
+		},
+		"AudienceMismatch": {
+			mutation: func(claims map[string]any) { claims["aud"] = []any{"kubernetes"} },
+			want:     RuleError{Path: "jwt[0].issuer.audiences", Message: "aud claim [kubernetes] does not contain any of [YOUR_CLIENT_ID]"},
+		},
+		"Expired": {
+			mutation: func(claims map[string]any) { claims["exp"] = float64(now.Add(-time.Hour).Unix()) },
+			want:     RuleError{Path: "jwt[0]", Rule: "exp", Message: "token has expired at " + time.Unix(now.Add(-time.Hour).Unix(), 0).String()},
+		},
+		"RequiredClaim": {
+			mutation: func(claims map[string]any) { claims["hd"] = "example.org" },
+			want:     RuleError{Path: "jwt[0].claimValidationRules[0]", Rule: "hd", Message: `claim must be "example.com" but was example.org`},
+		},
+		"ClaimExpression": {
+			mutation: func(claims map[string]any) { claims["email_verified"] = false },
+			want:     RuleError{Path: "jwt[0].claimValidationRules[1]", Rule: "claims.email_verified == true", Message: "email must be verified"},
+		},
+		"MissingUsernameClaim": {
+			mutation: func(claims map[string]any) { delete(claims, "email") },
+			want:     RuleError{Path: "jwt[0].claimMappings.username", Rule: "email", Message: "claim is missing or not a string"},
+		},
+		"UserValidationRule": {
+			mutation: func(claims map[string]any) { claims["email"] = "system:admin" },
+			want:     RuleError{Path: "jwt[0].userValidationRules[0]", Rule: "!user.username.startsWith('system:')", Message: "username cannot use reserved system: prefix"},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			claims := validClaims()
+			tc.mutation(claims)
+			_, err := Evaluate(c, claims, now)
+			var got *RuleError
+			if !errors.As(err, &got) {
+				t.Fatalf("error wants RuleError but was %v", err)
+			}
+			if diff := cmp.Diff(tc.want, *got); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("Expressions", func(t *testing.T) {
+		c := AuthenticationConfiguration{
+			JWT: []JWTAuthenticator{
+				{
+					Issuer: Issuer{
+						URL:                 "https://issuer.example.com",
+						Audiences:           []string{"YOUR_CLIENT_ID", "kubernetes"},
+						AudienceMatchPolicy: AudienceMatchPolicyMatchAny,
+					},
+					ClaimMappings: ClaimMappings{
+						Username: PrefixedClaimOrExpression{Expression: "'user:' + claims.sub"},
+						Groups:   PrefixedClaimOrExpression{Expression: "claims.roles.map(r, 'role:' + r)"},
+						UID:      ClaimOrExpression{Expression: "claims.sub"},
+					},
+				},
+			},
+		}
+		claims := map[string]any{
+			"iss":   "https://issuer.example.com",
+			"aud":   []any{"kubernetes"},
+			"sub":   "YOUR_SUBJECT",
+			"roles": []any{"admin"},
+		}
+		got, err := Evaluate(c, claims, now)
+		if err != nil {
+			t.Fatalf("Evaluate error: %s", err)
+		}
+		want := &UserInfo{
+			Username: "user:YOUR_SUBJECT",
+			UID:      "YOUR_SUBJECT",
+			Groups:   []string{"role:admin"},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
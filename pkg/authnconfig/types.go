@@ -0,0 +1,75 @@
+// Package authnconfig provides the structured authentication configuration of kube-apiserver.
+//
+// See https://kubernetes.io/docs/reference/access-authn-authz/authentication/#using-authentication-configuration
+package authnconfig
+
+const (
+	APIVersion = "apiserver.config.k8s.io/v1"
+	Kind       = "AuthenticationConfiguration"
+)
+
+// AuthenticationConfiguration represents a subset of apiserver.config.k8s.io/v1 AuthenticationConfiguration.
+type AuthenticationConfiguration struct {
+	APIVersion string             `json:"apiVersion"`
+	Kind       string             `json:"kind"`
+	JWT        []JWTAuthenticator `json:"jwt"`
+}
+
+// JWTAuthenticator represents an issuer of JWT and how to map the claims to a user.
+type JWTAuthenticator struct {
+	Issuer               Issuer                `json:"issuer"`
+	ClaimValidationRules []ClaimValidationRule `json:"claimValidationRules,omitempty"`
+	ClaimMappings        ClaimMappings         `json:"claimMappings"`
+	UserValidationRules  []UserValidationRule  `json:"userValidationRules,omitempty"`
+}
+
+// AudienceMatchPolicyMatchAny is the only audience match policy supported by kube-apiserver.
+const AudienceMatchPolicyMatchAny = "MatchAny"
+
+type Issuer struct {
+	URL                  string   `json:"url"`
+	DiscoveryURL         string   `json:"discoveryURL,omitempty"`
+	CertificateAuthority string   `json:"certificateAuthority,omitempty"`
+	Audiences            []string `json:"audiences"`
+	AudienceMatchPolicy  string   `json:"audienceMatchPolicy,omitempty"`
+}
+
+// ClaimValidationRule represents a rule to validate the claims.
+// Either Claim or Expression is set.
+type ClaimValidationRule struct {
+	Claim         string `json:"claim,omitempty"`
+	RequiredValue string `json:"requiredValue,omitempty"`
+	Expression    string `json:"expression,omitempty"`
+	Message       string `json:"message,omitempty"`
+}
+
+type ClaimMappings struct {
+	Username PrefixedClaimOrExpression `json:"username"`
+	Groups   PrefixedClaimOrExpression `json:"groups,omitzero"`
+	UID      ClaimOrExpression         `json:"uid,omitzero"`
+	Extra    []ExtraMapping            `json:"extra,omitempty"`
+}
+
+// PrefixedClaimOrExpression represents a claim with the prefix or a CEL expression.
+// Prefix is required if Claim is set.
+type PrefixedClaimOrExpression struct {
+	Claim      string  `json:"claim,omitempty"`
+	Prefix     *string `json:"prefix,omitempty"`
+	Expression string  `json:"expression,omitempty"`
+}
+
+type ClaimOrExpression struct {
+	Claim      string `json:"claim,omitempty"`
+	Expression string `json:"expression,omitempty"`
+}
+
+type ExtraMapping struct {
+	Key             string `json:"key"`
+	ValueExpression string `json:"valueExpression"`
+}
+
+// UserValidationRule represents a rule to validate the user after the claim mappings.
+type UserValidationRule struct {
+	Expression string `json:"expression"`
+	Message    string `json:"message,omitempty"`
+}
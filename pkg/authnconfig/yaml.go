@@ -0,0 +1,35 @@
+package authnconfig
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Marshal returns the YAML representation of the configuration.
+func Marshal(c AuthenticationConfiguration) ([]byte, error) {
+	b, err := yaml.Marshal(c)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal the authentication configuration: %w", err)
+	}
+	return b, nil
+}
+
+// Load reads the configuration from the file.
+func Load(filename string) (*AuthenticationConfiguration, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s: %w", filename, err)
+	}
+	var c AuthenticationConfiguration
+	if err := yaml.UnmarshalStrict(b, &c); err != nil {
+		return nil, fmt.Errorf("invalid authentication configuration %s: %w", filename, err)
+	}
+	// v1alpha1 and v1beta1 have the same schema as v1
+	if !strings.HasPrefix(c.APIVersion, "apiserver.config.k8s.io/") || c.Kind != Kind {
+		return nil, fmt.Errorf("%s must be %s %s but was %s %s", filename, APIVersion, Kind, c.APIVersion, c.Kind)
+	}
+	return &c, nil
+}
@@ -0,0 +1,171 @@
+// Package claimpolicy provides the policy of claims which a token must satisfy
+// before it is passed to kubectl.
+package claimpolicy
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/togethercomputer/together-kubelogin/pkg/jwt"
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/ext"
+)
+
+// Policy represents the rules of the claims.
+// The zero value has no rule.
+type Policy struct {
+	// RequiredClaims are the rules of NAME=VALUE.
+	// If the claim is a list, it must contain the value.
+	RequiredClaims []RequiredClaim
+	// Expressions are the CEL expressions which must return true.
+	// The claims are available as the variable claims.
+	Expressions []string
+}
+
+// RequiredClaim represents a rule that the claim must have the value.
+type RequiredClaim struct {
+	Claim string
+	Value string
+}
+
+func (r RequiredClaim) String() string {
+	return fmt.Sprintf("%s=%s", r.Claim, r.Value)
+}
+
+// ParseRequiredClaim parses a rule of NAME=VALUE.
+func ParseRequiredClaim(s string) (RequiredClaim, error) {
+	claim, value, ok := strings.Cut(s, "=")
+	if !ok || claim == "" {
+		return RequiredClaim{}, fmt.Errorf("required claim must be NAME=VALUE but was %q", s)
+	}
+	return RequiredClaim{Claim: claim, Value: value}, nil
+}
+
+// IsZero returns true if the policy has no rule.
+func (p Policy) IsZero() bool {
+	return len(p.RequiredClaims) == 0 && len(p.Expressions) == 0
+}
+
+// RuleError represents the rule which rejected the token.
+type RuleError struct {
+	Rule    string // e.g. email_verified=true or "k8s-admins" in claims.groups
+	Message string
+}
+
+func (e *RuleError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Rule, e.Message)
+}
+
+// Evaluate applies the rules to the claims in order.
+// If a rule rejects the claims, it returns a RuleError.
+func (p Policy) Evaluate(claims *jwt.Claims) error {
+	for _, r := range p.RequiredClaims {
+		if err := evaluateRequiredClaim(r, claims); err != nil {
+			return err
+		}
+	}
+	for _, expression := range p.Expressions {
+		if err := evaluateExpression(expression, claims); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func evaluateRequiredClaim(r RequiredClaim, claims *jwt.Claims) error {
+	value, ok := claims.Raw[r.Claim]
+	if !ok {
+		return &RuleError{Rule: r.String(), Message: "claim is missing"}
+	}
+	switch v := value.(type) {
+	case []any:
+		if slices.ContainsFunc(v, func(e any) bool { return formatValue(e) == r.Value }) {
+			return nil
+		}
+		return &RuleError{Rule: r.String(), Message: fmt.Sprintf("claim %v does not contain %q", v, r.Value)}
+	default:
+		if formatValue(v) == r.Value {
+			return nil
+		}
+		return &RuleError{Rule: r.String(), Message: fmt.Sprintf("claim must be %q but was %v", r.Value, v)}
+	}
+}
+
+// formatValue returns the string representation of a JSON value.
+func formatValue(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+var celEnv = mustNewCELEnv()
+
+func mustNewCELEnv() *cel.Env {
+	env, err := cel.NewEnv(
+		cel.Variable("claims", cel.MapType(cel.StringType, cel.DynType)),
+		cel.OptionalTypes(),
+		cel.CrossTypeNumericComparisons(true),
+		ext.Strings(),
+		ext.Lists(),
+		ext.Sets(),
+	)
+	if err != nil {
+		panic(err)
+	}
+	return env
+}
+
+// Validate returns an error if an expression is invalid.
+// It is useful to report an error before authentication.
+func (p Policy) Validate() error {
+	for _, expression := range p.Expressions {
+		ast, issues := celEnv.Compile(expression)
+		if issues.Err() != nil {
+			return &RuleError{Rule: expression, Message: fmt.Sprintf("invalid expression: %s", issues.Err())}
+		}
+		// a field of claims is dynamic and checked on evaluation
+		if out := ast.OutputType(); !out.IsExactType(cel.BoolType) && !out.IsExactType(cel.DynType) {
+			return &RuleError{Rule: expression, Message: fmt.Sprintf("expression must return a bool but returns %s", out)}
+		}
+	}
+	return nil
+}
+
+func evaluateExpression(expression string, claims *jwt.Claims) error {
+	ast, issues := celEnv.Compile(expression)
+	if issues.Err() != nil {
+		return &RuleError{Rule: expression, Message: fmt.Sprintf("invalid expression: %s", issues.Err())}
+	}
+	program, err := celEnv.Program(ast)
+	if err != nil {
+		return &RuleError{Rule: expression, Message: fmt.Sprintf("invalid expression: %s", err)}
+	}
+	raw := claims.Raw
+	if raw == nil {
+		raw = map[string]any{}
+	}
+	value, _, err := program.Eval(map[string]any{"claims": raw})
+	if err != nil {
+		return &RuleError{Rule: expression, Message: fmt.Sprintf("evaluation error: %s", err)}
+	}
+	if types.IsError(value) {
+		return &RuleError{Rule: expression, Message: fmt.Sprintf("evaluation error: %v", value)}
+	}
+	ok, isBool := value.Value().(bool)
+	if !isBool {
+		return &RuleError{Rule: expression, Message: fmt.Sprintf("expression must return a bool but returned %s", value.Type().TypeName())}
+	}
+	if !ok {
+		return &RuleError{Rule: expression, Message: "expression returned false"}
+	}
+	return nil
+}
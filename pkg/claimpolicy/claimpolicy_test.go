@@ -0,0 +1,123 @@
+package claimpolicy
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/togethercomputer/together-kubelogin/pkg/jwt"
+)
+
+func TestPolicy_Evaluate(t *testing.T) {
+	claims := &jwt.Claims{
+		Raw: map[string]any{
+			"email":          "alice@example.com",
+			"email_verified": true,
+			"groups":         []any{"developers", "k8s-admins"},
+			"aud":            "kubernetes",
+			"level":          float64(3),
+		},
+	}
+	tests := map[string]struct {
+		policy  Policy
+		wantErr *RuleError
+	}{
+		"NoRule": {},
+		"RequiredClaims": {
+			policy: Policy{
+				RequiredClaims: []RequiredClaim{
+					{Claim: "email_verified", Value: "true"},
+					{Claim: "groups", Value: "k8s-admins"},
+					{Claim: "aud", Value: "kubernetes"},
+					{Claim: "level", Value: "3"},
+				},
+			},
+		},
+		"RequiredClaimMissing": {
+			policy:  Policy{RequiredClaims: []RequiredClaim{{Claim: "hd", Value: "example.com"}}},
+			wantErr: &RuleError{Rule: "hd=example.com", Message: "claim is missing"},
+		},
+		"RequiredClaimNotInList": {
+			policy:  Policy{RequiredClaims: []RequiredClaim{{Claim: "groups", Value: "sre"}}},
+			wantErr: &RuleError{Rule: "groups=sre", Message: `claim [developers k8s-admins] does not contain "sre"`},
+		},
+		"RequiredClaimNotEqual": {
+			policy:  Policy{RequiredClaims: []RequiredClaim{{Claim: "email_verified", Value: "false"}}},
+			wantErr: &RuleError{Rule: "email_verified=false", Message: `claim must be "false" but was true`},
+		},
+		"Expressions": {
+			policy: Policy{
+				Expressions: []string{
+					`claims.email_verified == true`,
+					`"k8s-admins" in claims.groups`,
+					`claims.email.endsWith("@example.com")`,
+				},
+			},
+		},
+		"ExpressionReturnedFalse": {
+			policy: Policy{
+				Expressions: []string{
+					`claims.email_verified == true`,
+					`"sre" in claims.groups`,
+				},
+			},
+			wantErr: &RuleError{Rule: `"sre" in claims.groups`, Message: "expression returned false"},
+		},
+		"ExpressionNotBool": {
+			policy:  Policy{Expressions: []string{`claims.email`}},
+			wantErr: &RuleError{Rule: `claims.email`, Message: "expression must return a bool but returned string"},
+		},
+	}
+	for name, c := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := c.policy.Evaluate(claims)
+			if c.wantErr == nil {
+				if err != nil {
+					t.Fatalf("Evaluate returned error: %s", err)
+				}
+				return
+			}
+			var ruleErr *RuleError
+			if !errors.As(err, &ruleErr) {
+				t.Fatalf("error wants RuleError but got %v", err)
+			}
+			if diff := cmp.Diff(c.wantErr, ruleErr); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPolicy_Validate(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		p := Policy{Expressions: []string{`claims.email_verified == true`, `claims.email_verified`}}
+		if err := p.Validate(); err != nil {
+			t.Errorf("Validate returned error: %s", err)
+		}
+	})
+	t.Run("SyntaxError", func(t *testing.T) {
+		p := Policy{Expressions: []string{`claims.email_verified ==`}}
+		if err := p.Validate(); err == nil {
+			t.Errorf("Validate wants error but got nil")
+		}
+	})
+	t.Run("NotBool", func(t *testing.T) {
+		p := Policy{Expressions: []string{`"foo"`}}
+		if err := p.Validate(); err == nil {
+			t.Errorf("Validate wants error but got nil")
+		}
+	})
+}
+
+func TestParseRequiredClaim(t *testing.T) {
+	got, err := ParseRequiredClaim("hd=example.com")
+	if err != nil {
+		t.Fatalf("ParseRequiredClaim returned error: %s", err)
+	}
+	if diff := cmp.Diff(RequiredClaim{Claim: "hd", Value: "example.com"}, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+	if _, err := ParseRequiredClaim("hd"); err == nil {
+		t.Errorf("ParseRequiredClaim wants error but got nil")
+	}
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	tokencacheagent "github.com/togethercomputer/together-kubelogin/pkg/tokencache/agent"
+	"github.com/togethercomputer/together-kubelogin/pkg/usecases/agent"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// getDefaultAgentSocket returns the socket in $XDG_RUNTIME_DIR if set,
+// otherwise in the token cache directory.
+func getDefaultAgentSocket() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "kubelogin", "agent.sock")
+	}
+	return filepath.Join(getDefaultTokenCacheDir(), "agent.sock")
+}
+
+type agentOptions struct {
+	Socket        string
+	RefreshBefore time.Duration
+}
+
+func (o *agentOptions) addFlags(f *pflag.FlagSet) {
+	f.StringVar(&o.Socket, "socket", getDefaultAgentSocket(), "Path to the Unix domain socket")
+	f.DurationVar(&o.RefreshBefore, "refresh-before", time.Minute, "Refresh the token before the expiry")
+}
+
+type Agent struct {
+	Agent agent.Interface
+}
+
+func (cmd *Agent) New() *cobra.Command {
+	var o agentOptions
+	c := &cobra.Command{
+		Use:   "agent [flags]",
+		Short: "Run the token agent",
+		Long: fmt.Sprintf(`Run the token agent.
+
+This holds the tokens in memory and refreshes them before the expiry.
+It serves the token cache over the Unix domain socket to the processes of the same user.
+If $%[1]s is set, get-token uses the agent, otherwise it falls back to the token cache.
+`, tokencacheagent.SocketEnvName),
+		Example: fmt.Sprintf(`  # Run the agent in background
+  kubelogin agent &
+  export %s=%s`, tokencacheagent.SocketEnvName, getDefaultAgentSocket()),
+		Args: cobra.NoArgs,
+		RunE: func(c *cobra.Command, _ []string) error {
+			in := agent.Input{
+				Socket:        expandHomedir(o.Socket),
+				RefreshBefore: o.RefreshBefore,
+			}
+			if err := cmd.Agent.Do(c.Context(), in); err != nil {
+				return fmt.Errorf("agent: %w", err)
+			}
+			return nil
+		},
+	}
+	c.Flags().SortFlags = false
+	o.addFlags(c.Flags())
+	return c
+}
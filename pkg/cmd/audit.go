@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/togethercomputer/together-kubelogin/pkg/audit"
+	"github.com/togethercomputer/together-kubelogin/pkg/usecases/auditshow"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+func getDefaultAuditLog() string {
+	return filepath.Join(getDefaultTokenCacheDir(), "audit.log")
+}
+
+// auditOptions represents the options for the audit log.
+type auditOptions struct {
+	AuditLog           string
+	AuditLogMaxSizeMB  int
+	AuditLogMaxBackups int
+}
+
+func (o *auditOptions) addFlags(f *pflag.FlagSet) {
+	f.StringVar(&o.AuditLog, "audit-log", "", fmt.Sprintf("If set, append the authentication events to the audit log file (e.g. %s)", getDefaultAuditLog()))
+	o.addRotationFlags(f)
+}
+
+func (o *auditOptions) addRotationFlags(f *pflag.FlagSet) {
+	f.IntVar(&o.AuditLogMaxSizeMB, "audit-log-max-size", 10, "Max size of the audit log file in megabytes before rotation")
+	f.IntVar(&o.AuditLogMaxBackups, "audit-log-max-backups", 3, "Number of the rotated audit log files to keep")
+}
+
+func (o *auditOptions) expandHomedir() {
+	o.AuditLog = expandHomedir(o.AuditLog)
+}
+
+// auditConfig returns the config of the audit log.
+// It returns the zero value if the audit log is disabled.
+func (o *auditOptions) auditConfig() audit.Config {
+	if o.AuditLog == "" {
+		return audit.Config{}
+	}
+	return audit.Config{
+		Filename:   o.AuditLog,
+		MaxSize:    int64(o.AuditLogMaxSizeMB) * 1024 * 1024,
+		MaxBackups: o.AuditLogMaxBackups,
+	}
+}
+
+var allAuditEvents = strings.Join([]string{
+	string(audit.EventCacheHit),
+	string(audit.EventRefresh),
+	string(audit.EventInteractiveLogin),
+	string(audit.EventFailure),
+}, "|")
+
+var allAuditShowFormats = strings.Join([]string{string(auditshow.FormatTable), string(auditshow.FormatJSON)}, "|")
+
+// auditShowOptions represents the options for audit show command.
+type auditShowOptions struct {
+	auditOptions auditOptions
+	Since        time.Duration
+	Event        string
+	Issuer       string
+	Subject      string
+	Context      string
+	Format       string
+}
+
+func (o *auditShowOptions) addFlags(f *pflag.FlagSet) {
+	f.StringVar(&o.auditOptions.AuditLog, "audit-log", getDefaultAuditLog(), "Path to the audit log file")
+	o.auditOptions.addRotationFlags(f)
+	f.DurationVar(&o.Since, "since", 0, "If set, show only the records within the duration (e.g. 24h)")
+	f.StringVar(&o.Event, "event", "", fmt.Sprintf("If set, show only the event. One of (%s)", allAuditEvents))
+	f.StringVar(&o.Issuer, "issuer", "", "If set, show only the issuer URL")
+	f.StringVar(&o.Subject, "subject", "", "If set, show only the subject")
+	f.StringVar(&o.Context, "context", "", "If set, show only the kubeconfig context")
+	f.StringVar(&o.Format, "format", string(auditshow.FormatTable), fmt.Sprintf("Output format. One of (%s)", allAuditShowFormats))
+}
+
+func (o *auditShowOptions) auditShowInput() (auditshow.Input, error) {
+	o.auditOptions.expandHomedir()
+	in := auditshow.Input{
+		AuditConfig: o.auditOptions.auditConfig(),
+		Filter: auditshow.Filter{
+			Since:   o.Since,
+			Issuer:  o.Issuer,
+			Subject: o.Subject,
+			Context: o.Context,
+		},
+	}
+	switch o.Event {
+	case "":
+	case string(audit.EventCacheHit), string(audit.EventRefresh), string(audit.EventInteractiveLogin), string(audit.EventFailure):
+		in.Filter.Event = audit.Event(o.Event)
+	default:
+		return auditshow.Input{}, fmt.Errorf("event must be one of (%s)", allAuditEvents)
+	}
+	switch o.Format {
+	case string(auditshow.FormatTable), string(auditshow.FormatJSON):
+		in.Format = auditshow.Format(o.Format)
+	default:
+		return auditshow.Input{}, fmt.Errorf("format must be one of (%s)", allAuditShowFormats)
+	}
+	return in, nil
+}
+
+type Audit struct {
+	AuditShow auditshow.Interface
+}
+
+func (cmd *Audit) New() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "audit",
+		Short: "Show the audit log",
+		Long: `Show the audit log.
+
+The audit log is written by get-token and the standalone login if --audit-log is set.
+It records when and how the credentials were obtained, without any token.
+`,
+		Args: cobra.NoArgs,
+	}
+	c.AddCommand(cmd.newShow())
+	return c
+}
+
+func (cmd *Audit) newShow() *cobra.Command {
+	var o auditShowOptions
+	c := &cobra.Command{
+		Use:   "show [flags]",
+		Short: "Show the records in the audit log",
+		Example: `  # Show the failures in the last 24 hours
+  kubelogin audit show --since=24h --event=failure`,
+		Args: cobra.NoArgs,
+		RunE: func(c *cobra.Command, _ []string) error {
+			in, err := o.auditShowInput()
+			if err != nil {
+				return fmt.Errorf("audit show: %w", err)
+			}
+			if err := cmd.AuditShow.Do(c.Context(), in); err != nil {
+				return fmt.Errorf("audit show: %w", err)
+			}
+			return nil
+		},
+	}
+	c.Flags().SortFlags = false
+	o.addFlags(c.Flags())
+	return c
+}
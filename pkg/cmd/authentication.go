@@ -16,74 +16,146 @@ import (
 
 type authenticationOptions struct {
 	GrantType                  string
+	AutoGrantTypes             []string
 	ListenAddress              []string
 	AuthenticationTimeoutSec   int
 	SkipOpenBrowser            bool
 	BrowserCommand             string
 	LocalServerCertFile        string
 	LocalServerKeyFile         string
+	LocalServerSuccessTemplate string
+	LocalServerErrorTemplate   string
 	OpenURLAfterAuthentication string
 	AuthRequestExtraParams     map[string]string
+	TokenRequestExtraParams    map[string]string
+	PollingIntervalSec         int
 	Username                   string
 	Password                   string
+	PasswordFile               string
+	PasswordCommand            string
+	PasswordStdin              bool
+	OTPMode                    string
+	OTPParamName               string
+	OTPCommand                 string
 }
 
+var allOTPModes = strings.Join([]string{"append", "param"}, "|")
+
 var allGrantType = strings.Join([]string{
 	"auto",
 	"authcode",
 	"authcode-keyboard",
+	"authcode-paste",
 	"password",
 	"device-code",
 	"client-credentials",
 }, "|")
 
+// defaultAutoGrantTypes is the grant types tried in order when the grant type is auto.
+// It defaults to authcode only, so that auto does not change the grant by the environment unless configured.
+var defaultAutoGrantTypes = []string{"authcode"}
+
 func (o *authenticationOptions) addFlags(f *pflag.FlagSet) {
 	f.StringVar(&o.GrantType, "grant-type", "auto", fmt.Sprintf("Authorization grant type to use. One of (%s)", allGrantType))
-	f.StringSliceVar(&o.ListenAddress, "listen-address", defaultListenAddress, "[authcode] Address to bind to the local server. If multiple addresses are set, it will try binding in order")
+	f.StringSliceVar(&o.AutoGrantTypes, "auto-grant-types", defaultAutoGrantTypes, "[auto] Grant types to try in order. If a grant is not available in the environment or the provider, or fails by a runtime error, the next one is tried")
+	f.StringSliceVar(&o.ListenAddress, "listen-address", defaultListenAddress, "[authcode] Address to bind to the local server. If multiple addresses are set, it will try binding in order. Set the port to 0 to allocate a free port, such as 127.0.0.1:0 or [::1]:0. [authcode-paste] The first address is used for the redirect URL")
 	f.BoolVar(&o.SkipOpenBrowser, "skip-open-browser", false, "[authcode] Do not open the browser automatically")
 	f.StringVar(&o.BrowserCommand, "browser-command", "", "[authcode] Command to open the browser")
-	f.IntVar(&o.AuthenticationTimeoutSec, "authentication-timeout-sec", defaultAuthenticationTimeoutSec, "[authcode] Timeout of authentication in seconds")
+	f.IntVar(&o.AuthenticationTimeoutSec, "authentication-timeout-sec", 0, fmt.Sprintf("[authcode, device-code] Timeout of authentication in seconds. [authcode] Default to %d. [device-code] Default to the expiry of the code", defaultAuthenticationTimeoutSec))
 	f.StringVar(&o.LocalServerCertFile, "local-server-cert", "", "[authcode] Certificate path for the local server")
 	f.StringVar(&o.LocalServerKeyFile, "local-server-key", "", "[authcode] Certificate key path for the local server")
+	f.StringVar(&o.LocalServerSuccessTemplate, "local-server-success-template", "", "[authcode] Path to the html/template file of the success page of the local server")
+	f.StringVar(&o.LocalServerErrorTemplate, "local-server-error-template", "", "[authcode] Path to the html/template file of the error page of the local server")
 	f.StringVar(&o.OpenURLAfterAuthentication, "open-url-after-authentication", "", "[authcode] If set, open the URL in the browser after authentication")
-	f.StringToStringVar(&o.AuthRequestExtraParams, "oidc-auth-request-extra-params", nil, "[authcode, authcode-keyboard, client-credentials] Extra query parameters to send with an authentication request")
+	f.StringToStringVar(&o.AuthRequestExtraParams, "oidc-auth-request-extra-params", nil, "[authcode, authcode-keyboard, authcode-paste, device-code, client-credentials] Extra query parameters to send with an authentication request")
+	f.StringToStringVar(&o.TokenRequestExtraParams, "oidc-token-request-extra-params", nil, "[device-code] Extra parameters to send with a token request")
+	f.IntVar(&o.PollingIntervalSec, "device-code-polling-interval-sec", 0, "[device-code] Minimum interval of polling the token endpoint in seconds")
 	f.StringVar(&o.Username, "username", "", "[password] Username for resource owner password credentials grant")
 	f.StringVar(&o.Password, "password", "", "[password] Password for resource owner password credentials grant")
+	f.StringVar(&o.PasswordFile, "password-file", "", "[password] Read the password from the first line of the file")
+	f.StringVar(&o.PasswordCommand, "password-command", "", "[password] Read the password from the first line of the output of the command")
+	f.BoolVar(&o.PasswordStdin, "password-stdin", false, "[password] Read the password from the first line of the standard input")
+	f.StringVar(&o.OTPMode, "otp-mode", "", fmt.Sprintf("[password] If set, read a one-time password of the second factor. One of (%s)", allOTPModes))
+	f.StringVar(&o.OTPParamName, "otp-param-name", "otp", "[password] Name of the token request parameter for --otp-mode=param")
+	f.StringVar(&o.OTPCommand, "otp-command", "", "[password] Read the one-time password from the first line of the output of the command")
 }
 
 func (o *authenticationOptions) expandHomedir() {
 	o.LocalServerCertFile = expandHomedir(o.LocalServerCertFile)
 	o.LocalServerKeyFile = expandHomedir(o.LocalServerKeyFile)
+	o.LocalServerSuccessTemplate = expandHomedir(o.LocalServerSuccessTemplate)
+	o.LocalServerErrorTemplate = expandHomedir(o.LocalServerErrorTemplate)
+	o.PasswordFile = expandHomedir(o.PasswordFile)
 }
 
 func (o *authenticationOptions) grantOptionSet() (s authentication.GrantOptionSet, err error) {
-	switch {
-	case o.GrantType == "authcode" || (o.GrantType == "auto" && o.Username == ""):
+	if o.GrantType != "auto" {
+		return o.grantOptionSetOf(o.GrantType)
+	}
+	if o.Username != "" {
+		return o.grantOptionSetOf("password")
+	}
+	for _, grantType := range o.AutoGrantTypes {
+		if grantType == "auto" {
+			return s, fmt.Errorf("auto-grant-types must not contain auto")
+		}
+		candidate, err := o.grantOptionSetOf(grantType)
+		if err != nil {
+			return s, fmt.Errorf("invalid auto-grant-types: %w", err)
+		}
+		s.Candidates = append(s.Candidates, candidate)
+	}
+	if len(s.Candidates) == 0 {
+		return s, fmt.Errorf("auto-grant-types must not be empty")
+	}
+	return s, nil
+}
+
+func (o *authenticationOptions) grantOptionSetOf(grantType string) (s authentication.GrantOptionSet, err error) {
+	switch grantType {
+	case "authcode":
 		s.AuthCodeBrowserOption = &authcode.BrowserOption{
 			BindAddress:                o.ListenAddress,
 			SkipOpenBrowser:            o.SkipOpenBrowser,
 			BrowserCommand:             o.BrowserCommand,
-			AuthenticationTimeout:      time.Duration(o.AuthenticationTimeoutSec) * time.Second,
+			AuthenticationTimeout:      o.authenticationTimeout(defaultAuthenticationTimeoutSec * time.Second),
 			LocalServerCertFile:        o.LocalServerCertFile,
 			LocalServerKeyFile:         o.LocalServerKeyFile,
 			OpenURLAfterAuthentication: o.OpenURLAfterAuthentication,
 			AuthRequestExtraParams:     o.AuthRequestExtraParams,
+
+			LocalServerSuccessTemplateFile: o.LocalServerSuccessTemplate,
+			LocalServerErrorTemplateFile:   o.LocalServerErrorTemplate,
 		}
-	case o.GrantType == "authcode-keyboard":
+	case "authcode-keyboard":
 		s.AuthCodeKeyboardOption = &authcode.KeyboardOption{
 			AuthRequestExtraParams: o.AuthRequestExtraParams,
 		}
-	case o.GrantType == "password" || (o.GrantType == "auto" && o.Username != ""):
-		s.ROPCOption = &ropc.Option{
-			Username: o.Username,
-			Password: o.Password,
+	case "authcode-paste":
+		redirectURL, err := pasteRedirectURL(o.ListenAddress)
+		if err != nil {
+			return s, err
+		}
+		s.AuthCodePasteOption = &authcode.PasteOption{
+			RedirectURL:            redirectURL,
+			AuthRequestExtraParams: o.AuthRequestExtraParams,
+		}
+	case "password":
+		ropcOption, err := o.ropcOption()
+		if err != nil {
+			return s, err
 		}
-	case o.GrantType == "device-code":
+		s.ROPCOption = ropcOption
+	case "device-code":
 		s.DeviceCodeOption = &devicecode.Option{
-			SkipOpenBrowser: o.SkipOpenBrowser,
-			BrowserCommand:  o.BrowserCommand,
+			SkipOpenBrowser:         o.SkipOpenBrowser,
+			BrowserCommand:          o.BrowserCommand,
+			AuthenticationTimeout:   o.authenticationTimeout(0),
+			PollingInterval:         time.Duration(o.PollingIntervalSec) * time.Second,
+			AuthRequestExtraParams:  o.AuthRequestExtraParams,
+			TokenRequestExtraParams: o.TokenRequestExtraParams,
 		}
-	case o.GrantType == "client-credentials":
+	case "client-credentials":
 		endpointparams := make(map[string][]string, len(o.AuthRequestExtraParams))
 		for k, v := range o.AuthRequestExtraParams {
 			endpointparams[k] = []string{v}
@@ -94,3 +166,77 @@ func (o *authenticationOptions) grantOptionSet() (s authentication.GrantOptionSe
 	}
 	return
 }
+
+// authenticationTimeout returns the timeout, or the default if the flag is not set.
+func (o *authenticationOptions) authenticationTimeout(defaultTimeout time.Duration) time.Duration {
+	if o.AuthenticationTimeoutSec <= 0 {
+		return defaultTimeout
+	}
+	return time.Duration(o.AuthenticationTimeoutSec) * time.Second
+}
+
+// pasteRedirectURL returns the redirect URL of the first listen address,
+// which is same as the authcode flow, so that the same redirect URL can be registered.
+// If the port is 0, it returns the loopback URL without port,
+// because the browser does not need to reach the local server.
+func pasteRedirectURL(listenAddress []string) (string, error) {
+	if len(listenAddress) == 0 {
+		return "", fmt.Errorf("listen-address must be set for authcode-paste")
+	}
+	redirectURL, err := client.RedirectURLOf(listenAddress[0], false)
+	if err != nil {
+		return "", fmt.Errorf("invalid listen-address: %w", err)
+	}
+	return redirectURL, nil
+}
+
+func (o *authenticationOptions) ropcOption() (*ropc.Option, error) {
+	var sources []string
+	if o.Password != "" {
+		sources = append(sources, "--password")
+	}
+	if o.PasswordFile != "" {
+		sources = append(sources, "--password-file")
+	}
+	if o.PasswordCommand != "" {
+		sources = append(sources, "--password-command")
+	}
+	if o.PasswordStdin {
+		sources = append(sources, "--password-stdin")
+	}
+	if len(sources) > 1 {
+		return nil, fmt.Errorf("password sources are exclusive but got %s", strings.Join(sources, ", "))
+	}
+	if o.PasswordCommand != "" && strings.TrimSpace(o.PasswordCommand) == "" {
+		return nil, fmt.Errorf("password-command must not be blank")
+	}
+	if o.OTPCommand != "" && strings.TrimSpace(o.OTPCommand) == "" {
+		return nil, fmt.Errorf("otp-command must not be blank")
+	}
+	opt := &ropc.Option{
+		Username:        o.Username,
+		Password:        o.Password,
+		PasswordFile:    o.PasswordFile,
+		PasswordCommand: o.PasswordCommand,
+		PasswordStdin:   o.PasswordStdin,
+	}
+	switch o.OTPMode {
+	case "":
+		if o.OTPCommand != "" {
+			return nil, fmt.Errorf("otp-command requires otp-mode")
+		}
+	case "append":
+		opt.OTPMode = ropc.OTPModeAppend
+		opt.OTPCommand = o.OTPCommand
+	case "param":
+		if o.OTPParamName == "" {
+			return nil, fmt.Errorf("otp-param-name must be set for otp-mode=param")
+		}
+		opt.OTPMode = ropc.OTPModeParam
+		opt.OTPParamName = o.OTPParamName
+		opt.OTPCommand = o.OTPCommand
+	default:
+		return nil, fmt.Errorf("otp-mode must be one of (%s)", allOTPModes)
+	}
+	return opt, nil
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/togethercomputer/together-kubelogin/pkg/oidc"
+	"github.com/spf13/pflag"
+)
+
+var allPrompts = strings.Join([]string{"none", "login", "consent", "select_account"}, "|")
+
+// authenticationPolicyOptions represents the options of step-up authentication.
+type authenticationPolicyOptions struct {
+	ACRValues   []string
+	RequiredAMR []string
+	MaxAgeSec   int
+	Prompt      []string
+	LoginHint   string
+}
+
+func (o *authenticationPolicyOptions) addFlags(f *pflag.FlagSet) {
+	f.StringSliceVar(&o.ACRValues, "oidc-acr-values", nil, "Authentication context class references to request. The acr claim of the token must be one of them")
+	f.StringSliceVar(&o.RequiredAMR, "oidc-required-amr", nil, "Authentication methods references which the amr claim of the token must contain, such as mfa")
+	f.IntVar(&o.MaxAgeSec, "oidc-max-age", 0, "Maximum age of the authentication in seconds. The auth_time claim of the token must be within it. No limit if zero")
+	f.StringSliceVar(&o.Prompt, "oidc-prompt", nil, fmt.Sprintf("[authcode, authcode-keyboard, authcode-paste] Prompt of the authorization request. One of (%s)", allPrompts))
+	f.StringVar(&o.LoginHint, "oidc-login-hint", "", "Hint of the login identifier, such as the email address")
+}
+
+// apply sets the options to the provider.
+func (o *authenticationPolicyOptions) apply(p *oidc.Provider) error {
+	if o.MaxAgeSec < 0 {
+		return errors.New("oidc-max-age must not be negative")
+	}
+	for _, prompt := range o.Prompt {
+		if !slices.Contains(strings.Split(allPrompts, "|"), prompt) {
+			return fmt.Errorf("oidc-prompt must be one of (%s)", allPrompts)
+		}
+	}
+	if slices.Contains(o.Prompt, "none") && len(o.Prompt) > 1 {
+		return errors.New("oidc-prompt=none must not be combined with other values")
+	}
+	p.AuthenticationPolicy = oidc.AuthenticationPolicy{
+		ACRValues:   o.ACRValues,
+		RequiredAMR: o.RequiredAMR,
+		MaxAge:      time.Duration(o.MaxAgeSec) * time.Second,
+	}
+	p.Prompt = strings.Join(o.Prompt, " ")
+	p.LoginHint = o.LoginHint
+	return nil
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/togethercomputer/together-kubelogin/pkg/oidc"
+	"github.com/spf13/pflag"
+)
+
+func Test_authenticationPolicyOptions_apply(t *testing.T) {
+	tests := map[string]struct {
+		args    []string
+		wantErr bool
+	}{
+		"NoFlag": {},
+		"Prompt": {
+			args: []string{"--oidc-prompt", "login,consent"},
+		},
+		"InvalidPrompt": {
+			args:    []string{"--oidc-prompt", "always"},
+			wantErr: true,
+		},
+		"PromptNoneWithOthers": {
+			args:    []string{"--oidc-prompt", "none,login"},
+			wantErr: true,
+		},
+		"NegativeMaxAge": {
+			args:    []string{"--oidc-max-age", "-1"},
+			wantErr: true,
+		},
+	}
+	for name, c := range tests {
+		t.Run(name, func(t *testing.T) {
+			var o authenticationPolicyOptions
+			f := pflag.NewFlagSet("", pflag.ContinueOnError)
+			o.addFlags(f)
+			if err := f.Parse(c.args); err != nil {
+				t.Fatalf("Parse error: %s", err)
+			}
+			var p oidc.Provider
+			err := o.apply(&p)
+			if (err != nil) != c.wantErr {
+				t.Errorf("wantErr %v but got %v", c.wantErr, err)
+			}
+		})
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/togethercomputer/together-kubelogin/pkg/oidc/client"
 	"github.com/togethercomputer/together-kubelogin/pkg/usecases/authentication"
 	"github.com/togethercomputer/together-kubelogin/pkg/usecases/authentication/authcode"
+	"github.com/togethercomputer/together-kubelogin/pkg/usecases/authentication/devicecode"
 	"github.com/togethercomputer/together-kubelogin/pkg/usecases/authentication/ropc"
 	"github.com/spf13/pflag"
 )
@@ -19,9 +20,13 @@ func Test_authenticationOptions_grantOptionSet(t *testing.T) {
 	}{
 		"NoFlag": {
 			want: authentication.GrantOptionSet{
-				AuthCodeBrowserOption: &authcode.BrowserOption{
-					BindAddress:           defaultListenAddress,
-					AuthenticationTimeout: defaultAuthenticationTimeoutSec * time.Second,
+				Candidates: []authentication.GrantOptionSet{
+					{
+						AuthCodeBrowserOption: &authcode.BrowserOption{
+							BindAddress:           defaultListenAddress,
+							AuthenticationTimeout: defaultAuthenticationTimeoutSec * time.Second,
+						},
+					},
 				},
 			},
 		},
@@ -35,6 +40,8 @@ func Test_authenticationOptions_grantOptionSet(t *testing.T) {
 				"--authentication-timeout-sec", "10",
 				"--local-server-cert", "/path/to/local-server-cert",
 				"--local-server-key", "/path/to/local-server-key",
+				"--local-server-success-template", "/path/to/success.html",
+				"--local-server-error-template", "/path/to/error.html",
 				"--open-url-after-authentication", "https://example.com/success.html",
 				"--oidc-auth-request-extra-params", "ttl=86400",
 				"--oidc-auth-request-extra-params", "reauth=true",
@@ -51,6 +58,9 @@ func Test_authenticationOptions_grantOptionSet(t *testing.T) {
 					LocalServerKeyFile:         "/path/to/local-server-key",
 					OpenURLAfterAuthentication: "https://example.com/success.html",
 					AuthRequestExtraParams:     map[string]string{"ttl": "86400", "reauth": "true"},
+
+					LocalServerSuccessTemplateFile: "/path/to/success.html",
+					LocalServerErrorTemplateFile:   "/path/to/error.html",
 				},
 			},
 		},
@@ -74,6 +84,50 @@ func Test_authenticationOptions_grantOptionSet(t *testing.T) {
 				},
 			},
 		},
+		"GrantType=authcode-paste": {
+			args: []string{
+				"--grant-type", "authcode-paste",
+				"--listen-address", "127.0.0.1:10080",
+				"--listen-address", "127.0.0.1:20080",
+				"--oidc-auth-request-extra-params", "ttl=86400",
+			},
+			want: authentication.GrantOptionSet{
+				AuthCodePasteOption: &authcode.PasteOption{
+					RedirectURL:            "http://localhost:10080",
+					AuthRequestExtraParams: map[string]string{"ttl": "86400"},
+				},
+			},
+		},
+		"GrantType=device-code": {
+			args: []string{
+				"--grant-type", "device-code",
+				"--skip-open-browser",
+				"--authentication-timeout-sec", "600",
+				"--device-code-polling-interval-sec", "10",
+				"--oidc-auth-request-extra-params", "audience=https://api.example.com",
+				"--oidc-token-request-extra-params", "resource=https://api.example.com",
+			},
+			want: authentication.GrantOptionSet{
+				DeviceCodeOption: &devicecode.Option{
+					SkipOpenBrowser:         true,
+					AuthenticationTimeout:   600 * time.Second,
+					PollingInterval:         10 * time.Second,
+					AuthRequestExtraParams:  map[string]string{"audience": "https://api.example.com"},
+					TokenRequestExtraParams: map[string]string{"resource": "https://api.example.com"},
+				},
+			},
+		},
+		"GrantType=authcode-paste with dynamic port": {
+			args: []string{
+				"--grant-type", "authcode-paste",
+				"--listen-address", "[::1]:0",
+			},
+			want: authentication.GrantOptionSet{
+				AuthCodePasteOption: &authcode.PasteOption{
+					RedirectURL: "http://[::1]",
+				},
+			},
+		},
 		"GrantType=password": {
 			args: []string{
 				"--grant-type", "password",
@@ -89,6 +143,24 @@ func Test_authenticationOptions_grantOptionSet(t *testing.T) {
 				},
 			},
 		},
+		"GrantType=password with password-command and otp-mode=param": {
+			args: []string{
+				"--grant-type", "password",
+				"--username", "USER",
+				"--password-command", "pass show idp",
+				"--otp-mode", "param",
+				"--otp-command", "oathtool --totp KEY",
+			},
+			want: authentication.GrantOptionSet{
+				ROPCOption: &ropc.Option{
+					Username:        "USER",
+					PasswordCommand: "pass show idp",
+					OTPMode:         ropc.OTPModeParam,
+					OTPParamName:    "otp",
+					OTPCommand:      "oathtool --totp KEY",
+				},
+			},
+		},
 		"GrantType=client-credentials": {
 			args: []string{
 				"--grant-type", "client-credentials",
@@ -104,6 +176,21 @@ func Test_authenticationOptions_grantOptionSet(t *testing.T) {
 				},
 			},
 		},
+		"GrantType=auto with auto-grant-types": {
+			args: []string{
+				"--auto-grant-types", "device-code,authcode-keyboard",
+			},
+			want: authentication.GrantOptionSet{
+				Candidates: []authentication.GrantOptionSet{
+					{
+						DeviceCodeOption: &devicecode.Option{},
+					},
+					{
+						AuthCodeKeyboardOption: &authcode.KeyboardOption{},
+					},
+				},
+			},
+		},
 		"GrantType=auto": {
 			args: []string{
 				"--listen-address", "127.0.0.1:10080",
@@ -138,3 +225,43 @@ func Test_authenticationOptions_grantOptionSet(t *testing.T) {
 		})
 	}
 }
+
+func Test_authenticationOptions_grantOptionSet_error(t *testing.T) {
+	tests := map[string][]string{
+		"MultiplePasswordSources": {
+			"--grant-type", "password",
+			"--password-file", "/path/to/password",
+			"--password-stdin",
+		},
+		"InvalidOTPMode": {
+			"--grant-type", "password",
+			"--otp-mode", "sms",
+		},
+		"OTPCommandWithoutOTPMode": {
+			"--grant-type", "password",
+			"--otp-command", "oathtool --totp KEY",
+		},
+		"BlankPasswordCommand": {
+			"--grant-type", "password",
+			"--password-command", " ",
+		},
+		"BlankOTPCommand": {
+			"--grant-type", "password",
+			"--otp-mode", "append",
+			"--otp-command", " ",
+		},
+	}
+	for name, args := range tests {
+		t.Run(name, func(t *testing.T) {
+			var o authenticationOptions
+			f := pflag.NewFlagSet("", pflag.ContinueOnError)
+			o.addFlags(f)
+			if err := f.Parse(args); err != nil {
+				t.Fatalf("Parse error: %s", err)
+			}
+			if _, err := o.grantOptionSet(); err == nil {
+				t.Errorf("err wants non-nil but got nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/togethercomputer/together-kubelogin/pkg/claimpolicy"
+	"github.com/spf13/pflag"
+)
+
+type claimPolicyOptions struct {
+	Expressions    []string
+	RequiredClaims []string
+}
+
+func (o *claimPolicyOptions) addFlags(f *pflag.FlagSet) {
+	f.StringArrayVar(&o.Expressions, "claim-policy", nil, "CEL expression which the claims of the token must satisfy before passing it to kubectl, such as claims.email_verified == true")
+	f.StringArrayVar(&o.RequiredClaims, "required-claim", nil, "Claim which the token must have before passing it to kubectl, in the form of NAME=VALUE. If the claim is a list, it must contain the value")
+}
+
+func (o *claimPolicyOptions) claimPolicy() (claimpolicy.Policy, error) {
+	p := claimpolicy.Policy{Expressions: o.Expressions}
+	for _, s := range o.RequiredClaims {
+		r, err := claimpolicy.ParseRequiredClaim(s)
+		if err != nil {
+			return claimpolicy.Policy{}, fmt.Errorf("invalid --required-claim: %w", err)
+		}
+		p.RequiredClaims = append(p.RequiredClaims, r)
+	}
+	if err := p.Validate(); err != nil {
+		return claimpolicy.Policy{}, fmt.Errorf("invalid --claim-policy: %w", err)
+	}
+	return p, nil
+}
@@ -2,11 +2,17 @@ package cmd
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"os"
 	"runtime"
 
 	"github.com/google/wire"
 	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/logger"
+	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/tracing"
+	"github.com/togethercomputer/together-kubelogin/pkg/usecases/execcommand"
 	"github.com/spf13/cobra"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Set provides an implementation and interface for Cmd.
@@ -17,6 +23,12 @@ var Set = wire.NewSet(
 	wire.Struct(new(GetToken), "*"),
 	wire.Struct(new(Setup), "*"),
 	wire.Struct(new(Clean), "*"),
+	wire.Struct(new(VerifyAuthn), "*"),
+	wire.Struct(new(Login), "*"),
+	wire.Struct(new(Doctor), "*"),
+	wire.Struct(new(Exec), "*"),
+	wire.Struct(new(Agent), "*"),
+	wire.Struct(new(Audit), "*"),
 )
 
 type Interface interface {
@@ -29,11 +41,25 @@ const defaultAuthenticationTimeoutSec = 180
 
 // Cmd provides interaction with command line interface (CLI).
 type Cmd struct {
-	Root     *Root
-	GetToken *GetToken
-	Setup    *Setup
-	Clean    *Clean
-	Logger   logger.Interface
+	Root        *Root
+	GetToken    *GetToken
+	Setup       *Setup
+	Clean       *Clean
+	VerifyAuthn *VerifyAuthn
+	Login       *Login
+	Doctor      *Doctor
+	Exec        *Exec
+	Agent       *Agent
+	Audit       *Audit
+	Logger      logger.Interface
+}
+
+// bindEnvRecursive binds the environment variables to the flags of the command and subcommands.
+func bindEnvRecursive(c *cobra.Command) {
+	bindEnv(c)
+	for _, sub := range c.Commands() {
+		bindEnvRecursive(sub)
+	}
 }
 
 // Run parses the command line arguments and executes the specified use-case.
@@ -53,6 +79,24 @@ func (cmd *Cmd) Run(ctx context.Context, args []string, version string) int {
 	cleanCmd := cmd.Clean.New()
 	rootCmd.AddCommand(cleanCmd)
 
+	verifyAuthnCmd := cmd.VerifyAuthn.New()
+	rootCmd.AddCommand(verifyAuthnCmd)
+
+	loginCmd := cmd.Login.New()
+	rootCmd.AddCommand(loginCmd)
+
+	doctorCmd := cmd.Doctor.New()
+	rootCmd.AddCommand(doctorCmd)
+
+	execCmd := cmd.Exec.New()
+	rootCmd.AddCommand(execCmd)
+
+	agentCmd := cmd.Agent.New()
+	rootCmd.AddCommand(agentCmd)
+
+	auditCmd := cmd.Audit.New()
+	rootCmd.AddCommand(auditCmd)
+
 	versionCmd := &cobra.Command{
 		Use:   "version",
 		Short: "Print the version information",
@@ -63,8 +107,34 @@ func (cmd *Cmd) Run(ctx context.Context, args []string, version string) int {
 	}
 	rootCmd.AddCommand(versionCmd)
 
+	bindEnvRecursive(rootCmd)
+	span := trace.SpanFromContext(ctx)
+	shutdownTracing := func(context.Context) error { return nil }
+	rootCmd.PersistentPreRunE = func(c *cobra.Command, _ []string) error {
+		if err := applyEnv(c.Flags(), os.LookupEnv); err != nil {
+			return err
+		}
+		ctx, shutdown, err := cmd.Root.Tracing.Setup(c.Context(), version)
+		if err != nil {
+			return fmt.Errorf("tracing: %w", err)
+		}
+		shutdownTracing = shutdown
+		ctx, span = tracing.Start(ctx, c.CommandPath())
+		c.SetContext(ctx)
+		return nil
+	}
+
 	rootCmd.SetArgs(args[1:])
-	if err := rootCmd.ExecuteContext(ctx); err != nil {
+	err := rootCmd.ExecuteContext(ctx)
+	tracing.End(span, err)
+	if shutdownErr := shutdownTracing(ctx); shutdownErr != nil {
+		cmd.Logger.Printf("could not export the trace: %s", shutdownErr)
+	}
+	if err != nil {
+		var exitError execcommand.ExitError
+		if errors.As(err, &exitError) {
+			return exitError.Code
+		}
 		cmd.Logger.Printf("error: %s", err)
 		cmd.Logger.V(1).Infof("stacktrace: %+v", err)
 		return 1
@@ -4,21 +4,41 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"syscall"
 	"testing"
 	"time"
 
+	"github.com/togethercomputer/together-kubelogin/mocks/github.com/togethercomputer/together-kubelogin/pkg/kubeconfig/loader_mock"
+	"github.com/togethercomputer/together-kubelogin/mocks/github.com/togethercomputer/together-kubelogin/pkg/usecases/agent_mock"
+	"github.com/togethercomputer/together-kubelogin/mocks/github.com/togethercomputer/together-kubelogin/pkg/usecases/auditshow_mock"
 	"github.com/togethercomputer/together-kubelogin/mocks/github.com/togethercomputer/together-kubelogin/pkg/usecases/credentialplugin_mock"
+	"github.com/togethercomputer/together-kubelogin/mocks/github.com/togethercomputer/together-kubelogin/pkg/usecases/doctor_mock"
+	"github.com/togethercomputer/together-kubelogin/mocks/github.com/togethercomputer/together-kubelogin/pkg/usecases/execcommand_mock"
+	"github.com/togethercomputer/together-kubelogin/mocks/github.com/togethercomputer/together-kubelogin/pkg/usecases/login_mock"
 	"github.com/togethercomputer/together-kubelogin/mocks/github.com/togethercomputer/together-kubelogin/pkg/usecases/setup_mock"
 	"github.com/togethercomputer/together-kubelogin/mocks/github.com/togethercomputer/together-kubelogin/pkg/usecases/standalone_mock"
+	"github.com/togethercomputer/together-kubelogin/mocks/github.com/togethercomputer/together-kubelogin/pkg/usecases/verifyauthn_mock"
+	"github.com/togethercomputer/together-kubelogin/pkg/audit"
+	"github.com/togethercomputer/together-kubelogin/pkg/claimpolicy"
+	credentialplugintypes "github.com/togethercomputer/together-kubelogin/pkg/credentialplugin"
+	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/tracing"
+	"github.com/togethercomputer/together-kubelogin/pkg/kubeconfig"
 	"github.com/togethercomputer/together-kubelogin/pkg/oidc"
 	"github.com/togethercomputer/together-kubelogin/pkg/testing/logger"
 	"github.com/togethercomputer/together-kubelogin/pkg/tlsclientconfig"
 	"github.com/togethercomputer/together-kubelogin/pkg/tokencache"
+	"github.com/togethercomputer/together-kubelogin/pkg/usecases/agent"
+	"github.com/togethercomputer/together-kubelogin/pkg/usecases/auditshow"
 	"github.com/togethercomputer/together-kubelogin/pkg/usecases/authentication"
 	"github.com/togethercomputer/together-kubelogin/pkg/usecases/authentication/authcode"
+	"github.com/togethercomputer/together-kubelogin/pkg/usecases/authentication/devicecode"
 	"github.com/togethercomputer/together-kubelogin/pkg/usecases/credentialplugin"
+	"github.com/togethercomputer/together-kubelogin/pkg/usecases/doctor"
+	"github.com/togethercomputer/together-kubelogin/pkg/usecases/execcommand"
+	"github.com/togethercomputer/together-kubelogin/pkg/usecases/login"
 	"github.com/togethercomputer/together-kubelogin/pkg/usecases/setup"
 	"github.com/togethercomputer/together-kubelogin/pkg/usecases/standalone"
+	"github.com/togethercomputer/together-kubelogin/pkg/usecases/verifyauthn"
 )
 
 func TestCmd_Run(t *testing.T) {
@@ -26,9 +46,13 @@ func TestCmd_Run(t *testing.T) {
 	const version = "HEAD"
 
 	defaultGrantOptionSet := authentication.GrantOptionSet{
-		AuthCodeBrowserOption: &authcode.BrowserOption{
-			BindAddress:           defaultListenAddress,
-			AuthenticationTimeout: defaultAuthenticationTimeoutSec * time.Second,
+		Candidates: []authentication.GrantOptionSet{
+			{
+				AuthCodeBrowserOption: &authcode.BrowserOption{
+					BindAddress:           defaultListenAddress,
+					AuthenticationTimeout: defaultAuthenticationTimeoutSec * time.Second,
+				},
+			},
 		},
 	}
 
@@ -67,6 +91,7 @@ func TestCmd_Run(t *testing.T) {
 					Return(nil)
 				cmd := Cmd{
 					Root: &Root{
+						Tracing:    &tracing.Tracing{},
 						Standalone: mockStandalone,
 						Logger:     logger.New(t),
 					},
@@ -82,6 +107,7 @@ func TestCmd_Run(t *testing.T) {
 		t.Run("TooManyArgs", func(t *testing.T) {
 			cmd := Cmd{
 				Root: &Root{
+					Tracing:    &tracing.Tracing{},
 					Standalone: standalone_mock.NewMockInterface(t),
 					Logger:     logger.New(t),
 				},
@@ -119,6 +145,7 @@ func TestCmd_Run(t *testing.T) {
 						Directory: filepath.Join(userHomeDir, ".kube/cache/oidc-login"),
 					},
 					GrantOptionSet: defaultGrantOptionSet,
+					OutputFormat:   credentialplugintypes.OutputFormatExecCredential,
 				},
 			},
 			"FullOptions": {
@@ -148,6 +175,7 @@ func TestCmd_Run(t *testing.T) {
 						Storage:   tokencache.StorageKeyring,
 					},
 					GrantOptionSet: defaultGrantOptionSet,
+					OutputFormat:   credentialplugintypes.OutputFormatExecCredential,
 				},
 			},
 			"AccessToken": {
@@ -167,6 +195,80 @@ func TestCmd_Run(t *testing.T) {
 						Directory: filepath.Join(userHomeDir, ".kube/cache/oidc-login"),
 					},
 					GrantOptionSet: defaultGrantOptionSet,
+					OutputFormat:   credentialplugintypes.OutputFormatExecCredential,
+				},
+			},
+			"AuthenticationPolicy": {
+				args: []string{executable,
+					"get-token",
+					"--oidc-issuer-url", "https://issuer.example.com",
+					"--oidc-client-id", "YOUR_CLIENT_ID",
+					"--oidc-acr-values", "phr,phrh",
+					"--oidc-required-amr", "mfa",
+					"--oidc-max-age", "3600",
+					"--oidc-prompt", "login,consent",
+					"--oidc-login-hint", "alice@example.com",
+				},
+				in: credentialplugin.Input{
+					Provider: oidc.Provider{
+						IssuerURL: "https://issuer.example.com",
+						ClientID:  "YOUR_CLIENT_ID",
+						AuthenticationPolicy: oidc.AuthenticationPolicy{
+							ACRValues:   []string{"phr", "phrh"},
+							RequiredAMR: []string{"mfa"},
+							MaxAge:      time.Hour,
+						},
+						Prompt:    "login consent",
+						LoginHint: "alice@example.com",
+					},
+					TokenCacheConfig: tokencache.Config{
+						Directory: filepath.Join(userHomeDir, ".kube/cache/oidc-login"),
+					},
+					GrantOptionSet: defaultGrantOptionSet,
+					OutputFormat:   credentialplugintypes.OutputFormatExecCredential,
+				},
+			},
+			"ClaimPolicy": {
+				args: []string{executable,
+					"get-token",
+					"--oidc-issuer-url", "https://issuer.example.com",
+					"--oidc-client-id", "YOUR_CLIENT_ID",
+					"--claim-policy", `"k8s-admins" in claims.groups`,
+					"--required-claim", "email_verified=true",
+				},
+				in: credentialplugin.Input{
+					Provider: oidc.Provider{
+						IssuerURL: "https://issuer.example.com",
+						ClientID:  "YOUR_CLIENT_ID",
+					},
+					TokenCacheConfig: tokencache.Config{
+						Directory: filepath.Join(userHomeDir, ".kube/cache/oidc-login"),
+					},
+					GrantOptionSet: defaultGrantOptionSet,
+					OutputFormat:   credentialplugintypes.OutputFormatExecCredential,
+					ClaimPolicy: claimpolicy.Policy{
+						Expressions:    []string{`"k8s-admins" in claims.groups`},
+						RequiredClaims: []claimpolicy.RequiredClaim{{Claim: "email_verified", Value: "true"}},
+					},
+				},
+			},
+			"OutputHeader": {
+				args: []string{executable,
+					"get-token",
+					"--oidc-issuer-url", "https://issuer.example.com",
+					"--oidc-client-id", "YOUR_CLIENT_ID",
+					"--output", "header",
+				},
+				in: credentialplugin.Input{
+					Provider: oidc.Provider{
+						IssuerURL: "https://issuer.example.com",
+						ClientID:  "YOUR_CLIENT_ID",
+					},
+					TokenCacheConfig: tokencache.Config{
+						Directory: filepath.Join(userHomeDir, ".kube/cache/oidc-login"),
+					},
+					GrantOptionSet: defaultGrantOptionSet,
+					OutputFormat:   credentialplugintypes.OutputFormatHeader,
 				},
 			},
 			"HomedirExpansion": {
@@ -188,16 +290,21 @@ func TestCmd_Run(t *testing.T) {
 						Directory: filepath.Join(userHomeDir, ".kube/oidc-cache"),
 					},
 					GrantOptionSet: authentication.GrantOptionSet{
-						AuthCodeBrowserOption: &authcode.BrowserOption{
-							BindAddress:           defaultListenAddress,
-							AuthenticationTimeout: defaultAuthenticationTimeoutSec * time.Second,
-							LocalServerCertFile:   filepath.Join(userHomeDir, ".kube/oidc-server.crt"),
-							LocalServerKeyFile:    filepath.Join(userHomeDir, ".kube/oidc-server.key"),
+						Candidates: []authentication.GrantOptionSet{
+							{
+								AuthCodeBrowserOption: &authcode.BrowserOption{
+									BindAddress:           defaultListenAddress,
+									AuthenticationTimeout: defaultAuthenticationTimeoutSec * time.Second,
+									LocalServerCertFile:   filepath.Join(userHomeDir, ".kube/oidc-server.crt"),
+									LocalServerKeyFile:    filepath.Join(userHomeDir, ".kube/oidc-server.key"),
+								},
+							},
 						},
 					},
 					TLSClientConfig: tlsclientconfig.Config{
 						CACertFilename: []string{filepath.Join(userHomeDir, ".kube/ca.crt")},
 					},
+					OutputFormat: credentialplugintypes.OutputFormatExecCredential,
 				},
 			},
 		}
@@ -210,7 +317,8 @@ func TestCmd_Run(t *testing.T) {
 					Return(nil)
 				cmd := Cmd{
 					Root: &Root{
-						Logger: logger.New(t),
+						Tracing: &tracing.Tracing{},
+						Logger:  logger.New(t),
 					},
 					GetToken: &GetToken{
 						GetToken: getToken,
@@ -225,11 +333,152 @@ func TestCmd_Run(t *testing.T) {
 			})
 		}
 
+		t.Run("Profile", func(t *testing.T) {
+			configFilename := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(configFilename, []byte(`profiles:
+  together-prod:
+    oidc-issuer-url: https://issuer.example.com
+    oidc-client-id: YOUR_CLIENT_ID
+    oidc-extra-scope: [email, profile]
+    token-cache-storage: keyring
+    grant-type: device-code
+  invalid:
+    oidc-issuer-url: https://issuer.example.com
+    grant-type: browser
+`), 0600); err != nil {
+				t.Fatalf("WriteFile error: %s", err)
+			}
+			t.Setenv("KUBELOGIN_CONFIG", configFilename)
+
+			t.Run("WithOverride", func(t *testing.T) {
+				ctx := context.TODO()
+				getToken := credentialplugin_mock.NewMockInterface(t)
+				getToken.EXPECT().
+					Do(ctx, credentialplugin.Input{
+						Provider: oidc.Provider{
+							IssuerURL:   "https://issuer.example.com",
+							ClientID:    "YOUR_CLIENT_ID",
+							ExtraScopes: []string{"groups"},
+						},
+						TokenCacheConfig: tokencache.Config{
+							Directory: filepath.Join(userHomeDir, ".kube/cache/oidc-login"),
+							Storage:   tokencache.StorageKeyring,
+						},
+						GrantOptionSet: authentication.GrantOptionSet{
+							DeviceCodeOption: &devicecode.Option{},
+						},
+						OutputFormat: credentialplugintypes.OutputFormatExecCredential,
+					}).
+					Return(nil)
+				cmd := Cmd{
+					Root: &Root{
+						Tracing: &tracing.Tracing{},
+						Logger:  logger.New(t),
+					},
+					GetToken: &GetToken{
+						GetToken: getToken,
+						Logger:   logger.New(t),
+					},
+					Logger: logger.New(t),
+				}
+				exitCode := cmd.Run(ctx, []string{executable, "get-token",
+					"--profile", "together-prod",
+					"--oidc-extra-scope", "groups",
+				}, version)
+				if exitCode != 0 {
+					t.Errorf("exitCode wants 0 but %d", exitCode)
+				}
+			})
+
+			t.Run("InvalidProfile", func(t *testing.T) {
+				ctx := context.TODO()
+				cmd := Cmd{
+					Root: &Root{
+						Tracing: &tracing.Tracing{},
+						Logger:  logger.New(t),
+					},
+					GetToken: &GetToken{
+						GetToken: credentialplugin_mock.NewMockInterface(t),
+						Logger:   logger.New(t),
+					},
+					Logger: logger.New(t),
+				}
+				exitCode := cmd.Run(ctx, []string{executable, "get-token", "--profile", "invalid"}, version)
+				if exitCode != 1 {
+					t.Errorf("exitCode wants 1 but %d", exitCode)
+				}
+			})
+
+			t.Run("WithEnv", func(t *testing.T) {
+				t.Setenv("KUBELOGIN_PROFILE", "together-prod")
+				t.Setenv("KUBELOGIN_TOKEN_CACHE_STORAGE", "disk")
+				t.Setenv("KUBELOGIN_OIDC_EXTRA_SCOPE", "email,groups")
+				t.Setenv("KUBELOGIN_OIDC_CLIENT_ID", "ANOTHER_CLIENT_ID")
+				ctx := context.TODO()
+				getToken := credentialplugin_mock.NewMockInterface(t)
+				getToken.EXPECT().
+					Do(ctx, credentialplugin.Input{
+						Provider: oidc.Provider{
+							IssuerURL:   "https://issuer.example.com",
+							ClientID:    "YOUR_CLIENT_ID",
+							ExtraScopes: []string{"email", "groups"},
+						},
+						TokenCacheConfig: tokencache.Config{
+							Directory: filepath.Join(userHomeDir, ".kube/cache/oidc-login"),
+							Storage:   tokencache.StorageDisk,
+						},
+						GrantOptionSet: authentication.GrantOptionSet{
+							DeviceCodeOption: &devicecode.Option{},
+						},
+						OutputFormat: credentialplugintypes.OutputFormatExecCredential,
+					}).
+					Return(nil)
+				cmd := Cmd{
+					Root: &Root{
+						Tracing: &tracing.Tracing{},
+						Logger:  logger.New(t),
+					},
+					GetToken: &GetToken{
+						GetToken: getToken,
+						Logger:   logger.New(t),
+					},
+					Logger: logger.New(t),
+				}
+				exitCode := cmd.Run(ctx, []string{executable, "get-token",
+					"--oidc-client-id", "YOUR_CLIENT_ID",
+				}, version)
+				if exitCode != 0 {
+					t.Errorf("exitCode wants 0 but %d", exitCode)
+				}
+			})
+
+			t.Run("InvalidEnv", func(t *testing.T) {
+				t.Setenv("KUBELOGIN_AUTHENTICATION_TIMEOUT_SEC", "1m")
+				ctx := context.TODO()
+				cmd := Cmd{
+					Root: &Root{
+						Tracing: &tracing.Tracing{},
+						Logger:  logger.New(t),
+					},
+					GetToken: &GetToken{
+						GetToken: credentialplugin_mock.NewMockInterface(t),
+						Logger:   logger.New(t),
+					},
+					Logger: logger.New(t),
+				}
+				exitCode := cmd.Run(ctx, []string{executable, "get-token", "--profile", "together-prod"}, version)
+				if exitCode != 1 {
+					t.Errorf("exitCode wants 1 but %d", exitCode)
+				}
+			})
+		})
+
 		t.Run("MissingMandatoryOptions", func(t *testing.T) {
 			ctx := context.TODO()
 			cmd := Cmd{
 				Root: &Root{
-					Logger: logger.New(t),
+					Tracing: &tracing.Tracing{},
+					Logger:  logger.New(t),
 				},
 				GetToken: &GetToken{
 					GetToken: credentialplugin_mock.NewMockInterface(t),
@@ -247,7 +496,8 @@ func TestCmd_Run(t *testing.T) {
 			ctx := context.TODO()
 			cmd := Cmd{
 				Root: &Root{
-					Logger: logger.New(t),
+					Tracing: &tracing.Tracing{},
+					Logger:  logger.New(t),
 				},
 				GetToken: &GetToken{
 					GetToken: credentialplugin_mock.NewMockInterface(t),
@@ -268,7 +518,8 @@ func TestCmd_Run(t *testing.T) {
 			cmd := Cmd{
 				Logger: logger.New(t),
 				Root: &Root{
-					Logger: logger.New(t),
+					Tracing: &tracing.Tracing{},
+					Logger:  logger.New(t),
 				},
 			}
 			exitCode := cmd.Run(ctx, []string{executable, "setup"}, version)
@@ -295,7 +546,8 @@ func TestCmd_Run(t *testing.T) {
 			cmd := Cmd{
 				Logger: logger.New(t),
 				Root: &Root{
-					Logger: logger.New(t),
+					Tracing: &tracing.Tracing{},
+					Logger:  logger.New(t),
 				},
 				Setup: &Setup{
 					Setup: setupMock,
@@ -310,5 +562,593 @@ func TestCmd_Run(t *testing.T) {
 				t.Errorf("exitCode wants 0 but %d", exitCode)
 			}
 		})
+
+		t.Run("WithEnv", func(t *testing.T) {
+			t.Setenv("KUBELOGIN_OIDC_CLIENT_SECRET", "YOUR_SECRET")
+			ctx := context.TODO()
+			setupMock := setup_mock.NewMockInterface(t)
+			setupMock.EXPECT().Do(ctx, setup.Input{
+				IssuerURL:      "https://issuer.example.com",
+				ClientID:       "YOUR_CLIENT",
+				ClientSecret:   "YOUR_SECRET",
+				GrantOptionSet: defaultGrantOptionSet,
+				ChangedFlags: []string{
+					"--oidc-issuer-url=https://issuer.example.com",
+					"--oidc-client-id=YOUR_CLIENT",
+				},
+			}).Return(nil)
+			cmd := Cmd{
+				Logger: logger.New(t),
+				Root: &Root{
+					Tracing: &tracing.Tracing{},
+					Logger:  logger.New(t),
+				},
+				Setup: &Setup{
+					Setup: setupMock,
+				},
+			}
+			exitCode := cmd.Run(ctx, []string{executable, "setup",
+				"--oidc-issuer-url", "https://issuer.example.com",
+				"--oidc-client-id", "YOUR_CLIENT",
+			}, version)
+			if exitCode != 0 {
+				t.Errorf("exitCode wants 0 but %d", exitCode)
+			}
+		})
+
+		t.Run("WriteKubeconfig", func(t *testing.T) {
+			ctx := context.TODO()
+			setupMock := setup_mock.NewMockInterface(t)
+			setupMock.EXPECT().Do(ctx, setup.Input{
+				IssuerURL:      "https://issuer.example.com",
+				ClientID:       "YOUR_CLIENT",
+				GrantOptionSet: defaultGrantOptionSet,
+				ChangedFlags: []string{
+					"--oidc-issuer-url=https://issuer.example.com",
+					"--oidc-client-id=YOUR_CLIENT",
+				},
+				WriteKubeconfig: &setup.WriteKubeconfigInput{
+					Filename:      "/path/to/kubeconfig",
+					UserName:      "oidc",
+					ContextName:   "hello.k8s.local",
+					ClusterServer: "https://api.hello.k8s.local",
+				},
+			}).Return(nil)
+			cmd := Cmd{
+				Logger: logger.New(t),
+				Root: &Root{
+					Tracing: &tracing.Tracing{},
+					Logger:  logger.New(t),
+				},
+				Setup: &Setup{
+					Setup: setupMock,
+				},
+			}
+			exitCode := cmd.Run(ctx, []string{executable, "setup",
+				"--oidc-issuer-url", "https://issuer.example.com",
+				"--oidc-client-id", "YOUR_CLIENT",
+				"--write-kubeconfig",
+				"--kubeconfig", "/path/to/kubeconfig",
+				"--context-name", "hello.k8s.local",
+				"--cluster-server", "https://api.hello.k8s.local",
+			}, version)
+			if exitCode != 0 {
+				t.Errorf("exitCode wants 0 but %d", exitCode)
+			}
+		})
+
+		t.Run("EmitAuthenticationConfig", func(t *testing.T) {
+			ctx := context.TODO()
+			setupMock := setup_mock.NewMockInterface(t)
+			setupMock.EXPECT().Do(ctx, setup.Input{
+				IssuerURL:      "https://issuer.example.com",
+				ClientID:       "YOUR_CLIENT",
+				GrantOptionSet: defaultGrantOptionSet,
+				ChangedFlags: []string{
+					"--oidc-issuer-url=https://issuer.example.com",
+					"--oidc-client-id=YOUR_CLIENT",
+				},
+				ClaimMapping: setup.ClaimMappingInput{
+					UsernameClaim:  "email",
+					UsernamePrefix: "-",
+				},
+				AuthenticationConfig: &setup.AuthenticationConfigInput{
+					Filename:       "/path/to/authentication-config.yaml",
+					ExtraAudiences: []string{"kubernetes"},
+				},
+			}).Return(nil)
+			cmd := Cmd{
+				Logger: logger.New(t),
+				Root: &Root{
+					Tracing: &tracing.Tracing{},
+					Logger:  logger.New(t),
+				},
+				Setup: &Setup{
+					Setup: setupMock,
+				},
+			}
+			exitCode := cmd.Run(ctx, []string{executable, "setup",
+				"--oidc-issuer-url", "https://issuer.example.com",
+				"--oidc-client-id", "YOUR_CLIENT",
+				"--emit-authn-config",
+				"--authn-config-output", "/path/to/authentication-config.yaml",
+				"--authn-audience", "kubernetes",
+				"--username-claim", "email",
+				"--username-prefix", "-",
+			}, version)
+			if exitCode != 0 {
+				t.Errorf("exitCode wants 0 but %d", exitCode)
+			}
+		})
+		t.Run("EmitRBAC", func(t *testing.T) {
+			ctx := context.TODO()
+			setupMock := setup_mock.NewMockInterface(t)
+			setupMock.EXPECT().Do(ctx, setup.Input{
+				IssuerURL:      "https://issuer.example.com",
+				ClientID:       "YOUR_CLIENT",
+				GrantOptionSet: defaultGrantOptionSet,
+				ChangedFlags: []string{
+					"--oidc-issuer-url=https://issuer.example.com",
+					"--oidc-client-id=YOUR_CLIENT",
+				},
+				ClaimMapping: setup.ClaimMappingInput{
+					GroupsPrefix: "oidc:",
+				},
+				RBAC: &setup.RBACInput{
+					Role:           "view",
+					BindGroupClaim: "groups=developers",
+					Apply:          true,
+					Kubeconfig:     "/path/to/kubeconfig",
+				},
+			}).Return(nil)
+			cmd := Cmd{
+				Logger: logger.New(t),
+				Root: &Root{
+					Tracing: &tracing.Tracing{},
+					Logger:  logger.New(t),
+				},
+				Setup: &Setup{
+					Setup: setupMock,
+				},
+			}
+			exitCode := cmd.Run(ctx, []string{executable, "setup",
+				"--oidc-issuer-url", "https://issuer.example.com",
+				"--oidc-client-id", "YOUR_CLIENT",
+				"--emit-rbac",
+				"--role", "view",
+				"--bind-group", "groups=developers",
+				"--groups-prefix", "oidc:",
+				"--apply-rbac",
+				"--kubeconfig", "/path/to/kubeconfig",
+			}, version)
+			if exitCode != 0 {
+				t.Errorf("exitCode wants 0 but %d", exitCode)
+			}
+		})
+	})
+
+	t.Run("verify-authn", func(t *testing.T) {
+		t.Run("WithOptions", func(t *testing.T) {
+			ctx := context.TODO()
+			verifyAuthnMock := verifyauthn_mock.NewMockInterface(t)
+			verifyAuthnMock.EXPECT().Do(ctx, verifyauthn.Input{
+				AuthenticationConfigFilename: "/path/to/authentication-config.yaml",
+				Provider: oidc.Provider{
+					IssuerURL: "https://issuer.example.com",
+					ClientID:  "YOUR_CLIENT_ID",
+				},
+				TokenCacheConfig: tokencache.Config{
+					Directory: "/path/to/token-cache",
+				},
+			}).Return(nil)
+			cmd := Cmd{
+				Logger: logger.New(t),
+				Root: &Root{
+					Tracing: &tracing.Tracing{},
+					Logger:  logger.New(t),
+				},
+				VerifyAuthn: &VerifyAuthn{
+					VerifyAuthn: verifyAuthnMock,
+				},
+			}
+			exitCode := cmd.Run(ctx, []string{executable, "verify-authn",
+				"--authn-config", "/path/to/authentication-config.yaml",
+				"--oidc-issuer-url", "https://issuer.example.com",
+				"--oidc-client-id", "YOUR_CLIENT_ID",
+				"--token-cache-dir", "/path/to/token-cache",
+			}, version)
+			if exitCode != 0 {
+				t.Errorf("exitCode wants 0 but %d", exitCode)
+			}
+		})
+
+		t.Run("MissingAuthenticationConfig", func(t *testing.T) {
+			ctx := context.TODO()
+			cmd := Cmd{
+				Logger: logger.New(t),
+				Root: &Root{
+					Tracing: &tracing.Tracing{},
+					Logger:  logger.New(t),
+				},
+				VerifyAuthn: &VerifyAuthn{
+					VerifyAuthn: verifyauthn_mock.NewMockInterface(t),
+				},
+			}
+			exitCode := cmd.Run(ctx, []string{executable, "verify-authn",
+				"--oidc-issuer-url", "https://issuer.example.com",
+				"--oidc-client-id", "YOUR_CLIENT_ID",
+			}, version)
+			if exitCode != 1 {
+				t.Errorf("exitCode wants 1 but %d", exitCode)
+			}
+		})
+	})
+
+	t.Run("login", func(t *testing.T) {
+		t.Run("AllContexts", func(t *testing.T) {
+			ctx := context.TODO()
+			kubeconfigLoaderMock := loader_mock.NewMockInterface(t)
+			kubeconfigLoaderMock.EXPECT().
+				ListExecContexts("/path/to/kubeconfig", []kubeconfig.ContextName(nil)).
+				Return([]kubeconfig.ExecContext{
+					{
+						ContextName:  "dev",
+						UserName:     "oidc",
+						GetTokenArgs: []string{"--oidc-issuer-url=https://issuer.example.com", "--oidc-client-id=YOUR_CLIENT_ID", "--token-cache-dir=/path/to/token-cache", "-v1"},
+					},
+				}, nil)
+			loginMock := login_mock.NewMockInterface(t)
+			loginMock.EXPECT().Do(ctx, login.Input{
+				Targets: []login.Target{
+					{
+						ContextName: "dev",
+						GetToken: credentialplugin.Input{
+							Provider: oidc.Provider{
+								IssuerURL: "https://issuer.example.com",
+								ClientID:  "YOUR_CLIENT_ID",
+							},
+							TokenCacheConfig: tokencache.Config{
+								Directory: "/path/to/token-cache",
+							},
+							GrantOptionSet: defaultGrantOptionSet,
+						},
+					},
+				},
+			}).Return(nil)
+			cmd := Cmd{
+				Logger: logger.New(t),
+				Root: &Root{
+					Tracing: &tracing.Tracing{},
+					Logger:  logger.New(t),
+				},
+				Login: &Login{
+					Login:            loginMock,
+					KubeconfigLoader: kubeconfigLoaderMock,
+				},
+			}
+			exitCode := cmd.Run(ctx, []string{executable, "login",
+				"--all-contexts",
+				"--kubeconfig", "/path/to/kubeconfig",
+			}, version)
+			if exitCode != 0 {
+				t.Errorf("exitCode wants 0 but %d", exitCode)
+			}
+		})
+
+		t.Run("ExecEnv", func(t *testing.T) {
+			ctx := context.TODO()
+			kubeconfigLoaderMock := loader_mock.NewMockInterface(t)
+			kubeconfigLoaderMock.EXPECT().
+				ListExecContexts("/path/to/kubeconfig", []kubeconfig.ContextName{"dev"}).
+				Return([]kubeconfig.ExecContext{
+					{
+						ContextName:  "dev",
+						UserName:     "oidc",
+						GetTokenArgs: []string{"--oidc-issuer-url=https://issuer.example.com"},
+						Env: map[string]string{
+							"KUBELOGIN_OIDC_CLIENT_ID": "YOUR_CLIENT_ID",
+							"OIDC_CLIENT_SECRET":       "YOUR_CLIENT_SECRET",
+							"KUBECACHEDIR":             "/path/to/cache",
+						},
+					},
+				}, nil)
+			loginMock := login_mock.NewMockInterface(t)
+			loginMock.EXPECT().Do(ctx, login.Input{
+				Targets: []login.Target{
+					{
+						ContextName: "dev",
+						GetToken: credentialplugin.Input{
+							Provider: oidc.Provider{
+								IssuerURL:    "https://issuer.example.com",
+								ClientID:     "YOUR_CLIENT_ID",
+								ClientSecret: "YOUR_CLIENT_SECRET",
+							},
+							TokenCacheConfig: tokencache.Config{
+								Directory: filepath.Join("/path/to/cache", "oidc-login"),
+							},
+							GrantOptionSet: defaultGrantOptionSet,
+						},
+					},
+				},
+			}).Return(nil)
+			cmd := Cmd{
+				Logger: logger.New(t),
+				Root: &Root{
+					Tracing: &tracing.Tracing{},
+					Logger:  logger.New(t),
+				},
+				Login: &Login{
+					Login:            loginMock,
+					KubeconfigLoader: kubeconfigLoaderMock,
+				},
+			}
+			exitCode := cmd.Run(ctx, []string{executable, "login",
+				"--contexts", "dev",
+				"--kubeconfig", "/path/to/kubeconfig",
+			}, version)
+			if exitCode != 0 {
+				t.Errorf("exitCode wants 0 but %d", exitCode)
+			}
+		})
+
+		t.Run("NoContexts", func(t *testing.T) {
+			ctx := context.TODO()
+			cmd := Cmd{
+				Logger: logger.New(t),
+				Root: &Root{
+					Tracing: &tracing.Tracing{},
+					Logger:  logger.New(t),
+				},
+				Login: &Login{
+					Login:            login_mock.NewMockInterface(t),
+					KubeconfigLoader: loader_mock.NewMockInterface(t),
+				},
+			}
+			exitCode := cmd.Run(ctx, []string{executable, "login"}, version)
+			if exitCode != 1 {
+				t.Errorf("exitCode wants 1 but %d", exitCode)
+			}
+		})
+	})
+
+	t.Run("doctor", func(t *testing.T) {
+		t.Run("WithOptions", func(t *testing.T) {
+			ctx := context.TODO()
+			doctorMock := doctor_mock.NewMockInterface(t)
+			doctorMock.EXPECT().Do(ctx, doctor.Input{
+				Provider: oidc.Provider{
+					IssuerURL: "https://issuer.example.com",
+					ClientID:  "YOUR_CLIENT_ID",
+				},
+				GrantOptionSet: authentication.GrantOptionSet{
+					DeviceCodeOption: &devicecode.Option{},
+				},
+				TokenCacheConfig: tokencache.Config{
+					Directory: "/path/to/token-cache",
+					Storage:   tokencache.StorageKeyring,
+				},
+				Format: doctor.FormatJSON,
+			}).Return(nil)
+			cmd := Cmd{
+				Logger: logger.New(t),
+				Root: &Root{
+					Tracing: &tracing.Tracing{},
+					Logger:  logger.New(t),
+				},
+				Doctor: &Doctor{
+					Doctor: doctorMock,
+				},
+			}
+			exitCode := cmd.Run(ctx, []string{executable, "doctor",
+				"--format", "json",
+				"--oidc-issuer-url", "https://issuer.example.com",
+				"--oidc-client-id", "YOUR_CLIENT_ID",
+				"--grant-type", "device-code",
+				"--token-cache-dir", "/path/to/token-cache",
+				"--token-cache-storage", "keyring",
+			}, version)
+			if exitCode != 0 {
+				t.Errorf("exitCode wants 0 but %d", exitCode)
+			}
+		})
+
+		t.Run("InvalidFormat", func(t *testing.T) {
+			ctx := context.TODO()
+			cmd := Cmd{
+				Logger: logger.New(t),
+				Root: &Root{
+					Tracing: &tracing.Tracing{},
+					Logger:  logger.New(t),
+				},
+				Doctor: &Doctor{
+					Doctor: doctor_mock.NewMockInterface(t),
+				},
+			}
+			exitCode := cmd.Run(ctx, []string{executable, "doctor",
+				"--format", "yaml",
+				"--oidc-issuer-url", "https://issuer.example.com",
+				"--oidc-client-id", "YOUR_CLIENT_ID",
+			}, version)
+			if exitCode != 1 {
+				t.Errorf("exitCode wants 1 but %d", exitCode)
+			}
+		})
+	})
+
+	t.Run("exec", func(t *testing.T) {
+		t.Run("TokenFile", func(t *testing.T) {
+			ctx := context.TODO()
+			execMock := execcommand_mock.NewMockInterface(t)
+			execMock.EXPECT().Do(ctx, execcommand.Input{
+				GetToken: credentialplugin.Input{
+					Provider: oidc.Provider{
+						IssuerURL: "https://issuer.example.com",
+						ClientID:  "YOUR_CLIENT_ID",
+					},
+					TokenCacheConfig: tokencache.Config{
+						Directory: "/path/to/token-cache",
+					},
+					GrantOptionSet: defaultGrantOptionSet,
+				},
+				Command:       []string{"my-service", "--verbose"},
+				TokenFile:     &execcommand.TokenFileInput{},
+				RefreshAction: execcommand.RefreshActionNone,
+				RefreshBefore: time.Minute,
+			}).Return(execcommand.ExitError{Code: 3})
+			cmd := Cmd{
+				Logger: logger.New(t),
+				Root: &Root{
+					Tracing: &tracing.Tracing{},
+					Logger:  logger.New(t),
+				},
+				Exec: &Exec{
+					Exec: execMock,
+				},
+			}
+			exitCode := cmd.Run(ctx, []string{executable, "exec",
+				"--oidc-issuer-url", "https://issuer.example.com",
+				"--oidc-client-id", "YOUR_CLIENT_ID",
+				"--token-cache-dir", "/path/to/token-cache",
+				"--env=",
+				"--token-file", "auto",
+				"--",
+				"my-service", "--verbose",
+			}, version)
+			if exitCode != 3 {
+				t.Errorf("exitCode wants 3 but %d", exitCode)
+			}
+		})
+
+		t.Run("SignalOnRefresh", func(t *testing.T) {
+			ctx := context.TODO()
+			execMock := execcommand_mock.NewMockInterface(t)
+			execMock.EXPECT().Do(ctx, execcommand.Input{
+				GetToken: credentialplugin.Input{
+					Provider: oidc.Provider{
+						IssuerURL: "https://issuer.example.com",
+						ClientID:  "YOUR_CLIENT_ID",
+					},
+					TokenCacheConfig: tokencache.Config{
+						Directory: "/path/to/token-cache",
+					},
+					GrantOptionSet: defaultGrantOptionSet,
+				},
+				Command:       []string{"my-service"},
+				EnvName:       "TOKEN",
+				TokenFile:     &execcommand.TokenFileInput{Filename: "/path/to/token"},
+				RefreshAction: execcommand.RefreshActionSignal,
+				RefreshSignal: syscall.SIGHUP,
+				RefreshBefore: 5 * time.Minute,
+			}).Return(nil)
+			cmd := Cmd{
+				Logger: logger.New(t),
+				Root: &Root{
+					Tracing: &tracing.Tracing{},
+					Logger:  logger.New(t),
+				},
+				Exec: &Exec{
+					Exec: execMock,
+				},
+			}
+			exitCode := cmd.Run(ctx, []string{executable, "exec",
+				"--oidc-issuer-url", "https://issuer.example.com",
+				"--oidc-client-id", "YOUR_CLIENT_ID",
+				"--token-cache-dir", "/path/to/token-cache",
+				"--env", "TOKEN",
+				"--token-file", "/path/to/token",
+				"--on-refresh", "signal",
+				"--refresh-before", "5m",
+				"--",
+				"my-service",
+			}, version)
+			if exitCode != 0 {
+				t.Errorf("exitCode wants 0 but %d", exitCode)
+			}
+		})
+
+		t.Run("NoCommand", func(t *testing.T) {
+			ctx := context.TODO()
+			cmd := Cmd{
+				Logger: logger.New(t),
+				Root: &Root{
+					Tracing: &tracing.Tracing{},
+					Logger:  logger.New(t),
+				},
+				Exec: &Exec{
+					Exec: execcommand_mock.NewMockInterface(t),
+				},
+			}
+			exitCode := cmd.Run(ctx, []string{executable, "exec",
+				"--oidc-issuer-url", "https://issuer.example.com",
+				"--oidc-client-id", "YOUR_CLIENT_ID",
+			}, version)
+			if exitCode != 1 {
+				t.Errorf("exitCode wants 1 but %d", exitCode)
+			}
+		})
+	})
+
+	t.Run("agent", func(t *testing.T) {
+		ctx := context.TODO()
+		agentMock := agent_mock.NewMockInterface(t)
+		agentMock.EXPECT().Do(ctx, agent.Input{
+			Socket:        "/path/to/agent.sock",
+			RefreshBefore: 5 * time.Minute,
+		}).Return(nil)
+		cmd := Cmd{
+			Logger: logger.New(t),
+			Root: &Root{
+				Tracing: &tracing.Tracing{},
+				Logger:  logger.New(t),
+			},
+			Agent: &Agent{
+				Agent: agentMock,
+			},
+		}
+		exitCode := cmd.Run(ctx, []string{executable, "agent",
+			"--socket", "/path/to/agent.sock",
+			"--refresh-before", "5m",
+		}, version)
+		if exitCode != 0 {
+			t.Errorf("exitCode wants 0 but %d", exitCode)
+		}
+	})
+
+	t.Run("audit show", func(t *testing.T) {
+		ctx := context.TODO()
+		auditShowMock := auditshow_mock.NewMockInterface(t)
+		auditShowMock.EXPECT().Do(ctx, auditshow.Input{
+			AuditConfig: audit.Config{
+				Filename:   "/path/to/audit.log",
+				MaxSize:    10 * 1024 * 1024,
+				MaxBackups: 3,
+			},
+			Filter: auditshow.Filter{
+				Since:   24 * time.Hour,
+				Event:   audit.EventFailure,
+				Subject: "YOUR_SUBJECT",
+			},
+			Format: auditshow.FormatJSON,
+		}).Return(nil)
+		cmd := Cmd{
+			Logger: logger.New(t),
+			Root: &Root{
+				Tracing: &tracing.Tracing{},
+				Logger:  logger.New(t),
+			},
+			Audit: &Audit{
+				AuditShow: auditShowMock,
+			},
+		}
+		exitCode := cmd.Run(ctx, []string{executable, "audit", "show",
+			"--audit-log", "/path/to/audit.log",
+			"--since", "24h",
+			"--event", "failure",
+			"--subject", "YOUR_SUBJECT",
+			"--format", "json",
+		}, version)
+		if exitCode != 0 {
+			t.Errorf("exitCode wants 0 but %d", exitCode)
+		}
 	})
 }
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/togethercomputer/together-kubelogin/pkg/usecases/doctor"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var allDoctorFormats = strings.Join([]string{string(doctor.FormatTable), string(doctor.FormatJSON)}, "|")
+
+// doctorOptions represents the options for doctor command.
+type doctorOptions struct {
+	getTokenOptions
+	Format string
+}
+
+func (o *doctorOptions) addFlags(f *pflag.FlagSet) {
+	f.StringVar(&o.Format, "format", string(doctor.FormatTable), fmt.Sprintf("Output format. One of (%s)", allDoctorFormats))
+	o.getTokenOptions.addFlags(f)
+}
+
+func (o *doctorOptions) format() (doctor.Format, error) {
+	switch o.Format {
+	case string(doctor.FormatTable):
+		return doctor.FormatTable, nil
+	case string(doctor.FormatJSON):
+		return doctor.FormatJSON, nil
+	default:
+		return "", fmt.Errorf("format must be one of (%s)", allDoctorFormats)
+	}
+}
+
+type Doctor struct {
+	Doctor doctor.Interface
+}
+
+func (cmd *Doctor) New() *cobra.Command {
+	var o doctorOptions
+	c := &cobra.Command{
+		Use:   "doctor [flags]",
+		Short: "Diagnose the login configuration",
+		Long: `Diagnose the login configuration.
+
+This takes the same flags as get-token, and checks the following items without authentication:
+discovery and issuer, TLS certificate, JWKS, grant type, PKCE method, listen address,
+clock offset against the provider, token cache directory and keyring.
+Each check reports pass, warn, fail or skip.
+It exits with an error if any check fails.
+`,
+		Args: cobra.NoArgs,
+		RunE: func(c *cobra.Command, _ []string) error {
+			if err := o.resolve(c.Flags()); err != nil {
+				return fmt.Errorf("doctor: %w", err)
+			}
+			format, err := o.format()
+			if err != nil {
+				return fmt.Errorf("doctor: %w", err)
+			}
+			credentialPluginInput, err := o.credentialPluginInput()
+			if err != nil {
+				return fmt.Errorf("doctor: %w", err)
+			}
+			in := doctor.Input{
+				Provider:         credentialPluginInput.Provider,
+				GrantOptionSet:   credentialPluginInput.GrantOptionSet,
+				TLSClientConfig:  credentialPluginInput.TLSClientConfig,
+				TokenCacheConfig: credentialPluginInput.TokenCacheConfig,
+				Format:           format,
+			}
+			if err := cmd.Doctor.Do(c.Context(), in); err != nil {
+				return fmt.Errorf("doctor: %w", err)
+			}
+			return nil
+		},
+	}
+	c.Flags().SortFlags = false
+	o.addFlags(c.Flags())
+	return c
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// envPrefix is the prefix of the environment variables bound to the flags.
+// For example, --grant-type is bound to KUBELOGIN_GRANT_TYPE.
+//
+// The precedence is flag > environment variable > profile > default.
+const envPrefix = "KUBELOGIN_"
+
+// envExcludedFlags are not bound to the environment variables.
+var envExcludedFlags = []string{"help", "version"}
+
+// envAnnotation is the annotation of a flag set by the environment variable.
+const envAnnotation = "kubelogin_env"
+
+func envName(flagName string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// bindEnv shows the environment variables in the usage of the flags of the command.
+// The persistent flags are shown in the command which defines them.
+func bindEnv(c *cobra.Command) {
+	c.LocalFlags().VisitAll(func(flag *pflag.Flag) {
+		if isEnvExcluded(flag) {
+			return
+		}
+		flag.Usage = fmt.Sprintf("%s (env: %s)", flag.Usage, envName(flag.Name))
+	})
+}
+
+// applyEnv sets the environment variables to the flags which are not explicitly set.
+// A slice flag accepts the comma separated values.
+func applyEnv(f *pflag.FlagSet, lookupEnv func(string) (string, bool)) error {
+	var err error
+	f.VisitAll(func(flag *pflag.Flag) {
+		if err != nil || flag.Changed || isEnvExcluded(flag) {
+			return
+		}
+		value, ok := lookupEnv(envName(flag.Name))
+		if !ok {
+			return
+		}
+		if setErr := setFlagFromEnv(f, flag, value); setErr != nil {
+			err = fmt.Errorf("invalid environment variable %s: %w", envName(flag.Name), setErr)
+			return
+		}
+		if flag.Annotations == nil {
+			flag.Annotations = make(map[string][]string)
+		}
+		flag.Annotations[envAnnotation] = []string{envName(flag.Name)}
+	})
+	return err
+}
+
+// isSetFromEnv returns true if the flag is set by the environment variable.
+func isSetFromEnv(flag *pflag.Flag) bool {
+	_, ok := flag.Annotations[envAnnotation]
+	return ok
+}
+
+// execEnvLookup returns the function to look up the environment variables of an exec plugin,
+// which take precedence over the environment variables of this process, as kubectl does.
+func execEnvLookup(env map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		if value, ok := env[name]; ok {
+			return value, true
+		}
+		return os.LookupEnv(name)
+	}
+}
+
+func setFlagFromEnv(f *pflag.FlagSet, flag *pflag.Flag, value string) error {
+	if flag.Value.Type() != "stringArray" {
+		return f.Set(flag.Name, value)
+	}
+	// stringArray does not split the value
+	for _, v := range strings.Split(value, ",") {
+		if err := f.Set(flag.Name, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isEnvExcluded(flag *pflag.Flag) bool {
+	for _, name := range envExcludedFlags {
+		if flag.Name == name {
+			return true
+		}
+	}
+	return flag.Deprecated != ""
+}
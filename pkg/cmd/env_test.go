@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+func Test_envName(t *testing.T) {
+	tests := map[string]string{
+		"grant-type":                 "KUBELOGIN_GRANT_TYPE",
+		"oidc-issuer-url":            "KUBELOGIN_OIDC_ISSUER_URL",
+		"certificate-authority-data": "KUBELOGIN_CERTIFICATE_AUTHORITY_DATA",
+		"authentication-timeout-sec": "KUBELOGIN_AUTHENTICATION_TIMEOUT_SEC",
+	}
+	for flagName, want := range tests {
+		t.Run(flagName, func(t *testing.T) {
+			if got := envName(flagName); got != want {
+				t.Errorf("envName wants %s but was %s", want, got)
+			}
+		})
+	}
+}
+
+func Test_applyEnv(t *testing.T) {
+	t.Setenv("KUBELOGIN_OIDC_ISSUER_URL", "https://issuer.example.com")
+	t.Setenv("KUBELOGIN_OIDC_CLIENT_ID", "YOUR_CLIENT_ID")
+	t.Setenv("KUBELOGIN_CERTIFICATE_AUTHORITY", "/path/to/ca1.crt,/path/to/ca2.crt")
+	t.Setenv("KUBELOGIN_SKIP_OPEN_BROWSER", "true")
+
+	var o getTokenOptions
+	f := pflag.NewFlagSet("get-token", pflag.ContinueOnError)
+	o.addFlags(f)
+	if err := f.Parse([]string{"--oidc-client-id", "ANOTHER_CLIENT_ID"}); err != nil {
+		t.Fatalf("Parse error: %s", err)
+	}
+	if err := applyEnv(f, os.LookupEnv); err != nil {
+		t.Fatalf("applyEnv error: %s", err)
+	}
+	if want := "https://issuer.example.com"; o.IssuerURL != want {
+		t.Errorf("IssuerURL wants %s but was %s", want, o.IssuerURL)
+	}
+	if want := "ANOTHER_CLIENT_ID"; o.ClientID != want {
+		t.Errorf("ClientID wants %s but was %s", want, o.ClientID)
+	}
+	if diff := cmp.Diff([]string{"/path/to/ca1.crt", "/path/to/ca2.crt"}, o.tlsOptions.CACertFilename); diff != "" {
+		t.Errorf("CACertFilename mismatch (-want +got):\n%s", diff)
+	}
+	if !o.authenticationOptions.SkipOpenBrowser {
+		t.Errorf("SkipOpenBrowser wants true")
+	}
+	if !f.Changed("oidc-issuer-url") {
+		t.Errorf("oidc-issuer-url wants to be changed")
+	}
+	if !isSetFromEnv(f.Lookup("oidc-issuer-url")) {
+		t.Errorf("oidc-issuer-url wants to be set from the environment variable")
+	}
+	if isSetFromEnv(f.Lookup("oidc-client-id")) {
+		t.Errorf("oidc-client-id wants not to be set from the environment variable")
+	}
+}
+
+func Test_applyEnv_persistentFlags(t *testing.T) {
+	t.Setenv("KUBELOGIN_LOG_UNREDACTED", "true")
+
+	var unredacted bool
+	rootCmd := &cobra.Command{Use: "kubelogin"}
+	rootCmd.PersistentFlags().BoolVar(&unredacted, "log-unredacted", false, "")
+	subCmd := &cobra.Command{Use: "get-token", Run: func(*cobra.Command, []string) {}}
+	rootCmd.AddCommand(subCmd)
+	bindEnvRecursive(rootCmd)
+	rootCmd.PersistentPreRunE = func(c *cobra.Command, _ []string) error {
+		return applyEnv(c.Flags(), os.LookupEnv)
+	}
+	rootCmd.SetArgs([]string{"get-token"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Execute error: %s", err)
+	}
+	if !unredacted {
+		t.Errorf("log-unredacted wants true")
+	}
+	if want := " (env: KUBELOGIN_LOG_UNREDACTED)"; rootCmd.PersistentFlags().Lookup("log-unredacted").Usage != want {
+		t.Errorf("Usage wants %q but was %q", want, rootCmd.PersistentFlags().Lookup("log-unredacted").Usage)
+	}
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/togethercomputer/together-kubelogin/pkg/usecases/execcommand"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var allRefreshActions = strings.Join([]string{
+	"auto",
+	string(execcommand.RefreshActionNone),
+	string(execcommand.RefreshActionSignal),
+	string(execcommand.RefreshActionRestart),
+}, "|")
+
+var refreshSignals = map[string]os.Signal{
+	"HUP":  syscall.SIGHUP,
+	"INT":  syscall.SIGINT,
+	"QUIT": syscall.SIGQUIT,
+	"TERM": syscall.SIGTERM,
+}
+
+// tokenFileInTempDir is the value of --token-file to create the file in a private temporary directory.
+const tokenFileInTempDir = "auto"
+
+// execOptions represents the options for exec command.
+type execOptions struct {
+	getTokenOptions
+	EnvName       string
+	TokenFile     string
+	OnRefresh     string
+	RefreshSignal string
+	RefreshBefore time.Duration
+}
+
+func (o *execOptions) addFlags(f *pflag.FlagSet) {
+	f.StringVar(&o.EnvName, "env", "KUBELOGIN_TOKEN", "Name of the environment variable to pass the token. If empty, the token is not passed by the environment variable")
+	f.StringVar(&o.TokenFile, "token-file", "", fmt.Sprintf("If set, write the token to the file and pass the path by $%s. An existing file is overwritten and kept on exit. If %s, create the file in a private temporary directory and remove it on exit", execcommand.TokenFileEnvName, tokenFileInTempDir))
+	f.StringVar(&o.OnRefresh, "on-refresh", "auto", fmt.Sprintf("Action to the command when the token is refreshed. One of (%s). auto means restart if no token file, otherwise none", allRefreshActions))
+	f.StringVar(&o.RefreshSignal, "refresh-signal", "HUP", "[on-refresh=signal] Signal to send to the command. One of (HUP|INT|QUIT|TERM)")
+	f.DurationVar(&o.RefreshBefore, "refresh-before", time.Minute, "Refresh the token before the expiry")
+	o.getTokenOptions.addFlags(f)
+}
+
+func (o *execOptions) expandHomedir() {
+	if o.TokenFile != tokenFileInTempDir {
+		o.TokenFile = expandHomedir(o.TokenFile)
+	}
+}
+
+func (o *execOptions) execInput(command []string) (execcommand.Input, error) {
+	credentialPluginInput, err := o.credentialPluginInput()
+	if err != nil {
+		return execcommand.Input{}, err
+	}
+	in := execcommand.Input{
+		GetToken:      credentialPluginInput,
+		Command:       command,
+		EnvName:       o.EnvName,
+		RefreshBefore: o.RefreshBefore,
+	}
+	switch o.TokenFile {
+	case "":
+	case tokenFileInTempDir:
+		in.TokenFile = &execcommand.TokenFileInput{}
+	default:
+		in.TokenFile = &execcommand.TokenFileInput{Filename: o.TokenFile}
+	}
+	if in.EnvName == "" && in.TokenFile == nil {
+		return execcommand.Input{}, fmt.Errorf("either --env or --token-file must be set")
+	}
+	switch o.OnRefresh {
+	case "auto":
+		in.RefreshAction = execcommand.RefreshActionRestart
+		if in.TokenFile != nil {
+			in.RefreshAction = execcommand.RefreshActionNone
+		}
+	case string(execcommand.RefreshActionNone), string(execcommand.RefreshActionRestart):
+		in.RefreshAction = execcommand.RefreshAction(o.OnRefresh)
+	case string(execcommand.RefreshActionSignal):
+		sig, ok := refreshSignals[strings.TrimPrefix(strings.ToUpper(o.RefreshSignal), "SIG")]
+		if !ok {
+			return execcommand.Input{}, fmt.Errorf("refresh-signal must be one of (HUP|INT|QUIT|TERM)")
+		}
+		in.RefreshAction = execcommand.RefreshActionSignal
+		in.RefreshSignal = sig
+	default:
+		return execcommand.Input{}, fmt.Errorf("on-refresh must be one of (%s)", allRefreshActions)
+	}
+	return in, nil
+}
+
+type Exec struct {
+	Exec execcommand.Interface
+}
+
+func (cmd *Exec) New() *cobra.Command {
+	var o execOptions
+	c := &cobra.Command{
+		Use:   "exec [flags] -- COMMAND [ARGS...]",
+		Short: "Run a command with a token",
+		Long: `Run a command with a token.
+
+This gets a token in the same way as get-token, and runs the command with the token
+in the environment variable or the file.
+It refreshes the token before the expiry, and then restarts the command, sends a signal
+or rotates the token file.
+It forwards the signals to the command, and exits with the exit code of the command.
+`,
+		Example: `  # Pass the token by $KUBELOGIN_TOKEN
+  kubelogin exec --profile=together-prod -- sh -c 'curl -H "Authorization: Bearer $KUBELOGIN_TOKEN" https://api.example.com'
+
+  # Pass the token by $KUBELOGIN_TOKEN_FILE
+  kubelogin exec --profile=together-prod --env= --token-file=auto -- my-service`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			if err := o.resolve(c.Flags()); err != nil {
+				return fmt.Errorf("exec: %w", err)
+			}
+			o.expandHomedir()
+			in, err := o.execInput(args)
+			if err != nil {
+				return fmt.Errorf("exec: %w", err)
+			}
+			if err := cmd.Exec.Do(c.Context(), in); err != nil {
+				return fmt.Errorf("exec: %w", err)
+			}
+			return nil
+		},
+	}
+	c.Flags().SortFlags = false
+	c.Flags().SetInterspersed(false)
+	o.addFlags(c.Flags())
+	return c
+}
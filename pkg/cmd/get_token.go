@@ -4,9 +4,12 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strings"
 
+	credentialplugintypes "github.com/togethercomputer/together-kubelogin/pkg/credentialplugin"
 	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/logger"
 	"github.com/togethercomputer/together-kubelogin/pkg/oidc"
+	"github.com/togethercomputer/together-kubelogin/pkg/profile"
 	"github.com/togethercomputer/together-kubelogin/pkg/usecases/credentialplugin"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -14,9 +17,11 @@ import (
 
 // getTokenOptions represents the options for get-token command.
 type getTokenOptions struct {
+	Profile               string
 	IssuerURL             string
 	ClientID              string
 	ClientSecret          string
+	ClientSecretFile      string
 	RedirectURL           string
 	ExtraScopes           []string
 	UseAccessToken        bool
@@ -25,14 +30,29 @@ type getTokenOptions struct {
 	tlsOptions            tlsOptions
 	pkceOptions           pkceOptions
 	authenticationOptions authenticationOptions
+	policyOptions         authenticationPolicyOptions
+	claimPolicyOptions    claimPolicyOptions
+	auditOptions          auditOptions
 	ForceRefresh          bool
+
+	// lookupEnv looks up an environment variable, default to os.LookupEnv
+	lookupEnv func(string) (string, bool)
+}
+
+func (o *getTokenOptions) lookupEnvFunc() func(string) (string, bool) {
+	if o.lookupEnv != nil {
+		return o.lookupEnv
+	}
+	return os.LookupEnv
 }
 
 func (o *getTokenOptions) addFlags(f *pflag.FlagSet) {
+	f.StringVar(&o.Profile, "profile", "", fmt.Sprintf("Name of the profile in the config file ($%s or ~/.config/kubelogin/config.yaml). Flags take precedence over the profile", profile.EnvName))
 	f.StringVar(&o.IssuerURL, "oidc-issuer-url", "", "Issuer URL of the provider (mandatory)")
 	f.StringVar(&o.ClientID, "oidc-client-id", "", "Client ID of the provider (mandatory)")
 	f.StringVar(&o.ClientSecret, "oidc-client-secret", "", "Client secret of the provider. When PKCE (S256) is enabled, the OIDC_CLIENT_SECRET env var is ignored — use this flag instead.")
-	f.StringVar(&o.RedirectURL, "oidc-redirect-url", "", "[authcode, authcode-keyboard] Redirect URL")
+	f.StringVar(&o.ClientSecretFile, "oidc-client-secret-file", "", "Path to a file containing the client secret of the provider")
+	f.StringVar(&o.RedirectURL, "oidc-redirect-url", "", "[authcode, authcode-keyboard, authcode-paste] Redirect URL")
 	f.StringSliceVar(&o.ExtraScopes, "oidc-extra-scope", nil, "Scopes to request to the provider")
 	f.BoolVar(&o.UseAccessToken, "oidc-use-access-token", false, "Instead of using the id_token, use the access_token to authenticate to Kubernetes")
 	f.StringToStringVar(&o.RequestHeaders, "oidc-request-header", nil, "HTTP headers to send with an authentication request")
@@ -41,12 +61,132 @@ func (o *getTokenOptions) addFlags(f *pflag.FlagSet) {
 	o.tlsOptions.addFlags(f)
 	o.pkceOptions.addFlags(f)
 	o.authenticationOptions.addFlags(f)
+	o.policyOptions.addFlags(f)
+	o.claimPolicyOptions.addFlags(f)
+	o.auditOptions.addFlags(f)
 }
 
 func (o *getTokenOptions) expandHomedir() {
+	o.ClientSecretFile = expandHomedir(o.ClientSecretFile)
 	o.tokenCacheOptions.expandHomedir()
 	o.authenticationOptions.expandHomedir()
 	o.tlsOptions.expandHomedir()
+	o.auditOptions.expandHomedir()
+}
+
+// resolve applies the profile to the flags and validates the options.
+// It must be called after the flags are parsed.
+func (o *getTokenOptions) resolve(f *pflag.FlagSet) error {
+	if o.Profile != "" {
+		p, err := loadProfile(o.Profile, o.lookupEnvFunc())
+		if err != nil {
+			return err
+		}
+		if err := applyProfile(f, p); err != nil {
+			return err
+		}
+	}
+	if err := o.validateMandatoryFlags(); err != nil {
+		return err
+	}
+	o.expandHomedir()
+	return nil
+}
+
+// credentialPluginInput returns the input of the credential plugin use-case.
+func (o *getTokenOptions) credentialPluginInput() (credentialplugin.Input, error) {
+	clientSecret := o.ClientSecret
+	if clientSecret == "" && o.ClientSecretFile != "" {
+		b, err := os.ReadFile(o.ClientSecretFile)
+		if err != nil {
+			return credentialplugin.Input{}, fmt.Errorf("could not read the client secret: %w", err)
+		}
+		clientSecret = strings.TrimSpace(string(b))
+	}
+	if clientSecret == "" {
+		// Fall back to OIDC_CLIENT_SECRET env var, but only when PKCE is
+		// not explicitly set to S256. Providers using PKCE (Okta, Auth0)
+		// don't need a client secret — sending one causes "invalid_client".
+		// Google requires both PKCE and a secret, so Google kubeconfigs
+		// should use --oidc-client-secret flag directly instead of env var.
+		if o.pkceOptions.PKCEMethod != "S256" && !o.pkceOptions.UsePKCE {
+			clientSecret, _ = o.lookupEnvFunc()("OIDC_CLIENT_SECRET")
+		}
+	}
+	grantOptionSet, err := o.authenticationOptions.grantOptionSet()
+	if err != nil {
+		return credentialplugin.Input{}, err
+	}
+	tokenCacheConfig, err := o.tokenCacheOptions.tokenCacheConfig()
+	if err != nil {
+		return credentialplugin.Input{}, err
+	}
+	pkceMethod, err := o.pkceOptions.pkceMethod()
+	if err != nil {
+		return credentialplugin.Input{}, err
+	}
+	provider := oidc.Provider{
+		IssuerURL:      o.IssuerURL,
+		ClientID:       o.ClientID,
+		ClientSecret:   clientSecret,
+		RedirectURL:    o.RedirectURL,
+		PKCEMethod:     pkceMethod,
+		UseAccessToken: o.UseAccessToken,
+		ExtraScopes:    o.ExtraScopes,
+		RequestHeaders: o.RequestHeaders,
+	}
+	if err := o.policyOptions.apply(&provider); err != nil {
+		return credentialplugin.Input{}, err
+	}
+	claimPolicy, err := o.claimPolicyOptions.claimPolicy()
+	if err != nil {
+		return credentialplugin.Input{}, err
+	}
+	tlsClientConfig, err := o.tlsOptions.tlsClientConfig()
+	if err != nil {
+		return credentialplugin.Input{}, err
+	}
+	return credentialplugin.Input{
+		Provider:         provider,
+		ForceRefresh:     o.ForceRefresh,
+		TokenCacheConfig: tokenCacheConfig,
+		GrantOptionSet:   grantOptionSet,
+		TLSClientConfig:  tlsClientConfig,
+		AuditConfig:      o.auditOptions.auditConfig(),
+		ClaimPolicy:      claimPolicy,
+	}, nil
+}
+
+// validateMandatoryFlags returns an error if a mandatory flag is missing.
+func (o *getTokenOptions) validateMandatoryFlags() error {
+	if o.IssuerURL == "" {
+		return errors.New("--oidc-issuer-url is missing")
+	}
+	if o.ClientID == "" {
+		return errors.New("--oidc-client-id is missing")
+	}
+	return nil
+}
+
+var allOutputFormats = strings.Join([]string{
+	string(credentialplugintypes.OutputFormatExecCredential),
+	string(credentialplugintypes.OutputFormatToken),
+	string(credentialplugintypes.OutputFormatJSON),
+	string(credentialplugintypes.OutputFormatEnv),
+	string(credentialplugintypes.OutputFormatHeader),
+}, "|")
+
+func outputFormat(s string) (credentialplugintypes.OutputFormat, error) {
+	switch f := credentialplugintypes.OutputFormat(s); f {
+	case credentialplugintypes.OutputFormatExecCredential,
+		credentialplugintypes.OutputFormatToken,
+		credentialplugintypes.OutputFormatJSON,
+		credentialplugintypes.OutputFormatEnv,
+		credentialplugintypes.OutputFormatHeader:
+		return f, nil
+	default:
+		return "", fmt.Errorf("output must be one of (%s)", allOutputFormats)
+	}
 }
 
 type GetToken struct {
@@ -56,62 +196,23 @@ type GetToken struct {
 
 func (cmd *GetToken) New() *cobra.Command {
 	var o getTokenOptions
+	var output string
 	c := &cobra.Command{
 		Use:   "get-token [flags]",
 		Short: "Run as a kubectl credential plugin",
-		Args: func(c *cobra.Command, args []string) error {
-			if err := cobra.NoArgs(c, args); err != nil {
-				return err
-			}
-			if o.IssuerURL == "" {
-				return errors.New("--oidc-issuer-url is missing")
-			}
-			if o.ClientID == "" {
-				return errors.New("--oidc-client-id is missing")
-			}
-			return nil
-		},
+		Args:  cobra.NoArgs,
 		RunE: func(c *cobra.Command, _ []string) error {
-			o.expandHomedir()
-			clientSecret := o.ClientSecret
-			if clientSecret == "" {
-				// Fall back to OIDC_CLIENT_SECRET env var, but only when PKCE is
-				// not explicitly set to S256. Providers using PKCE (Okta, Auth0)
-				// don't need a client secret — sending one causes "invalid_client".
-				// Google requires both PKCE and a secret, so Google kubeconfigs
-				// should use --oidc-client-secret flag directly instead of env var.
-				if o.pkceOptions.PKCEMethod != "S256" && !o.pkceOptions.UsePKCE {
-					clientSecret = os.Getenv("OIDC_CLIENT_SECRET")
-				}
-			}
-			grantOptionSet, err := o.authenticationOptions.grantOptionSet()
-			if err != nil {
+			if err := o.resolve(c.Flags()); err != nil {
 				return fmt.Errorf("get-token: %w", err)
 			}
-			tokenCacheConfig, err := o.tokenCacheOptions.tokenCacheConfig()
+			in, err := o.credentialPluginInput()
 			if err != nil {
 				return fmt.Errorf("get-token: %w", err)
 			}
-			pkceMethod, err := o.pkceOptions.pkceMethod()
+			in.OutputFormat, err = outputFormat(output)
 			if err != nil {
 				return fmt.Errorf("get-token: %w", err)
 			}
-			in := credentialplugin.Input{
-				Provider: oidc.Provider{
-					IssuerURL:      o.IssuerURL,
-					ClientID:       o.ClientID,
-					ClientSecret:   clientSecret,
-					RedirectURL:    o.RedirectURL,
-					PKCEMethod:     pkceMethod,
-					UseAccessToken: o.UseAccessToken,
-					ExtraScopes:    o.ExtraScopes,
-					RequestHeaders: o.RequestHeaders,
-				},
-				ForceRefresh:     o.ForceRefresh,
-				TokenCacheConfig: tokenCacheConfig,
-				GrantOptionSet:   grantOptionSet,
-				TLSClientConfig:  o.tlsOptions.tlsClientConfig(),
-			}
 			if err := cmd.GetToken.Do(c.Context(), in); err != nil {
 				return fmt.Errorf("get-token: %w", err)
 			}
@@ -120,5 +221,11 @@ func (cmd *GetToken) New() *cobra.Command {
 	}
 	c.Flags().SortFlags = false
 	o.addFlags(c.Flags())
+	addOutputFlag(c.Flags(), &output)
 	return c
 }
+
+func addOutputFlag(f *pflag.FlagSet, output *string) {
+	f.StringVar(output, "output", string(credentialplugintypes.OutputFormatExecCredential),
+		fmt.Sprintf("Format to write the token. One of (%s)", allOutputFormats))
+}
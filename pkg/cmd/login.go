@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/togethercomputer/together-kubelogin/pkg/kubeconfig"
+	"github.com/togethercomputer/together-kubelogin/pkg/kubeconfig/loader"
+	"github.com/togethercomputer/together-kubelogin/pkg/usecases/login"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// loginOptions represents the options for login command.
+type loginOptions struct {
+	Kubeconfig  string
+	AllContexts bool
+	Contexts    []string
+}
+
+func (o *loginOptions) addFlags(f *pflag.FlagSet) {
+	f.StringVar(&o.Kubeconfig, "kubeconfig", "", "Path to the kubeconfig file")
+	f.BoolVar(&o.AllContexts, "all-contexts", false, "Log in to all contexts which use get-token")
+	f.StringSliceVar(&o.Contexts, "contexts", nil, "Names of the contexts to log in to")
+}
+
+func (o *loginOptions) contextNames() []kubeconfig.ContextName {
+	var contextNames []kubeconfig.ContextName
+	for _, c := range o.Contexts {
+		contextNames = append(contextNames, kubeconfig.ContextName(c))
+	}
+	return contextNames
+}
+
+// parseGetTokenArgs parses the arguments of get-token in the kubeconfig.
+// It ignores unknown flags such as the log flags.
+// The environment variables of the exec plugin take precedence over this process.
+func parseGetTokenArgs(args []string, env map[string]string) (*getTokenOptions, error) {
+	o := getTokenOptions{lookupEnv: execEnvLookup(env)}
+	f := pflag.NewFlagSet("get-token", pflag.ContinueOnError)
+	f.ParseErrorsAllowlist.UnknownFlags = true
+	o.addFlags(f)
+	if err := f.Parse(args); err != nil {
+		return nil, err
+	}
+	if kubeCacheDir, ok := env["KUBECACHEDIR"]; ok && !f.Changed("token-cache-dir") {
+		o.tokenCacheOptions.TokenCacheDir = filepath.Join(kubeCacheDir, "oidc-login")
+	}
+	// get-token reads the same environment variables
+	if err := applyEnv(f, o.lookupEnv); err != nil {
+		return nil, err
+	}
+	if err := o.resolve(f); err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+type Login struct {
+	Login            login.Interface
+	KubeconfigLoader loader.Interface
+}
+
+func (cmd *Login) New() *cobra.Command {
+	var o loginOptions
+	c := &cobra.Command{
+		Use:   "login (--all-contexts | --contexts=NAME,...) [flags]",
+		Short: "Log in to the contexts at once",
+		Long: `Log in to the contexts at once.
+
+This finds the contexts of which user runs get-token of kubelogin in the kubeconfig.
+It authenticates once for each token cache, i.e. the provider and TLS options,
+and writes the token cache for every context.
+If another client of the same issuer has a token, it tries the token exchange.
+`,
+		Args: cobra.NoArgs,
+		RunE: func(c *cobra.Command, _ []string) error {
+			if o.AllContexts == (len(o.Contexts) > 0) {
+				return errors.New("login: either --all-contexts or --contexts must be set")
+			}
+			o.Kubeconfig = expandHomedir(o.Kubeconfig)
+			execContexts, err := cmd.KubeconfigLoader.ListExecContexts(o.Kubeconfig, o.contextNames())
+			if err != nil {
+				return fmt.Errorf("login: %w", err)
+			}
+			var in login.Input
+			for _, execContext := range execContexts {
+				getTokenOptions, err := parseGetTokenArgs(execContext.GetTokenArgs, execContext.Env)
+				if err != nil {
+					return fmt.Errorf("login: invalid get-token arguments of context %s: %w", execContext.ContextName, err)
+				}
+				getTokenInput, err := getTokenOptions.credentialPluginInput()
+				if err != nil {
+					return fmt.Errorf("login: invalid get-token arguments of context %s: %w", execContext.ContextName, err)
+				}
+				in.Targets = append(in.Targets, login.Target{
+					ContextName: execContext.ContextName,
+					GetToken:    getTokenInput,
+				})
+			}
+			if err := cmd.Login.Do(c.Context(), in); err != nil {
+				return fmt.Errorf("login: %w", err)
+			}
+			return nil
+		},
+	}
+	c.Flags().SortFlags = false
+	o.addFlags(c.Flags())
+	return c
+}
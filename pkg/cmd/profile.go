@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/togethercomputer/together-kubelogin/pkg/profile"
+	"github.com/spf13/pflag"
+)
+
+// enumFlags are validated on loading a profile,
+// so that an error names the file and line.
+var enumFlags = map[string]string{
+	"grant-type":          allGrantType,
+	"oidc-pkce-method":    allPKCEMethods,
+	"oidc-prompt":         allPrompts,
+	"otp-mode":            allOTPModes,
+	"tls-min-version":     allTLSVersions,
+	"token-cache-storage": allTokenCacheStorage,
+	"output":              allOutputFormats,
+}
+
+// applyProfile sets the options of the profile to the flags which are not explicitly set.
+// It skips an option of another command, so that the commands can share a profile.
+func applyProfile(f *pflag.FlagSet, p *profile.Profile) error {
+	for _, o := range p.Options {
+		if o.Name == "profile" || !isProfileOption(o.Name) {
+			return p.Errorf(o, "unknown option %s", o.Name)
+		}
+		flag := f.Lookup(o.Name)
+		if flag == nil || flag.Changed {
+			continue
+		}
+		for _, v := range o.Values {
+			if all, ok := enumFlags[o.Name]; ok && !slices.Contains(strings.Split(all, "|"), v) {
+				return p.Errorf(o, "%s must be one of (%s)", o.Name, all)
+			}
+			if err := f.Set(o.Name, v); err != nil {
+				return p.Errorf(o, "%s", err)
+			}
+		}
+	}
+	return nil
+}
+
+// loadProfile returns the profile of the name in the config file.
+func loadProfile(name string, lookupEnv func(string) (string, bool)) (*profile.Profile, error) {
+	p, err := profile.Load(expandHomedir(profile.FilenameOf(lookupEnv)), name)
+	if err != nil {
+		return nil, fmt.Errorf("could not load the profile: %w", err)
+	}
+	return p, nil
+}
+
+// isProfileOption returns true if any command which reads a profile has the flag.
+func isProfileOption(name string) bool {
+	for _, addFlags := range []func(*pflag.FlagSet){
+		func(f *pflag.FlagSet) {
+			new(getTokenOptions).addFlags(f)
+			addOutputFlag(f, new(string))
+		},
+		new(execOptions).addFlags,
+		new(doctorOptions).addFlags,
+		new(verifyAuthnOptions).addFlags,
+	} {
+		f := pflag.NewFlagSet("profile", pflag.ContinueOnError)
+		addFlags(f)
+		if f.Lookup(name) != nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/togethercomputer/together-kubelogin/pkg/profile"
+	"github.com/spf13/pflag"
+)
+
+func Test_applyProfile(t *testing.T) {
+	p := &profile.Profile{
+		Name:     "together-prod",
+		Filename: "config.yaml",
+		Options: []profile.Option{
+			{Name: "oidc-issuer-url", Values: []string{"https://issuer.example.com"}, Line: 3},
+			{Name: "oidc-client-id", Values: []string{"YOUR_CLIENT_ID"}, Line: 4},
+			{Name: "oidc-extra-scope", Values: []string{"email", "profile"}, Line: 5},
+		},
+	}
+
+	t.Run("FlagTakesPrecedence", func(t *testing.T) {
+		var o getTokenOptions
+		f := pflag.NewFlagSet("get-token", pflag.ContinueOnError)
+		o.addFlags(f)
+		if err := f.Parse([]string{"--oidc-client-id", "ANOTHER_CLIENT_ID"}); err != nil {
+			t.Fatalf("Parse error: %s", err)
+		}
+		if err := applyProfile(f, p); err != nil {
+			t.Fatalf("applyProfile error: %s", err)
+		}
+		if want := "https://issuer.example.com"; o.IssuerURL != want {
+			t.Errorf("IssuerURL wants %s but was %s", want, o.IssuerURL)
+		}
+		if want := "ANOTHER_CLIENT_ID"; o.ClientID != want {
+			t.Errorf("ClientID wants %s but was %s", want, o.ClientID)
+		}
+		if diff := cmp.Diff([]string{"email", "profile"}, o.ExtraScopes); diff != "" {
+			t.Errorf("ExtraScopes mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("OptionOfAnotherCommand", func(t *testing.T) {
+		shared := &profile.Profile{
+			Filename: "config.yaml",
+			Options: []profile.Option{
+				{Name: "oidc-issuer-url", Values: []string{"https://issuer.example.com"}, Line: 3},
+				{Name: "output", Values: []string{"header"}, Line: 4},
+				{Name: "token-file", Values: []string{"auto"}, Line: 5},
+			},
+		}
+		var o execOptions
+		f := pflag.NewFlagSet("exec", pflag.ContinueOnError)
+		o.addFlags(f)
+		if err := applyProfile(f, shared); err != nil {
+			t.Fatalf("applyProfile error: %s", err)
+		}
+		if want := "https://issuer.example.com"; o.IssuerURL != want {
+			t.Errorf("IssuerURL wants %s but was %s", want, o.IssuerURL)
+		}
+		if want := "auto"; o.TokenFile != want {
+			t.Errorf("TokenFile wants %s but was %s", want, o.TokenFile)
+		}
+	})
+
+	tests := map[string]struct {
+		option profile.Option
+		want   string
+	}{
+		"UnknownOption": {
+			option: profile.Option{Name: "oidc-issuer", Values: []string{"https://issuer.example.com"}, Line: 6},
+			want:   "config.yaml:6: unknown option oidc-issuer",
+		},
+		"InvalidEnum": {
+			option: profile.Option{Name: "grant-type", Values: []string{"browser"}, Line: 7},
+			want:   "config.yaml:7: grant-type must be one of (" + allGrantType + ")",
+		},
+		"InvalidValue": {
+			option: profile.Option{Name: "authentication-timeout-sec", Values: []string{"1m"}, Line: 8},
+			want:   `config.yaml:8: invalid argument "1m" for "--authentication-timeout-sec" flag: strconv.ParseInt: parsing "1m": invalid syntax`,
+		},
+	}
+	for name, c := range tests {
+		t.Run(name, func(t *testing.T) {
+			var o getTokenOptions
+			f := pflag.NewFlagSet("get-token", pflag.ContinueOnError)
+			o.addFlags(f)
+			err := applyProfile(f, &profile.Profile{Filename: "config.yaml", Options: []profile.Option{c.option}})
+			if err == nil {
+				t.Fatalf("applyProfile wants an error but was nil")
+			}
+			if err.Error() != c.want {
+				t.Errorf("error wants %q but was %q", c.want, err.Error())
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/logger"
+	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/tracing"
 	"github.com/togethercomputer/together-kubelogin/pkg/kubeconfig"
 	"github.com/togethercomputer/together-kubelogin/pkg/usecases/standalone"
 	"github.com/spf13/cobra"
@@ -27,6 +28,7 @@ type rootOptions struct {
 	User                  string
 	tlsOptions            tlsOptions
 	authenticationOptions authenticationOptions
+	auditOptions          auditOptions
 }
 
 func (o *rootOptions) addFlags(f *pflag.FlagSet) {
@@ -35,11 +37,13 @@ func (o *rootOptions) addFlags(f *pflag.FlagSet) {
 	f.StringVar(&o.User, "user", "", "Name of the kubeconfig user to use. Prior to --context")
 	o.tlsOptions.addFlags(f)
 	o.authenticationOptions.addFlags(f)
+	o.auditOptions.addFlags(f)
 }
 
 type Root struct {
 	Standalone standalone.Interface
 	Logger     logger.Interface
+	Tracing    tracing.Interface
 }
 
 func (cmd *Root) New() *cobra.Command {
@@ -54,12 +58,18 @@ func (cmd *Root) New() *cobra.Command {
 			if err != nil {
 				return fmt.Errorf("invalid option: %w", err)
 			}
+			tlsClientConfig, err := o.tlsOptions.tlsClientConfig()
+			if err != nil {
+				return fmt.Errorf("invalid option: %w", err)
+			}
+			o.auditOptions.expandHomedir()
 			in := standalone.Input{
 				KubeconfigFilename: o.Kubeconfig,
 				KubeconfigContext:  kubeconfig.ContextName(o.Context),
 				KubeconfigUser:     kubeconfig.UserName(o.User),
 				GrantOptionSet:     grantOptionSet,
-				TLSClientConfig:    o.tlsOptions.tlsClientConfig(),
+				TLSClientConfig:    tlsClientConfig,
+				AuditConfig:        o.auditOptions.auditConfig(),
 			}
 			if err := cmd.Standalone.Do(c.Context(), in); err != nil {
 				return fmt.Errorf("login: %w", err)
@@ -70,5 +80,6 @@ func (cmd *Root) New() *cobra.Command {
 	c.Flags().SortFlags = false
 	o.addFlags(c.Flags())
 	cmd.Logger.AddFlags(c.PersistentFlags())
+	cmd.Tracing.AddFlags(c.PersistentFlags())
 	return c
 }
@@ -1,10 +1,13 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 
 	_ "embed"
 
+	"github.com/togethercomputer/together-kubelogin/pkg/kubeconfig"
+	"github.com/togethercomputer/together-kubelogin/pkg/oidc"
 	"github.com/togethercomputer/together-kubelogin/pkg/usecases/setup"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -22,19 +25,148 @@ type setupOptions struct {
 	tlsOptions            tlsOptions
 	pkceOptions           pkceOptions
 	authenticationOptions authenticationOptions
+	policyOptions         authenticationPolicyOptions
 }
 
 func (o *setupOptions) addFlags(f *pflag.FlagSet) {
 	f.StringVar(&o.IssuerURL, "oidc-issuer-url", "", "Issuer URL of the provider")
 	f.StringVar(&o.ClientID, "oidc-client-id", "", "Client ID of the provider")
 	f.StringVar(&o.ClientSecret, "oidc-client-secret", "", "Client secret of the provider")
-	f.StringVar(&o.RedirectURL, "oidc-redirect-url", "", "[authcode, authcode-keyboard] Redirect URL")
+	f.StringVar(&o.RedirectURL, "oidc-redirect-url", "", "[authcode, authcode-keyboard, authcode-paste] Redirect URL")
 	f.StringSliceVar(&o.ExtraScopes, "oidc-extra-scope", nil, "Scopes to request to the provider")
 	f.BoolVar(&o.UseAccessToken, "oidc-use-access-token", false, "Instead of using the id_token, use the access_token to authenticate to Kubernetes")
 	f.StringToStringVar(&o.RequestHeaders, "oidc-request-header", nil, "HTTP headers to send with an authentication request")
 	o.tlsOptions.addFlags(f)
 	o.pkceOptions.addFlags(f)
 	o.authenticationOptions.addFlags(f)
+	o.policyOptions.addFlags(f)
+}
+
+// setupKubeconfigOptions represents the options to write the kubeconfig.
+// They are not passed to get-token command.
+type setupKubeconfigOptions struct {
+	WriteKubeconfig bool
+	Kubeconfig      string
+	UserName        string
+	ContextName     string
+	ClusterServer   string
+	ClusterCA       string
+}
+
+func (o *setupKubeconfigOptions) addFlags(f *pflag.FlagSet) {
+	f.BoolVar(&o.WriteKubeconfig, "write-kubeconfig", false, "If set, write the user, cluster and context into the kubeconfig")
+	f.StringVar(&o.Kubeconfig, "kubeconfig", "", "[write-kubeconfig, apply-rbac] Path to the kubeconfig file")
+	f.StringVar(&o.UserName, "user-name", "oidc", "[write-kubeconfig] Name of the kubeconfig user to write")
+	f.StringVar(&o.ContextName, "context-name", "oidc", "[write-kubeconfig] Name of the kubeconfig context and cluster to write")
+	f.StringVar(&o.ClusterServer, "cluster-server", "", "[write-kubeconfig] URL of the Kubernetes API server. If set, write the cluster and context")
+	f.StringVar(&o.ClusterCA, "cluster-ca", "", "[write-kubeconfig] Path to a cert file for the certificate authority of the Kubernetes API server")
+}
+
+func (o *setupKubeconfigOptions) expandHomedir() {
+	o.Kubeconfig = expandHomedir(o.Kubeconfig)
+	o.ClusterCA = expandHomedir(o.ClusterCA)
+}
+
+func (o *setupKubeconfigOptions) writeKubeconfigInput() (*setup.WriteKubeconfigInput, error) {
+	if !o.WriteKubeconfig {
+		return nil, nil
+	}
+	if o.ClusterCA != "" && o.ClusterServer == "" {
+		return nil, errors.New("--cluster-ca requires --cluster-server")
+	}
+	return &setup.WriteKubeconfigInput{
+		Filename:          o.Kubeconfig,
+		UserName:          kubeconfig.UserName(o.UserName),
+		ContextName:       kubeconfig.ContextName(o.ContextName),
+		ClusterServer:     o.ClusterServer,
+		ClusterCAFilename: o.ClusterCA,
+	}, nil
+}
+
+// setupAuthenticationConfigOptions represents the options to generate an AuthenticationConfiguration.
+// They are not passed to get-token command.
+type setupAuthenticationConfigOptions struct {
+	EmitAuthenticationConfig bool
+	Output                   string
+	ExtraAudiences           []string
+	UsernameClaim            string
+	UsernamePrefix           string
+	GroupsClaim              string
+	GroupsPrefix             string
+}
+
+func (o *setupAuthenticationConfigOptions) addFlags(f *pflag.FlagSet) {
+	f.BoolVar(&o.EmitAuthenticationConfig, "emit-authn-config", false, "If set, generate an AuthenticationConfiguration of kube-apiserver")
+	f.StringVar(&o.Output, "authn-config-output", "", "[emit-authn-config] If set, write the AuthenticationConfiguration to the file")
+	f.StringSliceVar(&o.ExtraAudiences, "authn-audience", nil, "[emit-authn-config] Audiences to accept in addition to the client ID")
+	f.StringVar(&o.UsernameClaim, "username-claim", "", "Claim to use as the username. Suggested from the token by default")
+	f.StringVar(&o.UsernamePrefix, "username-prefix", "", `Prefix of the username. Same as --oidc-username-prefix of kube-apiserver, "-" means no prefix`)
+	f.StringVar(&o.GroupsClaim, "groups-claim", "", "Claim to use as the groups. Suggested from the token by default")
+	f.StringVar(&o.GroupsPrefix, "groups-prefix", "", "Prefix of the groups")
+}
+
+func (o *setupAuthenticationConfigOptions) expandHomedir() {
+	o.Output = expandHomedir(o.Output)
+}
+
+func (o *setupAuthenticationConfigOptions) claimMappingInput() setup.ClaimMappingInput {
+	return setup.ClaimMappingInput{
+		UsernameClaim:  o.UsernameClaim,
+		UsernamePrefix: o.UsernamePrefix,
+		GroupsClaim:    o.GroupsClaim,
+		GroupsPrefix:   o.GroupsPrefix,
+	}
+}
+
+func (o *setupAuthenticationConfigOptions) authenticationConfigInput() *setup.AuthenticationConfigInput {
+	if !o.EmitAuthenticationConfig {
+		return nil
+	}
+	return &setup.AuthenticationConfigInput{
+		Filename:       o.Output,
+		ExtraAudiences: o.ExtraAudiences,
+	}
+}
+
+// setupRBACOptions represents the options to generate a role binding.
+// They are not passed to get-token command.
+type setupRBACOptions struct {
+	EmitRBAC       bool
+	Role           string
+	Namespace      string
+	BindGroupClaim string
+	Output         string
+	Apply          bool
+}
+
+func (o *setupRBACOptions) addFlags(f *pflag.FlagSet) {
+	f.BoolVar(&o.EmitRBAC, "emit-rbac", false, "If set, generate a ClusterRoleBinding or RoleBinding for the authenticated user")
+	f.StringVar(&o.Role, "role", "cluster-admin", "[emit-rbac] Name of the ClusterRole to bind, e.g. cluster-admin or view")
+	f.StringVar(&o.Namespace, "rbac-namespace", "", "[emit-rbac] If set, generate a RoleBinding in the namespace instead of a ClusterRoleBinding")
+	f.StringVar(&o.BindGroupClaim, "bind-group", "", "[emit-rbac] If set, bind the group of the claim instead of the user. CLAIM=VALUE, or CLAIM if the claim has only one group")
+	f.StringVar(&o.Output, "rbac-output", "", "[emit-rbac] If set, write the manifest to the file")
+	f.BoolVar(&o.Apply, "apply-rbac", false, "[emit-rbac] If set, apply the manifest with the current context of the kubeconfig")
+}
+
+func (o *setupRBACOptions) expandHomedir() {
+	o.Output = expandHomedir(o.Output)
+}
+
+func (o *setupRBACOptions) rbacInput(kubeconfig string) (*setup.RBACInput, error) {
+	if !o.EmitRBAC {
+		return nil, nil
+	}
+	if o.Role == "" {
+		return nil, errors.New("--role must be set")
+	}
+	return &setup.RBACInput{
+		Role:           o.Role,
+		Namespace:      o.Namespace,
+		BindGroupClaim: o.BindGroupClaim,
+		Filename:       o.Output,
+		Apply:          o.Apply,
+		Kubeconfig:     kubeconfig,
+	}, nil
 }
 
 type Setup struct {
@@ -46,6 +178,14 @@ var setupLongDescription string
 
 func (cmd *Setup) New() *cobra.Command {
 	var o setupOptions
+	var ko setupKubeconfigOptions
+	var ao setupAuthenticationConfigOptions
+	var ro setupRBACOptions
+	setupOnlyFlags := pflag.NewFlagSet("setup", pflag.ContinueOnError)
+	setupOnlyFlags.SortFlags = false
+	ko.addFlags(setupOnlyFlags)
+	ao.addFlags(setupOnlyFlags)
+	ro.addFlags(setupOnlyFlags)
 	c := &cobra.Command{
 		Use:   "setup",
 		Short: "Show the setup instruction",
@@ -54,7 +194,8 @@ func (cmd *Setup) New() *cobra.Command {
 		RunE: func(c *cobra.Command, _ []string) error {
 			var changedFlags []string
 			c.Flags().VisitAll(func(f *pflag.Flag) {
-				if !f.Changed {
+				// the environment variables may contain the secrets
+				if !f.Changed || isSetFromEnv(f) || setupOnlyFlags.Lookup(f.Name) != nil {
 					return
 				}
 				if sliceValue, ok := f.Value.(pflag.SliceValue); ok {
@@ -74,18 +215,44 @@ func (cmd *Setup) New() *cobra.Command {
 			if err != nil {
 				return fmt.Errorf("setup: %w", err)
 			}
+			var policy oidc.Provider
+			if err := o.policyOptions.apply(&policy); err != nil {
+				return fmt.Errorf("setup: %w", err)
+			}
+			ko.expandHomedir()
+			writeKubeconfigInput, err := ko.writeKubeconfigInput()
+			if err != nil {
+				return fmt.Errorf("setup: %w", err)
+			}
+			tlsClientConfig, err := o.tlsOptions.tlsClientConfig()
+			if err != nil {
+				return fmt.Errorf("setup: %w", err)
+			}
+			ao.expandHomedir()
+			ro.expandHomedir()
+			rbacInput, err := ro.rbacInput(ko.Kubeconfig)
+			if err != nil {
+				return fmt.Errorf("setup: %w", err)
+			}
 			in := setup.Input{
-				IssuerURL:       o.IssuerURL,
-				ClientID:        o.ClientID,
-				ClientSecret:    o.ClientSecret,
-				RedirectURL:     o.RedirectURL,
-				ExtraScopes:     o.ExtraScopes,
-				UseAccessToken:  o.UseAccessToken,
-				RequestHeaders:  o.RequestHeaders,
-				PKCEMethod:      pkceMethod,
-				GrantOptionSet:  grantOptionSet,
-				TLSClientConfig: o.tlsOptions.tlsClientConfig(),
-				ChangedFlags:    changedFlags,
+				IssuerURL:            o.IssuerURL,
+				ClientID:             o.ClientID,
+				ClientSecret:         o.ClientSecret,
+				RedirectURL:          o.RedirectURL,
+				ExtraScopes:          o.ExtraScopes,
+				UseAccessToken:       o.UseAccessToken,
+				RequestHeaders:       o.RequestHeaders,
+				PKCEMethod:           pkceMethod,
+				AuthenticationPolicy: policy.AuthenticationPolicy,
+				Prompt:               policy.Prompt,
+				LoginHint:            policy.LoginHint,
+				GrantOptionSet:       grantOptionSet,
+				TLSClientConfig:      tlsClientConfig,
+				ChangedFlags:         changedFlags,
+				ClaimMapping:         ao.claimMappingInput(),
+				WriteKubeconfig:      writeKubeconfigInput,
+				AuthenticationConfig: ao.authenticationConfigInput(),
+				RBAC:                 rbacInput,
 			}
 			if in.IssuerURL == "" || in.ClientID == "" {
 				return c.Help()
@@ -98,5 +265,6 @@ func (cmd *Setup) New() *cobra.Command {
 	}
 	c.Flags().SortFlags = false
 	o.addFlags(c.Flags())
+	c.Flags().AddFlagSet(setupOnlyFlags)
 	return c
 }
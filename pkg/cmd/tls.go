@@ -2,14 +2,23 @@ package cmd
 
 import (
 	"crypto/tls"
+	"fmt"
+	"strings"
 
 	"github.com/togethercomputer/together-kubelogin/pkg/tlsclientconfig"
 	"github.com/spf13/pflag"
 )
 
+var allTLSVersions = strings.Join([]string{"1.0", "1.1", "1.2", "1.3"}, "|")
+
 type tlsOptions struct {
 	CACertFilename            []string
 	CACertData                []string
+	UseSystemCertPool         bool
+	ClientCertFilename        string
+	ClientKeyFilename         string
+	MinVersion                string
+	ServerName                string
 	SkipTLSVerify             bool
 	RenegotiateOnceAsClient   bool
 	RenegotiateFreelyAsClient bool
@@ -18,6 +27,11 @@ type tlsOptions struct {
 func (o *tlsOptions) addFlags(f *pflag.FlagSet) {
 	f.StringArrayVar(&o.CACertFilename, "certificate-authority", nil, "Path to a cert file for the certificate authority")
 	f.StringArrayVar(&o.CACertData, "certificate-authority-data", nil, "Base64 encoded cert for the certificate authority")
+	f.BoolVar(&o.UseSystemCertPool, "certificate-authority-append-system", false, "If set, trust the system certificate authorities in addition to --certificate-authority and --certificate-authority-data")
+	f.StringVar(&o.ClientCertFilename, "client-certificate", "", "Path to a client certificate file for mutual TLS with the provider")
+	f.StringVar(&o.ClientKeyFilename, "client-key", "", "Path to a client key file for mutual TLS with the provider")
+	f.StringVar(&o.MinVersion, "tls-min-version", "", fmt.Sprintf("Minimum TLS version. One of (%s)", allTLSVersions))
+	f.StringVar(&o.ServerName, "tls-server-name", "", "Server name to verify the certificate of the issuer. Default to the host of the URL. The other hosts of the provider are verified by their own names")
 	f.BoolVar(&o.SkipTLSVerify, "insecure-skip-tls-verify", false, "[SECURITY RISK] If set, the server's certificate will not be checked for validity")
 	f.BoolVar(&o.RenegotiateOnceAsClient, "tls-renegotiation-once", false, "If set, allow a remote server to request renegotiation once per connection")
 	f.BoolVar(&o.RenegotiateFreelyAsClient, "tls-renegotiation-freely", false, "If set, allow a remote server to repeatedly request renegotiation")
@@ -30,14 +44,45 @@ func (o *tlsOptions) expandHomedir() {
 		caCertFilenames = append(caCertFilenames, expanded)
 	}
 	o.CACertFilename = caCertFilenames
+	o.ClientCertFilename = expandHomedir(o.ClientCertFilename)
+	o.ClientKeyFilename = expandHomedir(o.ClientKeyFilename)
 }
 
-func (o tlsOptions) tlsClientConfig() tlsclientconfig.Config {
+func (o tlsOptions) tlsClientConfig() (tlsclientconfig.Config, error) {
+	if (o.ClientCertFilename == "") != (o.ClientKeyFilename == "") {
+		return tlsclientconfig.Config{}, fmt.Errorf("client-certificate and client-key must be set together")
+	}
+	minVersion, err := o.minVersion()
+	if err != nil {
+		return tlsclientconfig.Config{}, err
+	}
 	return tlsclientconfig.Config{
-		CACertFilename: o.CACertFilename,
-		CACertData:     o.CACertData,
-		SkipTLSVerify:  o.SkipTLSVerify,
-		Renegotiation:  o.renegotiationSupport(),
+		CACertFilename:     o.CACertFilename,
+		CACertData:         o.CACertData,
+		UseSystemCertPool:  o.UseSystemCertPool,
+		ClientCertFilename: o.ClientCertFilename,
+		ClientKeyFilename:  o.ClientKeyFilename,
+		MinVersion:         minVersion,
+		ServerName:         o.ServerName,
+		SkipTLSVerify:      o.SkipTLSVerify,
+		Renegotiation:      o.renegotiationSupport(),
+	}, nil
+}
+
+func (o tlsOptions) minVersion() (uint16, error) {
+	switch o.MinVersion {
+	case "":
+		return 0, nil
+	case "1.0":
+		return tls.VersionTLS10, nil
+	case "1.1":
+		return tls.VersionTLS11, nil
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("tls-min-version must be one of (%s)", allTLSVersions)
 	}
 }
 
@@ -57,6 +57,36 @@ func Test_tlsOptions_tlsClientConfig(t *testing.T) {
 				CACertData: []string{"base64encoded1", "base64encoded2"},
 			},
 		},
+		"UseSystemCertPool": {
+			args: []string{
+				"--certificate-authority", "/path/to/cert1",
+				"--certificate-authority-append-system",
+			},
+			want: tlsclientconfig.Config{
+				CACertFilename:    []string{"/path/to/cert1"},
+				UseSystemCertPool: true,
+			},
+		},
+		"ClientCertificate": {
+			args: []string{
+				"--client-certificate", "/path/to/client.crt",
+				"--client-key", "/path/to/client.key",
+			},
+			want: tlsclientconfig.Config{
+				ClientCertFilename: "/path/to/client.crt",
+				ClientKeyFilename:  "/path/to/client.key",
+			},
+		},
+		"MinVersionAndServerName": {
+			args: []string{
+				"--tls-min-version", "1.3",
+				"--tls-server-name", "idp.example.com",
+			},
+			want: tlsclientconfig.Config{
+				MinVersion: tls.VersionTLS13,
+				ServerName: "idp.example.com",
+			},
+		},
 		"RenegotiateOnceAsClient": {
 			args: []string{
 				"--tls-renegotiation-once",
@@ -83,10 +113,33 @@ func Test_tlsOptions_tlsClientConfig(t *testing.T) {
 			if err := f.Parse(c.args); err != nil {
 				t.Fatalf("Parse error: %s", err)
 			}
-			got := o.tlsClientConfig()
+			got, err := o.tlsClientConfig()
+			if err != nil {
+				t.Fatalf("tlsClientConfig error: %s", err)
+			}
 			if diff := cmp.Diff(c.want, got); diff != "" {
 				t.Errorf("mismatch (-want +got):\n%s", diff)
 			}
 		})
 	}
 }
+
+func Test_tlsOptions_tlsClientConfig_error(t *testing.T) {
+	tests := map[string][]string{
+		"ClientCertificateWithoutKey": {"--client-certificate", "/path/to/client.crt"},
+		"InvalidMinVersion":           {"--tls-min-version", "1.4"},
+	}
+	for name, args := range tests {
+		t.Run(name, func(t *testing.T) {
+			var o tlsOptions
+			f := pflag.NewFlagSet("", pflag.ContinueOnError)
+			o.addFlags(f)
+			if err := f.Parse(args); err != nil {
+				t.Fatalf("Parse error: %s", err)
+			}
+			if _, err := o.tlsClientConfig(); err == nil {
+				t.Errorf("err wants non-nil but got nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/togethercomputer/together-kubelogin/pkg/usecases/verifyauthn"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// verifyAuthnOptions represents the options for verify-authn command.
+type verifyAuthnOptions struct {
+	getTokenOptions
+	AuthenticationConfig string
+}
+
+func (o *verifyAuthnOptions) addFlags(f *pflag.FlagSet) {
+	f.StringVar(&o.AuthenticationConfig, "authn-config", "", "Path to the AuthenticationConfiguration of kube-apiserver (mandatory)")
+	o.getTokenOptions.addFlags(f)
+}
+
+type VerifyAuthn struct {
+	VerifyAuthn verifyauthn.Interface
+}
+
+func (cmd *VerifyAuthn) New() *cobra.Command {
+	var o verifyAuthnOptions
+	c := &cobra.Command{
+		Use:   "verify-authn --authn-config=FILE [flags]",
+		Short: "Evaluate the token cache against the AuthenticationConfiguration of kube-apiserver",
+		Long: `Evaluate the token cache against the AuthenticationConfiguration of kube-apiserver.
+
+This finds the token cache by the same flags as get-token, and evaluates it offline
+in the same way as the structured authentication of kube-apiserver:
+issuer, audiences, claimValidationRules, claimMappings and userValidationRules.
+It shows the resulting user, or the rule which rejects the token.
+Note that this does not verify the signature of the token.
+`,
+		Args: cobra.NoArgs,
+		RunE: func(c *cobra.Command, _ []string) error {
+			if o.AuthenticationConfig == "" {
+				return errors.New("verify-authn: --authn-config is missing")
+			}
+			if err := o.resolve(c.Flags()); err != nil {
+				return fmt.Errorf("verify-authn: %w", err)
+			}
+			o.AuthenticationConfig = expandHomedir(o.AuthenticationConfig)
+			credentialPluginInput, err := o.credentialPluginInput()
+			if err != nil {
+				return fmt.Errorf("verify-authn: %w", err)
+			}
+			in := verifyauthn.Input{
+				AuthenticationConfigFilename: o.AuthenticationConfig,
+				Provider:                     credentialPluginInput.Provider,
+				TokenCacheConfig:             credentialPluginInput.TokenCacheConfig,
+				TLSClientConfig:              credentialPluginInput.TLSClientConfig,
+			}
+			if ropcOption := credentialPluginInput.GrantOptionSet.ROPCOption; ropcOption != nil {
+				in.Username = ropcOption.Username
+			}
+			if err := cmd.VerifyAuthn.Do(c.Context(), in); err != nil {
+				return fmt.Errorf("verify-authn: %w", err)
+			}
+			return nil
+		},
+	}
+	c.Flags().SortFlags = false
+	o.addFlags(c.Flags())
+	return c
+}
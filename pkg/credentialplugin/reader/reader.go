@@ -33,7 +33,12 @@ func (r Reader) Read() (credentialplugin.Input, error) {
 	if err := json.Unmarshal([]byte(execInfo), &execCredential); err != nil {
 		return credentialplugin.Input{}, fmt.Errorf("invalid KUBERNETES_EXEC_INFO: %w", err)
 	}
-	return credentialplugin.Input{
+	in := credentialplugin.Input{
 		ClientAuthenticationAPIVersion: execCredential.APIVersion,
-	}, nil
+		NonInteractive:                 !execCredential.Spec.Interactive,
+	}
+	if execCredential.Spec.Cluster != nil {
+		in.ClusterServer = execCredential.Spec.Cluster.Server
+	}
+	return in, nil
 }
@@ -41,4 +41,38 @@ func TestReader_Read(t *testing.T) {
 			t.Errorf("input mismatch (-want +got):\n%s", diff)
 		}
 	})
+	t.Run("KUBERNETES_EXEC_INFO is not interactive", func(t *testing.T) {
+		t.Setenv(
+			"KUBERNETES_EXEC_INFO",
+			`{"kind":"ExecCredential","apiVersion":"client.authentication.k8s.io/v1","spec":{"interactive":false}}`,
+		)
+		input, err := reader.Read()
+		if err != nil {
+			t.Errorf("Read returned error: %v", err)
+		}
+		want := credentialplugin.Input{
+			ClientAuthenticationAPIVersion: "client.authentication.k8s.io/v1",
+			NonInteractive:                 true,
+		}
+		if diff := cmp.Diff(want, input); diff != "" {
+			t.Errorf("input mismatch (-want +got):\n%s", diff)
+		}
+	})
+	t.Run("KUBERNETES_EXEC_INFO has cluster", func(t *testing.T) {
+		t.Setenv(
+			"KUBERNETES_EXEC_INFO",
+			`{"kind":"ExecCredential","apiVersion":"client.authentication.k8s.io/v1","spec":{"cluster":{"server":"https://api.example.com"},"interactive":true}}`,
+		)
+		input, err := reader.Read()
+		if err != nil {
+			t.Errorf("Read returned error: %v", err)
+		}
+		want := credentialplugin.Input{
+			ClientAuthenticationAPIVersion: "client.authentication.k8s.io/v1",
+			ClusterServer:                  "https://api.example.com",
+		}
+		if diff := cmp.Diff(want, input); diff != "" {
+			t.Errorf("input mismatch (-want +got):\n%s", diff)
+		}
+	})
 }
@@ -7,6 +7,8 @@ import "time"
 // This may be a zero value if the input is not available.
 type Input struct {
 	ClientAuthenticationAPIVersion string
+	ClusterServer                  string // set if provideClusterInfo is true
+	NonInteractive                 bool   // set if kubectl does not provide the standard input
 }
 
 // Output represents an output object of the credential plugin.
@@ -14,4 +16,17 @@ type Output struct {
 	Token                          string
 	Expiry                         time.Time
 	ClientAuthenticationAPIVersion string
+	Format                         OutputFormat
 }
+
+// OutputFormat represents the format to write the token.
+// The zero value is ExecCredential for client-go.
+type OutputFormat string
+
+const (
+	OutputFormatExecCredential OutputFormat = "execcredential"
+	OutputFormatToken          OutputFormat = "token"  // raw token
+	OutputFormatJSON           OutputFormat = "json"   // token, expiry and claims
+	OutputFormatEnv            OutputFormat = "env"    // export statements of shell
+	OutputFormatHeader         OutputFormat = "header" // Authorization header of HTTP
+)
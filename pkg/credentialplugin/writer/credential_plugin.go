@@ -26,8 +26,29 @@ type Writer struct {
 	Stdout stdio.Stdout
 }
 
-// Write writes the ExecCredential to standard output for kubectl.
+// Write writes the token to standard output in the format.
+// By default, it writes the ExecCredential for kubectl.
 func (w *Writer) Write(out credentialplugin.Output) error {
+	switch out.Format {
+	case credentialplugin.OutputFormatExecCredential, "":
+		return w.writeExecCredential(out)
+	case credentialplugin.OutputFormatToken:
+		_, err := fmt.Fprintln(w.Stdout, out.Token)
+		return err
+	case credentialplugin.OutputFormatJSON:
+		return w.writeJSON(out)
+	case credentialplugin.OutputFormatEnv:
+		_, err := fmt.Fprint(w.Stdout, generateEnv(out))
+		return err
+	case credentialplugin.OutputFormatHeader:
+		_, err := fmt.Fprintf(w.Stdout, "Authorization: Bearer %s\n", out.Token)
+		return err
+	default:
+		return fmt.Errorf("unknown output format: %s", out.Format)
+	}
+}
+
+func (w *Writer) writeExecCredential(out credentialplugin.Output) error {
 	execCredential, err := generateExecCredential(out)
 	if err != nil {
 		return fmt.Errorf("generate ExecCredential: %w", err)
@@ -6,7 +6,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/togethercomputer/together-kubelogin/pkg/credentialplugin"
+	testingJWT "github.com/togethercomputer/together-kubelogin/pkg/testing/jwt"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	clientauthenticationv1 "k8s.io/client-go/pkg/apis/clientauthentication/v1"
@@ -136,6 +138,78 @@ func TestWriter_Write(t *testing.T) {
 	})
 }
 
+func TestWriter_WriteFormat(t *testing.T) {
+	expiryTime := time.Date(2026, 12, 31, 23, 59, 59, 0, time.UTC)
+	token := testingJWT.EncodeF(t, func(claims *testingJWT.Claims) {
+		claims.Subject = "YOUR_SUBJECT"
+		claims.ExpiresAt = jwt.NewNumericDate(expiryTime)
+	})
+
+	t.Run("Token", func(t *testing.T) {
+		var stdout bytes.Buffer
+		w := Writer{Stdout: &stdout}
+		err := w.Write(credentialplugin.Output{Token: token, Expiry: expiryTime, Format: credentialplugin.OutputFormatToken})
+		require.NoError(t, err)
+		assert.Equal(t, token+"\n", stdout.String())
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		var stdout bytes.Buffer
+		w := Writer{Stdout: &stdout}
+		err := w.Write(credentialplugin.Output{Token: token, Expiry: expiryTime, Format: credentialplugin.OutputFormatJSON})
+		require.NoError(t, err)
+
+		var got struct {
+			Token  string         `json:"token"`
+			Expiry time.Time      `json:"expiry"`
+			Claims map[string]any `json:"claims"`
+		}
+		err = json.Unmarshal(stdout.Bytes(), &got)
+		require.NoError(t, err)
+		assert.Equal(t, token, got.Token)
+		assert.Equal(t, expiryTime, got.Expiry)
+		assert.Equal(t, "YOUR_SUBJECT", got.Claims["sub"])
+	})
+
+	t.Run("JSON_OpaqueToken", func(t *testing.T) {
+		var stdout bytes.Buffer
+		w := Writer{Stdout: &stdout}
+		err := w.Write(credentialplugin.Output{Token: "opaque-token", Format: credentialplugin.OutputFormatJSON})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"token":"opaque-token"}`, stdout.String())
+	})
+
+	t.Run("Env", func(t *testing.T) {
+		var stdout bytes.Buffer
+		w := Writer{Stdout: &stdout}
+		err := w.Write(credentialplugin.Output{Token: "test-token", Expiry: expiryTime, Format: credentialplugin.OutputFormatEnv})
+		require.NoError(t, err)
+		assert.Equal(t, `export KUBELOGIN_TOKEN='test-token'
+export KUBELOGIN_TOKEN_EXPIRY='2026-12-31T23:59:59Z'
+`, stdout.String())
+	})
+
+	t.Run("Header", func(t *testing.T) {
+		var stdout bytes.Buffer
+		w := Writer{Stdout: &stdout}
+		err := w.Write(credentialplugin.Output{Token: "test-token", Expiry: expiryTime, Format: credentialplugin.OutputFormatHeader})
+		require.NoError(t, err)
+		assert.Equal(t, "Authorization: Bearer test-token\n", stdout.String())
+	})
+
+	t.Run("UnknownFormat", func(t *testing.T) {
+		var stdout bytes.Buffer
+		w := Writer{Stdout: &stdout}
+		err := w.Write(credentialplugin.Output{Token: "test-token", Format: "yaml"})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "unknown output format")
+	})
+}
+
+func Test_shellQuote(t *testing.T) {
+	assert.Equal(t, `'it'\''s'`, shellQuote("it's"))
+}
+
 func TestGenerateExecCredential(t *testing.T) {
 	expiryTime := time.Date(2026, 12, 31, 23, 59, 59, 0, time.UTC)
 
@@ -0,0 +1,56 @@
+package writer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/togethercomputer/together-kubelogin/pkg/credentialplugin"
+	"github.com/togethercomputer/together-kubelogin/pkg/jwt"
+)
+
+// Names of the environment variables written by the env format.
+const (
+	envToken       = "KUBELOGIN_TOKEN"
+	envTokenExpiry = "KUBELOGIN_TOKEN_EXPIRY"
+)
+
+type tokenJSON struct {
+	Token  string         `json:"token"`
+	Expiry *time.Time     `json:"expiry,omitempty"`
+	Claims map[string]any `json:"claims,omitempty"`
+}
+
+// writeJSON writes the token with the expiry and claims.
+// The claims are omitted if the token is not a JWT, such as an opaque access token.
+func (w *Writer) writeJSON(out credentialplugin.Output) error {
+	v := tokenJSON{Token: out.Token}
+	if !out.Expiry.IsZero() {
+		expiry := out.Expiry.UTC()
+		v.Expiry = &expiry
+	}
+	if claims, err := jwt.DecodePayloadAsMap(out.Token); err == nil {
+		v.Claims = claims
+	}
+	e := json.NewEncoder(w.Stdout)
+	e.SetIndent("", "  ")
+	if err := e.Encode(v); err != nil {
+		return fmt.Errorf("write JSON: %w", err)
+	}
+	return nil
+}
+
+// generateEnv returns the export statements, which can be evaluated by a POSIX shell.
+func generateEnv(out credentialplugin.Output) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "export %s=%s\n", envToken, shellQuote(out.Token))
+	if !out.Expiry.IsZero() {
+		fmt.Fprintf(&b, "export %s=%s\n", envTokenExpiry, shellQuote(out.Expiry.UTC().Format(time.RFC3339)))
+	}
+	return b.String()
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -6,6 +6,7 @@ package di
 
 import (
 	"github.com/google/wire"
+	auditrepository "github.com/togethercomputer/together-kubelogin/pkg/audit/repository"
 	"github.com/togethercomputer/together-kubelogin/pkg/cmd"
 	credentialpluginreader "github.com/togethercomputer/together-kubelogin/pkg/credentialplugin/reader"
 	credentialpluginwriter "github.com/togethercomputer/together-kubelogin/pkg/credentialplugin/writer"
@@ -14,16 +15,25 @@ import (
 	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/logger"
 	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/reader"
 	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/stdio"
+	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/terminal"
+	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/tracing"
 	kubeconfigLoader "github.com/togethercomputer/together-kubelogin/pkg/kubeconfig/loader"
 	kubeconfigWriter "github.com/togethercomputer/together-kubelogin/pkg/kubeconfig/writer"
 	"github.com/togethercomputer/together-kubelogin/pkg/oidc/client"
+	rbacApplier "github.com/togethercomputer/together-kubelogin/pkg/rbac/applier"
 	"github.com/togethercomputer/together-kubelogin/pkg/tlsclientconfig/loader"
-	"github.com/togethercomputer/together-kubelogin/pkg/tokencache/repository"
+	tokencacheagent "github.com/togethercomputer/together-kubelogin/pkg/tokencache/agent"
+	"github.com/togethercomputer/together-kubelogin/pkg/usecases/agent"
+	"github.com/togethercomputer/together-kubelogin/pkg/usecases/auditshow"
 	"github.com/togethercomputer/together-kubelogin/pkg/usecases/authentication"
 	"github.com/togethercomputer/together-kubelogin/pkg/usecases/clean"
 	"github.com/togethercomputer/together-kubelogin/pkg/usecases/credentialplugin"
+	"github.com/togethercomputer/together-kubelogin/pkg/usecases/doctor"
+	"github.com/togethercomputer/together-kubelogin/pkg/usecases/execcommand"
+	"github.com/togethercomputer/together-kubelogin/pkg/usecases/login"
 	"github.com/togethercomputer/together-kubelogin/pkg/usecases/setup"
 	"github.com/togethercomputer/together-kubelogin/pkg/usecases/standalone"
+	"github.com/togethercomputer/together-kubelogin/pkg/usecases/verifyauthn"
 )
 
 // NewCmd returns an instance of infrastructure.Cmd.
@@ -49,17 +59,27 @@ func NewCmdForHeadless(clock.Interface, stdio.Stdin, stdio.Stdout, logger.Interf
 		credentialplugin.Set,
 		setup.Set,
 		clean.Set,
+		verifyauthn.Set,
+		login.Set,
+		doctor.Set,
+		execcommand.Set,
+		agent.Set,
+		auditshow.Set,
 
 		// infrastructure
 		cmd.Set,
 		reader.Set,
 		kubeconfigLoader.Set,
 		kubeconfigWriter.Set,
-		repository.Set,
+		rbacApplier.Set,
+		tokencacheagent.Set,
+		auditrepository.Set,
 		client.Set,
 		loader.Set,
 		credentialpluginreader.Set,
 		credentialpluginwriter.Set,
+		tracing.Set,
+		terminal.Set,
 	)
 	return nil
 }
@@ -7,6 +7,7 @@
 package di
 
 import (
+	"github.com/togethercomputer/together-kubelogin/pkg/audit/repository"
 	"github.com/togethercomputer/together-kubelogin/pkg/cmd"
 	reader2 "github.com/togethercomputer/together-kubelogin/pkg/credentialplugin/reader"
 	writer2 "github.com/togethercomputer/together-kubelogin/pkg/credentialplugin/writer"
@@ -15,11 +16,17 @@ import (
 	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/logger"
 	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/reader"
 	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/stdio"
+	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/terminal"
+	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/tracing"
 	loader2 "github.com/togethercomputer/together-kubelogin/pkg/kubeconfig/loader"
 	"github.com/togethercomputer/together-kubelogin/pkg/kubeconfig/writer"
 	"github.com/togethercomputer/together-kubelogin/pkg/oidc/client"
+	"github.com/togethercomputer/together-kubelogin/pkg/rbac/applier"
 	"github.com/togethercomputer/together-kubelogin/pkg/tlsclientconfig/loader"
-	"github.com/togethercomputer/together-kubelogin/pkg/tokencache/repository"
+	"github.com/togethercomputer/together-kubelogin/pkg/tokencache/agent"
+	repository2 "github.com/togethercomputer/together-kubelogin/pkg/tokencache/repository"
+	agent2 "github.com/togethercomputer/together-kubelogin/pkg/usecases/agent"
+	"github.com/togethercomputer/together-kubelogin/pkg/usecases/auditshow"
 	"github.com/togethercomputer/together-kubelogin/pkg/usecases/authentication"
 	"github.com/togethercomputer/together-kubelogin/pkg/usecases/authentication/authcode"
 	"github.com/togethercomputer/together-kubelogin/pkg/usecases/authentication/clientcredentials"
@@ -27,8 +34,12 @@ import (
 	"github.com/togethercomputer/together-kubelogin/pkg/usecases/authentication/ropc"
 	"github.com/togethercomputer/together-kubelogin/pkg/usecases/clean"
 	"github.com/togethercomputer/together-kubelogin/pkg/usecases/credentialplugin"
+	"github.com/togethercomputer/together-kubelogin/pkg/usecases/doctor"
+	"github.com/togethercomputer/together-kubelogin/pkg/usecases/execcommand"
+	"github.com/togethercomputer/together-kubelogin/pkg/usecases/login"
 	"github.com/togethercomputer/together-kubelogin/pkg/usecases/setup"
 	"github.com/togethercomputer/together-kubelogin/pkg/usecases/standalone"
+	"github.com/togethercomputer/together-kubelogin/pkg/usecases/verifyauthn"
 	"os"
 )
 
@@ -69,13 +80,21 @@ func NewCmdForHeadless(clockInterface clock.Interface, stdin stdio.Stdin, stdout
 		Reader: readerReader,
 		Logger: loggerInterface,
 	}
+	paste := &authcode.Paste{
+		Reader: readerReader,
+		Logger: loggerInterface,
+	}
 	ropcROPC := &ropc.ROPC{
 		Reader: readerReader,
+		Stdin:  stdin,
 		Logger: loggerInterface,
 	}
+	stderr := &terminal.Stderr{}
 	deviceCode := &devicecode.DeviceCode{
-		Browser: browserInterface,
-		Logger:  loggerInterface,
+		Browser:  browserInterface,
+		Terminal: stderr,
+		Clock:    clockInterface,
+		Logger:   loggerInterface,
 	}
 	clientCredentials := &clientcredentials.ClientCredentials{
 		Logger: loggerInterface,
@@ -83,35 +102,49 @@ func NewCmdForHeadless(clockInterface clock.Interface, stdin stdio.Stdin, stdout
 	authenticationAuthentication := &authentication.Authentication{
 		ClientFactory:     factory,
 		Logger:            loggerInterface,
+		Clock:             clockInterface,
+		Browser:           browserInterface,
 		AuthCodeBrowser:   authcodeBrowser,
 		AuthCodeKeyboard:  keyboard,
+		AuthCodePaste:     paste,
 		ROPC:              ropcROPC,
 		DeviceCode:        deviceCode,
 		ClientCredentials: clientCredentials,
 	}
-	loader3 := &loader2.Loader{}
+	loader3 := &loader2.Loader{
+		Logger: loggerInterface,
+	}
 	writerWriter := &writer.Writer{}
+	repositoryRepository := &repository.Repository{}
 	standaloneStandalone := &standalone.Standalone{
 		Authentication:   authenticationAuthentication,
 		KubeconfigLoader: loader3,
 		KubeconfigWriter: writerWriter,
+		AuditRepository:  repositoryRepository,
 		Logger:           loggerInterface,
 		Clock:            clockInterface,
 	}
+	tracingInterface := tracing.New()
 	root := &cmd.Root{
 		Standalone: standaloneStandalone,
 		Logger:     loggerInterface,
+		Tracing:    tracingInterface,
+	}
+	repository3 := &repository2.Repository{}
+	agentRepository := &agent.Repository{
+		Local:  repository3,
+		Logger: loggerInterface,
 	}
-	repositoryRepository := &repository.Repository{}
 	reader3 := &reader2.Reader{}
 	writer3 := &writer2.Writer{
 		Stdout: stdout,
 	}
 	getToken := &credentialplugin.GetToken{
 		Authentication:         authenticationAuthentication,
-		TokenCacheRepository:   repositoryRepository,
+		TokenCacheRepository:   agentRepository,
 		CredentialPluginReader: reader3,
 		CredentialPluginWriter: writer3,
+		AuditRepository:        repositoryRepository,
 		Logger:                 loggerInterface,
 		Clock:                  clockInterface,
 	}
@@ -119,26 +152,90 @@ func NewCmdForHeadless(clockInterface clock.Interface, stdin stdio.Stdin, stdout
 		GetToken: getToken,
 		Logger:   loggerInterface,
 	}
+	applierApplier := &applier.Applier{}
 	setupSetup := &setup.Setup{
-		Authentication: authenticationAuthentication,
-		Logger:         loggerInterface,
+		Authentication:   authenticationAuthentication,
+		KubeconfigWriter: writerWriter,
+		RBACApplier:      applierApplier,
+		Reader:           readerReader,
+		Logger:           loggerInterface,
 	}
 	cmdSetup := &cmd.Setup{
 		Setup: setupSetup,
 	}
 	cleanClean := &clean.Clean{
-		TokenCacheRepository: repositoryRepository,
+		TokenCacheRepository: agentRepository,
 		Logger:               loggerInterface,
 	}
 	cmdClean := &cmd.Clean{
 		Clean: cleanClean,
 	}
-	cmdCmd := &cmd.Cmd{
-		Root:     root,
-		GetToken: cmdGetToken,
-		Setup:    cmdSetup,
-		Clean:    cmdClean,
+	verifyAuthn := &verifyauthn.VerifyAuthn{
+		TokenCacheRepository: agentRepository,
+		Logger:               loggerInterface,
+		Clock:                clockInterface,
+	}
+	cmdVerifyAuthn := &cmd.VerifyAuthn{
+		VerifyAuthn: verifyAuthn,
+	}
+	loginLogin := &login.Login{
+		Authentication:       authenticationAuthentication,
+		ClientFactory:        factory,
+		TokenCacheRepository: agentRepository,
+		Logger:               loggerInterface,
+		Clock:                clockInterface,
+	}
+	cmdLogin := &cmd.Login{
+		Login:            loginLogin,
+		KubeconfigLoader: loader3,
+	}
+	loader4 := &loader.Loader{}
+	doctorDoctor := &doctor.Doctor{
+		Loader: loader4,
+		Clock:  clockInterface,
+		Stdout: stdout,
+		Logger: loggerInterface,
+	}
+	cmdDoctor := &cmd.Doctor{
+		Doctor: doctorDoctor,
+	}
+	exec := &execcommand.Exec{
+		GetToken: getToken,
+		Clock:    clockInterface,
 		Logger:   loggerInterface,
 	}
+	cmdExec := &cmd.Exec{
+		Exec: exec,
+	}
+	agentAgent := &agent2.Agent{
+		Authentication:  authenticationAuthentication,
+		LocalRepository: repository3,
+		Clock:           clockInterface,
+		Logger:          loggerInterface,
+	}
+	cmdAgent := &cmd.Agent{
+		Agent: agentAgent,
+	}
+	auditShow := &auditshow.AuditShow{
+		AuditRepository: repositoryRepository,
+		Clock:           clockInterface,
+		Stdout:          stdout,
+	}
+	audit := &cmd.Audit{
+		AuditShow: auditShow,
+	}
+	cmdCmd := &cmd.Cmd{
+		Root:        root,
+		GetToken:    cmdGetToken,
+		Setup:       cmdSetup,
+		Clean:       cmdClean,
+		VerifyAuthn: cmdVerifyAuthn,
+		Login:       cmdLogin,
+		Doctor:      cmdDoctor,
+		Exec:        cmdExec,
+		Agent:       cmdAgent,
+		Audit:       audit,
+		Logger:      loggerInterface,
+	}
 	return cmdCmd
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 	"os/exec"
+	"runtime"
 
 	"github.com/google/wire"
 	"github.com/pkg/browser"
@@ -24,6 +25,9 @@ var Set = wire.NewSet(
 type Interface interface {
 	Open(url string) error
 	OpenCommand(ctx context.Context, url, command string) error
+	// IsAvailable returns false if the default browser cannot be opened,
+	// such as an SSH session or no display.
+	IsAvailable() bool
 }
 
 type Browser struct{}
@@ -33,6 +37,26 @@ func (*Browser) Open(url string) error {
 	return browser.OpenURL(url)
 }
 
+// IsAvailable returns true if the BROWSER environment variable is set.
+// Otherwise it returns false on an SSH session, or Linux without a display.
+func (*Browser) IsAvailable() bool {
+	return isAvailable(os.Getenv, runtime.GOOS)
+}
+
+func isAvailable(getenv func(string) string, goos string) bool {
+	if getenv("BROWSER") != "" {
+		return true
+	}
+	if getenv("SSH_CONNECTION") != "" {
+		return false
+	}
+	switch goos {
+	case "darwin", "windows":
+		return true
+	}
+	return getenv("DISPLAY") != "" || getenv("WAYLAND_DISPLAY") != ""
+}
+
 // OpenCommand opens the browser using the command.
 func (*Browser) OpenCommand(ctx context.Context, url, command string) error {
 	c := exec.CommandContext(ctx, command, url)
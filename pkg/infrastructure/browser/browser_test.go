@@ -0,0 +1,26 @@
+package browser
+
+import "testing"
+
+func Test_isAvailable(t *testing.T) {
+	tests := map[string]struct {
+		env  map[string]string
+		goos string
+		want bool
+	}{
+		"Linux with DISPLAY":         {env: map[string]string{"DISPLAY": ":0"}, goos: "linux", want: true},
+		"Linux with WAYLAND_DISPLAY": {env: map[string]string{"WAYLAND_DISPLAY": "wayland-0"}, goos: "linux", want: true},
+		"Linux without display":      {goos: "linux", want: false},
+		"macOS":                      {goos: "darwin", want: true},
+		"SSH session":                {env: map[string]string{"SSH_CONNECTION": "192.0.2.1 22 192.0.2.2 22", "DISPLAY": ":0"}, goos: "darwin", want: false},
+		"SSH session with BROWSER":   {env: map[string]string{"SSH_CONNECTION": "192.0.2.1 22 192.0.2.2 22", "BROWSER": "w3m"}, goos: "linux", want: true},
+	}
+	for name, c := range tests {
+		t.Run(name, func(t *testing.T) {
+			getenv := func(key string) string { return c.env[key] }
+			if got := isAvailable(getenv, c.goos); got != c.want {
+				t.Errorf("isAvailable wants %v but got %v", c.want, got)
+			}
+		})
+	}
+}
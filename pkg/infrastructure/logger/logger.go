@@ -1,9 +1,16 @@
 package logger
 
 import (
+	"encoding/json"
 	"flag"
+	"fmt"
+	"io"
 	"log"
 	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/google/wire"
 	"github.com/spf13/pflag"
@@ -19,6 +26,8 @@ var Set = wire.NewSet(
 func New() Interface {
 	return &Logger{
 		goLogger: log.New(os.Stderr, "", 0),
+		out:      os.Stderr,
+		format:   FormatText,
 	}
 }
 
@@ -27,34 +36,164 @@ type Interface interface {
 	Printf(format string, args ...interface{})
 	V(level int) Verbose
 	IsEnabled(level int) bool
+	Event(event string, fields Fields)
+	IsUnredacted() bool
 }
 
 type Verbose interface {
 	Infof(format string, args ...interface{})
 }
 
+// Fields represents the fields of an event such as issuer, grant and duration.
+type Fields map[string]any
+
+// levelEvent is the level to write an event in the text format.
+const levelEvent = 1
+
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
 type goLogger interface {
 	Printf(format string, v ...interface{})
 }
 
 // Logger provides logging facility using log.Logger and klog.
+// It masks the secrets such as tokens in the messages unless unredacted.
 type Logger struct {
 	goLogger
+	out        io.Writer
+	mu         sync.Mutex // guards out in the JSON format
+	format     string
+	unredacted bool
 }
 
 // AddFlags adds the flags such as -v.
-func (*Logger) AddFlags(f *pflag.FlagSet) {
+func (l *Logger) AddFlags(f *pflag.FlagSet) {
 	gf := flag.NewFlagSet("", flag.ContinueOnError)
 	klog.InitFlags(gf)
 	f.AddGoFlagSet(gf)
+	f.Var((*formatValue)(&l.format), "log-format", fmt.Sprintf("Format of the log (%s|%s)", FormatText, FormatJSON))
+	f.BoolVar(&l.unredacted, "log-unredacted", false, "If set, do not mask the secrets in the log. This may expose your tokens and passwords")
+}
+
+// Printf writes the message.
+func (l *Logger) Printf(format string, args ...interface{}) {
+	msg := l.redact(fmt.Sprintf(format, args...))
+	if l.format == FormatJSON {
+		l.writeJSON(map[string]any{"level": "info", "msg": msg})
+		return
+	}
+	l.goLogger.Printf("%s", msg)
 }
 
 // V returns a logger enabled only if the level is enabled.
-func (*Logger) V(level int) Verbose {
-	return klog.V(klog.Level(level))
+func (l *Logger) V(level int) Verbose {
+	if !l.IsEnabled(level) {
+		return noopVerbose{}
+	}
+	return &verbose{logger: l, level: level}
 }
 
 // IsEnabled returns true if the level is enabled.
 func (*Logger) IsEnabled(level int) bool {
 	return klog.V(klog.Level(level)).Enabled()
 }
+
+// IsUnredacted returns true if the secrets should not be masked.
+func (l *Logger) IsUnredacted() bool {
+	return l.unredacted
+}
+
+// Event writes the event with the fields.
+// In the JSON format, it is always written.
+// In the text format, it is written only if the level 1 is enabled.
+func (l *Logger) Event(event string, fields Fields) {
+	if l.format == FormatJSON {
+		e := map[string]any{"level": "info", "event": event}
+		for k, v := range fields {
+			e[k] = l.redactValue(v)
+		}
+		l.writeJSON(e)
+		return
+	}
+	if !l.IsEnabled(levelEvent) {
+		return
+	}
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	fmt.Fprintf(&b, "event=%s", event)
+	for _, k := range keys {
+		fmt.Fprintf(&b, " %s=%v", k, l.redactValue(fields[k]))
+	}
+	klog.V(levelEvent).InfoDepth(1, b.String())
+}
+
+func (l *Logger) writeJSON(e map[string]any) {
+	e["time"] = time.Now().Format(time.RFC3339Nano)
+	b, err := json.Marshal(e)
+	if err != nil {
+		b = []byte(fmt.Sprintf(`{"level":"error","msg":%q}`, err.Error()))
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, _ = l.out.Write(append(b, '\n'))
+}
+
+func (l *Logger) redact(s string) string {
+	if l.unredacted {
+		return s
+	}
+	return Redact(s)
+}
+
+func (l *Logger) redactValue(v any) any {
+	switch v := v.(type) {
+	case string:
+		return l.redact(v)
+	case error:
+		return l.redact(v.Error())
+	case time.Duration:
+		return v.String()
+	}
+	return v
+}
+
+type verbose struct {
+	logger *Logger
+	level  int
+}
+
+func (v *verbose) Infof(format string, args ...interface{}) {
+	msg := v.logger.redact(fmt.Sprintf(format, args...))
+	if v.logger.format == FormatJSON {
+		v.logger.writeJSON(map[string]any{"level": "debug", "v": v.level, "msg": msg})
+		return
+	}
+	klog.V(klog.Level(v.level)).InfoDepth(1, msg)
+}
+
+type noopVerbose struct{}
+
+func (noopVerbose) Infof(string, ...interface{}) {}
+
+// formatValue validates the value of --log-format.
+type formatValue string
+
+func (f *formatValue) String() string { return string(*f) }
+
+func (f *formatValue) Set(s string) error {
+	switch s {
+	case FormatText, FormatJSON:
+		*f = formatValue(s)
+		return nil
+	}
+	return fmt.Errorf("must be one of (%s|%s)", FormatText, FormatJSON)
+}
+
+func (*formatValue) Type() string { return "string" }
@@ -0,0 +1,71 @@
+package logger
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Redacted is the replacement of a secret.
+const Redacted = "REDACTED"
+
+// sensitiveKeys are the names of parameters and fields which contain a secret.
+var sensitiveKeys = []string{
+	"access_token",
+	"id_token",
+	"refresh_token",
+	"device_code",
+	"code",
+	"code_verifier",
+	"client_secret",
+	"client_assertion",
+	"assertion",
+	"password",
+	"otp",
+	"token",
+}
+
+var (
+	keys = strings.Join(sensitiveKeys, "|")
+
+	// "key": "value" in JSON
+	jsonFieldPattern = regexp.MustCompile(`(?i)("(?:` + keys + `)"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+	// key=value in a query string or form
+	paramPattern = regexp.MustCompile(`(?i)(^|[?&\s,;])((?:` + keys + `)=)[^&\s,;"]+`)
+	// Authorization: Bearer value
+	authorizationHeaderPattern = regexp.MustCompile(`(?im)^((?:proxy-)?authorization:[ \t]*)(?:(\S+)[ \t]+)?\S+(\r?)$`)
+	// Cookie: name=value; name=value
+	cookieHeaderPattern = regexp.MustCompile(`(?im)^((?:set-)?cookie:[ \t]*)(.*?)(\r?)$`)
+	cookieValuePattern  = regexp.MustCompile(`(^|;\s*)([^=;\s]+)=[^;]*`)
+	// header.payload.signature
+	jwtPattern = regexp.MustCompile(`eyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+)
+
+// Redact masks the secrets in the message, such as tokens, client secrets,
+// authorization codes, passwords and cookie values.
+// This is best effort and does not guarantee that no secret is leaked.
+func Redact(s string) string {
+	s = authorizationHeaderPattern.ReplaceAllStringFunc(s, func(m string) string {
+		sm := authorizationHeaderPattern.FindStringSubmatch(m)
+		if sm[2] != "" {
+			return sm[1] + sm[2] + " " + Redacted + sm[3]
+		}
+		return sm[1] + Redacted + sm[3]
+	})
+	s = cookieHeaderPattern.ReplaceAllStringFunc(s, func(m string) string {
+		sm := cookieHeaderPattern.FindStringSubmatch(m)
+		if strings.HasPrefix(strings.ToLower(sm[1]), "set-cookie") {
+			// mask only the value, not the attributes
+			name, _, _ := strings.Cut(sm[2], "=")
+			_, attrs, _ := strings.Cut(sm[2], ";")
+			if attrs != "" {
+				attrs = ";" + attrs
+			}
+			return sm[1] + name + "=" + Redacted + attrs + sm[3]
+		}
+		return sm[1] + cookieValuePattern.ReplaceAllString(sm[2], "${1}${2}="+Redacted) + sm[3]
+	})
+	s = jsonFieldPattern.ReplaceAllString(s, `${1}"`+Redacted+`"`)
+	s = paramPattern.ReplaceAllString(s, "${1}${2}"+Redacted)
+	s = jwtPattern.ReplaceAllString(s, Redacted)
+	return s
+}
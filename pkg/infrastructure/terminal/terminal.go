@@ -0,0 +1,34 @@
+// Package terminal provides the standard error for interactive output.
+package terminal
+
+import (
+	"os"
+
+	"github.com/google/wire"
+	"golang.org/x/term"
+)
+
+// Set provides an implementation and interface for Terminal.
+var Set = wire.NewSet(
+	wire.Struct(new(Stderr), "*"),
+	wire.Bind(new(Interface), new(*Stderr)),
+)
+
+// Interface provides the output for a human, such as a QR code or progress.
+type Interface interface {
+	Write(p []byte) (n int, err error)
+	// IsTerminal returns true if the output is a terminal.
+	// If false, the caller should not write any control sequence.
+	IsTerminal() bool
+}
+
+// Stderr writes to the standard error.
+type Stderr struct{}
+
+func (*Stderr) Write(p []byte) (int, error) {
+	return os.Stderr.Write(p)
+}
+
+func (*Stderr) IsTerminal() bool {
+	return term.IsTerminal(int(os.Stderr.Fd()))
+}
@@ -0,0 +1,150 @@
+// Package tracing provides OpenTelemetry tracing of the login flow.
+// It is disabled by default, and then the spans are not recorded.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/google/wire"
+	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/logger"
+	"github.com/spf13/pflag"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Set provides an implementation and interface for Tracing.
+var Set = wire.NewSet(
+	New,
+)
+
+// New returns a Tracing, that is disabled until Setup is called with the flags.
+func New() Interface {
+	return &Tracing{}
+}
+
+const instrumentationName = "github.com/togethercomputer/together-kubelogin"
+
+// TraceparentEnvName is the environment variable of the parent span,
+// so that a caller can include kubelogin in the trace.
+const TraceparentEnvName = "TRACEPARENT"
+
+const (
+	ExporterNone = "none"
+	ExporterOTLP = "otlp"
+	ExporterFile = "file"
+)
+
+var allExporters = strings.Join([]string{ExporterNone, ExporterOTLP, ExporterFile}, "|")
+
+type Interface interface {
+	AddFlags(f *pflag.FlagSet)
+	Setup(ctx context.Context, version string) (context.Context, func(context.Context) error, error)
+}
+
+// Tracing sets up the exporter of the spans.
+// The zero value does not export any span.
+type Tracing struct {
+	exporter     string
+	otlpEndpoint string
+	file         string
+}
+
+// AddFlags adds the flags of tracing.
+func (t *Tracing) AddFlags(f *pflag.FlagSet) {
+	f.StringVar(&t.exporter, "trace-exporter", ExporterNone, fmt.Sprintf("Exporter of the trace spans. One of (%s)", allExporters))
+	f.StringVar(&t.otlpEndpoint, "trace-otlp-endpoint", "", "[otlp] URL of the OTLP/HTTP endpoint. Default to $OTEL_EXPORTER_OTLP_ENDPOINT or http://localhost:4318")
+	f.StringVar(&t.file, "trace-file", "", "[file] Path to the file to append the spans in JSON")
+}
+
+// Setup starts exporting the spans.
+// It returns the context with the parent span given by $TRACEPARENT if set,
+// and a function to flush the spans.
+func (t *Tracing) Setup(ctx context.Context, version string) (context.Context, func(context.Context) error, error) {
+	noop := func(context.Context) error { return nil }
+	var exporter sdktrace.SpanExporter
+	var closeFile func() error
+	switch t.exporter {
+	case "", ExporterNone:
+		return ctx, noop, nil
+	case ExporterOTLP:
+		var opts []otlptracehttp.Option
+		if t.otlpEndpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpointURL(t.otlpEndpoint))
+		}
+		e, err := otlptracehttp.New(ctx, opts...)
+		if err != nil {
+			return ctx, noop, fmt.Errorf("could not create the OTLP exporter: %w", err)
+		}
+		exporter = e
+	case ExporterFile:
+		if t.file == "" {
+			return ctx, noop, fmt.Errorf("--trace-file is required for the file exporter")
+		}
+		f, err := os.OpenFile(t.file, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+		if err != nil {
+			return ctx, noop, fmt.Errorf("could not open the trace file: %w", err)
+		}
+		e, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			_ = f.Close()
+			return ctx, noop, fmt.Errorf("could not create the file exporter: %w", err)
+		}
+		exporter = e
+		closeFile = f.Close
+	default:
+		return ctx, noop, fmt.Errorf("trace-exporter must be one of (%s)", allExporters)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(
+			semconv.ServiceName("kubelogin"),
+			semconv.ServiceVersion(version),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	if traceparent := os.Getenv(TraceparentEnvName); traceparent != "" {
+		carrier := propagation.MapCarrier{"traceparent": traceparent}
+		ctx = otel.GetTextMapPropagator().Extract(ctx, carrier)
+	}
+	shutdown := func(ctx context.Context) error {
+		if err := provider.Shutdown(ctx); err != nil {
+			return fmt.Errorf("could not flush the spans: %w", err)
+		}
+		if closeFile != nil {
+			return closeFile()
+		}
+		return nil
+	}
+	return ctx, shutdown, nil
+}
+
+// Start starts a span of the name.
+// If tracing is disabled, it returns the context as-is and a no-op span.
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	spanCtx, span := otel.Tracer(instrumentationName).Start(ctx, name, opts...)
+	if !span.IsRecording() {
+		return ctx, span
+	}
+	return spanCtx, span
+}
+
+// End records the error if any and ends the span.
+// The error message is redacted, because it may contain a secret.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.SetStatus(codes.Error, logger.Redact(err.Error()))
+	}
+	span.End()
+}
@@ -0,0 +1,87 @@
+package tracing
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestTracing_Setup(t *testing.T) {
+	t.Cleanup(func() {
+		otel.SetTracerProvider(noop.NewTracerProvider())
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+	})
+
+	t.Run("None", func(t *testing.T) {
+		ctx := context.TODO()
+		var tr Tracing
+		gotCtx, shutdown, err := tr.Setup(ctx, "HEAD")
+		if err != nil {
+			t.Fatalf("Setup error: %s", err)
+		}
+		if gotCtx != ctx {
+			t.Errorf("context wants as-is")
+		}
+		if err := shutdown(ctx); err != nil {
+			t.Errorf("shutdown error: %s", err)
+		}
+	})
+
+	t.Run("File", func(t *testing.T) {
+		t.Setenv(TraceparentEnvName, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		filename := filepath.Join(t.TempDir(), "trace.json")
+		var tr Tracing
+		f := pflag.NewFlagSet("test", pflag.ContinueOnError)
+		tr.AddFlags(f)
+		if err := f.Parse([]string{"--trace-exporter=file", "--trace-file", filename}); err != nil {
+			t.Fatalf("Parse error: %s", err)
+		}
+		ctx, shutdown, err := tr.Setup(context.TODO(), "HEAD")
+		if err != nil {
+			t.Fatalf("Setup error: %s", err)
+		}
+		_, span := Start(ctx, "get-token")
+		if got := span.SpanContext().TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+			t.Errorf("trace ID wants the parent but %s", got)
+		}
+		End(span, nil)
+		if err := shutdown(context.TODO()); err != nil {
+			t.Fatalf("shutdown error: %s", err)
+		}
+		b, err := os.ReadFile(filename)
+		if err != nil {
+			t.Fatalf("could not read the trace file: %s", err)
+		}
+		if !strings.Contains(string(b), `"Name":"get-token"`) {
+			t.Errorf("trace file wants the span but %s", b)
+		}
+	})
+
+	t.Run("FileWithoutFilename", func(t *testing.T) {
+		tr := Tracing{exporter: ExporterFile}
+		if _, _, err := tr.Setup(context.TODO(), "HEAD"); err == nil {
+			t.Errorf("Setup wants error")
+		}
+	})
+}
+
+func TestStart(t *testing.T) {
+	otel.SetTracerProvider(noop.NewTracerProvider())
+	ctx := context.TODO()
+	gotCtx, span := Start(ctx, "disabled")
+	defer span.End()
+	if gotCtx != ctx {
+		t.Errorf("context wants as-is when the span is not recorded")
+	}
+	if trace.SpanFromContext(gotCtx).IsRecording() {
+		t.Errorf("span wants non-recording")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -19,23 +20,73 @@ func DecodeWithoutVerify(s string) (*Claims, error) {
 		return nil, fmt.Errorf("could not decode the payload: %w", err)
 	}
 	var claims struct {
-		Subject   string `json:"sub,omitempty"`
-		ExpiresAt int64  `json:"exp,omitempty"`
+		Subject   string      `json:"sub,omitempty"`
+		ExpiresAt int64       `json:"exp,omitempty"`
+		ACR       string      `json:"acr,omitempty"`
+		AMR       stringList  `json:"amr,omitempty"`
+		AuthTime  numericDate `json:"auth_time,omitempty"`
 	}
 	if err := json.NewDecoder(bytes.NewReader(payload)).Decode(&claims); err != nil {
 		return nil, fmt.Errorf("could not decode the json of token: %w", err)
 	}
+	var raw map[string]any
+	if err := json.NewDecoder(bytes.NewReader(payload)).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("could not decode the json of token: %w", err)
+	}
 	var prettyJson bytes.Buffer
 	if err := json.Indent(&prettyJson, payload, "", "  "); err != nil {
 		return nil, fmt.Errorf("could not indent the json of token: %w", err)
 	}
+	var authTime time.Time
+	if claims.AuthTime > 0 {
+		authTime = time.Unix(int64(claims.AuthTime), 0)
+	}
 	return &Claims{
-		Subject: claims.Subject,
-		Expiry:  time.Unix(claims.ExpiresAt, 0),
-		Pretty:  prettyJson.String(),
+		Subject:  claims.Subject,
+		Expiry:   time.Unix(claims.ExpiresAt, 0),
+		ACR:      claims.ACR,
+		AMR:      claims.AMR,
+		AuthTime: authTime,
+		Raw:      raw,
+		Pretty:   prettyJson.String(),
 	}, nil
 }
 
+// stringList is a list of strings, which accepts a single string as well.
+// Some providers return the amr claim as a string.
+type stringList []string
+
+func (l *stringList) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*l = stringList{s}
+		return nil
+	}
+	var a []string
+	if err := json.Unmarshal(b, &a); err != nil {
+		return fmt.Errorf("wants a string or an array of strings: %w", err)
+	}
+	*l = a
+	return nil
+}
+
+// numericDate is the seconds since the epoch, which accepts a number or a string.
+// Some providers return the auth_time claim as a string.
+type numericDate int64
+
+func (d *numericDate) UnmarshalJSON(b []byte) error {
+	var n json.Number
+	if err := json.Unmarshal(b, &n); err != nil {
+		return fmt.Errorf("wants a number or a string: %w", err)
+	}
+	f, err := strconv.ParseFloat(n.String(), 64)
+	if err != nil {
+		return fmt.Errorf("wants a number or a string: %w", err)
+	}
+	*d = numericDate(f)
+	return nil
+}
+
 // DecodePayloadAsPrettyJSON decodes the JWT string and returns the pretty JSON string.
 func DecodePayloadAsPrettyJSON(s string) (string, error) {
 	payload, err := DecodePayloadAsRawJSON(s)
@@ -49,6 +100,20 @@ func DecodePayloadAsPrettyJSON(s string) (string, error) {
 	return prettyJson.String(), nil
 }
 
+// DecodePayloadAsMap decodes the JWT string and returns the claims as a map.
+// Note that this method does not verify the signature and always trust it.
+func DecodePayloadAsMap(s string) (map[string]any, error) {
+	payload, err := DecodePayloadAsRawJSON(s)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode the payload: %w", err)
+	}
+	var claims map[string]any
+	if err := json.NewDecoder(bytes.NewReader(payload)).Decode(&claims); err != nil {
+		return nil, fmt.Errorf("could not decode the json of token: %w", err)
+	}
+	return claims, nil
+}
+
 // DecodePayloadAsRawJSON extracts the payload and returns the raw JSON.
 func DecodePayloadAsRawJSON(s string) ([]byte, error) {
 	parts := strings.SplitN(s, ".", 3)
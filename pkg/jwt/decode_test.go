@@ -1,6 +1,7 @@
 package jwt
 
 import (
+	"encoding/base64"
 	"testing"
 	"time"
 
@@ -23,6 +24,11 @@ func TestDecode(t *testing.T) {
 		want := &Claims{
 			Subject: "",
 			Expiry:  time.Unix(1300819380, 0),
+			Raw: map[string]any{
+				"iss":                        "joe",
+				"exp":                        float64(1300819380),
+				"http://example.com/is_root": true,
+			},
 			Pretty: `{
   "iss": "joe",
   "exp": 1300819380,
@@ -34,6 +40,47 @@ func TestDecode(t *testing.T) {
 		}
 	})
 
+	t.Run("AuthenticationClaims", func(t *testing.T) {
+		for name, tc := range map[string]struct {
+			payload string
+			wantAMR []string
+		}{
+			"Numbers": {
+				payload: `{"amr":["pwd","mfa"],"auth_time":1300819380}`,
+				wantAMR: []string{"pwd", "mfa"},
+			},
+			"Strings": {
+				payload: `{"amr":"pwd","auth_time":"1300819380"}`,
+				wantAMR: []string{"pwd"},
+			},
+			"Float": {
+				payload: `{"amr":["pwd"],"auth_time":1300819380.5}`,
+				wantAMR: []string{"pwd"},
+			},
+		} {
+			t.Run(name, func(t *testing.T) {
+				token := "HEADER." + base64.RawURLEncoding.EncodeToString([]byte(tc.payload)) + ".SIGNATURE"
+				got, err := DecodeWithoutVerify(token)
+				if err != nil {
+					t.Fatalf("Decode error: %s", err)
+				}
+				if diff := cmp.Diff(tc.wantAMR, got.AMR); diff != "" {
+					t.Errorf("AMR mismatch (-want +got):\n%s", diff)
+				}
+				if want := time.Unix(1300819380, 0); !got.AuthTime.Equal(want) {
+					t.Errorf("AuthTime wants %s but was %s", want, got.AuthTime)
+				}
+			})
+		}
+	})
+
+	t.Run("InvalidAuthTime", func(t *testing.T) {
+		token := "HEADER." + base64.RawURLEncoding.EncodeToString([]byte(`{"auth_time":"yesterday"}`)) + ".SIGNATURE"
+		if _, err := DecodeWithoutVerify(token); err == nil {
+			t.Errorf("error wants non-nil but nil")
+		}
+	})
+
 	t.Run("InvalidToken", func(t *testing.T) {
 		decodedToken, err := DecodeWithoutVerify("HEADER.INVALID_TOKEN.SIGNATURE")
 		if err == nil {
@@ -46,3 +93,25 @@ func TestDecode(t *testing.T) {
 		}
 	})
 }
+
+func TestDecodePayloadAsMap(t *testing.T) {
+	const (
+		// https://tools.ietf.org/html/rfc7519#section-3.1
+		header    = "eyJ0eXAiOiJKV1QiLA0KICJhbGciOiJIUzI1NiJ9"
+		payload   = "eyJpc3MiOiJqb2UiLA0KICJleHAiOjEzMDA4MTkzODAsDQogImh0dHA6Ly9leGFtcGxlLmNvbS9pc19yb290Ijp0cnVlfQ"
+		signature = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+		token     = header + "." + payload + "." + signature
+	)
+	got, err := DecodePayloadAsMap(token)
+	if err != nil {
+		t.Fatalf("Decode error: %s", err)
+	}
+	want := map[string]any{
+		"iss":                        "joe",
+		"exp":                        float64(1300819380),
+		"http://example.com/is_root": true,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -4,9 +4,13 @@ import "time"
 
 // Claims represents claims of an ID token.
 type Claims struct {
-	Subject string
-	Expiry  time.Time
-	Pretty  string // string representation for debug and logging
+	Subject  string
+	Expiry   time.Time
+	ACR      string         // optional, authentication context class reference
+	AMR      []string       // optional, authentication methods references
+	AuthTime time.Time      // optional, zero if auth_time is not set
+	Raw      map[string]any // all claims decoded from the JSON
+	Pretty   string         // string representation for debug and logging
 }
 
 // Clock provides the current time.
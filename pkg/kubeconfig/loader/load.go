@@ -3,9 +3,13 @@ package loader
 import (
 	"errors"
 	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
 
 	"github.com/google/wire"
+	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/logger"
 	"github.com/togethercomputer/together-kubelogin/pkg/kubeconfig"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/tools/clientcmd/api"
@@ -18,9 +22,12 @@ var Set = wire.NewSet(
 
 type Interface interface {
 	GetCurrentAuthProvider(explicitFilename string, contextName kubeconfig.ContextName, userName kubeconfig.UserName) (*kubeconfig.AuthProvider, error)
+	ListExecContexts(explicitFilename string, contextNames []kubeconfig.ContextName) ([]kubeconfig.ExecContext, error)
 }
 
-type Loader struct{}
+type Loader struct {
+	Logger logger.Interface
+}
 
 func (Loader) GetCurrentAuthProvider(explicitFilename string, contextName kubeconfig.ContextName, userName kubeconfig.UserName) (*kubeconfig.AuthProvider, error) {
 	config, err := loadByDefaultRules(explicitFilename)
@@ -34,6 +41,99 @@ func (Loader) GetCurrentAuthProvider(explicitFilename string, contextName kubeco
 	return auth, nil
 }
 
+// ListExecContexts returns the contexts of which user runs get-token as the credential plugin.
+// If contextNames is empty, this returns all such contexts in the kubeconfig,
+// and skips a context of which user does not exist.
+// Otherwise, this returns an error if any context does not exist or does not run get-token.
+func (l Loader) ListExecContexts(explicitFilename string, contextNames []kubeconfig.ContextName) ([]kubeconfig.ExecContext, error) {
+	config, err := loadByDefaultRules(explicitFilename)
+	if err != nil {
+		return nil, fmt.Errorf("could not load the kubeconfig: %w", err)
+	}
+	if len(contextNames) > 0 {
+		var execContexts []kubeconfig.ExecContext
+		for _, contextName := range contextNames {
+			execContext, err := findExecContext(config, contextName)
+			if err != nil {
+				return nil, err
+			}
+			if execContext == nil {
+				return nil, fmt.Errorf("context %s does not use get-token", contextName)
+			}
+			execContexts = append(execContexts, *execContext)
+		}
+		return execContexts, nil
+	}
+	var names []string
+	for name := range config.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var execContexts []kubeconfig.ExecContext
+	for _, name := range names {
+		execContext, err := findExecContext(config, kubeconfig.ContextName(name))
+		if err != nil {
+			l.Logger.Printf("Skipped the context %s: %s", name, err)
+			continue
+		}
+		if execContext != nil {
+			execContexts = append(execContexts, *execContext)
+		}
+	}
+	return execContexts, nil
+}
+
+// findExecContext returns nil if the user of the context does not run get-token.
+func findExecContext(config *api.Config, contextName kubeconfig.ContextName) (*kubeconfig.ExecContext, error) {
+	contextNode, ok := config.Contexts[string(contextName)]
+	if !ok {
+		return nil, fmt.Errorf("context %s does not exist", contextName)
+	}
+	userNode, ok := config.AuthInfos[contextNode.AuthInfo]
+	if !ok {
+		return nil, fmt.Errorf("user %s of context %s does not exist", contextNode.AuthInfo, contextName)
+	}
+	if userNode.Exec == nil {
+		return nil, nil
+	}
+	getTokenArgs, ok := findGetTokenArgs(userNode.Exec)
+	if !ok {
+		return nil, nil
+	}
+	var env map[string]string
+	for _, e := range userNode.Exec.Env {
+		if env == nil {
+			env = make(map[string]string)
+		}
+		env[e.Name] = e.Value
+	}
+	return &kubeconfig.ExecContext{
+		ContextName:  contextName,
+		UserName:     kubeconfig.UserName(contextNode.AuthInfo),
+		GetTokenArgs: getTokenArgs,
+		Env:          env,
+	}, nil
+}
+
+// findGetTokenArgs returns the arguments after get-token if the exec plugin is kubelogin,
+// that is kubelogin, kubectl-oidc_login or kubectl oidc-login.
+// It returns false for another plugin, such as aws eks get-token.
+func findGetTokenArgs(exec *api.ExecConfig) ([]string, bool) {
+	i := slices.Index(exec.Args, "get-token")
+	if i < 0 {
+		return nil, false
+	}
+	switch strings.TrimSuffix(filepath.Base(exec.Command), ".exe") {
+	case "kubelogin", "kubectl-oidc_login":
+		return exec.Args[i+1:], true
+	case "kubectl":
+		if slices.Contains(exec.Args[:i], "oidc-login") {
+			return exec.Args[i+1:], true
+		}
+	}
+	return nil, false
+}
+
 func loadByDefaultRules(explicitFilename string) (*api.Config, error) {
 	rules := clientcmd.NewDefaultClientConfigLoadingRules()
 	rules.ExplicitPath = explicitFilename
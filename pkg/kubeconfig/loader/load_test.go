@@ -6,6 +6,7 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/togethercomputer/together-kubelogin/pkg/kubeconfig"
+	"github.com/togethercomputer/together-kubelogin/pkg/testing/logger"
 	"k8s.io/client-go/tools/clientcmd/api"
 )
 
@@ -201,3 +202,49 @@ func Test_findCurrentAuthProvider(t *testing.T) {
 		}
 	})
 }
+
+func TestLoader_ListExecContexts(t *testing.T) {
+	getTokenArgs := []string{"--oidc-issuer-url=https://issuer.example.com", "--oidc-client-id=YOUR_CLIENT_ID"}
+
+	t.Run("AllContexts", func(t *testing.T) {
+		got, err := Loader{Logger: logger.New(t)}.ListExecContexts("testdata/kubeconfig.exec.yaml", nil)
+		if err != nil {
+			t.Fatalf("ListExecContexts error: %s", err)
+		}
+		want := []kubeconfig.ExecContext{
+			{ContextName: "dev", UserName: "oidc", GetTokenArgs: getTokenArgs},
+			{ContextName: "prod", UserName: "oidc", GetTokenArgs: getTokenArgs},
+			{
+				ContextName:  "prod-env",
+				UserName:     "oidc-env",
+				GetTokenArgs: getTokenArgs,
+				Env:          map[string]string{"OIDC_CLIENT_SECRET": "YOUR_CLIENT_SECRET"},
+			},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("GivenContexts", func(t *testing.T) {
+		got, err := Loader{Logger: logger.New(t)}.ListExecContexts("testdata/kubeconfig.exec.yaml", []kubeconfig.ContextName{"prod"})
+		if err != nil {
+			t.Fatalf("ListExecContexts error: %s", err)
+		}
+		want := []kubeconfig.ExecContext{
+			{ContextName: "prod", UserName: "oidc", GetTokenArgs: getTokenArgs},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	for _, contextName := range []kubeconfig.ContextName{"prod-static", "prod-eks", "prod-missing"} {
+		t.Run("GivenContextWithoutGetToken/"+string(contextName), func(t *testing.T) {
+			_, err := Loader{Logger: logger.New(t)}.ListExecContexts("testdata/kubeconfig.exec.yaml", []kubeconfig.ContextName{contextName})
+			if err == nil {
+				t.Errorf("ListExecContexts wants an error but was nil")
+			}
+		})
+	}
+}
@@ -21,3 +21,25 @@ type AuthProvider struct {
 	IDToken                     string      // (optional) id-token
 	RefreshToken                string      // (optional) refresh-token
 }
+
+// ExecConfig represents a user, cluster and context which use the credential plugin,
+// i.e. users.user.exec, clusters and contexts in a kubeconfig.
+type ExecConfig struct {
+	UserName                    UserName    // User name
+	ContextName                 ContextName // (optional) Context name
+	ClusterName                 string      // (optional) Cluster name
+	ClusterServer               string      // (optional) clusters.cluster.server
+	ClusterCertificateAuthority []byte      // (optional) clusters.cluster.certificate-authority-data
+	Command                     string      // users.user.exec.command
+	Args                        []string    // users.user.exec.args
+	InstallHint                 string      // users.user.exec.installHint
+	InteractiveMode             string      // users.user.exec.interactiveMode
+}
+
+// ExecContext represents a context of which user runs get-token as the credential plugin.
+type ExecContext struct {
+	ContextName  ContextName
+	UserName     UserName
+	GetTokenArgs []string          // users.user.exec.args after get-token
+	Env          map[string]string // users.user.exec.env
+}
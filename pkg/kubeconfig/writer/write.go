@@ -1,12 +1,17 @@
 package writer
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/google/wire"
 	"github.com/togethercomputer/together-kubelogin/pkg/kubeconfig"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
 )
 
 var Set = wire.NewSet(
@@ -16,6 +21,8 @@ var Set = wire.NewSet(
 
 type Interface interface {
 	UpdateAuthProvider(p kubeconfig.AuthProvider) error
+	FindExecConfigConflicts(filename string, c kubeconfig.ExecConfig) ([]string, error)
+	MergeExecConfig(filename string, c kubeconfig.ExecConfig) (string, error)
 }
 
 type Writer struct{}
@@ -61,3 +68,103 @@ func setOrDeleteKey(m map[string]string, key, value string) {
 	}
 	m[key] = value
 }
+
+// FindExecConfigConflicts returns the entries in the kubeconfig which have the same name as the ExecConfig.
+// If filename is empty, it uses the default kubeconfig as kubectl.
+func (Writer) FindExecConfigConflicts(filename string, c kubeconfig.ExecConfig) ([]string, error) {
+	filename = resolveFilename(filename)
+	config, err := loadOrNewConfig(filename)
+	if err != nil {
+		return nil, err
+	}
+	var conflicts []string
+	if _, ok := config.AuthInfos[string(c.UserName)]; ok {
+		conflicts = append(conflicts, fmt.Sprintf("user %s", c.UserName))
+	}
+	if _, ok := config.Clusters[c.ClusterName]; ok && c.ClusterName != "" {
+		conflicts = append(conflicts, fmt.Sprintf("cluster %s", c.ClusterName))
+	}
+	if _, ok := config.Contexts[string(c.ContextName)]; ok && c.ContextName != "" {
+		conflicts = append(conflicts, fmt.Sprintf("context %s", c.ContextName))
+	}
+	return conflicts, nil
+}
+
+// MergeExecConfig adds or replaces the user, cluster and context in the kubeconfig.
+// If filename is empty, it uses the default kubeconfig as kubectl.
+// If the kubeconfig exists, it copies the file to a backup before writing.
+// It returns the filename of the backup, or an empty string if no backup is created.
+func (Writer) MergeExecConfig(filename string, c kubeconfig.ExecConfig) (string, error) {
+	filename = resolveFilename(filename)
+	config, err := loadOrNewConfig(filename)
+	if err != nil {
+		return "", err
+	}
+	backupFilename, err := backupFile(filename)
+	if err != nil {
+		return "", fmt.Errorf("could not back up %s: %w", filename, err)
+	}
+
+	userNode := api.NewAuthInfo()
+	userNode.Exec = &api.ExecConfig{
+		APIVersion:         "client.authentication.k8s.io/v1",
+		Command:            c.Command,
+		Args:               c.Args,
+		InstallHint:        c.InstallHint,
+		InteractiveMode:    api.ExecInteractiveMode(c.InteractiveMode),
+		ProvideClusterInfo: true,
+	}
+	config.AuthInfos[string(c.UserName)] = userNode
+	if c.ClusterName != "" {
+		clusterNode := api.NewCluster()
+		clusterNode.Server = c.ClusterServer
+		clusterNode.CertificateAuthorityData = c.ClusterCertificateAuthority
+		config.Clusters[c.ClusterName] = clusterNode
+	}
+	if c.ContextName != "" {
+		contextNode := api.NewContext()
+		contextNode.Cluster = c.ClusterName
+		contextNode.AuthInfo = string(c.UserName)
+		config.Contexts[string(c.ContextName)] = contextNode
+		if config.CurrentContext == "" {
+			config.CurrentContext = string(c.ContextName)
+		}
+	}
+	if err := clientcmd.WriteToFile(*config, filename); err != nil {
+		return backupFilename, fmt.Errorf("could not write %s: %w", filename, err)
+	}
+	return backupFilename, nil
+}
+
+func resolveFilename(filename string) string {
+	if filename != "" {
+		return filename
+	}
+	return clientcmd.NewDefaultClientConfigLoadingRules().GetDefaultFilename()
+}
+
+func loadOrNewConfig(filename string) (*api.Config, error) {
+	config, err := clientcmd.LoadFromFile(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return api.NewConfig(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not load %s: %w", filename, err)
+	}
+	return config, nil
+}
+
+func backupFile(filename string) (string, error) {
+	b, err := os.ReadFile(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("read error: %w", err)
+	}
+	backupFilename := fmt.Sprintf("%s.backup-%s", filename, time.Now().Format("20060102-150405"))
+	if err := os.WriteFile(backupFilename, b, 0600); err != nil {
+		return "", fmt.Errorf("write error: %w", err)
+	}
+	return backupFilename, nil
+}
@@ -124,3 +124,116 @@ func newKubeconfigFile(t *testing.T) string {
 	}
 	return f
 }
+
+func TestWriter_MergeExecConfig(t *testing.T) {
+	var w Writer
+	execConfig := kubeconfig.ExecConfig{
+		UserName:                    "oidc",
+		ContextName:                 "oidc@hello.k8s.local",
+		ClusterName:                 "hello.k8s.local",
+		ClusterServer:               "https://api.hello.k8s.local",
+		ClusterCertificateAuthority: []byte("CA"),
+		Command:                     "kubectl",
+		Args:                        []string{"oidc-login", "get-token", "--oidc-issuer-url=https://issuer.example.com"},
+		InstallHint:                 "YOUR_INSTALL_HINT",
+		InteractiveMode:             "IfAvailable",
+	}
+	const wantExecConfig = `apiVersion: v1
+clusters:
+- cluster:
+    certificate-authority-data: Q0E=
+    server: https://api.hello.k8s.local
+  name: hello.k8s.local
+contexts:
+- context:
+    cluster: hello.k8s.local
+    user: oidc
+  name: oidc@hello.k8s.local
+current-context: oidc@hello.k8s.local
+kind: Config
+preferences: {}
+users:
+`
+	const wantExecUser = `- name: oidc
+  user:
+    exec:
+      apiVersion: client.authentication.k8s.io/v1
+      args:
+      - oidc-login
+      - get-token
+      - --oidc-issuer-url=https://issuer.example.com
+      command: kubectl
+      env: null
+      installHint: YOUR_INSTALL_HINT
+      interactiveMode: IfAvailable
+      provideClusterInfo: true
+`
+
+	t.Run("NewFile", func(t *testing.T) {
+		f := filepath.Join(t.TempDir(), "kubeconfig")
+		conflicts, err := w.FindExecConfigConflicts(f, execConfig)
+		if err != nil {
+			t.Fatalf("FindExecConfigConflicts error: %s", err)
+		}
+		if len(conflicts) > 0 {
+			t.Errorf("conflicts wants empty but got %v", conflicts)
+		}
+		backupFilename, err := w.MergeExecConfig(f, execConfig)
+		if err != nil {
+			t.Fatalf("MergeExecConfig error: %s", err)
+		}
+		if backupFilename != "" {
+			t.Errorf("backupFilename wants empty but got %s", backupFilename)
+		}
+		b, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatalf("Could not read kubeconfig: %s", err)
+		}
+		if diff := cmp.Diff(wantExecConfig+wantExecUser, string(b)); diff != "" {
+			t.Errorf("kubeconfig mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("ExistingFile", func(t *testing.T) {
+		f := newKubeconfigFile(t)
+		if _, err := w.MergeExecConfig(f, execConfig); err != nil {
+			t.Fatalf("MergeExecConfig error: %s", err)
+		}
+		conflicts, err := w.FindExecConfigConflicts(f, execConfig)
+		if err != nil {
+			t.Fatalf("FindExecConfigConflicts error: %s", err)
+		}
+		wantConflicts := []string{"user oidc", "cluster hello.k8s.local", "context oidc@hello.k8s.local"}
+		if diff := cmp.Diff(wantConflicts, conflicts); diff != "" {
+			t.Errorf("conflicts mismatch (-want +got):\n%s", diff)
+		}
+		backupFilename, err := w.MergeExecConfig(f, execConfig)
+		if err != nil {
+			t.Fatalf("MergeExecConfig error: %s", err)
+		}
+		if backupFilename == "" {
+			t.Fatalf("backupFilename wants non-empty")
+		}
+		backup, err := os.ReadFile(backupFilename)
+		if err != nil {
+			t.Fatalf("Could not read the backup: %s", err)
+		}
+		b, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatalf("Could not read kubeconfig: %s", err)
+		}
+		if diff := cmp.Diff(string(backup), string(b)); diff != "" {
+			t.Errorf("kubeconfig mismatch (-want +got):\n%s", diff)
+		}
+		const wantGoogleUser = `- name: google
+  user:
+    auth-provider:
+      config:
+        idp-issuer-url: https://accounts.google.com
+      name: oidc
+`
+		if diff := cmp.Diff(wantExecConfig+wantGoogleUser+wantExecUser, string(b)); diff != "" {
+			t.Errorf("kubeconfig mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
@@ -2,7 +2,9 @@ package client
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
 
 	gooidc "github.com/coreos/go-oidc/v3/oidc"
 	"github.com/togethercomputer/together-kubelogin/pkg/oidc"
@@ -16,12 +18,14 @@ type AuthCodeURLInput struct {
 	Nonce                  string
 	PKCEParams             pkce.Params
 	AuthRequestExtraParams map[string]string
+	RedirectURL            string // optional, used if the provider has no redirect URL
 }
 
 type ExchangeAuthCodeInput struct {
-	Code       string
-	PKCEParams pkce.Params
-	Nonce      string
+	Code        string
+	PKCEParams  pkce.Params
+	Nonce       string
+	RedirectURL string // optional, used if the provider has no redirect URL
 }
 
 type GetTokenByAuthCodeInput struct {
@@ -30,7 +34,8 @@ type GetTokenByAuthCodeInput struct {
 	Nonce                  string
 	PKCEParams             pkce.Params
 	AuthRequestExtraParams map[string]string
-	LocalServerSuccessHTML string
+	LocalServerSuccessHTML func(tokenSet *oidc.TokenSet) string // tokenSet is nil if unavailable
+	LocalServerErrorHTML   func(e LocalServerError) string
 	LocalServerCertFile    string
 	LocalServerKeyFile     string
 }
@@ -42,43 +47,112 @@ func (c *client) NegotiatedPKCEMethod() pkce.Method {
 // GetTokenByAuthCode performs the authorization code flow.
 func (c *client) GetTokenByAuthCode(ctx context.Context, in GetTokenByAuthCodeInput, localServerReadyChan chan<- string) (*oidc.TokenSet, error) {
 	ctx = c.wrapContext(ctx)
+	page := newLocalServerPage(in, c.logger)
 	config := oauth2cli.Config{
 		OAuth2Config:           c.oauth2Config,
 		State:                  in.State,
-		AuthCodeOptions:        authorizationRequestOptions(in.Nonce, in.PKCEParams, in.AuthRequestExtraParams),
+		AuthCodeOptions:        c.authorizationRequestOptions(in.Nonce, in.PKCEParams, in.AuthRequestExtraParams),
 		TokenRequestOptions:    tokenRequestOptions(in.PKCEParams),
 		LocalServerBindAddress: in.BindAddress,
 		LocalServerReadyChan:   localServerReadyChan,
-		LocalServerSuccessHTML: in.LocalServerSuccessHTML,
+		LocalServerMiddleware:  page.middleware,
 		LocalServerCertFile:    in.LocalServerCertFile,
 		LocalServerKeyFile:     in.LocalServerKeyFile,
 		Logf:                   c.logger.V(1).Infof,
 	}
-	token, err := oauth2cli.GetToken(ctx, config)
+	var token *oauth2.Token
+	var err error
+	if config.OAuth2Config.RedirectURL == "" {
+		// oauth2cli derives the redirect URL of localhost with the bound port.
+		// Bind here to derive the redirect URL from the bound address.
+		bindAddress := in.BindAddress
+		if len(bindAddress) == 0 {
+			bindAddress = []string{"127.0.0.1:0"}
+		}
+		https := in.LocalServerCertFile != "" && in.LocalServerKeyFile != ""
+		token, err = c.getTokenOnReservedAddress(ctx, config, bindAddress, https)
+	} else if token, err = getTokenByLocalServer(ctx, config); err != nil {
+		err = fmt.Errorf("oauth2 error: %w", err)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("oauth2 error: %w", err)
+		page.respond(nil, err)
+		return nil, err
 	}
-	return c.verifyToken(ctx, token, in.Nonce)
+	tokenSet, err := c.verifyToken(ctx, token, in.Nonce)
+	page.respond(tokenSet, err)
+	return tokenSet, err
+}
+
+// maxBindAttempts is the number of attempts to start the local server on a reserved address.
+const maxBindAttempts = 3
+
+// getTokenOnReservedAddress reserves a bind address and starts the local server on it.
+// Another process may bind to the reserved port before the local server starts.
+// In that case, it reserves an address again from the same or next candidates.
+func (c *client) getTokenOnReservedAddress(ctx context.Context, config oauth2cli.Config, bindAddress []string, https bool) (*oauth2.Token, error) {
+	for attempt := 1; ; attempt++ {
+		address, redirectURL, next, err := reserveBindAddress(bindAddress, https)
+		if err != nil {
+			return nil, fmt.Errorf("could not start a local server: %w", err)
+		}
+		c.logger.V(1).Infof("using the redirect URL %s", redirectURL)
+		config.LocalServerBindAddress = []string{address}
+		config.OAuth2Config.RedirectURL = redirectURL
+		token, err := getTokenByLocalServer(ctx, config)
+		if err != nil && isBindError(err) && len(next) > 0 && attempt < maxBindAttempts {
+			c.logger.V(1).Infof("could not bind to the reserved address %s: %s", address, err)
+			bindAddress = next
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("oauth2 error: %w", err)
+		}
+		return token, nil
+	}
+}
+
+// getTokenByLocalServer is replaced in the tests.
+var getTokenByLocalServer = oauth2cli.GetToken
+
+// isBindError returns true if the local server could not bind to the address.
+func isBindError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "listen"
 }
 
 // GetAuthCodeURL returns the URL of authentication request for the authorization code flow.
 func (c *client) GetAuthCodeURL(in AuthCodeURLInput) string {
-	opts := authorizationRequestOptions(in.Nonce, in.PKCEParams, in.AuthRequestExtraParams)
-	return c.oauth2Config.AuthCodeURL(in.State, opts...)
+	opts := c.authorizationRequestOptions(in.Nonce, in.PKCEParams, in.AuthRequestExtraParams)
+	config := c.oauth2ConfigWithRedirectURL(in.RedirectURL)
+	return config.AuthCodeURL(in.State, opts...)
 }
 
 // ExchangeAuthCode exchanges the authorization code and token.
 func (c *client) ExchangeAuthCode(ctx context.Context, in ExchangeAuthCodeInput) (*oidc.TokenSet, error) {
 	ctx = c.wrapContext(ctx)
 	opts := tokenRequestOptions(in.PKCEParams)
-	token, err := c.oauth2Config.Exchange(ctx, in.Code, opts...)
+	config := c.oauth2ConfigWithRedirectURL(in.RedirectURL)
+	token, err := config.Exchange(ctx, in.Code, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("exchange error: %w", err)
 	}
 	return c.verifyToken(ctx, token, in.Nonce)
 }
 
-func authorizationRequestOptions(nonce string, pkceParams pkce.Params, extraParams map[string]string) []oauth2.AuthCodeOption {
+// oauth2ConfigWithRedirectURL returns the config with the redirect URL,
+// if the provider has no redirect URL.
+// The redirect URL must be same between the authorization request and token request.
+func (c *client) oauth2ConfigWithRedirectURL(redirectURL string) oauth2.Config {
+	config := c.oauth2Config
+	if config.RedirectURL == "" {
+		config.RedirectURL = redirectURL
+	}
+	return config
+}
+
+// authorizationRequestOptions returns the options of the authorization request.
+// The extra parameters take precedence over the parameters of the provider.
+func (c *client) authorizationRequestOptions(nonce string, pkceParams pkce.Params, extraParams map[string]string) []oauth2.AuthCodeOption {
 	opts := []oauth2.AuthCodeOption{
 		oauth2.AccessTypeOffline,
 		gooidc.Nonce(nonce),
@@ -86,6 +160,9 @@ func authorizationRequestOptions(nonce string, pkceParams pkce.Params, extraPara
 	if pkceOpt := pkceParams.AuthCodeOption(); pkceOpt != nil {
 		opts = append(opts, pkceOpt)
 	}
+	for key, value := range c.authRequestParams {
+		opts = append(opts, oauth2.SetAuthURLParam(key, value))
+	}
 	for key, value := range extraParams {
 		opts = append(opts, oauth2.SetAuthURLParam(key, value))
 	}
@@ -0,0 +1,106 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/togethercomputer/together-kubelogin/pkg/testing/logger"
+	"github.com/int128/oauth2cli"
+	"golang.org/x/oauth2"
+)
+
+func TestClient_getTokenOnReservedAddress(t *testing.T) {
+	bindError := func(address string) error {
+		return fmt.Errorf("authorization error: could not start a local server: %w",
+			&net.OpError{Op: "listen", Net: "tcp", Err: fmt.Errorf("address %s already in use", address)})
+	}
+	replaceGetToken := func(t *testing.T, errs ...error) *[]string {
+		var addresses []string
+		getTokenByLocalServer = func(_ context.Context, config oauth2cli.Config) (*oauth2.Token, error) {
+			addresses = append(addresses, config.LocalServerBindAddress...)
+			if len(errs) > 0 {
+				err := errs[0]
+				errs = errs[1:]
+				return nil, err
+			}
+			return &oauth2.Token{AccessToken: "YOUR_ACCESS_TOKEN"}, nil
+		}
+		t.Cleanup(func() { getTokenByLocalServer = oauth2cli.GetToken })
+		return &addresses
+	}
+	newFixedPort := func(t *testing.T) string {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Listen error: %s", err)
+		}
+		address := l.Addr().String()
+		if err := l.Close(); err != nil {
+			t.Fatalf("Close error: %s", err)
+		}
+		return address
+	}
+	c := &client{logger: logger.New(t)}
+
+	t.Run("FallbackToNextFixedPort", func(t *testing.T) {
+		first, second := newFixedPort(t), newFixedPort(t)
+		addresses := replaceGetToken(t, bindError(first))
+		token, err := c.getTokenOnReservedAddress(context.TODO(), oauth2cli.Config{}, []string{first, second}, false)
+		if err != nil {
+			t.Fatalf("getTokenOnReservedAddress error: %s", err)
+		}
+		if token.AccessToken != "YOUR_ACCESS_TOKEN" {
+			t.Errorf("AccessToken wants YOUR_ACCESS_TOKEN but was %s", token.AccessToken)
+		}
+		if diff := cmp.Diff([]string{first, second}, *addresses); diff != "" {
+			t.Errorf("addresses mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("RetryDynamicPort", func(t *testing.T) {
+		addresses := replaceGetToken(t, bindError("127.0.0.1:0"))
+		if _, err := c.getTokenOnReservedAddress(context.TODO(), oauth2cli.Config{}, []string{"127.0.0.1:0"}, false); err != nil {
+			t.Fatalf("getTokenOnReservedAddress error: %s", err)
+		}
+		if len(*addresses) != 2 {
+			t.Errorf("getToken wants 2 calls but was %v", *addresses)
+		}
+	})
+
+	t.Run("TooManyAttempts", func(t *testing.T) {
+		replaceGetToken(t, bindError("1"), bindError("2"), bindError("3"), bindError("4"))
+		if _, err := c.getTokenOnReservedAddress(context.TODO(), oauth2cli.Config{}, []string{"127.0.0.1:0"}, false); err == nil {
+			t.Errorf("getTokenOnReservedAddress wants an error but was nil")
+		}
+	})
+
+	t.Run("NoRetryOnOtherError", func(t *testing.T) {
+		addresses := replaceGetToken(t, fmt.Errorf("authorization error: context canceled"))
+		if _, err := c.getTokenOnReservedAddress(context.TODO(), oauth2cli.Config{}, []string{"127.0.0.1:0"}, false); err == nil {
+			t.Errorf("getTokenOnReservedAddress wants an error but was nil")
+		}
+		if len(*addresses) != 1 {
+			t.Errorf("getToken wants 1 call but was %v", *addresses)
+		}
+	})
+}
+
+func Test_isBindError(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen error: %s", err)
+	}
+	t.Cleanup(func() { _ = l.Close() })
+	_, err = oauth2cli.GetToken(context.TODO(), oauth2cli.Config{LocalServerBindAddress: []string{l.Addr().String()}})
+	if err == nil {
+		t.Fatalf("GetToken wants an error but was nil")
+	}
+	if !isBindError(err) {
+		t.Errorf("isBindError wants true for %s", err)
+	}
+	if isBindError(context.Canceled) {
+		t.Errorf("isBindError wants false for %s", context.Canceled)
+	}
+}
@@ -4,12 +4,15 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"slices"
 	"time"
 
 	gooidc "github.com/coreos/go-oidc/v3/oidc"
 	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/clock"
 	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/logger"
+	"github.com/togethercomputer/together-kubelogin/pkg/jwt"
 	"github.com/togethercomputer/together-kubelogin/pkg/oidc"
+	"github.com/togethercomputer/together-kubelogin/pkg/oidc/client/transport"
 	"github.com/togethercomputer/together-kubelogin/pkg/pkce"
 	"github.com/int128/oauth2dev"
 	"golang.org/x/oauth2"
@@ -20,11 +23,13 @@ type Interface interface {
 	ExchangeAuthCode(ctx context.Context, in ExchangeAuthCodeInput) (*oidc.TokenSet, error)
 	GetTokenByAuthCode(ctx context.Context, in GetTokenByAuthCodeInput, localServerReadyChan chan<- string) (*oidc.TokenSet, error)
 	NegotiatedPKCEMethod() pkce.Method
-	GetTokenByROPC(ctx context.Context, username, password string) (*oidc.TokenSet, error)
+	SupportsGrantType(grantType string) bool
+	GetTokenByROPC(ctx context.Context, in GetTokenByROPCInput) (*oidc.TokenSet, error)
 	GetTokenByClientCredentials(ctx context.Context, in GetTokenByClientCredentialsInput) (*oidc.TokenSet, error)
-	GetDeviceAuthorization(ctx context.Context) (*oauth2dev.AuthorizationResponse, error)
-	ExchangeDeviceCode(ctx context.Context, authResponse *oauth2dev.AuthorizationResponse) (*oidc.TokenSet, error)
+	GetDeviceAuthorization(ctx context.Context, in GetDeviceAuthorizationInput) (*oauth2dev.AuthorizationResponse, error)
+	ExchangeDeviceCode(ctx context.Context, in ExchangeDeviceCodeInput) (*oidc.TokenSet, error)
 	Refresh(ctx context.Context, refreshToken string) (*oidc.TokenSet, error)
+	ExchangeToken(ctx context.Context, subjectToken string) (*oidc.TokenSet, error)
 }
 
 type client struct {
@@ -34,7 +39,10 @@ type client struct {
 	clock                clock.Interface
 	logger               logger.Interface
 	negotiatedPKCEMethod pkce.Method
+	grantTypesSupported  []string
 	useAccessToken       bool
+	authenticationPolicy oidc.AuthenticationPolicy
+	authRequestParams    map[string]string // sent with the authorization request, such as acr_values
 }
 
 func (c *client) wrapContext(ctx context.Context) context.Context {
@@ -44,6 +52,55 @@ func (c *client) wrapContext(ctx context.Context) context.Context {
 	return ctx
 }
 
+// wrapContextWithFormParams returns the context with the HTTP client
+// which adds the parameters to the form body of each request.
+func (c *client) wrapContextWithFormParams(ctx context.Context, params map[string]string) context.Context {
+	if len(params) == 0 {
+		return c.wrapContext(ctx)
+	}
+	var hc http.Client
+	if c.httpClient != nil {
+		hc = *c.httpClient
+	}
+	base := hc.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	hc.Transport = &transport.WithFormParams{Base: base, Params: params}
+	return context.WithValue(ctx, oauth2.HTTPClient, &hc)
+}
+
+// Grant types in the discovery document.
+const (
+	GrantTypeAuthorizationCode = "authorization_code"
+	GrantTypeDeviceCode        = "urn:ietf:params:oauth:grant-type:device_code"
+)
+
+// SupportsGrantType returns true if the provider supports the grant type.
+// If grant_types_supported is omitted, the default is authorization_code and implicit.
+// The device code grant requires device_authorization_endpoint,
+// and it is assumed to be supported if grant_types_supported is omitted.
+func (c *client) SupportsGrantType(grantType string) bool {
+	endpoint := c.provider.Endpoint()
+	switch grantType {
+	case GrantTypeAuthorizationCode:
+		if endpoint.AuthURL == "" {
+			return false
+		}
+	case GrantTypeDeviceCode:
+		if endpoint.DeviceAuthURL == "" {
+			return false
+		}
+		if len(c.grantTypesSupported) == 0 {
+			return true
+		}
+	}
+	if len(c.grantTypesSupported) == 0 {
+		return grantType == GrantTypeAuthorizationCode || grantType == "implicit"
+	}
+	return slices.Contains(c.grantTypesSupported, grantType)
+}
+
 // Refresh sends a refresh token request and returns a token set.
 func (c *client) Refresh(ctx context.Context, refreshToken string) (*oidc.TokenSet, error) {
 	ctx = c.wrapContext(ctx)
@@ -74,6 +131,16 @@ func (c *client) verifyToken(ctx context.Context, token *oauth2.Token, nonce str
 	if nonce != "" && nonce != verifiedIDToken.Nonce {
 		return nil, fmt.Errorf("nonce did not match (wants %s but got %s)", nonce, verifiedIDToken.Nonce)
 	}
+	if !c.authenticationPolicy.IsZero() {
+		// the signature has been verified above
+		claims, err := jwt.DecodeWithoutVerify(idToken)
+		if err != nil {
+			return nil, fmt.Errorf("could not decode the ID token: %w", err)
+		}
+		if err := c.authenticationPolicy.Verify(claims, c.clock.Now()); err != nil {
+			return nil, fmt.Errorf("the ID token does not satisfy the authentication policy: %w", err)
+		}
+	}
 
 	if c.useAccessToken {
 		accessToken, ok := token.Extra("access_token").(string)
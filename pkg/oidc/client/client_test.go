@@ -0,0 +1,109 @@
+package client
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	gooidc "github.com/coreos/go-oidc/v3/oidc"
+	"github.com/togethercomputer/together-kubelogin/pkg/oidc"
+	"golang.org/x/oauth2"
+)
+
+func TestClient_SupportsGrantType(t *testing.T) {
+	tests := map[string]struct {
+		providerConfig      gooidc.ProviderConfig
+		grantTypesSupported []string
+		want                map[string]bool
+	}{
+		"GrantTypesSupportedIsOmitted": {
+			providerConfig: gooidc.ProviderConfig{AuthURL: "https://issuer.example.com/auth"},
+			want: map[string]bool{
+				GrantTypeAuthorizationCode: true,
+				GrantTypeDeviceCode:        false,
+				"password":                 false,
+			},
+		},
+		"DeviceAuthorizationEndpoint": {
+			providerConfig: gooidc.ProviderConfig{
+				AuthURL:       "https://issuer.example.com/auth",
+				DeviceAuthURL: "https://issuer.example.com/device",
+			},
+			want: map[string]bool{
+				GrantTypeAuthorizationCode: true,
+				GrantTypeDeviceCode:        true,
+			},
+		},
+		"GrantTypesSupported": {
+			providerConfig: gooidc.ProviderConfig{
+				AuthURL:       "https://issuer.example.com/auth",
+				DeviceAuthURL: "https://issuer.example.com/device",
+			},
+			grantTypesSupported: []string{"authorization_code", "password"},
+			want: map[string]bool{
+				GrantTypeAuthorizationCode: true,
+				GrantTypeDeviceCode:        false,
+				"password":                 true,
+			},
+		},
+		"NoAuthorizationEndpoint": {
+			providerConfig:      gooidc.ProviderConfig{DeviceAuthURL: "https://issuer.example.com/device"},
+			grantTypesSupported: []string{"authorization_code", GrantTypeDeviceCode},
+			want: map[string]bool{
+				GrantTypeAuthorizationCode: false,
+				GrantTypeDeviceCode:        true,
+			},
+		},
+	}
+	for name, c := range tests {
+		t.Run(name, func(t *testing.T) {
+			oidcClient := &client{
+				provider:            c.providerConfig.NewProvider(context.TODO()),
+				grantTypesSupported: c.grantTypesSupported,
+			}
+			for grantType, want := range c.want {
+				if got := oidcClient.SupportsGrantType(grantType); got != want {
+					t.Errorf("SupportsGrantType(%s) wants %v but got %v", grantType, want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestClient_GetAuthCodeURL(t *testing.T) {
+	providerConfig := gooidc.ProviderConfig{AuthURL: "https://issuer.example.com/auth"}
+	provider := providerConfig.NewProvider(context.TODO())
+	oidcClient := &client{
+		provider: provider,
+		oauth2Config: oauth2.Config{
+			Endpoint: provider.Endpoint(),
+			ClientID: "YOUR_CLIENT_ID",
+		},
+		authRequestParams: authRequestParamsOf(oidc.Provider{
+			AuthenticationPolicy: oidc.AuthenticationPolicy{ACRValues: []string{"phr"}, MaxAge: time.Hour},
+			Prompt:               "login",
+			LoginHint:            "alice@example.com",
+		}),
+	}
+	got, err := url.Parse(oidcClient.GetAuthCodeURL(AuthCodeURLInput{
+		State:                  "YOUR_STATE",
+		Nonce:                  "YOUR_NONCE",
+		RedirectURL:            "http://localhost:8000",
+		AuthRequestExtraParams: map[string]string{"prompt": "consent"},
+	}))
+	if err != nil {
+		t.Fatalf("invalid URL: %s", err)
+	}
+	want := map[string]string{
+		"acr_values": "phr",
+		"max_age":    "3600",
+		"login_hint": "alice@example.com",
+		"prompt":     "consent", // extra params take precedence
+	}
+	for key, value := range want {
+		if got := got.Query().Get(key); got != value {
+			t.Errorf("%s wants %s but got %s", key, value, got)
+		}
+	}
+}
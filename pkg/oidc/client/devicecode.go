@@ -2,16 +2,44 @@ package client
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"time"
 
 	"github.com/togethercomputer/together-kubelogin/pkg/oidc"
 	"github.com/int128/oauth2dev"
 	"golang.org/x/oauth2"
 )
 
+type GetDeviceAuthorizationInput struct {
+	AuthRequestExtraParams map[string]string
+}
+
+type ExchangeDeviceCodeInput struct {
+	AuthResponse            *oauth2dev.AuthorizationResponse
+	TokenRequestExtraParams map[string]string
+	// MinInterval overrides the interval of the authorization response if it is longer.
+	MinInterval time.Duration // optional
+	// OnSlowDown is called with the new interval when the provider asks to slow down the polling.
+	OnSlowDown func(interval time.Duration) // optional
+}
+
+// slowDownIncrement is the increment of the interval on slow_down,
+// described in https://www.rfc-editor.org/rfc/rfc8628#section-3.5
+var slowDownIncrement = 5 * time.Second
+
+// defaultPollingInterval is used if the provider does not return the interval.
+var defaultPollingInterval = 5 * time.Second
+
 // GetDeviceAuthorization initializes the device authorization code challenge
-func (c *client) GetDeviceAuthorization(ctx context.Context) (*oauth2dev.AuthorizationResponse, error) {
-	ctx = c.wrapContext(ctx)
+func (c *client) GetDeviceAuthorization(ctx context.Context, in GetDeviceAuthorizationInput) (*oauth2dev.AuthorizationResponse, error) {
+	params := make(map[string]string)
+	maps.Copy(params, c.authRequestParams)
+	// the device authorization request always prompts the user
+	delete(params, "prompt")
+	maps.Copy(params, in.AuthRequestExtraParams)
+	ctx = c.wrapContextWithFormParams(ctx, params)
 	config := c.oauth2Config
 	config.Endpoint = oauth2.Endpoint{
 		AuthURL: c.provider.Endpoint().DeviceAuthURL,
@@ -19,12 +47,48 @@ func (c *client) GetDeviceAuthorization(ctx context.Context) (*oauth2dev.Authori
 	return oauth2dev.RetrieveCode(ctx, config)
 }
 
-// ExchangeDeviceCode exchanges the device authorization code for an oidc.TokenSet
-func (c *client) ExchangeDeviceCode(ctx context.Context, authResponse *oauth2dev.AuthorizationResponse) (*oidc.TokenSet, error) {
-	ctx = c.wrapContext(ctx)
-	tokenResponse, err := oauth2dev.PollToken(ctx, c.oauth2Config, *authResponse)
+// ExchangeDeviceCode exchanges the device authorization code for an oidc.TokenSet.
+// It polls the token endpoint until the user authorizes the request.
+func (c *client) ExchangeDeviceCode(ctx context.Context, in ExchangeDeviceCodeInput) (*oidc.TokenSet, error) {
+	tokenResponse, err := c.pollDeviceToken(c.wrapContextWithFormParams(ctx, in.TokenRequestExtraParams), in)
 	if err != nil {
 		return nil, fmt.Errorf("device-code: exchange failed: %w", err)
 	}
-	return c.verifyToken(ctx, tokenResponse, "")
+	return c.verifyToken(c.wrapContext(ctx), tokenResponse, "")
+}
+
+func (c *client) pollDeviceToken(ctx context.Context, in ExchangeDeviceCodeInput) (*oauth2.Token, error) {
+	interval := in.AuthResponse.IntervalDuration()
+	if interval <= 0 {
+		interval = defaultPollingInterval
+	}
+	if interval < in.MinInterval {
+		interval = in.MinInterval
+	}
+	for {
+		token, err := oauth2dev.RetrieveToken(ctx, c.oauth2Config, in.AuthResponse.DeviceCode)
+		if err == nil {
+			return token, nil
+		}
+		var eresp oauth2dev.TokenErrorResponse
+		if !errors.As(err, &eresp) {
+			return nil, fmt.Errorf("token request: %w", err)
+		}
+		switch eresp.ErrorCode {
+		case oauth2dev.TokenErrorAuthorizationPending:
+		case oauth2dev.TokenErrorSlowDown:
+			interval += slowDownIncrement
+			c.logger.V(1).Infof("the provider asked to slow down, polling every %s", interval)
+			if in.OnSlowDown != nil {
+				in.OnSlowDown(interval)
+			}
+		default:
+			return nil, fmt.Errorf("token request: %w", err)
+		}
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
 }
@@ -0,0 +1,108 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/togethercomputer/together-kubelogin/pkg/testing/logger"
+	"github.com/int128/oauth2dev"
+	"golang.org/x/oauth2"
+)
+
+func TestClient_pollDeviceToken(t *testing.T) {
+	slowDownIncrement, defaultPollingInterval = time.Millisecond, time.Millisecond
+	t.Cleanup(func() {
+		slowDownIncrement, defaultPollingInterval = 5*time.Second, 5*time.Second
+	})
+	var forms []url.Values
+	newClient := func(t *testing.T, responses ...string) *client {
+		forms = nil
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := r.ParseForm(); err != nil {
+				t.Errorf("could not parse the form: %s", err)
+			}
+			forms = append(forms, r.PostForm)
+			if r.FormValue("device_code") != "DEVICE_CODE" {
+				t.Errorf("device_code wants DEVICE_CODE but was %s", r.FormValue("device_code"))
+			}
+			if len(responses) == 0 {
+				t.Errorf("unexpected token request")
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			body := responses[0]
+			responses = responses[1:]
+			w.Header().Set("Content-Type", "application/json")
+			if body != `{"access_token":"YOUR_ACCESS_TOKEN","token_type":"Bearer"}` {
+				w.WriteHeader(http.StatusBadRequest)
+			}
+			_, _ = w.Write([]byte(body))
+		}))
+		t.Cleanup(server.Close)
+		return &client{
+			oauth2Config: oauth2.Config{ClientID: "YOUR_CLIENT_ID", Endpoint: oauth2.Endpoint{TokenURL: server.URL}},
+			logger:       logger.New(t),
+		}
+	}
+	authResponse := &oauth2dev.AuthorizationResponse{DeviceCode: "DEVICE_CODE"}
+
+	t.Run("SlowDown", func(t *testing.T) {
+		c := newClient(t,
+			`{"error":"authorization_pending"}`,
+			`{"error":"slow_down"}`,
+			`{"access_token":"YOUR_ACCESS_TOKEN","token_type":"Bearer"}`,
+		)
+		var intervals []time.Duration
+		token, err := c.pollDeviceToken(context.TODO(), ExchangeDeviceCodeInput{
+			AuthResponse: authResponse,
+			OnSlowDown:   func(interval time.Duration) { intervals = append(intervals, interval) },
+		})
+		if err != nil {
+			t.Fatalf("pollDeviceToken error: %s", err)
+		}
+		if token.AccessToken != "YOUR_ACCESS_TOKEN" {
+			t.Errorf("AccessToken wants YOUR_ACCESS_TOKEN but was %s", token.AccessToken)
+		}
+		if diff := cmp.Diff([]time.Duration{2 * time.Millisecond}, intervals); diff != "" {
+			t.Errorf("intervals mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("TokenRequestExtraParams", func(t *testing.T) {
+		c := newClient(t, `{"access_token":"YOUR_ACCESS_TOKEN","token_type":"Bearer"}`)
+		in := ExchangeDeviceCodeInput{
+			AuthResponse:            authResponse,
+			TokenRequestExtraParams: map[string]string{"resource": "https://api.example.com"},
+		}
+		if _, err := c.pollDeviceToken(c.wrapContextWithFormParams(context.TODO(), in.TokenRequestExtraParams), in); err != nil {
+			t.Fatalf("pollDeviceToken error: %s", err)
+		}
+		if len(forms) != 1 {
+			t.Fatalf("token requests wants 1 but was %d", len(forms))
+		}
+		if got := forms[0].Get("resource"); got != "https://api.example.com" {
+			t.Errorf("resource wants https://api.example.com but was %s", got)
+		}
+	})
+
+	t.Run("AccessDenied", func(t *testing.T) {
+		c := newClient(t,
+			`{"error":"authorization_pending"}`,
+			`{"error":"access_denied"}`,
+		)
+		_, err := c.pollDeviceToken(context.TODO(), ExchangeDeviceCodeInput{AuthResponse: authResponse})
+		var eresp oauth2dev.TokenErrorResponse
+		if !errors.As(err, &eresp) {
+			t.Fatalf("error wants TokenErrorResponse but was %v", err)
+		}
+		if eresp.ErrorCode != oauth2dev.TokenErrorAccessDenied {
+			t.Errorf("ErrorCode wants access_denied but was %s", eresp.ErrorCode)
+		}
+	})
+}
@@ -11,11 +11,14 @@ import (
 	"github.com/google/wire"
 	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/clock"
 	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/logger"
+	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/tracing"
 	"github.com/togethercomputer/together-kubelogin/pkg/oidc"
 	"github.com/togethercomputer/together-kubelogin/pkg/oidc/client/transport"
 	"github.com/togethercomputer/together-kubelogin/pkg/pkce"
 	"github.com/togethercomputer/together-kubelogin/pkg/tlsclientconfig"
 	"github.com/togethercomputer/together-kubelogin/pkg/tlsclientconfig/loader"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/oauth2"
 )
 
@@ -36,16 +39,27 @@ type Factory struct {
 
 // New returns an instance of infrastructure.Interface with the given configuration.
 func (f *Factory) New(ctx context.Context, prov oidc.Provider, tlsClientConfig tlsclientconfig.Config) (Interface, error) {
+	ctx, span := tracing.Start(ctx, "Factory.New",
+		trace.WithAttributes(attribute.String("oidc.issuer", prov.IssuerURL)))
+	c, err := f.new(ctx, prov, tlsClientConfig)
+	tracing.End(span, err)
+	return c, err
+}
+
+func (f *Factory) new(ctx context.Context, prov oidc.Provider, tlsClientConfig tlsclientconfig.Config) (Interface, error) {
 	rawTLSClientConfig, err := f.Loader.Load(tlsClientConfig)
 	if err != nil {
 		return nil, fmt.Errorf("could not load the TLS client config: %w", err)
 	}
+	baseTransport, err := transport.ForIssuer(rawTLSClientConfig, prov.IssuerURL)
+	if err != nil {
+		return nil, err
+	}
 	httpClient := &http.Client{
 		Transport: &transport.WithHeader{
 			Base: &transport.WithLogging{
-				Base: &http.Transport{
-					TLSClientConfig: rawTLSClientConfig,
-					Proxy:           http.ProxyFromEnvironment,
+				Base: &transport.WithTracing{
+					Base: baseTransport,
 				},
 				Logger: f.Logger,
 			},
@@ -58,9 +72,9 @@ func (f *Factory) New(ctx context.Context, prov oidc.Provider, tlsClientConfig t
 	if err != nil {
 		return nil, fmt.Errorf("oidc discovery error: %w", err)
 	}
-	supportedPKCEMethods, err := extractSupportedPKCEMethods(provider)
+	supported, err := extractSupported(provider)
 	if err != nil {
-		return nil, fmt.Errorf("could not determine supported PKCE methods: %w", err)
+		return nil, fmt.Errorf("could not determine supported methods: %w", err)
 	}
 
 	endpoint := provider.Endpoint()
@@ -80,11 +94,26 @@ func (f *Factory) New(ctx context.Context, prov oidc.Provider, tlsClientConfig t
 		},
 		clock:                f.Clock,
 		logger:               f.Logger,
-		negotiatedPKCEMethod: determinePKCEMethod(supportedPKCEMethods, prov.PKCEMethod),
+		negotiatedPKCEMethod: determinePKCEMethod(supported.CodeChallengeMethodsSupported, prov.PKCEMethod),
+		grantTypesSupported:  supported.GrantTypesSupported,
 		useAccessToken:       prov.UseAccessToken,
+		authenticationPolicy: prov.AuthenticationPolicy,
+		authRequestParams:    authRequestParamsOf(prov),
 	}, nil
 }
 
+// authRequestParamsOf returns the parameters of the authorization request for the provider.
+func authRequestParamsOf(prov oidc.Provider) map[string]string {
+	params := prov.AuthenticationPolicy.AuthRequestParams()
+	if prov.Prompt != "" {
+		params["prompt"] = prov.Prompt
+	}
+	if prov.LoginHint != "" {
+		params["login_hint"] = prov.LoginHint
+	}
+	return params
+}
+
 func determinePKCEMethod(supportedMethods []string, preferredMethod oidc.PKCEMethod) pkce.Method {
 	switch preferredMethod {
 	case oidc.PKCEMethodNo:
@@ -99,12 +128,15 @@ func determinePKCEMethod(supportedMethods []string, preferredMethod oidc.PKCEMet
 	}
 }
 
-func extractSupportedPKCEMethods(provider *gooidc.Provider) ([]string, error) {
-	var claims struct {
-		CodeChallengeMethodsSupported []string `json:"code_challenge_methods_supported"`
-	}
+type supportedClaims struct {
+	CodeChallengeMethodsSupported []string `json:"code_challenge_methods_supported"`
+	GrantTypesSupported           []string `json:"grant_types_supported"`
+}
+
+func extractSupported(provider *gooidc.Provider) (*supportedClaims, error) {
+	var claims supportedClaims
 	if err := provider.Claims(&claims); err != nil {
 		return nil, fmt.Errorf("invalid discovery document: %w", err)
 	}
-	return claims.CodeChallengeMethodsSupported, nil
+	return &claims, nil
 }
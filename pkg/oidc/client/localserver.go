@@ -0,0 +1,185 @@
+package client
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/logger"
+	"github.com/togethercomputer/together-kubelogin/pkg/oidc"
+	"github.com/int128/oauth2cli"
+	"golang.org/x/oauth2"
+)
+
+// LocalServerError represents an error shown in the page of the local server.
+type LocalServerError struct {
+	Code        string // error code of OAuth 2.0, such as access_denied
+	Description string
+}
+
+// localServerResultTimeout is the max duration to hold the response until the token is verified.
+var localServerResultTimeout = 30 * time.Second
+
+// localServerPage renders the response to the redirect from the provider.
+//
+// The local server receives the authorization code before the token request,
+// so the page cannot contain the claims of the token at that time.
+// It hijacks the connection of the redirect, and then
+// responds the page after the token is verified.
+type localServerPage struct {
+	successHTML func(tokenSet *oidc.TokenSet) string
+	errorHTML   func(e LocalServerError) string
+	logger      logger.Interface
+
+	once     sync.Once
+	waiting  atomic.Bool
+	resultCh chan localServerResult
+	done     chan struct{}
+}
+
+type localServerResult struct {
+	tokenSet *oidc.TokenSet
+	err      error
+}
+
+func newLocalServerPage(in GetTokenByAuthCodeInput, l logger.Interface) *localServerPage {
+	successHTML, errorHTML := in.LocalServerSuccessHTML, in.LocalServerErrorHTML
+	if successHTML == nil {
+		successHTML = func(*oidc.TokenSet) string { return oauth2cli.DefaultLocalServerSuccessHTML }
+	}
+	if errorHTML == nil {
+		errorHTML = func(e LocalServerError) string {
+			return html.EscapeString(fmt.Sprintf("authorization error: %s %s", e.Code, e.Description))
+		}
+	}
+	return &localServerPage{
+		successHTML: successHTML,
+		errorHTML:   errorHTML,
+		logger:      l,
+		resultCh:    make(chan localServerResult, 1),
+		done:        make(chan struct{}),
+	}
+}
+
+func (p *localServerPage) middleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if r.Method != "GET" || r.URL.Path != "/" || (q.Get("code") == "" && q.Get("error") == "") {
+			h.ServeHTTP(w, r)
+			return
+		}
+		handled := false
+		p.once.Do(func() {
+			handled = true
+			p.handleCallback(h, w, r)
+		})
+		if !handled {
+			h.ServeHTTP(w, r)
+		}
+	})
+}
+
+func (p *localServerPage) handleCallback(h http.Handler, w http.ResponseWriter, r *http.Request) {
+	// Pass the redirect to oauth2cli to receive the code or error.
+	rw := &statusRecorder{header: make(http.Header), status: http.StatusOK}
+	h.ServeHTTP(rw, r)
+
+	q := r.URL.Query()
+	if errorCode := q.Get("error"); errorCode != "" {
+		p.write(w, http.StatusOK, p.errorHTML(LocalServerError{Code: errorCode, Description: q.Get("error_description")}))
+		return
+	}
+	if rw.status >= 400 {
+		p.write(w, http.StatusOK, p.errorHTML(LocalServerError{Code: "invalid_request", Description: "state does not match"}))
+		return
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		// HTTP/2 does not support hijacking.
+		p.write(w, http.StatusOK, p.successHTML(nil))
+		return
+	}
+	// Set before hijacking, because the server may shut down and the token request may finish soon.
+	p.waiting.Store(true)
+	defer close(p.done)
+	conn, bufrw, err := hijacker.Hijack()
+	if err != nil {
+		p.logger.V(1).Infof("could not hijack the connection of the local server: %s", err)
+		p.write(w, http.StatusOK, p.successHTML(nil))
+		return
+	}
+	defer func() {
+		if err := conn.Close(); err != nil {
+			p.logger.V(1).Infof("could not close the connection of the local server: %s", err)
+		}
+	}()
+
+	var body string
+	select {
+	case result := <-p.resultCh:
+		if result.err != nil {
+			body = p.errorHTML(toLocalServerError(result.err))
+		} else {
+			body = p.successHTML(result.tokenSet)
+		}
+	case <-time.After(localServerResultTimeout):
+		body = p.successHTML(nil)
+	}
+	var b bytes.Buffer
+	_, _ = fmt.Fprintf(&b, "HTTP/1.1 200 OK\r\n")
+	_, _ = fmt.Fprintf(&b, "Content-Type: text/html; charset=utf-8\r\n")
+	_, _ = fmt.Fprintf(&b, "Content-Length: %d\r\n", len(body))
+	_, _ = fmt.Fprintf(&b, "Connection: close\r\n\r\n")
+	b.WriteString(body)
+	if _, err := bufrw.Write(b.Bytes()); err != nil {
+		p.logger.V(1).Infof("could not write the response of the local server: %s", err)
+		return
+	}
+	if err := bufrw.Flush(); err != nil {
+		p.logger.V(1).Infof("could not write the response of the local server: %s", err)
+	}
+}
+
+func (p *localServerPage) write(w http.ResponseWriter, status int, body string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if _, err := w.Write([]byte(body)); err != nil {
+		p.logger.V(1).Infof("could not write the response of the local server: %s", err)
+	}
+}
+
+// respond sends the result to the waiting page, and waits until the page is sent.
+func (p *localServerPage) respond(tokenSet *oidc.TokenSet, err error) {
+	p.resultCh <- localServerResult{tokenSet: tokenSet, err: err}
+	if !p.waiting.Load() {
+		return
+	}
+	select {
+	case <-p.done:
+	case <-time.After(5 * time.Second):
+	}
+}
+
+func toLocalServerError(err error) LocalServerError {
+	var retrieveError *oauth2.RetrieveError
+	if errors.As(err, &retrieveError) && retrieveError.ErrorCode != "" {
+		return LocalServerError{Code: retrieveError.ErrorCode, Description: retrieveError.ErrorDescription}
+	}
+	return LocalServerError{Code: "server_error", Description: logger.Redact(err.Error())}
+}
+
+// statusRecorder is a http.ResponseWriter to discard the response of oauth2cli.
+type statusRecorder struct {
+	header http.Header
+	status int
+}
+
+func (r *statusRecorder) Header() http.Header         { return r.header }
+func (r *statusRecorder) Write(b []byte) (int, error) { return len(b), nil }
+func (r *statusRecorder) WriteHeader(status int)      { r.status = status }
@@ -0,0 +1,101 @@
+package client
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/togethercomputer/together-kubelogin/pkg/oidc"
+	"github.com/togethercomputer/together-kubelogin/pkg/testing/logger"
+)
+
+func TestLocalServerPage(t *testing.T) {
+	newPage := func(t *testing.T) *localServerPage {
+		return newLocalServerPage(GetTokenByAuthCodeInput{
+			LocalServerSuccessHTML: func(tokenSet *oidc.TokenSet) string {
+				if tokenSet == nil {
+					return "SUCCESS"
+				}
+				return "SUCCESS " + tokenSet.IDToken
+			},
+			LocalServerErrorHTML: func(e LocalServerError) string {
+				return "ERROR " + e.Code + " " + e.Description
+			},
+		}, logger.New(t))
+	}
+	// oauth2cli responds 500 if the state does not match
+	oauth2cliHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("state") != "STATE" {
+			http.Error(w, "authorization error", http.StatusInternalServerError)
+			return
+		}
+		_, _ = io.WriteString(w, "oauth2cli")
+	})
+	get := func(t *testing.T, url string) string {
+		resp, err := http.Get(url)
+		if err != nil {
+			t.Fatalf("could not send a request: %s", err)
+		}
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+		if resp.StatusCode != 200 {
+			t.Errorf("StatusCode wants 200 but %d", resp.StatusCode)
+		}
+		b, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("could not read the body: %s", err)
+		}
+		return string(b)
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		page := newPage(t)
+		server := httptest.NewServer(page.middleware(oauth2cliHandler))
+		defer server.Close()
+		bodyCh := make(chan string, 1)
+		go func() {
+			bodyCh <- get(t, server.URL+"/?code=CODE&state=STATE")
+		}()
+		// wait for the page to hold the connection
+		for !page.waiting.Load() {
+			runtime.Gosched()
+		}
+		page.respond(&oidc.TokenSet{IDToken: "YOUR_ID_TOKEN"}, nil)
+		if got := <-bodyCh; got != "SUCCESS YOUR_ID_TOKEN" {
+			t.Errorf("body wants SUCCESS YOUR_ID_TOKEN but was %s", got)
+		}
+	})
+
+	t.Run("ErrorFromProvider", func(t *testing.T) {
+		page := newPage(t)
+		server := httptest.NewServer(page.middleware(oauth2cliHandler))
+		defer server.Close()
+		got := get(t, server.URL+"/?error=access_denied&error_description=denied&state=STATE")
+		if got != "ERROR access_denied denied" {
+			t.Errorf("body wants ERROR access_denied denied but was %s", got)
+		}
+	})
+
+	t.Run("StateMismatch", func(t *testing.T) {
+		page := newPage(t)
+		server := httptest.NewServer(page.middleware(oauth2cliHandler))
+		defer server.Close()
+		got := get(t, server.URL+"/?code=CODE&state=ANOTHER")
+		if !strings.HasPrefix(got, "ERROR invalid_request") {
+			t.Errorf("body wants ERROR invalid_request but was %s", got)
+		}
+	})
+
+	t.Run("Index", func(t *testing.T) {
+		page := newPage(t)
+		server := httptest.NewServer(page.middleware(oauth2cliHandler))
+		defer server.Close()
+		if got := get(t, server.URL+"/?state=STATE"); got != "oauth2cli" {
+			t.Errorf("body wants oauth2cli but was %s", got)
+		}
+	})
+}
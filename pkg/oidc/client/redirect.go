@@ -0,0 +1,113 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+)
+
+// RedirectURLOf returns the redirect URL of the local server bound to the address.
+// If the port is 0, it returns the URL without port,
+// because the port is allocated on binding.
+//
+// For compatibility, it returns localhost for a fixed port of IPv4.
+// For a dynamic port or IPv6, it returns the loopback IP literal,
+// as recommended in https://www.rfc-editor.org/rfc/rfc8252#section-7.3.
+// Most providers accept any port of the loopback IP literal.
+// For HTTPS, it always returns localhost,
+// because the certificate of the local server usually does not contain the IP address.
+func RedirectURLOf(bindAddress string, https bool) (string, error) {
+	host, port, err := splitBindAddress(bindAddress)
+	if err != nil {
+		return "", err
+	}
+	return redirectURL(redirectHost(host, port, https), port, https), nil
+}
+
+// RedirectURLsToRegister returns the redirect URLs to register to the provider.
+func RedirectURLsToRegister(bindAddresses []string, https bool) ([]string, error) {
+	var redirectURLs []string
+	for _, bindAddress := range bindAddresses {
+		u, err := RedirectURLOf(bindAddress, https)
+		if err != nil {
+			return nil, err
+		}
+		if _, port, _ := splitBindAddress(bindAddress); port == 0 {
+			u += " (any port)"
+		}
+		redirectURLs = append(redirectURLs, u)
+	}
+	return redirectURLs, nil
+}
+
+// reserveBindAddress returns the first address which the local server can bind to,
+// with the allocated port and the redirect URL.
+// It also returns the candidates to retry if the local server could not bind to the address,
+// that is the same candidate for a dynamic port, or the next candidates for a fixed port.
+func reserveBindAddress(bindAddresses []string, https bool) (string, string, []string, error) {
+	var errs []error
+	for i, bindAddress := range bindAddresses {
+		host, port, err := splitBindAddress(bindAddress)
+		if err != nil {
+			return "", "", nil, err
+		}
+		l, err := net.Listen("tcp", bindAddress)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		boundPort := l.Addr().(*net.TCPAddr).Port
+		if err := l.Close(); err != nil {
+			return "", "", nil, fmt.Errorf("could not close the listener: %w", err)
+		}
+		next := bindAddresses[i+1:]
+		if port == 0 {
+			next = bindAddresses[i:]
+		}
+		return net.JoinHostPort(host, strconv.Itoa(boundPort)), redirectURL(redirectHost(host, port, https), boundPort, https), next, nil
+	}
+	return "", "", nil, fmt.Errorf("could not bind to any address: %w", errors.Join(errs...))
+}
+
+func splitBindAddress(bindAddress string) (string, int, error) {
+	host, portString, err := net.SplitHostPort(bindAddress)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid bind address %s: %w", bindAddress, err)
+	}
+	port, err := strconv.Atoi(portString)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid port of bind address %s: %w", bindAddress, err)
+	}
+	return host, port, nil
+}
+
+func redirectHost(bindHost string, port int, https bool) string {
+	ip := net.ParseIP(bindHost)
+	if https || (port != 0 && (ip == nil || ip.To4() != nil)) {
+		return "localhost"
+	}
+	switch {
+	case ip == nil:
+		return "127.0.0.1"
+	case ip.IsUnspecified() && ip.To4() != nil:
+		return "127.0.0.1"
+	case ip.IsUnspecified():
+		return "::1"
+	}
+	return ip.String()
+}
+
+func redirectURL(host string, port int, https bool) string {
+	u := url.URL{Scheme: "http", Host: host}
+	if https {
+		u.Scheme = "https"
+	}
+	if port != 0 {
+		u.Host = net.JoinHostPort(host, strconv.Itoa(port))
+	} else if ip := net.ParseIP(host); ip != nil && ip.To4() == nil {
+		u.Host = "[" + host + "]"
+	}
+	return u.String()
+}
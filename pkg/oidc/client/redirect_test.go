@@ -0,0 +1,118 @@
+package client
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRedirectURLOf(t *testing.T) {
+	for bindAddress, want := range map[string]string{
+		"127.0.0.1:8000":   "http://localhost:8000",
+		"0.0.0.0:8000":     "http://localhost:8000",
+		"localhost:8000":   "http://localhost:8000",
+		":8000":            "http://localhost:8000",
+		"127.0.0.1:0":      "http://127.0.0.1",
+		"localhost:0":      "http://127.0.0.1",
+		"[::1]:8000":       "http://[::1]:8000",
+		"[::1]:0":          "http://[::1]",
+		"[::]:0":           "http://[::1]",
+		"192.168.0.1:8000": "http://localhost:8000",
+	} {
+		t.Run(bindAddress, func(t *testing.T) {
+			got, err := RedirectURLOf(bindAddress, false)
+			if err != nil {
+				t.Fatalf("RedirectURLOf error: %s", err)
+			}
+			if got != want {
+				t.Errorf("wants %s but got %s", want, got)
+			}
+		})
+	}
+
+	t.Run("HTTPS", func(t *testing.T) {
+		for bindAddress, want := range map[string]string{
+			"127.0.0.1:8443": "https://localhost:8443",
+			"127.0.0.1:0":    "https://localhost",
+			"[::1]:8443":     "https://localhost:8443",
+		} {
+			got, err := RedirectURLOf(bindAddress, true)
+			if err != nil {
+				t.Fatalf("RedirectURLOf error: %s", err)
+			}
+			if got != want {
+				t.Errorf("%s wants %s but got %s", bindAddress, want, got)
+			}
+		}
+	})
+
+	t.Run("InvalidAddress", func(t *testing.T) {
+		if _, err := RedirectURLOf("127.0.0.1", false); err == nil {
+			t.Errorf("RedirectURLOf wants error but no error")
+		}
+	})
+}
+
+func TestRedirectURLsToRegister(t *testing.T) {
+	got, err := RedirectURLsToRegister([]string{"127.0.0.1:8000", "127.0.0.1:0", "[::1]:0"}, false)
+	if err != nil {
+		t.Fatalf("RedirectURLsToRegister error: %s", err)
+	}
+	want := []string{"http://localhost:8000", "http://127.0.0.1 (any port)", "http://[::1] (any port)"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func Test_reserveBindAddress(t *testing.T) {
+	t.Run("DynamicPort", func(t *testing.T) {
+		address, redirectURL, next, err := reserveBindAddress([]string{"127.0.0.1:0"}, false)
+		if err != nil {
+			t.Fatalf("reserveBindAddress error: %s", err)
+		}
+		_, port, err := net.SplitHostPort(address)
+		if err != nil {
+			t.Fatalf("invalid address %s: %s", address, err)
+		}
+		if port == "0" {
+			t.Errorf("port must be allocated but was %s", address)
+		}
+		if want := "http://127.0.0.1:" + port; redirectURL != want {
+			t.Errorf("redirectURL wants %s but got %s", want, redirectURL)
+		}
+		if diff := cmp.Diff([]string{"127.0.0.1:0"}, next); diff != "" {
+			t.Errorf("next mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("FallbackToNextAddress", func(t *testing.T) {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Listen error: %s", err)
+		}
+		t.Cleanup(func() { _ = l.Close() })
+		address, redirectURL, _, err := reserveBindAddress([]string{l.Addr().String(), "127.0.0.1:0"}, false)
+		if err != nil {
+			t.Fatalf("reserveBindAddress error: %s", err)
+		}
+		if address == l.Addr().String() {
+			t.Errorf("address must not be the used port %s", address)
+		}
+		if !strings.HasPrefix(redirectURL, "http://127.0.0.1:") {
+			t.Errorf("redirectURL wants the loopback IP literal but got %s", redirectURL)
+		}
+	})
+
+	t.Run("NoAddressIsAvailable", func(t *testing.T) {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Listen error: %s", err)
+		}
+		t.Cleanup(func() { _ = l.Close() })
+		if _, _, _, err := reserveBindAddress([]string{l.Addr().String()}, false); err == nil {
+			t.Errorf("reserveBindAddress wants error but no error")
+		}
+	})
+}
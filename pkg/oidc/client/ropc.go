@@ -7,10 +7,16 @@ import (
 	"github.com/togethercomputer/together-kubelogin/pkg/oidc"
 )
 
+type GetTokenByROPCInput struct {
+	Username                string
+	Password                string
+	TokenRequestExtraParams map[string]string // optional, such as an OTP
+}
+
 // GetTokenByROPC performs the resource owner password credentials flow.
-func (c *client) GetTokenByROPC(ctx context.Context, username, password string) (*oidc.TokenSet, error) {
-	ctx = c.wrapContext(ctx)
-	token, err := c.oauth2Config.PasswordCredentialsToken(ctx, username, password)
+func (c *client) GetTokenByROPC(ctx context.Context, in GetTokenByROPCInput) (*oidc.TokenSet, error) {
+	ctx = c.wrapContextWithFormParams(ctx, in.TokenRequestExtraParams)
+	token, err := c.oauth2Config.PasswordCredentialsToken(ctx, in.Username, in.Password)
 	if err != nil {
 		return nil, fmt.Errorf("resource owner password credentials flow error: %w", err)
 	}
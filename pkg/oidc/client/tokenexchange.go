@@ -0,0 +1,52 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/togethercomputer/together-kubelogin/pkg/oidc"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// Token types of the token exchange.
+// https://datatracker.ietf.org/doc/html/rfc8693#section-3
+const (
+	tokenExchangeGrantType = "urn:ietf:params:oauth:grant-type:token-exchange"
+	tokenTypeIDToken       = "urn:ietf:params:oauth:token-type:id_token"
+	tokenTypeAccessToken   = "urn:ietf:params:oauth:token-type:access_token"
+)
+
+// ExchangeToken performs the token exchange (RFC 8693).
+// It exchanges the ID token issued to another client for an ID token of this client.
+// If the access token is used, the subject token is an access token.
+func (c *client) ExchangeToken(ctx context.Context, subjectToken string) (*oidc.TokenSet, error) {
+	ctx = c.wrapContext(ctx)
+	subjectTokenType := tokenTypeIDToken
+	if c.useAccessToken {
+		subjectTokenType = tokenTypeAccessToken
+	}
+	config := clientcredentials.Config{
+		ClientID:     c.oauth2Config.ClientID,
+		ClientSecret: c.oauth2Config.ClientSecret,
+		TokenURL:     c.oauth2Config.Endpoint.TokenURL,
+		Scopes:       c.oauth2Config.Scopes,
+		EndpointParams: map[string][]string{
+			// clientcredentials allows the grant_type to be overridden
+			"grant_type":           {tokenExchangeGrantType},
+			"subject_token":        {subjectToken},
+			"subject_token_type":   {subjectTokenType},
+			"requested_token_type": {tokenTypeIDToken},
+			"audience":             {c.oauth2Config.ClientID},
+		},
+		AuthStyle: c.oauth2Config.Endpoint.AuthStyle,
+	}
+	token, err := config.Token(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("token exchange error: %w", err)
+	}
+	if _, ok := token.Extra("id_token").(string); !ok && token.Extra("issued_token_type") == tokenTypeIDToken {
+		// the issued token is returned in the access_token field
+		token = token.WithExtra(map[string]any{"id_token": token.AccessToken, "access_token": token.AccessToken})
+	}
+	return c.verifyToken(ctx, token, "")
+}
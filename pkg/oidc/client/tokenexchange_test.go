@@ -0,0 +1,47 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/togethercomputer/together-kubelogin/pkg/testing/logger"
+	"golang.org/x/oauth2"
+)
+
+func TestClient_ExchangeToken(t *testing.T) {
+	tests := map[string]struct {
+		useAccessToken bool
+		want           string
+	}{
+		"IDToken":     {want: tokenTypeIDToken},
+		"AccessToken": {useAccessToken: true, want: tokenTypeAccessToken},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var subjectTokenType string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				subjectTokenType = r.FormValue("subject_token_type")
+				if r.FormValue("subject_token") != "SUBJECT_TOKEN" {
+					t.Errorf("subject_token wants SUBJECT_TOKEN but was %s", r.FormValue("subject_token"))
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"error":"invalid_request"}`))
+			}))
+			t.Cleanup(server.Close)
+			c := &client{
+				oauth2Config:   oauth2.Config{ClientID: "YOUR_CLIENT_ID", Endpoint: oauth2.Endpoint{TokenURL: server.URL}},
+				logger:         logger.New(t),
+				useAccessToken: tc.useAccessToken,
+			}
+			if _, err := c.ExchangeToken(context.TODO(), "SUBJECT_TOKEN"); err == nil {
+				t.Errorf("ExchangeToken wants an error but was nil")
+			}
+			if subjectTokenType != tc.want {
+				t.Errorf("subject_token_type wants %s but was %s", tc.want, subjectTokenType)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package transport
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// WithFormParams is a RoundTripper that adds parameters to the form body of each request.
+//
+// The device authorization and token requests of oauth2dev do not accept extra parameters,
+// such as audience or resource required by some providers.
+type WithFormParams struct {
+	Base   http.RoundTripper
+	Params map[string]string
+}
+
+func (t *WithFormParams) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body == nil || req.Header.Get("Content-Type") != "application/x-www-form-urlencoded" {
+		return t.Base.RoundTrip(req)
+	}
+	b, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, fmt.Errorf("could not read the request body: %w", err)
+	}
+	if err := req.Body.Close(); err != nil {
+		return nil, fmt.Errorf("could not close the request body: %w", err)
+	}
+	form, err := url.ParseQuery(string(b))
+	if err != nil {
+		return nil, fmt.Errorf("invalid form body: %w", err)
+	}
+	for key, value := range t.Params {
+		if !form.Has(key) {
+			form.Set(key, value)
+		}
+	}
+	body := []byte(form.Encode())
+	req = req.Clone(req.Context())
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(body)), nil }
+	req.ContentLength = int64(len(body))
+	req.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	return t.Base.RoundTrip(req)
+}
@@ -0,0 +1,46 @@
+package transport
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWithFormParams_RoundTrip(t *testing.T) {
+	req, err := http.NewRequest("POST", "https://issuer.example.com/device", strings.NewReader("client_id=YOUR_CLIENT_ID&scope=openid"))
+	if err != nil {
+		t.Fatalf("could not create a request: %s", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	base := &mockTransport{resp: &http.Response{StatusCode: 200}}
+	transport := &WithFormParams{
+		Base:   base,
+		Params: map[string]string{"audience": "https://api.example.com", "scope": "ignored"},
+	}
+	if _, err := transport.RoundTrip(req); err != nil {
+		t.Fatalf("RoundTrip error: %s", err)
+	}
+	b, err := io.ReadAll(base.req.Body)
+	if err != nil {
+		t.Fatalf("could not read the body: %s", err)
+	}
+	got, err := url.ParseQuery(string(b))
+	if err != nil {
+		t.Fatalf("invalid body: %s", err)
+	}
+	want := url.Values{
+		"client_id": {"YOUR_CLIENT_ID"},
+		"scope":     {"openid"},
+		"audience":  {"https://api.example.com"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("body mismatch (-want +got):\n%s", diff)
+	}
+	if base.req.ContentLength != int64(len(b)) {
+		t.Errorf("ContentLength wants %d but was %d", len(b), base.req.ContentLength)
+	}
+}
@@ -0,0 +1,53 @@
+package transport
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// ByHost is a RoundTripper that sends a request to the host by Base,
+// and a request to another host by Other.
+// The port is ignored, because TLS verifies the host name only.
+type ByHost struct {
+	Host  string // host name without port
+	Base  http.RoundTripper
+	Other http.RoundTripper
+}
+
+func (t *ByHost) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Hostname() == t.Host {
+		return t.Base.RoundTrip(req)
+	}
+	return t.Other.RoundTrip(req)
+}
+
+// ForIssuer returns a RoundTripper to the provider with the TLS config.
+//
+// The server name of the TLS config applies only to the host of the issuer,
+// because the discovery may return the endpoints on other hosts.
+// The other hosts are verified by their own names.
+func ForIssuer(tlsConfig *tls.Config, issuerURL string) (http.RoundTripper, error) {
+	issuerTransport := &http.Transport{
+		TLSClientConfig: tlsConfig,
+		Proxy:           http.ProxyFromEnvironment,
+	}
+	if tlsConfig.ServerName == "" {
+		return issuerTransport, nil
+	}
+	u, err := url.Parse(issuerURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid issuer URL: %w", err)
+	}
+	otherTLSConfig := tlsConfig.Clone()
+	otherTLSConfig.ServerName = ""
+	return &ByHost{
+		Host: u.Hostname(),
+		Base: issuerTransport,
+		Other: &http.Transport{
+			TLSClientConfig: otherTLSConfig,
+			Proxy:           http.ProxyFromEnvironment,
+		},
+	}, nil
+}
@@ -0,0 +1,61 @@
+package transport
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestByHost_RoundTrip(t *testing.T) {
+	base := &mockTransport{resp: &http.Response{StatusCode: 200}}
+	other := &mockTransport{resp: &http.Response{StatusCode: 200}}
+	transport := &ByHost{Host: "issuer.example.com", Base: base, Other: other}
+
+	issuerReq := httptest.NewRequest("GET", "https://issuer.example.com:443/.well-known/openid-configuration", nil)
+	if _, err := transport.RoundTrip(issuerReq); err != nil {
+		t.Fatalf("RoundTrip error: %s", err)
+	}
+	if base.req != issuerReq {
+		t.Errorf("request to the issuer wants to be sent by Base")
+	}
+
+	tokenReq := httptest.NewRequest("POST", "https://login.example.com/token", nil)
+	if _, err := transport.RoundTrip(tokenReq); err != nil {
+		t.Fatalf("RoundTrip error: %s", err)
+	}
+	if other.req != tokenReq {
+		t.Errorf("request to another host wants to be sent by Other")
+	}
+}
+
+func TestForIssuer(t *testing.T) {
+	t.Run("NoServerName", func(t *testing.T) {
+		got, err := ForIssuer(&tls.Config{}, "https://issuer.example.com")
+		if err != nil {
+			t.Fatalf("ForIssuer error: %s", err)
+		}
+		if _, ok := got.(*http.Transport); !ok {
+			t.Errorf("ForIssuer wants *http.Transport but was %T", got)
+		}
+	})
+	t.Run("ServerName", func(t *testing.T) {
+		got, err := ForIssuer(&tls.Config{ServerName: "sni.example.com"}, "https://issuer.example.com/realms/dev")
+		if err != nil {
+			t.Fatalf("ForIssuer error: %s", err)
+		}
+		byHost, ok := got.(*ByHost)
+		if !ok {
+			t.Fatalf("ForIssuer wants *ByHost but was %T", got)
+		}
+		if byHost.Host != "issuer.example.com" {
+			t.Errorf("Host wants issuer.example.com but was %s", byHost.Host)
+		}
+		if serverName := byHost.Base.(*http.Transport).TLSClientConfig.ServerName; serverName != "sni.example.com" {
+			t.Errorf("ServerName of the issuer wants sni.example.com but was %s", serverName)
+		}
+		if serverName := byHost.Other.(*http.Transport).TLSClientConfig.ServerName; serverName != "" {
+			t.Errorf("ServerName of another host wants empty but was %s", serverName)
+		}
+	})
+}
@@ -12,6 +12,8 @@ const (
 	levelDumpBody    = 3
 )
 
+// WithLogging dumps the requests and responses.
+// It masks the secrets such as Authorization header and tokens unless the logger is unredacted.
 type WithLogging struct {
 	Base   http.RoundTripper
 	Logger logger.Interface
@@ -27,7 +29,7 @@ func (t *WithLogging) RoundTrip(req *http.Request) (*http.Response, error) {
 		t.Logger.V(levelDumpHeaders).Infof("could not dump the request: %s", err)
 		return t.Base.RoundTrip(req)
 	}
-	t.Logger.V(levelDumpHeaders).Infof("%s", string(reqDump))
+	t.Logger.V(levelDumpHeaders).Infof("%s", t.redact(reqDump))
 	resp, err := t.Base.RoundTrip(req)
 	if err != nil {
 		return resp, err
@@ -37,6 +39,13 @@ func (t *WithLogging) RoundTrip(req *http.Request) (*http.Response, error) {
 		t.Logger.V(levelDumpHeaders).Infof("could not dump the response: %s", err)
 		return resp, err
 	}
-	t.Logger.V(levelDumpHeaders).Infof("%s", string(respDump))
+	t.Logger.V(levelDumpHeaders).Infof("%s", t.redact(respDump))
 	return resp, err
 }
+
+func (t *WithLogging) redact(dump []byte) string {
+	if t.Logger.IsUnredacted() {
+		return string(dump)
+	}
+	return logger.Redact(string(dump))
+}
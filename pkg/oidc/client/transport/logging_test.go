@@ -2,12 +2,14 @@ package transport
 
 import (
 	"bufio"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 
 	"github.com/togethercomputer/together-kubelogin/pkg/testing/logger"
+	"github.com/spf13/pflag"
 )
 
 type mockTransport struct {
@@ -47,3 +49,64 @@ dummy`)), req)
 		t.Errorf("resp wants %v but %v", resp, gotResp)
 	}
 }
+
+type recorder struct {
+	logs []string
+}
+
+func (r *recorder) Logf(format string, v ...interface{}) {
+	r.logs = append(r.logs, fmt.Sprintf(format, v...))
+}
+
+func TestWithLogging_RoundTrip_Redaction(t *testing.T) {
+	for name, c := range map[string]struct {
+		args        []string
+		contains    string
+		notContains []string
+	}{
+		"Redacted": {
+			args:        []string{"-v=3"},
+			contains:    "Authorization: Bearer REDACTED",
+			notContains: []string{"YOUR_TOKEN", "YOUR_CODE", "YOUR_ID_TOKEN"},
+		},
+		"Unredacted": {
+			args:     []string{"-v=3", "--log-unredacted"},
+			contains: "Authorization: Bearer YOUR_TOKEN",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "http://example.com/token", strings.NewReader("code=YOUR_CODE"))
+			req.Header.Set("Authorization", "Bearer YOUR_TOKEN")
+			resp, err := http.ReadResponse(bufio.NewReader(strings.NewReader(`HTTP/1.1 200 OK
+Content-Type: application/json
+
+{"id_token":"YOUR_ID_TOKEN"}`)), req)
+			if err != nil {
+				t.Fatalf("could not create a response: %s", err)
+			}
+			var r recorder
+			l := logger.New(&r)
+			f := pflag.NewFlagSet("", pflag.ContinueOnError)
+			l.AddFlags(f)
+			if err := f.Parse(c.args); err != nil {
+				t.Fatalf("could not parse the flags: %s", err)
+			}
+			transport := &WithLogging{
+				Base:   &mockTransport{resp: resp},
+				Logger: l,
+			}
+			if _, err := transport.RoundTrip(req); err != nil {
+				t.Fatalf("RoundTrip error: %s", err)
+			}
+			logs := strings.Join(r.logs, "\n")
+			if !strings.Contains(logs, c.contains) {
+				t.Errorf("log wants to contain %q but got:\n%s", c.contains, logs)
+			}
+			for _, secret := range c.notContains {
+				if strings.Contains(logs, secret) {
+					t.Errorf("log must not contain %s but got:\n%s", secret, logs)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package transport
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WithTracing is a RoundTripper that records a span of each request.
+// It propagates the trace context to the provider by traceparent header.
+// The query string is not recorded, because it may contain a secret.
+type WithTracing struct {
+	Base http.RoundTripper
+}
+
+func (t *WithTracing) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, span := tracing.Start(req.Context(), fmt.Sprintf("HTTP %s", req.Method),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", req.Method),
+			attribute.String("server.address", req.URL.Host),
+			attribute.String("url.path", req.URL.Path),
+		),
+	)
+	if !span.IsRecording() {
+		span.End()
+		return t.Base.RoundTrip(req)
+	}
+	req = req.Clone(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+	resp, err := t.Base.RoundTrip(req)
+	if err != nil {
+		tracing.End(span, err)
+		return resp, err
+	}
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode >= 400 {
+		tracing.End(span, fmt.Errorf("%s", resp.Status))
+		return resp, nil
+	}
+	tracing.End(span, nil)
+	return resp, nil
+}
@@ -0,0 +1,64 @@
+package transport
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestWithTracing_RoundTrip(t *testing.T) {
+	t.Run("Disabled", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "https://issuer.example.com/token?code=SECRET", nil)
+		base := &mockTransport{resp: &http.Response{StatusCode: 200}}
+		transport := &WithTracing{Base: base}
+		if _, err := transport.RoundTrip(req); err != nil {
+			t.Fatalf("RoundTrip error: %s", err)
+		}
+		if base.req != req {
+			t.Errorf("request wants as-is but was cloned")
+		}
+	})
+
+	t.Run("Enabled", func(t *testing.T) {
+		exporter := tracetest.NewInMemoryExporter()
+		provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+		otel.SetTracerProvider(provider)
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+		t.Cleanup(func() {
+			otel.SetTracerProvider(noop.NewTracerProvider())
+			otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+		})
+
+		req := httptest.NewRequest("POST", "https://issuer.example.com/token?code=SECRET", nil)
+		base := &mockTransport{resp: &http.Response{StatusCode: 400, Status: "400 Bad Request"}}
+		transport := &WithTracing{Base: base}
+		if _, err := transport.RoundTrip(req); err != nil {
+			t.Fatalf("RoundTrip error: %s", err)
+		}
+		if base.req.Header.Get("traceparent") == "" {
+			t.Errorf("traceparent header wants non-empty")
+		}
+		spans := exporter.GetSpans()
+		if len(spans) != 1 {
+			t.Fatalf("len(spans) wants 1 but %d", len(spans))
+		}
+		span := spans[0]
+		if span.Name != "HTTP POST" {
+			t.Errorf("span name wants HTTP POST but %s", span.Name)
+		}
+		if span.Status.Description != "400 Bad Request" {
+			t.Errorf("span status wants 400 Bad Request but %s", span.Status.Description)
+		}
+		for _, attr := range span.Attributes {
+			if attr.Value.Emit() == "SECRET" || attr.Value.Emit() == "code=SECRET" {
+				t.Errorf("span must not contain the query but %s=%s", attr.Key, attr.Value.Emit())
+			}
+		}
+	})
+}
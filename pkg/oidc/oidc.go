@@ -19,6 +19,11 @@ type Provider struct {
 	PKCEMethod     PKCEMethod
 	UseAccessToken bool
 	RequestHeaders map[string]string
+	// AuthenticationPolicy is requested in the authorization request
+	// and verified against the ID token.
+	AuthenticationPolicy AuthenticationPolicy // optional
+	Prompt               string               // optional, prompt of the authorization request
+	LoginHint            string               // optional, login_hint of the authorization request
 }
 
 // PKCEMethod represents a preferred method of PKCE.
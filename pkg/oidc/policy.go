@@ -0,0 +1,73 @@
+package oidc
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/togethercomputer/together-kubelogin/pkg/jwt"
+)
+
+// AuthenticationPolicy represents the requirements of an authentication,
+// such as multi-factor authentication or a recent login.
+// The zero value has no requirement.
+type AuthenticationPolicy struct {
+	ACRValues   []string      // optional, acr claim must be one of them
+	RequiredAMR []string      // optional, amr claim must contain all of them
+	MaxAge      time.Duration // optional, auth_time claim must be within it
+}
+
+// IsZero returns true if the policy has no requirement.
+func (p AuthenticationPolicy) IsZero() bool {
+	return len(p.ACRValues) == 0 && len(p.RequiredAMR) == 0 && p.MaxAge == 0
+}
+
+// AuthRequestParams returns the parameters of the authorization request.
+// See https://openid.net/specs/openid-connect-core-1_0.html#AuthRequest
+func (p AuthenticationPolicy) AuthRequestParams() map[string]string {
+	params := make(map[string]string)
+	if len(p.ACRValues) > 0 {
+		params["acr_values"] = strings.Join(p.ACRValues, " ")
+	}
+	if p.MaxAge > 0 {
+		params["max_age"] = strconv.FormatInt(int64(p.MaxAge/time.Second), 10)
+	}
+	return params
+}
+
+// Verify returns an error if the claims do not satisfy the policy.
+func (p AuthenticationPolicy) Verify(claims *jwt.Claims, now time.Time) error {
+	if len(p.ACRValues) > 0 && !slices.Contains(p.ACRValues, claims.ACR) {
+		return fmt.Errorf("acr %q is not one of %s", claims.ACR, strings.Join(p.ACRValues, ", "))
+	}
+	for _, method := range p.RequiredAMR {
+		if !slices.Contains(claims.AMR, method) {
+			return fmt.Errorf("amr %v does not contain %s", claims.AMR, method)
+		}
+	}
+	if p.MaxAge > 0 {
+		if claims.AuthTime.IsZero() {
+			return fmt.Errorf("auth_time is missing in the token")
+		}
+		if now.Sub(claims.AuthTime) > p.MaxAge {
+			return fmt.Errorf("authenticated at %s, which is older than the max age %s", claims.AuthTime, p.MaxAge)
+		}
+	}
+	return nil
+}
+
+// VerifyCachedTokenSet returns an error if the cached token does not satisfy the authentication policy.
+// It does not verify the signature, because the token has been verified before caching.
+// If UseAccessToken is set, it does nothing, because the access token may not have the claims.
+func (p Provider) VerifyCachedTokenSet(ts TokenSet, now time.Time) error {
+	if p.AuthenticationPolicy.IsZero() || p.UseAccessToken {
+		return nil
+	}
+	claims, err := ts.DecodeWithoutVerify()
+	if err != nil {
+		return fmt.Errorf("invalid token: %w", err)
+	}
+	return p.AuthenticationPolicy.Verify(claims, now)
+}
@@ -0,0 +1,67 @@
+package oidc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/togethercomputer/together-kubelogin/pkg/jwt"
+)
+
+func TestAuthenticationPolicy_Verify(t *testing.T) {
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	policy := AuthenticationPolicy{
+		ACRValues:   []string{"phr", "phrh"},
+		RequiredAMR: []string{"mfa"},
+		MaxAge:      time.Hour,
+	}
+	tests := map[string]struct {
+		claims  jwt.Claims
+		wantErr bool
+	}{
+		"Satisfied": {
+			claims: jwt.Claims{ACR: "phr", AMR: []string{"pwd", "mfa"}, AuthTime: now.Add(-time.Hour)},
+		},
+		"ACRNotAllowed": {
+			claims:  jwt.Claims{ACR: "0", AMR: []string{"mfa"}, AuthTime: now},
+			wantErr: true,
+		},
+		"AMRMissing": {
+			claims:  jwt.Claims{ACR: "phr", AMR: []string{"pwd"}, AuthTime: now},
+			wantErr: true,
+		},
+		"AuthTimeMissing": {
+			claims:  jwt.Claims{ACR: "phr", AMR: []string{"mfa"}},
+			wantErr: true,
+		},
+		"AuthTimeTooOld": {
+			claims:  jwt.Claims{ACR: "phr", AMR: []string{"mfa"}, AuthTime: now.Add(-time.Hour - time.Second)},
+			wantErr: true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := policy.Verify(&tc.claims, now)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("wantErr %v but got %v", tc.wantErr, err)
+			}
+		})
+	}
+	t.Run("ZeroValue", func(t *testing.T) {
+		if err := (AuthenticationPolicy{}).Verify(&jwt.Claims{}, now); err != nil {
+			t.Errorf("err wants nil but got %s", err)
+		}
+	})
+}
+
+func TestAuthenticationPolicy_AuthRequestParams(t *testing.T) {
+	policy := AuthenticationPolicy{
+		ACRValues:   []string{"phr", "phrh"},
+		RequiredAMR: []string{"mfa"},
+		MaxAge:      time.Hour,
+	}
+	want := map[string]string{"acr_values": "phr phrh", "max_age": "3600"}
+	if diff := cmp.Diff(want, policy.AuthRequestParams()); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
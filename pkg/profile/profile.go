@@ -0,0 +1,144 @@
+// Package profile provides the named profiles in the config file of kubelogin.
+//
+// The config file looks like:
+//
+//	profiles:
+//	  together-prod:
+//	    oidc-issuer-url: https://auth.together.ai
+//	    oidc-client-id: YOUR_CLIENT_ID
+//	    oidc-extra-scope: [email, profile]
+//	    token-cache-storage: keyring
+//
+// A key of a profile is the name of a flag of get-token.
+package profile
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+	"k8s.io/client-go/util/homedir"
+)
+
+// EnvName is the environment variable to override the path to the config file.
+const EnvName = "KUBELOGIN_CONFIG"
+
+// Filename returns the path to the config file.
+// It defaults to ~/.config/kubelogin/config.yaml.
+func Filename() string {
+	return FilenameOf(os.LookupEnv)
+}
+
+// FilenameOf returns the path to the config file by the given environment variables.
+func FilenameOf(lookupEnv func(string) (string, bool)) string {
+	if filename, ok := lookupEnv(EnvName); ok {
+		return filename
+	}
+	if configHome, ok := lookupEnv("XDG_CONFIG_HOME"); ok {
+		return filepath.Join(configHome, "kubelogin", "config.yaml")
+	}
+	return filepath.Join(homedir.HomeDir(), ".config", "kubelogin", "config.yaml")
+}
+
+// Profile represents a named set of options.
+type Profile struct {
+	Name     string
+	Filename string
+	Options  []Option
+}
+
+// Option represents an option in a profile.
+// A sequence has multiple values and a mapping has the values of KEY=VALUE.
+type Option struct {
+	Name   string
+	Values []string
+	Line   int
+}
+
+// Errorf returns an error which names the file and line of the option.
+func (p Profile) Errorf(o Option, format string, args ...any) error {
+	return fmt.Errorf("%s:%d: %s", p.Filename, o.Line, fmt.Sprintf(format, args...))
+}
+
+// Load returns the profile of the name in the config file.
+func Load(filename, name string) (*Profile, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("could not read the config file: %w", err)
+	}
+	var root yaml.Node
+	decoder := yaml.NewDecoder(bytes.NewReader(b))
+	if err := decoder.Decode(&root); err != nil {
+		return nil, fmt.Errorf("%s: invalid YAML: %w", filename, err)
+	}
+	if len(root.Content) == 0 {
+		return nil, fmt.Errorf("%s: profile %s is not found", filename, name)
+	}
+	doc := root.Content[0]
+	if doc.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s:%d: config must be a mapping", filename, doc.Line)
+	}
+	profiles := lookup(doc, "profiles")
+	if profiles == nil {
+		return nil, fmt.Errorf("%s: profiles is missing", filename)
+	}
+	if profiles.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s:%d: profiles must be a mapping", filename, profiles.Line)
+	}
+	node := lookup(profiles, name)
+	if node == nil {
+		return nil, fmt.Errorf("%s: profile %s is not found", filename, name)
+	}
+	if node.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s:%d: profile %s must be a mapping", filename, node.Line, name)
+	}
+	p := Profile{Name: name, Filename: filename}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		values, err := decodeValues(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %s: %w", filename, value.Line, key.Value, err)
+		}
+		p.Options = append(p.Options, Option{Name: key.Value, Values: values, Line: key.Line})
+	}
+	return &p, nil
+}
+
+func lookup(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func decodeValues(node *yaml.Node) ([]string, error) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return []string{node.Value}, nil
+	case yaml.SequenceNode:
+		var values []string
+		for _, e := range node.Content {
+			if e.Kind != yaml.ScalarNode {
+				return nil, fmt.Errorf("element of a sequence must be a scalar")
+			}
+			values = append(values, e.Value)
+		}
+		return values, nil
+	case yaml.MappingNode:
+		var values []string
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			k, v := node.Content[i], node.Content[i+1]
+			if v.Kind != yaml.ScalarNode {
+				return nil, fmt.Errorf("value of a mapping must be a scalar")
+			}
+			values = append(values, k.Value+"="+v.Value)
+		}
+		return values, nil
+	default:
+		return nil, fmt.Errorf("unsupported value")
+	}
+}
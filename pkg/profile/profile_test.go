@@ -0,0 +1,70 @@
+package profile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const config = `profiles:
+  together-prod:
+    oidc-issuer-url: https://auth.together.ai
+    oidc-client-id: YOUR_CLIENT_ID
+    oidc-extra-scope: [email, profile]
+    oidc-request-header:
+      Origin: localhost
+    insecure-skip-tls-verify: true
+  invalid:
+    oidc-extra-scope:
+    - [email]
+`
+
+func TestLoad(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(filename, []byte(config), 0600); err != nil {
+		t.Fatalf("WriteFile error: %s", err)
+	}
+
+	t.Run("Found", func(t *testing.T) {
+		got, err := Load(filename, "together-prod")
+		if err != nil {
+			t.Fatalf("Load error: %s", err)
+		}
+		want := &Profile{
+			Name:     "together-prod",
+			Filename: filename,
+			Options: []Option{
+				{Name: "oidc-issuer-url", Values: []string{"https://auth.together.ai"}, Line: 3},
+				{Name: "oidc-client-id", Values: []string{"YOUR_CLIENT_ID"}, Line: 4},
+				{Name: "oidc-extra-scope", Values: []string{"email", "profile"}, Line: 5},
+				{Name: "oidc-request-header", Values: []string{"Origin=localhost"}, Line: 6},
+				{Name: "insecure-skip-tls-verify", Values: []string{"true"}, Line: 8},
+			},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		_, err := Load(filename, "together-dev")
+		if err == nil {
+			t.Fatalf("Load wants an error but was nil")
+		}
+		if want := filename + ": profile together-dev is not found"; err.Error() != want {
+			t.Errorf("error wants %q but was %q", want, err.Error())
+		}
+	})
+
+	t.Run("InvalidValue", func(t *testing.T) {
+		_, err := Load(filename, "invalid")
+		if err == nil {
+			t.Fatalf("Load wants an error but was nil")
+		}
+		if want := filename + ":11: oidc-extra-scope: element of a sequence must be a scalar"; err.Error() != want {
+			t.Errorf("error wants %q but was %q", want, err.Error())
+		}
+	})
+}
@@ -0,0 +1,60 @@
+// Package applier provides the server-side apply of RBAC resources to a cluster.
+package applier
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/wire"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+var Set = wire.NewSet(
+	wire.Struct(new(Applier), "*"),
+	wire.Bind(new(Interface), new(*Applier)),
+)
+
+type Interface interface {
+	Apply(ctx context.Context, kubeconfigFilename string, obj *unstructured.Unstructured) error
+}
+
+// fieldManager is the field manager of server-side apply.
+const fieldManager = "kubelogin"
+
+var resources = map[schema.GroupVersionKind]schema.GroupVersionResource{
+	{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRoleBinding"}: {Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterrolebindings"},
+	{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "RoleBinding"}:        {Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "rolebindings"},
+}
+
+type Applier struct{}
+
+// Apply applies the resource with the current context of the kubeconfig.
+// If kubeconfigFilename is empty, it uses the default kubeconfig as kubectl.
+func (Applier) Apply(ctx context.Context, kubeconfigFilename string, obj *unstructured.Unstructured) error {
+	gvr, ok := resources[obj.GroupVersionKind()]
+	if !ok {
+		return fmt.Errorf("unsupported kind %s", obj.GroupVersionKind())
+	}
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = kubeconfigFilename
+	restConfig, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{}).ClientConfig()
+	if err != nil {
+		return fmt.Errorf("could not load the kubeconfig: %w", err)
+	}
+	client, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return fmt.Errorf("could not create a client: %w", err)
+	}
+	var resource dynamic.ResourceInterface = client.Resource(gvr)
+	if obj.GetNamespace() != "" {
+		resource = client.Resource(gvr).Namespace(obj.GetNamespace())
+	}
+	if _, err := resource.Apply(ctx, obj.GetName(), obj, metav1.ApplyOptions{FieldManager: fieldManager, Force: true}); err != nil {
+		return fmt.Errorf("could not apply %s %s: %w", obj.GetKind(), obj.GetName(), err)
+	}
+	return nil
+}
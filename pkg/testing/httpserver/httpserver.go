@@ -1,16 +1,31 @@
 package main
 
 import (
+	"html/template"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/togethercomputer/together-kubelogin/pkg/usecases/authentication/authcode"
 )
 
+var sampleData = authcode.LocalServerTemplateData{
+	Subject:          "YOUR_SUBJECT",
+	Email:            "you@example.com",
+	Expiry:           time.Now().Add(time.Hour),
+	Cluster:          "YOUR_CONTEXT",
+	Error:            "access_denied",
+	ErrorDescription: "The user denied the request",
+}
+
 func main() {
-	http.HandleFunc("/BrowserSuccessHTML", func(w http.ResponseWriter, r *http.Request) {
+	http.HandleFunc("/DefaultSuccessTemplate", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("content-type", "text/html")
+		_ = template.Must(template.New("").Parse(authcode.DefaultSuccessTemplate)).Execute(w, sampleData)
+	})
+	http.HandleFunc("/DefaultErrorTemplate", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Add("content-type", "text/html")
-		_, _ = w.Write([]byte(authcode.BrowserSuccessHTML))
+		_ = template.Must(template.New("").Parse(authcode.DefaultErrorTemplate)).Execute(w, sampleData)
 	})
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Add("content-type", "text/html")
@@ -18,7 +33,8 @@ func main() {
 <html>
 <body>
 <ul>
-<li><a href="BrowserSuccessHTML">BrowserSuccessHTML</a></li>
+<li><a href="DefaultSuccessTemplate">DefaultSuccessTemplate</a></li>
+<li><a href="DefaultErrorTemplate">DefaultErrorTemplate</a></li>
 </ul>
 </body>
 </html>
@@ -25,6 +25,7 @@ type Claims struct {
 	Audience      []string `json:"aud,omitempty"`
 	Nonce         string   `json:"nonce,omitempty"`
 	Groups        []string `json:"groups,omitempty"`
+	Email         string   `json:"email,omitempty"`
 	EmailVerified bool     `json:"email_verified,omitempty"`
 }
 
@@ -17,12 +17,15 @@ type testingLogger interface {
 
 // Logger provides logging facility using testing.T.
 type Logger struct {
-	t        testingLogger
-	maxLevel int
+	t          testingLogger
+	maxLevel   int
+	unredacted bool
 }
 
 func (l *Logger) AddFlags(f *pflag.FlagSet) {
 	f.IntVarP(&l.maxLevel, "v", "v", 0, "dummy flag used in the tests")
+	f.String("log-format", "text", "dummy flag used in the tests")
+	f.BoolVar(&l.unredacted, "log-unredacted", false, "dummy flag used in the tests")
 }
 
 func (l *Logger) Printf(format string, args ...interface{}) {
@@ -40,6 +43,14 @@ func (l *Logger) IsEnabled(level int) bool {
 	return level <= l.maxLevel
 }
 
+func (l *Logger) Event(event string, fields logger.Fields) {
+	l.t.Logf("event=%s %v", event, fields)
+}
+
+func (l *Logger) IsUnredacted() bool {
+	return l.unredacted
+}
+
 type verbose struct {
 	t     testingLogger
 	level int
@@ -6,6 +6,13 @@ import "crypto/tls"
 type Config struct {
 	CACertFilename []string
 	CACertData     []string
-	SkipTLSVerify  bool
-	Renegotiation  tls.RenegotiationSupport
+	// If set, the CA certificates are appended to the system cert pool.
+	// Otherwise, only the CA certificates are trusted.
+	UseSystemCertPool  bool
+	ClientCertFilename string
+	ClientKeyFilename  string
+	MinVersion         uint16 // default to the crypto/tls default
+	ServerName         string // default to the host of the URL, applied only to the issuer host
+	SkipTLSVerify      bool
+	Renegotiation      tls.RenegotiationSupport
 }
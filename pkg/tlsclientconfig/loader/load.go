@@ -27,7 +27,10 @@ type Interface interface {
 type Loader struct{}
 
 func (l *Loader) Load(config tlsclientconfig.Config) (*tls.Config, error) {
-	rootCAs := x509.NewCertPool()
+	rootCAs, err := newCertPool(config)
+	if err != nil {
+		return nil, err
+	}
 	for _, f := range config.CACertFilename {
 		if err := addFile(rootCAs, f); err != nil {
 			return nil, fmt.Errorf("could not load the certificate from %s: %w", f, err)
@@ -38,15 +41,40 @@ func (l *Loader) Load(config tlsclientconfig.Config) (*tls.Config, error) {
 			return nil, fmt.Errorf("could not load the certificate: %w", err)
 		}
 	}
-	if rootCAs.Equal(x509.NewCertPool()) {
-		// if empty, use the host's root CA set
-		rootCAs = nil
-	}
-	return &tls.Config{
+	tlsConfig := &tls.Config{
 		RootCAs:            rootCAs,
+		MinVersion:         config.MinVersion,
+		ServerName:         config.ServerName,
 		InsecureSkipVerify: config.SkipTLSVerify,
 		Renegotiation:      config.Renegotiation,
-	}, nil
+	}
+	if config.ClientCertFilename != "" || config.ClientKeyFilename != "" {
+		if config.ClientCertFilename == "" || config.ClientKeyFilename == "" {
+			return nil, errors.New("both client certificate and key must be set")
+		}
+		cert, err := tls.LoadX509KeyPair(config.ClientCertFilename, config.ClientKeyFilename)
+		if err != nil {
+			return nil, fmt.Errorf("could not load the client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+// newCertPool returns a pool to add the CA certificates.
+// It returns nil if no CA certificate is given, so that the host's root CA set is used.
+func newCertPool(config tlsclientconfig.Config) (*x509.CertPool, error) {
+	if len(config.CACertFilename) == 0 && len(config.CACertData) == 0 {
+		return nil, nil
+	}
+	if !config.UseSystemCertPool {
+		return x509.NewCertPool(), nil
+	}
+	p, err := x509.SystemCertPool()
+	if err != nil {
+		return nil, fmt.Errorf("could not load the system cert pool: %w", err)
+	}
+	return p, nil
 }
 
 func addFile(p *x509.CertPool, filename string) error {
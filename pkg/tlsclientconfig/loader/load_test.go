@@ -1,6 +1,8 @@
 package loader
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"os"
 	"testing"
 
@@ -48,6 +50,59 @@ func TestLoader_Load(t *testing.T) {
 			t.Errorf("RootCAs wants non-nil but was nil")
 		}
 	})
+	t.Run("UseSystemCertPool", func(t *testing.T) {
+		systemCertPool, err := x509.SystemCertPool()
+		if err != nil {
+			t.Skipf("SystemCertPool error: %s", err)
+		}
+		cfg, err := loader.Load(tlsclientconfig.Config{
+			CACertFilename:    []string{"testdata/ca1.crt"},
+			UseSystemCertPool: true,
+		})
+		if err != nil {
+			t.Fatalf("Load error: %s", err)
+		}
+		if cfg.RootCAs == nil {
+			t.Fatalf("RootCAs wants non-nil but was nil")
+		}
+		if cfg.RootCAs.Equal(systemCertPool) {
+			t.Errorf("RootCAs wants the system cert pool and the CA but was the system cert pool")
+		}
+		if err := addFile(systemCertPool, "testdata/ca1.crt"); err != nil {
+			t.Fatalf("addFile error: %s", err)
+		}
+		if !cfg.RootCAs.Equal(systemCertPool) {
+			t.Errorf("RootCAs wants the system cert pool and the CA")
+		}
+	})
+	t.Run("ClientCertificate", func(t *testing.T) {
+		cfg, err := loader.Load(tlsclientconfig.Config{
+			ClientCertFilename: "testdata/client.crt",
+			ClientKeyFilename:  "testdata/client.key",
+			MinVersion:         tls.VersionTLS12,
+			ServerName:         "idp.example.com",
+		})
+		if err != nil {
+			t.Fatalf("Load error: %s", err)
+		}
+		if len(cfg.Certificates) != 1 {
+			t.Errorf("len(Certificates) wants 1 but was %d", len(cfg.Certificates))
+		}
+		if cfg.MinVersion != tls.VersionTLS12 {
+			t.Errorf("MinVersion wants %d but was %d", tls.VersionTLS12, cfg.MinVersion)
+		}
+		if cfg.ServerName != "idp.example.com" {
+			t.Errorf("ServerName wants idp.example.com but was %s", cfg.ServerName)
+		}
+	})
+	t.Run("ClientCertificateWithoutKey", func(t *testing.T) {
+		_, err := loader.Load(tlsclientconfig.Config{
+			ClientCertFilename: "testdata/client.crt",
+		})
+		if err == nil {
+			t.Errorf("Load wants an error but was nil")
+		}
+	})
 }
 
 func readFile(t *testing.T, filename string) string {
@@ -0,0 +1,109 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/togethercomputer/together-kubelogin/pkg/oidc"
+	"github.com/togethercomputer/together-kubelogin/pkg/testing/logger"
+	"github.com/togethercomputer/together-kubelogin/pkg/tokencache"
+	"github.com/togethercomputer/together-kubelogin/pkg/tokencache/repository"
+)
+
+func TestRepository(t *testing.T) {
+	key := tokencache.Key{
+		Provider: oidc.Provider{
+			IssuerURL: "YOUR_ISSUER",
+			ClientID:  "YOUR_CLIENT_ID",
+		},
+	}
+	tokenSet := oidc.TokenSet{IDToken: "YOUR_ID_TOKEN", RefreshToken: "YOUR_REFRESH_TOKEN"}
+
+	t.Run("WithAgent", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.TODO())
+		defer cancel()
+		// a path in t.TempDir() may exceed the limit of the socket path
+		socketDir, err := os.MkdirTemp("", "kubelogin")
+		if err != nil {
+			t.Fatalf("MkdirTemp error: %s", err)
+		}
+		t.Cleanup(func() { _ = os.RemoveAll(socketDir) })
+		socket := filepath.Join(socketDir, "agent", "agent.sock")
+		l, err := Listen(socket)
+		if err != nil {
+			t.Fatalf("Listen error: %s", err)
+		}
+		serveErr := make(chan error, 1)
+		go func() { serveErr <- Serve(ctx, l, &repository.Repository{}, logger.New(t)) }()
+		t.Setenv(SocketEnvName, socket)
+
+		config := tokencache.Config{Directory: t.TempDir(), Storage: tokencache.StorageDisk}
+		r := &Repository{Local: &repository.Repository{}, Logger: logger.New(t)}
+		lock, err := r.Lock(config, key)
+		if err != nil {
+			t.Fatalf("Lock error: %s", err)
+		}
+		if err := r.Save(config, key, tokenSet); err != nil {
+			t.Fatalf("Save error: %s", err)
+		}
+		got, err := r.FindByKey(config, key)
+		if err != nil {
+			t.Fatalf("FindByKey error: %s", err)
+		}
+		if diff := cmp.Diff(&tokenSet, got); diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+		if err := lock.Close(); err != nil {
+			t.Errorf("Close error: %s", err)
+		}
+
+		// the agent writes through the local token cache
+		local, err := (&repository.Repository{}).FindByKey(config, key)
+		if err != nil {
+			t.Fatalf("FindByKey error: %s", err)
+		}
+		if diff := cmp.Diff(&tokenSet, local); diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+
+		if err := r.DeleteAll(config); err != nil {
+			t.Fatalf("DeleteAll error: %s", err)
+		}
+		if _, err := r.FindByKey(config, key); err == nil {
+			t.Errorf("FindByKey wants an error but got nil")
+		}
+
+		if _, err := Listen(socket); err == nil {
+			t.Errorf("Listen wants an error while the agent is running")
+		}
+		cancel()
+		select {
+		case err := <-serveErr:
+			if err != nil {
+				t.Errorf("Serve error: %s", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Errorf("Serve did not stop")
+		}
+	})
+
+	t.Run("FallbackToLocal", func(t *testing.T) {
+		t.Setenv(SocketEnvName, filepath.Join(t.TempDir(), "no-such-agent.sock"))
+		config := tokencache.Config{Directory: t.TempDir(), Storage: tokencache.StorageDisk}
+		r := &Repository{Local: &repository.Repository{}, Logger: logger.New(t)}
+		if err := r.Save(config, key, tokenSet); err != nil {
+			t.Fatalf("Save error: %s", err)
+		}
+		got, err := (&repository.Repository{}).FindByKey(config, key)
+		if err != nil {
+			t.Fatalf("FindByKey error: %s", err)
+		}
+		if diff := cmp.Diff(&tokenSet, got); diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
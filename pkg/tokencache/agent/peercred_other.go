@@ -0,0 +1,20 @@
+//go:build !linux && !darwin
+
+package agent
+
+import (
+	"errors"
+	"net"
+)
+
+var errUnsupportedPlatform = errors.New("the agent is not supported on this platform, because it cannot verify the peer credential")
+
+// checkPlatform returns an error, so that the agent and the client fail at startup
+// instead of rejecting every connection.
+func checkPlatform() error {
+	return errUnsupportedPlatform
+}
+
+func peerUID(*net.UnixConn) (int, error) {
+	return 0, errUnsupportedPlatform
+}
@@ -0,0 +1,35 @@
+// Package agent provides the token cache served by the kubelogin agent over a Unix domain socket.
+//
+// A request and response are a line of JSON.
+// The agent holds a lock while the connection of the Lock request is open.
+package agent
+
+import (
+	"github.com/togethercomputer/together-kubelogin/pkg/oidc"
+	"github.com/togethercomputer/together-kubelogin/pkg/tokencache"
+)
+
+// SocketEnvName is the environment variable of the path to the socket.
+// If it is set, get-token uses the agent.
+const SocketEnvName = "KUBELOGIN_AGENT_SOCK"
+
+type method string
+
+const (
+	methodFindByKey method = "FindByKey"
+	methodSave      method = "Save"
+	methodLock      method = "Lock"
+	methodDeleteAll method = "DeleteAll"
+)
+
+type request struct {
+	Method   method            `json:"method"`
+	Config   tokencache.Config `json:"config"`
+	Key      tokencache.Key    `json:"key"`
+	TokenSet *oidc.TokenSet    `json:"tokenSet,omitempty"`
+}
+
+type response struct {
+	TokenSet *oidc.TokenSet `json:"tokenSet,omitempty"`
+	Error    string         `json:"error,omitempty"`
+}
@@ -0,0 +1,142 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"time"
+
+	"github.com/google/wire"
+	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/logger"
+	"github.com/togethercomputer/together-kubelogin/pkg/oidc"
+	"github.com/togethercomputer/together-kubelogin/pkg/tokencache"
+	"github.com/togethercomputer/together-kubelogin/pkg/tokencache/repository"
+)
+
+// Set provides the token cache which uses the agent if available.
+var Set = wire.NewSet(
+	wire.Struct(new(repository.Repository)),
+	wire.Struct(new(Repository), "*"),
+	wire.Bind(new(repository.Interface), new(*Repository)),
+)
+
+const dialTimeout = time.Second
+
+// Repository provides access to the token cache via the agent.
+// If KUBELOGIN_AGENT_SOCK is not set or the agent is not available,
+// it falls back to the local repository.
+type Repository struct {
+	Local  *repository.Repository
+	Logger logger.Interface
+}
+
+func (r *Repository) FindByKey(config tokencache.Config, key tokencache.Key) (*oidc.TokenSet, error) {
+	conn, err := r.dial()
+	if err != nil {
+		return nil, err
+	}
+	if conn == nil {
+		return r.Local.FindByKey(config, key)
+	}
+	defer conn.Close()
+	resp, err := roundTrip(conn, request{Method: methodFindByKey, Config: config, Key: key})
+	if err != nil {
+		return nil, err
+	}
+	return resp.TokenSet, nil
+}
+
+func (r *Repository) Save(config tokencache.Config, key tokencache.Key, tokenSet oidc.TokenSet) error {
+	conn, err := r.dial()
+	if err != nil {
+		return err
+	}
+	if conn == nil {
+		return r.Local.Save(config, key, tokenSet)
+	}
+	defer conn.Close()
+	_, err = roundTrip(conn, request{Method: methodSave, Config: config, Key: key, TokenSet: &tokenSet})
+	return err
+}
+
+// Lock returns the connection to the agent.
+// The agent releases the lock when the connection is closed.
+func (r *Repository) Lock(config tokencache.Config, key tokencache.Key) (io.Closer, error) {
+	conn, err := r.dial()
+	if err != nil {
+		return nil, err
+	}
+	if conn == nil {
+		return r.Local.Lock(config, key)
+	}
+	if _, err := roundTrip(conn, request{Method: methodLock, Config: config, Key: key}); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+func (r *Repository) DeleteAll(config tokencache.Config) error {
+	conn, err := r.dial()
+	if err != nil {
+		return err
+	}
+	if conn == nil {
+		return r.Local.DeleteAll(config)
+	}
+	defer conn.Close()
+	_, err = roundTrip(conn, request{Method: methodDeleteAll, Config: config})
+	return err
+}
+
+// LockAuthentication acquires the lock in the local repository,
+// because the browser is opened on this host.
+func (r *Repository) LockAuthentication(config tokencache.Config, marker tokencache.AuthenticationMarker) (io.Closer, error) {
+	return r.Local.LockAuthentication(config, marker)
+}
+
+func (r *Repository) FindAuthenticationMarker(config tokencache.Config, issuerURL, clientID string) (*tokencache.AuthenticationMarker, error) {
+	return r.Local.FindAuthenticationMarker(config, issuerURL, clientID)
+}
+
+// FindLatestByProvider reads the local repository,
+// because the agent writes through the token cache to it.
+func (r *Repository) FindLatestByProvider(config tokencache.Config, key tokencache.Key, since time.Time) (*tokencache.LatestTokenSet, error) {
+	return r.Local.FindLatestByProvider(config, key, since)
+}
+
+// dial returns a connection to the agent, or nil if the agent is not available.
+// It returns an error if the agent is set on an unsupported platform.
+func (r *Repository) dial() (net.Conn, error) {
+	socket := os.Getenv(SocketEnvName)
+	if socket == "" {
+		return nil, nil
+	}
+	if err := checkPlatform(); err != nil {
+		return nil, fmt.Errorf("%s is set: %w", SocketEnvName, err)
+	}
+	conn, err := net.DialTimeout("unix", socket, dialTimeout)
+	if err != nil {
+		r.Logger.V(1).Infof("the agent is not available, falling back to the local token cache: %s", err)
+		return nil, nil
+	}
+	r.Logger.V(1).Infof("using the agent at %s", socket)
+	return conn, nil
+}
+
+func roundTrip(conn net.Conn, req request) (*response, error) {
+	if err := json.NewEncoder(conn).Encode(&req); err != nil {
+		return nil, fmt.Errorf("could not send the request to the agent: %w", err)
+	}
+	var resp response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("could not receive the response from the agent: %w", err)
+	}
+	if resp.Error != "" {
+		return nil, errors.New(resp.Error)
+	}
+	return &resp, nil
+}
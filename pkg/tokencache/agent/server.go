@@ -0,0 +1,132 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+
+	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/logger"
+	"github.com/togethercomputer/together-kubelogin/pkg/tokencache/repository"
+)
+
+// Listen creates the socket which is accessible only by the current user.
+// It removes the stale socket if exists.
+func Listen(socket string) (net.Listener, error) {
+	if err := checkPlatform(); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(socket), 0700); err != nil {
+		return nil, fmt.Errorf("could not create the directory of the socket: %w", err)
+	}
+	if conn, err := net.DialTimeout("unix", socket, dialTimeout); err == nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("another agent is running at %s", socket)
+	}
+	if err := os.Remove(socket); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("could not remove the stale socket: %w", err)
+	}
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		return nil, fmt.Errorf("could not listen on the socket: %w", err)
+	}
+	if err := os.Chmod(socket, 0600); err != nil {
+		_ = l.Close()
+		return nil, fmt.Errorf("could not change the permission of the socket: %w", err)
+	}
+	return l, nil
+}
+
+// Serve handles the requests by the backend until the context is done.
+// It rejects a connection from another user.
+func Serve(ctx context.Context, l net.Listener, backend repository.Interface, log logger.Interface) error {
+	go func() {
+		<-ctx.Done()
+		_ = l.Close()
+	}()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("could not accept a connection: %w", err)
+		}
+		go func() {
+			defer conn.Close()
+			if err := checkPeer(conn); err != nil {
+				log.Printf("rejected a connection: %s", err)
+				return
+			}
+			if err := handle(conn, backend); err != nil {
+				log.V(1).Infof("error while handling a request: %s", err)
+			}
+		}()
+	}
+}
+
+func checkPeer(conn net.Conn) error {
+	unixConn, ok := conn.(*net.UnixConn)
+	if !ok {
+		return errors.New("not a unix domain socket")
+	}
+	uid, err := peerUID(unixConn)
+	if err != nil {
+		return fmt.Errorf("could not get the peer credential: %w", err)
+	}
+	if uid != os.Getuid() {
+		return fmt.Errorf("the peer uid %d is not the current user", uid)
+	}
+	return nil
+}
+
+func handle(conn net.Conn, backend repository.Interface) error {
+	var req request
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		return fmt.Errorf("invalid request: %w", err)
+	}
+	var resp response
+	switch req.Method {
+	case methodFindByKey:
+		tokenSet, err := backend.FindByKey(req.Config, req.Key)
+		if err != nil {
+			resp.Error = err.Error()
+		}
+		resp.TokenSet = tokenSet
+	case methodSave:
+		if req.TokenSet == nil {
+			resp.Error = "tokenSet is missing"
+			break
+		}
+		if err := backend.Save(req.Config, req.Key, *req.TokenSet); err != nil {
+			resp.Error = err.Error()
+		}
+	case methodLock:
+		lock, err := backend.Lock(req.Config, req.Key)
+		if err != nil {
+			resp.Error = err.Error()
+			break
+		}
+		defer lock.Close()
+		if err := json.NewEncoder(conn).Encode(&resp); err != nil {
+			return fmt.Errorf("could not send the response: %w", err)
+		}
+		// hold the lock until the client closes the connection
+		_, _ = io.Copy(io.Discard, conn)
+		return nil
+	case methodDeleteAll:
+		if err := backend.DeleteAll(req.Config); err != nil {
+			resp.Error = err.Error()
+		}
+	default:
+		resp.Error = fmt.Sprintf("unknown method %s", req.Method)
+	}
+	if err := json.NewEncoder(conn).Encode(&resp); err != nil {
+		return fmt.Errorf("could not send the response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gofrs/flock"
+	"github.com/togethercomputer/together-kubelogin/pkg/oidc"
+	"github.com/togethercomputer/together-kubelogin/pkg/tokencache"
+)
+
+// LockAuthentication acquires the lock of authentication for the issuer and client ID of the marker.
+// This lock is shared by all keys of the same provider,
+// so that only one process opens the browser even if the keys are different.
+// The marker is published until the lock is released.
+func (r *Repository) LockAuthentication(config tokencache.Config, marker tokencache.AuthenticationMarker) (io.Closer, error) {
+	if config.Storage == tokencache.StorageNone {
+		return noneStorageCloser{}, nil
+	}
+	if err := os.MkdirAll(config.Directory, 0700); err != nil {
+		return nil, fmt.Errorf("could not create directory %s: %w", config.Directory, err)
+	}
+	lockFilepath, markerFilepath := authenticationFilepaths(config, marker.IssuerURL, marker.ClientID)
+	lockFile := flock.New(lockFilepath)
+	if err := lockFile.Lock(); err != nil {
+		return nil, fmt.Errorf("could not lock the file %s: %w", lockFilepath, err)
+	}
+	b, err := json.Marshal(&marker)
+	if err != nil {
+		_ = lockFile.Unlock()
+		return nil, fmt.Errorf("could not encode the marker: %w", err)
+	}
+	if err := os.WriteFile(markerFilepath, b, 0600); err != nil {
+		_ = lockFile.Unlock()
+		return nil, fmt.Errorf("could not write the marker %s: %w", markerFilepath, err)
+	}
+	return &authenticationLock{lockFile: lockFile, markerFilepath: markerFilepath}, nil
+}
+
+type authenticationLock struct {
+	lockFile       *flock.Flock
+	markerFilepath string
+}
+
+func (l *authenticationLock) Close() error {
+	if err := os.Remove(l.markerFilepath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		_ = l.lockFile.Unlock()
+		return fmt.Errorf("could not remove the marker: %w", err)
+	}
+	return l.lockFile.Unlock()
+}
+
+// FindAuthenticationMarker returns the marker of the authentication in progress.
+// It returns nil if no process is authenticating,
+// or the marker is left by a process which has exited without releasing the lock.
+func (r *Repository) FindAuthenticationMarker(config tokencache.Config, issuerURL, clientID string) (*tokencache.AuthenticationMarker, error) {
+	if config.Storage == tokencache.StorageNone {
+		return nil, nil
+	}
+	lockFilepath, markerFilepath := authenticationFilepaths(config, issuerURL, clientID)
+	b, err := os.ReadFile(markerFilepath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("could not read the marker: %w", err)
+	}
+	var marker tokencache.AuthenticationMarker
+	if err := json.Unmarshal(b, &marker); err != nil {
+		return nil, fmt.Errorf("invalid marker: %w", err)
+	}
+	lockFile := flock.New(lockFilepath)
+	locked, err := lockFile.TryLock()
+	if err != nil {
+		return nil, fmt.Errorf("could not check the lock %s: %w", lockFilepath, err)
+	}
+	if locked {
+		// no process holds the lock
+		_ = lockFile.Unlock()
+		return nil, nil
+	}
+	return &marker, nil
+}
+
+// latestTokenCache points to the token cache saved last for the provider.
+// A process which has waited for the authentication of another process reads it,
+// because the token cache key of the other process may be different.
+type latestTokenCache struct {
+	Checksum string    `json:"checksum"`
+	Username string    `json:"username,omitempty"`
+	SavedAt  time.Time `json:"saved_at"`
+}
+
+func writeLatestTokenCache(config tokencache.Config, key tokencache.Key, checksum string) error {
+	if err := os.MkdirAll(config.Directory, 0700); err != nil {
+		return fmt.Errorf("could not create directory %s: %w", config.Directory, err)
+	}
+	b, err := json.Marshal(&latestTokenCache{
+		Checksum: checksum,
+		Username: key.Username,
+		SavedAt:  time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("could not encode the latest token cache: %w", err)
+	}
+	p := latestTokenCacheFilepath(config, key.Provider.IssuerURL, key.Provider.ClientID)
+	if err := os.WriteFile(p, b, 0600); err != nil {
+		return fmt.Errorf("could not write the latest token cache %s: %w", p, err)
+	}
+	return nil
+}
+
+// FindLatestByProvider returns the token set saved last for the issuer, client ID and username of the key,
+// even if the other fields of the key are different.
+// It returns nil if no token set has been saved since the time.
+func (r *Repository) FindLatestByProvider(config tokencache.Config, key tokencache.Key, since time.Time) (*tokencache.LatestTokenSet, error) {
+	if config.Storage == tokencache.StorageNone {
+		return nil, nil
+	}
+	p := latestTokenCacheFilepath(config, key.Provider.IssuerURL, key.Provider.ClientID)
+	b, err := os.ReadFile(p)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("could not read the latest token cache: %w", err)
+	}
+	var latest latestTokenCache
+	if err := json.Unmarshal(b, &latest); err != nil {
+		return nil, fmt.Errorf("invalid latest token cache: %w", err)
+	}
+	if latest.Username != key.Username || latest.SavedAt.Before(since) {
+		return nil, nil
+	}
+	checksum, err := computeChecksum(key)
+	if err != nil {
+		return nil, fmt.Errorf("could not compute the key: %w", err)
+	}
+	var tokenSet *oidc.TokenSet
+	switch config.Storage {
+	case tokencache.StorageDisk:
+		tokenSet, err = readFromFile(config, latest.Checksum)
+	case tokencache.StorageKeyring:
+		tokenSet, err = readFromKeyring(latest.Checksum)
+	default:
+		return nil, fmt.Errorf("unknown storage mode: %v", config.Storage)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &tokencache.LatestTokenSet{TokenSet: *tokenSet, SameKey: latest.Checksum == checksum}, nil
+}
+
+func authenticationFilepaths(config tokencache.Config, issuerURL, clientID string) (lockFilepath, markerFilepath string) {
+	name := "authentication-" + providerChecksum(issuerURL, clientID)
+	return filepath.Join(config.Directory, name+".lock"), filepath.Join(config.Directory, name+".json")
+}
+
+func latestTokenCacheFilepath(config tokencache.Config, issuerURL, clientID string) string {
+	return filepath.Join(config.Directory, "latest-"+providerChecksum(issuerURL, clientID)+".json")
+}
+
+func providerChecksum(issuerURL, clientID string) string {
+	s := sha256.New()
+	_, _ = fmt.Fprintf(s, "%s\x00%s", issuerURL, clientID)
+	return hex.EncodeToString(s.Sum(nil))
+}
@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/togethercomputer/together-kubelogin/pkg/oidc"
+	"github.com/togethercomputer/together-kubelogin/pkg/tlsclientconfig"
+	"github.com/togethercomputer/together-kubelogin/pkg/tokencache"
+)
+
+func TestRepository_LockAuthentication(t *testing.T) {
+	var r Repository
+	marker := tokencache.AuthenticationMarker{
+		PID:       12345,
+		IssuerURL: "YOUR_ISSUER",
+		ClientID:  "YOUR_CLIENT_ID",
+		StartedAt: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		config := tokencache.Config{Directory: t.TempDir(), Storage: tokencache.StorageDisk}
+		got, err := r.FindAuthenticationMarker(config, "YOUR_ISSUER", "YOUR_CLIENT_ID")
+		if err != nil {
+			t.Fatalf("FindAuthenticationMarker error: %s", err)
+		}
+		if got != nil {
+			t.Errorf("marker wants nil but got %+v", got)
+		}
+
+		lock, err := r.LockAuthentication(config, marker)
+		if err != nil {
+			t.Fatalf("LockAuthentication error: %s", err)
+		}
+		got, err = r.FindAuthenticationMarker(config, "YOUR_ISSUER", "YOUR_CLIENT_ID")
+		if err != nil {
+			t.Fatalf("FindAuthenticationMarker error: %s", err)
+		}
+		if diff := cmp.Diff(&marker, got); diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+		got, err = r.FindAuthenticationMarker(config, "YOUR_ISSUER", "ANOTHER_CLIENT_ID")
+		if err != nil {
+			t.Fatalf("FindAuthenticationMarker error: %s", err)
+		}
+		if got != nil {
+			t.Errorf("marker of another client wants nil but got %+v", got)
+		}
+
+		if err := lock.Close(); err != nil {
+			t.Fatalf("Close error: %s", err)
+		}
+		got, err = r.FindAuthenticationMarker(config, "YOUR_ISSUER", "YOUR_CLIENT_ID")
+		if err != nil {
+			t.Fatalf("FindAuthenticationMarker error: %s", err)
+		}
+		if got != nil {
+			t.Errorf("marker wants nil after unlock but got %+v", got)
+		}
+	})
+
+	t.Run("StaleMarker", func(t *testing.T) {
+		config := tokencache.Config{Directory: t.TempDir(), Storage: tokencache.StorageDisk}
+		lock, err := r.LockAuthentication(config, marker)
+		if err != nil {
+			t.Fatalf("LockAuthentication error: %s", err)
+		}
+		// simulate a process exited without removing the marker
+		if err := lock.(*authenticationLock).lockFile.Unlock(); err != nil {
+			t.Fatalf("Unlock error: %s", err)
+		}
+		got, err := r.FindAuthenticationMarker(config, "YOUR_ISSUER", "YOUR_CLIENT_ID")
+		if err != nil {
+			t.Fatalf("FindAuthenticationMarker error: %s", err)
+		}
+		if got != nil {
+			t.Errorf("marker wants nil but got %+v", got)
+		}
+	})
+}
+
+func TestRepository_FindLatestByProvider(t *testing.T) {
+	var r Repository
+	provider := oidc.Provider{IssuerURL: "YOUR_ISSUER", ClientID: "YOUR_CLIENT_ID"}
+	savedKey := tokencache.Key{
+		Provider:        provider,
+		TLSClientConfig: tlsclientconfig.Config{CACertFilename: []string{"/path/to/cert1"}},
+	}
+	tokenSet := oidc.TokenSet{IDToken: "YOUR_ID_TOKEN", RefreshToken: "YOUR_REFRESH_TOKEN"}
+
+	t.Run("DifferentKeyOfSameProvider", func(t *testing.T) {
+		config := tokencache.Config{Directory: t.TempDir(), Storage: tokencache.StorageDisk}
+		since := time.Now().Add(-time.Second)
+		if err := r.Save(config, savedKey, tokenSet); err != nil {
+			t.Fatalf("Save error: %s", err)
+		}
+		key := tokencache.Key{
+			Provider:        provider,
+			TLSClientConfig: tlsclientconfig.Config{CACertFilename: []string{"/path/to/cert2"}},
+		}
+		got, err := r.FindLatestByProvider(config, key, since)
+		if err != nil {
+			t.Fatalf("FindLatestByProvider error: %s", err)
+		}
+		want := &tokencache.LatestTokenSet{TokenSet: tokenSet}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+	})
+	t.Run("DifferentScopes", func(t *testing.T) {
+		config := tokencache.Config{Directory: t.TempDir(), Storage: tokencache.StorageDisk}
+		since := time.Now().Add(-time.Second)
+		if err := r.Save(config, savedKey, tokenSet); err != nil {
+			t.Fatalf("Save error: %s", err)
+		}
+		key := savedKey
+		key.Provider.ExtraScopes = []string{"groups"}
+		got, err := r.FindLatestByProvider(config, key, since)
+		if err != nil {
+			t.Fatalf("FindLatestByProvider error: %s", err)
+		}
+		want := &tokencache.LatestTokenSet{TokenSet: tokenSet}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+	})
+	t.Run("SameKey", func(t *testing.T) {
+		config := tokencache.Config{Directory: t.TempDir(), Storage: tokencache.StorageDisk}
+		since := time.Now().Add(-time.Second)
+		if err := r.Save(config, savedKey, tokenSet); err != nil {
+			t.Fatalf("Save error: %s", err)
+		}
+		got, err := r.FindLatestByProvider(config, savedKey, since)
+		if err != nil {
+			t.Fatalf("FindLatestByProvider error: %s", err)
+		}
+		want := &tokencache.LatestTokenSet{TokenSet: tokenSet, SameKey: true}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+	})
+	t.Run("DifferentUsername", func(t *testing.T) {
+		config := tokencache.Config{Directory: t.TempDir(), Storage: tokencache.StorageDisk}
+		since := time.Now().Add(-time.Second)
+		if err := r.Save(config, savedKey, tokenSet); err != nil {
+			t.Fatalf("Save error: %s", err)
+		}
+		got, err := r.FindLatestByProvider(config, tokencache.Key{Provider: provider, Username: "USER"}, since)
+		if err != nil {
+			t.Fatalf("FindLatestByProvider error: %s", err)
+		}
+		if got != nil {
+			t.Errorf("token set wants nil but got %+v", got)
+		}
+	})
+	t.Run("SavedBeforeSince", func(t *testing.T) {
+		config := tokencache.Config{Directory: t.TempDir(), Storage: tokencache.StorageDisk}
+		if err := r.Save(config, savedKey, tokenSet); err != nil {
+			t.Fatalf("Save error: %s", err)
+		}
+		got, err := r.FindLatestByProvider(config, savedKey, time.Now().Add(time.Second))
+		if err != nil {
+			t.Fatalf("FindLatestByProvider error: %s", err)
+		}
+		if got != nil {
+			t.Errorf("token set wants nil but got %+v", got)
+		}
+	})
+	t.Run("NotFound", func(t *testing.T) {
+		config := tokencache.Config{Directory: t.TempDir(), Storage: tokencache.StorageDisk}
+		got, err := r.FindLatestByProvider(config, savedKey, time.Time{})
+		if err != nil {
+			t.Fatalf("FindLatestByProvider error: %s", err)
+		}
+		if got != nil {
+			t.Errorf("token set wants nil but got %+v", got)
+		}
+	})
+}
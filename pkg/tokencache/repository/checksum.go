@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+
+	"github.com/togethercomputer/together-kubelogin/pkg/oidc"
+	"github.com/togethercomputer/together-kubelogin/pkg/tokencache"
+)
+
+// computeChecksum returns the name of the token cache for the key.
+//
+// Gob encodes the names and fields of the types,
+// so adding a field to tokencache.Key changes the checksum and orphans the existing token caches.
+// To keep the checksum stable, the key is encoded in the layout of the first release,
+// and a field added later is encoded only if it is set.
+func computeChecksum(key tokencache.Key) (string, error) {
+	// These types must not be changed.
+	type Provider struct {
+		IssuerURL      string
+		ClientID       string
+		ClientSecret   string
+		ExtraScopes    []string
+		RedirectURL    string
+		PKCEMethod     oidc.PKCEMethod
+		UseAccessToken bool
+		RequestHeaders map[string]string
+	}
+	type Config struct {
+		CACertFilename []string
+		CACertData     []string
+		SkipTLSVerify  bool
+		Renegotiation  int
+	}
+	type Key struct {
+		Provider        Provider
+		TLSClientConfig Config
+		Username        string
+	}
+	// Add a field here to include it in the checksum.
+	type extension struct {
+		AuthenticationPolicy oidc.AuthenticationPolicy
+		Prompt               string
+		LoginHint            string
+		UseSystemCertPool    bool
+		ClientCertFilename   string
+		ClientKeyFilename    string
+		TLSMinVersion        uint16
+		TLSServerName        string
+	}
+
+	s := sha256.New()
+	e := gob.NewEncoder(s)
+	if err := e.Encode(&Key{
+		Provider: Provider{
+			IssuerURL:      key.Provider.IssuerURL,
+			ClientID:       key.Provider.ClientID,
+			ClientSecret:   key.Provider.ClientSecret,
+			ExtraScopes:    key.Provider.ExtraScopes,
+			RedirectURL:    key.Provider.RedirectURL,
+			PKCEMethod:     key.Provider.PKCEMethod,
+			UseAccessToken: key.Provider.UseAccessToken,
+			RequestHeaders: key.Provider.RequestHeaders,
+		},
+		TLSClientConfig: Config{
+			CACertFilename: key.TLSClientConfig.CACertFilename,
+			CACertData:     key.TLSClientConfig.CACertData,
+			SkipTLSVerify:  key.TLSClientConfig.SkipTLSVerify,
+			Renegotiation:  int(key.TLSClientConfig.Renegotiation),
+		},
+		Username: key.Username,
+	}); err != nil {
+		return "", fmt.Errorf("could not encode the key: %w", err)
+	}
+	ext := extension{
+		AuthenticationPolicy: key.Provider.AuthenticationPolicy,
+		Prompt:               key.Provider.Prompt,
+		LoginHint:            key.Provider.LoginHint,
+		UseSystemCertPool:    key.TLSClientConfig.UseSystemCertPool,
+		ClientCertFilename:   key.TLSClientConfig.ClientCertFilename,
+		ClientKeyFilename:    key.TLSClientConfig.ClientKeyFilename,
+		TLSMinVersion:        key.TLSClientConfig.MinVersion,
+		TLSServerName:        key.TLSClientConfig.ServerName,
+	}
+	if !reflect.ValueOf(ext).IsZero() {
+		if err := e.Encode(&ext); err != nil {
+			return "", fmt.Errorf("could not encode the key: %w", err)
+		}
+	}
+	h := hex.EncodeToString(s.Sum(nil))
+	return h, nil
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"crypto/tls"
+	"testing"
+	"time"
+
+	"github.com/togethercomputer/together-kubelogin/pkg/oidc"
+	"github.com/togethercomputer/together-kubelogin/pkg/tlsclientconfig"
+	"github.com/togethercomputer/together-kubelogin/pkg/tokencache"
+)
+
+func Test_computeChecksum(t *testing.T) {
+	minimalKey := tokencache.Key{
+		Provider: oidc.Provider{
+			IssuerURL: "https://issuer.example.com",
+			ClientID:  "YOUR_CLIENT_ID",
+		},
+	}
+	fullKey := tokencache.Key{
+		Provider: oidc.Provider{
+			IssuerURL:      "https://issuer.example.com",
+			ClientID:       "YOUR_CLIENT_ID",
+			ClientSecret:   "YOUR_CLIENT_SECRET",
+			ExtraScopes:    []string{"email"},
+			RedirectURL:    "http://localhost:8000",
+			PKCEMethod:     oidc.PKCEMethodS256,
+			UseAccessToken: true,
+			RequestHeaders: map[string]string{"X-Foo": "bar"},
+		},
+		TLSClientConfig: tlsclientconfig.Config{
+			CACertFilename: []string{"/path/to/cert"},
+			CACertData:     []string{"base64"},
+			SkipTLSVerify:  true,
+			Renegotiation:  tls.RenegotiateOnceAsClient,
+		},
+		Username: "USER",
+	}
+
+	// The checksums must not be changed, or the existing token caches are orphaned.
+	t.Run("StableForMinimalKey", func(t *testing.T) {
+		assertChecksum(t, minimalKey, "0170e28c112925d561cc680d00143a6acf47abd2af991d971c06824fec04ad95")
+	})
+	t.Run("StableForFullKey", func(t *testing.T) {
+		assertChecksum(t, fullKey, "aa6b05aee72a27e5fe85f5765b293fd03b5bceb54b4593f42e3793949b4e5e8e")
+	})
+
+	t.Run("ExtensionChangesChecksum", func(t *testing.T) {
+		for name, key := range map[string]tokencache.Key{
+			"AuthenticationPolicy": withProvider(minimalKey, func(p *oidc.Provider) {
+				p.AuthenticationPolicy = oidc.AuthenticationPolicy{MaxAge: time.Hour}
+			}),
+			"Prompt":    withProvider(minimalKey, func(p *oidc.Provider) { p.Prompt = "login" }),
+			"LoginHint": withProvider(minimalKey, func(p *oidc.Provider) { p.LoginHint = "alice" }),
+			"UseSystemCertPool": withTLSClientConfig(minimalKey, func(c *tlsclientconfig.Config) {
+				c.UseSystemCertPool = true
+			}),
+			"ClientCertificate": withTLSClientConfig(minimalKey, func(c *tlsclientconfig.Config) {
+				c.ClientCertFilename = "/path/to/client.crt"
+				c.ClientKeyFilename = "/path/to/client.key"
+			}),
+			"MinVersion": withTLSClientConfig(minimalKey, func(c *tlsclientconfig.Config) { c.MinVersion = tls.VersionTLS13 }),
+			"ServerName": withTLSClientConfig(minimalKey, func(c *tlsclientconfig.Config) { c.ServerName = "idp.example.com" }),
+		} {
+			t.Run(name, func(t *testing.T) {
+				base, err := computeChecksum(minimalKey)
+				if err != nil {
+					t.Fatalf("computeChecksum error: %s", err)
+				}
+				got, err := computeChecksum(key)
+				if err != nil {
+					t.Fatalf("computeChecksum error: %s", err)
+				}
+				if got == base {
+					t.Errorf("checksum wants to be changed but was %s", got)
+				}
+			})
+		}
+	})
+}
+
+func assertChecksum(t *testing.T, key tokencache.Key, want string) {
+	t.Helper()
+	got, err := computeChecksum(key)
+	if err != nil {
+		t.Fatalf("computeChecksum error: %s", err)
+	}
+	if got != want {
+		t.Errorf("checksum wants %s but was %s", want, got)
+	}
+}
+
+func withProvider(key tokencache.Key, f func(p *oidc.Provider)) tokencache.Key {
+	f(&key.Provider)
+	return key
+}
+
+func withTLSClientConfig(key tokencache.Key, f func(c *tlsclientconfig.Config)) tokencache.Key {
+	f(&key.TLSClientConfig)
+	return key
+}
@@ -1,14 +1,12 @@
 package repository
 
 import (
-	"crypto/sha256"
-	"encoding/gob"
-	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/gofrs/flock"
 	"github.com/google/wire"
@@ -28,6 +26,9 @@ type Interface interface {
 	Save(config tokencache.Config, key tokencache.Key, tokenSet oidc.TokenSet) error
 	Lock(config tokencache.Config, key tokencache.Key) (io.Closer, error)
 	DeleteAll(config tokencache.Config) error
+	LockAuthentication(config tokencache.Config, marker tokencache.AuthenticationMarker) (io.Closer, error)
+	FindAuthenticationMarker(config tokencache.Config, issuerURL, clientID string) (*tokencache.AuthenticationMarker, error)
+	FindLatestByProvider(config tokencache.Config, key tokencache.Key, since time.Time) (*tokencache.LatestTokenSet, error)
 }
 
 type entity struct {
@@ -109,14 +110,18 @@ func (r *Repository) Save(config tokencache.Config, key tokencache.Key, tokenSet
 	}
 	switch config.Storage {
 	case tokencache.StorageDisk:
-		return writeToFile(config, checksum, tokenSet)
+		err = writeToFile(config, checksum, tokenSet)
 	case tokencache.StorageKeyring:
-		return writeToKeyring(checksum, tokenSet)
+		err = writeToKeyring(checksum, tokenSet)
 	case tokencache.StorageNone:
 		return nil
 	default:
 		return fmt.Errorf("unknown storage mode: %v", config.Storage)
 	}
+	if err != nil {
+		return err
+	}
+	return writeLatestTokenCache(config, key, checksum)
 }
 
 func writeToFile(config tokencache.Config, checksum string, tokenSet oidc.TokenSet) error {
@@ -200,13 +205,3 @@ func encodeKey(tokenSet oidc.TokenSet) ([]byte, error) {
 	}
 	return json.Marshal(&e)
 }
-
-func computeChecksum(key tokencache.Key) (string, error) {
-	s := sha256.New()
-	e := gob.NewEncoder(s)
-	if err := e.Encode(&key); err != nil {
-		return "", fmt.Errorf("could not encode the key: %w", err)
-	}
-	h := hex.EncodeToString(s.Sum(nil))
-	return h, nil
-}
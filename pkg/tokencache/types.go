@@ -1,6 +1,8 @@
 package tokencache
 
 import (
+	"time"
+
 	"github.com/togethercomputer/together-kubelogin/pkg/oidc"
 	"github.com/togethercomputer/together-kubelogin/pkg/tlsclientconfig"
 )
@@ -12,6 +14,24 @@ type Key struct {
 	Username        string
 }
 
+// AuthenticationMarker represents an authentication in progress by a process.
+// Other processes can wait for the authentication instead of starting another one.
+type AuthenticationMarker struct {
+	PID       int       `json:"pid"`
+	IssuerURL string    `json:"issuer_url"`
+	ClientID  string    `json:"client_id"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// LatestTokenSet represents the token set saved last for a provider.
+type LatestTokenSet struct {
+	TokenSet oidc.TokenSet
+	// SameKey is set if the token set was saved with the same key.
+	// Otherwise, only the refresh token can be used,
+	// because the scopes or TLS options may be different.
+	SameKey bool
+}
+
 // Config represents a configuration for the token cache.
 type Config struct {
 	// Directory is a path to the directory to store a token cache.
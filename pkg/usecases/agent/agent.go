@@ -0,0 +1,273 @@
+// Package agent provides the use-case of the token agent.
+package agent
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/google/wire"
+	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/clock"
+	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/logger"
+	"github.com/togethercomputer/together-kubelogin/pkg/oidc"
+	"github.com/togethercomputer/together-kubelogin/pkg/tokencache"
+	tokencacheagent "github.com/togethercomputer/together-kubelogin/pkg/tokencache/agent"
+	"github.com/togethercomputer/together-kubelogin/pkg/tokencache/repository"
+	"github.com/togethercomputer/together-kubelogin/pkg/usecases/authentication"
+)
+
+var Set = wire.NewSet(
+	wire.Struct(new(Agent), "*"),
+	wire.Bind(new(Interface), new(*Agent)),
+)
+
+type Interface interface {
+	Do(ctx context.Context, in Input) error
+}
+
+// Input represents an input DTO of the Agent use-case.
+type Input struct {
+	Socket        string
+	RefreshBefore time.Duration
+}
+
+// Agent serves the token cache in memory over a Unix domain socket.
+// It writes through the token cache to the local repository,
+// and refreshes a token before the expiry.
+type Agent struct {
+	Authentication  authentication.Interface
+	LocalRepository *repository.Repository
+	Clock           clock.Interface
+	Logger          logger.Interface
+}
+
+func (u *Agent) Do(ctx context.Context, in Input) error {
+	l, err := tokencacheagent.Listen(in.Socket)
+	if err != nil {
+		return err
+	}
+	u.Logger.Printf("%s=%s; export %s", tokencacheagent.SocketEnvName, in.Socket, tokencacheagent.SocketEnvName)
+	m := &memory{
+		ctx:            ctx,
+		authentication: u.Authentication,
+		local:          u.LocalRepository,
+		clock:          u.Clock,
+		logger:         u.Logger,
+		refreshBefore:  in.RefreshBefore,
+		entries:        make(map[string]*entry),
+	}
+	defer m.stop()
+	if err := tokencacheagent.Serve(ctx, l, m, u.Logger); err != nil {
+		return fmt.Errorf("agent error: %w", err)
+	}
+	u.Logger.Printf("stopped the agent")
+	return nil
+}
+
+// entry represents a token cache in memory.
+type entry struct {
+	mu       sync.Mutex // held while locked by a client or refreshing
+	config   tokencache.Config
+	key      tokencache.Key
+	tokenSet *oidc.TokenSet
+	timer    *time.Timer
+}
+
+// memory implements repository.Interface in memory.
+type memory struct {
+	ctx            context.Context
+	authentication authentication.Interface
+	local          repository.Interface
+	clock          clock.Interface
+	logger         logger.Interface
+	refreshBefore  time.Duration
+
+	mu      sync.Mutex // guards entries
+	entries map[string]*entry
+}
+
+// entryKey returns the key of an entry.
+// The token cache config is a part of the key, because the local storage depends on it.
+func entryKey(config tokencache.Config, key tokencache.Key) string {
+	return fmt.Sprintf("%#v", struct {
+		tokencache.Config
+		tokencache.Key
+	}{config, key})
+}
+
+func (m *memory) entry(config tokencache.Config, key tokencache.Key) *entry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	k := entryKey(config, key)
+	e, ok := m.entries[k]
+	if !ok {
+		e = &entry{config: config, key: key}
+		m.entries[k] = e
+	}
+	return e
+}
+
+// FindByKey returns the token in memory.
+// If not found, it reads the local repository.
+// This is called while the client holds the lock of the entry.
+func (m *memory) FindByKey(config tokencache.Config, key tokencache.Key) (*oidc.TokenSet, error) {
+	e := m.entry(config, key)
+	m.mu.Lock()
+	tokenSet := e.tokenSet
+	m.mu.Unlock()
+	if tokenSet != nil {
+		return tokenSet, nil
+	}
+	tokenSet, err := m.local.FindByKey(config, key)
+	if err != nil {
+		return nil, err
+	}
+	m.store(e, tokenSet)
+	return tokenSet, nil
+}
+
+// Save writes the token to memory and the local repository.
+func (m *memory) Save(config tokencache.Config, key tokencache.Key, tokenSet oidc.TokenSet) error {
+	e := m.entry(config, key)
+	if err := m.local.Save(config, key, tokenSet); err != nil {
+		return err
+	}
+	m.store(e, &tokenSet)
+	return nil
+}
+
+// Lock locks the entry in memory and the local repository.
+func (m *memory) Lock(config tokencache.Config, key tokencache.Key) (io.Closer, error) {
+	e := m.entry(config, key)
+	e.mu.Lock()
+	localLock, err := m.local.Lock(config, key)
+	if err != nil {
+		e.mu.Unlock()
+		return nil, err
+	}
+	return entryLock{entry: e, local: localLock}, nil
+}
+
+// DeleteAll deletes the tokens in memory and the local repository.
+func (m *memory) DeleteAll(config tokencache.Config) error {
+	m.mu.Lock()
+	for k, e := range m.entries {
+		if e.config == config {
+			if e.timer != nil {
+				e.timer.Stop()
+			}
+			delete(m.entries, k)
+		}
+	}
+	m.mu.Unlock()
+	return m.local.DeleteAll(config)
+}
+
+func (m *memory) LockAuthentication(config tokencache.Config, marker tokencache.AuthenticationMarker) (io.Closer, error) {
+	return m.local.LockAuthentication(config, marker)
+}
+
+func (m *memory) FindAuthenticationMarker(config tokencache.Config, issuerURL, clientID string) (*tokencache.AuthenticationMarker, error) {
+	return m.local.FindAuthenticationMarker(config, issuerURL, clientID)
+}
+
+func (m *memory) FindLatestByProvider(config tokencache.Config, key tokencache.Key, since time.Time) (*tokencache.LatestTokenSet, error) {
+	return m.local.FindLatestByProvider(config, key, since)
+}
+
+type entryLock struct {
+	entry *entry
+	local io.Closer
+}
+
+func (l entryLock) Close() error {
+	defer l.entry.mu.Unlock()
+	return l.local.Close()
+}
+
+// store sets the token and schedules the refresh.
+func (m *memory) store(e *entry, tokenSet *oidc.TokenSet) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e.tokenSet = tokenSet
+	if e.timer != nil {
+		e.timer.Stop()
+		e.timer = nil
+	}
+	if tokenSet.RefreshToken == "" {
+		return
+	}
+	claims, err := tokenSet.DecodeWithoutVerify()
+	if err != nil {
+		return
+	}
+	delay := max(claims.Expiry.Sub(m.clock.Now())-m.refreshBefore, 0)
+	m.logger.V(1).Infof("scheduled the refresh of the token for %s in %s", e.key.Provider.IssuerURL, delay)
+	e.timer = time.AfterFunc(delay, func() { m.refresh(e) })
+}
+
+// refresh refreshes the token by the refresh token.
+// It holds the same lock of the local repository as get-token.
+// If it fails, the entry is dropped and a client will authenticate by itself.
+func (m *memory) refresh(e *entry) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	m.mu.Lock()
+	tokenSet := e.tokenSet
+	m.mu.Unlock()
+	if tokenSet == nil || m.ctx.Err() != nil {
+		return
+	}
+	localLock, err := m.local.Lock(e.config, e.key)
+	if err != nil {
+		m.logger.Printf("could not lock the token cache: %s", err)
+		return
+	}
+	defer func() {
+		if err := localLock.Close(); err != nil {
+			m.logger.Printf("could not unlock the token cache: %s", err)
+		}
+	}()
+	out, err := m.authentication.Do(m.ctx, authentication.Input{
+		Provider:        e.key.Provider,
+		TLSClientConfig: e.key.TLSClientConfig,
+		CachedTokenSet:  tokenSet,
+		RefreshOnly:     true,
+	})
+	if err != nil {
+		m.logger.Printf("could not refresh the token for %s: %s", e.key.Provider.IssuerURL, err)
+		m.drop(e)
+		return
+	}
+	if err := m.local.Save(e.config, e.key, out.TokenSet); err != nil {
+		m.logger.Printf("could not write the token cache: %s", err)
+	}
+	m.store(e, &out.TokenSet)
+	m.logger.V(1).Infof("refreshed the token for %s", e.key.Provider.IssuerURL)
+}
+
+// drop removes the entry from memory.
+func (m *memory) drop(e *entry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if e.timer != nil {
+		e.timer.Stop()
+		e.timer = nil
+	}
+	k := entryKey(e.config, e.key)
+	if m.entries[k] == e {
+		delete(m.entries, k)
+	}
+}
+
+func (m *memory) stop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, e := range m.entries {
+		if e.timer != nil {
+			e.timer.Stop()
+		}
+	}
+}
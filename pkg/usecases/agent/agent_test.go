@@ -0,0 +1,205 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/go-cmp/cmp"
+	"github.com/togethercomputer/together-kubelogin/mocks/github.com/togethercomputer/together-kubelogin/pkg/usecases/authentication_mock"
+	"github.com/togethercomputer/together-kubelogin/pkg/oidc"
+	"github.com/togethercomputer/together-kubelogin/pkg/testing/clock"
+	testingJWT "github.com/togethercomputer/together-kubelogin/pkg/testing/jwt"
+	"github.com/togethercomputer/together-kubelogin/pkg/testing/logger"
+	"github.com/togethercomputer/together-kubelogin/pkg/tokencache"
+	"github.com/togethercomputer/together-kubelogin/pkg/tokencache/repository"
+	"github.com/togethercomputer/together-kubelogin/pkg/usecases/authentication"
+)
+
+func TestMemory(t *testing.T) {
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC).Local()
+	key := tokencache.Key{
+		Provider: oidc.Provider{
+			IssuerURL: "https://accounts.google.com",
+			ClientID:  "YOUR_CLIENT_ID",
+		},
+	}
+	newTokenSet := func(expiry time.Time, refreshToken string) oidc.TokenSet {
+		return oidc.TokenSet{
+			IDToken: testingJWT.EncodeF(t, func(claims *testingJWT.Claims) {
+				claims.Issuer = "https://accounts.google.com"
+				claims.Subject = "YOUR_SUBJECT"
+				claims.ExpiresAt = jwt.NewNumericDate(expiry)
+			}),
+			RefreshToken: refreshToken,
+		}
+	}
+
+	t.Run("FindByKeyFromLocal", func(t *testing.T) {
+		config := tokencache.Config{Directory: t.TempDir(), Storage: tokencache.StorageDisk}
+		tokenSet := newTokenSet(now.Add(time.Hour), "")
+		var local repository.Repository
+		if err := local.Save(config, key, tokenSet); err != nil {
+			t.Fatalf("Save error: %s", err)
+		}
+		m := &memory{
+			ctx:            context.TODO(),
+			authentication: authentication_mock.NewMockInterface(t),
+			local:          &local,
+			clock:          clock.Fake(now),
+			logger:         logger.New(t),
+			refreshBefore:  time.Minute,
+			entries:        make(map[string]*entry),
+		}
+		defer m.stop()
+		got, err := m.FindByKey(config, key)
+		if err != nil {
+			t.Fatalf("FindByKey error: %s", err)
+		}
+		if diff := cmp.Diff(&tokenSet, got); diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+		if err := local.DeleteAll(config); err != nil {
+			t.Fatalf("DeleteAll error: %s", err)
+		}
+		// it should return the token in memory
+		got, err = m.FindByKey(config, key)
+		if err != nil {
+			t.Fatalf("FindByKey error: %s", err)
+		}
+		if diff := cmp.Diff(&tokenSet, got); diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("RefreshBeforeExpiry", func(t *testing.T) {
+		ctx := context.TODO()
+		config := tokencache.Config{Directory: t.TempDir(), Storage: tokencache.StorageDisk}
+		// the refresh is scheduled immediately, because it expires within refreshBefore
+		tokenSet := newTokenSet(now.Add(30*time.Second), "YOUR_REFRESH_TOKEN")
+		refreshedTokenSet := newTokenSet(now.Add(time.Hour), "NEW_REFRESH_TOKEN")
+		refreshed := make(chan struct{})
+		mockAuthentication := authentication_mock.NewMockInterface(t)
+		mockAuthentication.EXPECT().
+			Do(ctx, authentication.Input{
+				Provider:       key.Provider,
+				CachedTokenSet: &tokenSet,
+				RefreshOnly:    true,
+			}).
+			RunAndReturn(func(context.Context, authentication.Input) (*authentication.Output, error) {
+				defer close(refreshed)
+				return &authentication.Output{TokenSet: refreshedTokenSet}, nil
+			})
+		var local repository.Repository
+		m := &memory{
+			ctx:            ctx,
+			authentication: mockAuthentication,
+			local:          &local,
+			clock:          clock.Fake(now),
+			logger:         logger.New(t),
+			refreshBefore:  time.Minute,
+			entries:        make(map[string]*entry),
+		}
+		defer m.stop()
+		lock, err := m.Lock(config, key)
+		if err != nil {
+			t.Fatalf("Lock error: %s", err)
+		}
+		if err := m.Save(config, key, tokenSet); err != nil {
+			t.Fatalf("Save error: %s", err)
+		}
+		if err := lock.Close(); err != nil {
+			t.Fatalf("Close error: %s", err)
+		}
+		select {
+		case <-refreshed:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("the token was not refreshed")
+		}
+
+		// wait for the refresh to release the lock
+		lock, err = m.Lock(config, key)
+		if err != nil {
+			t.Fatalf("Lock error: %s", err)
+		}
+		defer lock.Close()
+		got, err := m.FindByKey(config, key)
+		if err != nil {
+			t.Fatalf("FindByKey error: %s", err)
+		}
+		if diff := cmp.Diff(&refreshedTokenSet, got); diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+		got, err = local.FindByKey(config, key)
+		if err != nil {
+			t.Fatalf("FindByKey error: %s", err)
+		}
+		if diff := cmp.Diff(&refreshedTokenSet, got); diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+	})
+	t.Run("DropOnRefreshError", func(t *testing.T) {
+		ctx := context.TODO()
+		config := tokencache.Config{Directory: t.TempDir(), Storage: tokencache.StorageDisk}
+		tokenSet := newTokenSet(now.Add(30*time.Second), "YOUR_REFRESH_TOKEN")
+		refreshed := make(chan struct{})
+		mockAuthentication := authentication_mock.NewMockInterface(t)
+		mockAuthentication.EXPECT().
+			Do(ctx, authentication.Input{
+				Provider:       key.Provider,
+				CachedTokenSet: &tokenSet,
+				RefreshOnly:    true,
+			}).
+			RunAndReturn(func(context.Context, authentication.Input) (*authentication.Output, error) {
+				defer close(refreshed)
+				return nil, errors.New("invalid_grant")
+			})
+		var local repository.Repository
+		m := &memory{
+			ctx:            ctx,
+			authentication: mockAuthentication,
+			local:          &local,
+			clock:          clock.Fake(now),
+			logger:         logger.New(t),
+			refreshBefore:  time.Minute,
+			entries:        make(map[string]*entry),
+		}
+		defer m.stop()
+		lock, err := m.Lock(config, key)
+		if err != nil {
+			t.Fatalf("Lock error: %s", err)
+		}
+		if err := m.Save(config, key, tokenSet); err != nil {
+			t.Fatalf("Save error: %s", err)
+		}
+		if err := lock.Close(); err != nil {
+			t.Fatalf("Close error: %s", err)
+		}
+		select {
+		case <-refreshed:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("the token was not refreshed")
+		}
+
+		// wait for the refresh to release the lock
+		lock, err = m.Lock(config, key)
+		if err != nil {
+			t.Fatalf("Lock error: %s", err)
+		}
+		defer lock.Close()
+		m.mu.Lock()
+		n := len(m.entries)
+		m.mu.Unlock()
+		if n != 1 {
+			t.Errorf("entries wants 1 (a new one by Lock) but was %d", n)
+		}
+		m.mu.Lock()
+		got := m.entries[entryKey(config, key)].tokenSet
+		m.mu.Unlock()
+		if got != nil {
+			t.Errorf("tokenSet wants nil but was %+v", got)
+		}
+	})
+}
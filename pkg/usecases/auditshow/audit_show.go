@@ -0,0 +1,122 @@
+// Package auditshow provides the use-case of showing the audit log.
+package auditshow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/wire"
+	"github.com/togethercomputer/together-kubelogin/pkg/audit"
+	auditrepository "github.com/togethercomputer/together-kubelogin/pkg/audit/repository"
+	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/clock"
+	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/stdio"
+)
+
+var Set = wire.NewSet(
+	wire.Struct(new(AuditShow), "*"),
+	wire.Bind(new(Interface), new(*AuditShow)),
+)
+
+type Interface interface {
+	Do(ctx context.Context, in Input) error
+}
+
+// Format represents the output format of the records.
+type Format string
+
+const (
+	FormatTable Format = "table"
+	FormatJSON  Format = "json" // JSON lines as stored
+)
+
+// Input represents an input DTO of the AuditShow use-case.
+type Input struct {
+	AuditConfig audit.Config
+	Filter      Filter
+	Format      Format
+}
+
+// Filter represents the conditions of records to show.
+// A zero value field matches any record.
+type Filter struct {
+	Since   time.Duration // relative to now
+	Event   audit.Event
+	Issuer  string
+	Subject string
+	Context string
+}
+
+func (f Filter) match(r audit.Record, now time.Time) bool {
+	switch {
+	case f.Since > 0 && r.Time.Before(now.Add(-f.Since)):
+		return false
+	case f.Event != "" && r.Event != f.Event:
+		return false
+	case f.Issuer != "" && r.Issuer != f.Issuer:
+		return false
+	case f.Subject != "" && r.Subject != f.Subject:
+		return false
+	case f.Context != "" && r.Context != f.Context:
+		return false
+	}
+	return true
+}
+
+// AuditShow shows the records in the audit log.
+type AuditShow struct {
+	AuditRepository auditrepository.Interface
+	Clock           clock.Interface
+	Stdout          stdio.Stdout
+}
+
+func (u *AuditShow) Do(ctx context.Context, in Input) error {
+	records, err := u.AuditRepository.FindAll(in.AuditConfig)
+	if err != nil {
+		return fmt.Errorf("could not read the audit log: %w", err)
+	}
+	now := u.Clock.Now()
+	var matched []audit.Record
+	for _, r := range records {
+		if in.Filter.match(r, now) {
+			matched = append(matched, r)
+		}
+	}
+	if err := u.print(in.Format, matched); err != nil {
+		return fmt.Errorf("could not write the records: %w", err)
+	}
+	return nil
+}
+
+func (u *AuditShow) print(format Format, records []audit.Record) error {
+	if format == FormatJSON {
+		e := json.NewEncoder(u.Stdout)
+		for _, r := range records {
+			if err := e.Encode(&r); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	w := tabwriter.NewWriter(u.Stdout, 0, 0, 2, ' ', 0)
+	if _, err := fmt.Fprintln(w, "TIME\tEVENT\tGRANT\tISSUER\tSUBJECT\tCONTEXT\tEXPIRY\tERROR"); err != nil {
+		return err
+	}
+	for _, r := range records {
+		var expiry string
+		if r.Expiry != nil {
+			expiry = r.Expiry.Format(time.RFC3339)
+		}
+		where := r.Context
+		if where == "" {
+			where = r.Cluster
+		}
+		if _, err := fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			r.Time.Format(time.RFC3339), r.Event, r.Grant, r.Issuer, r.Subject, where, expiry, r.ErrorClass); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
@@ -0,0 +1,97 @@
+package auditshow
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/togethercomputer/together-kubelogin/mocks/github.com/togethercomputer/together-kubelogin/pkg/audit/repository_mock"
+	"github.com/togethercomputer/together-kubelogin/pkg/audit"
+	"github.com/togethercomputer/together-kubelogin/pkg/testing/clock"
+)
+
+func TestAuditShow_Do(t *testing.T) {
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	expiry := now.Add(time.Hour)
+	config := audit.Config{Filename: "/path/to/audit.log"}
+	records := []audit.Record{
+		{
+			Time:       now.Add(-48 * time.Hour),
+			Event:      audit.EventFailure,
+			Grant:      "authcode",
+			Issuer:     "https://issuer.example.com",
+			ClientID:   "YOUR_CLIENT_ID",
+			ErrorClass: "timeout",
+		},
+		{
+			Time:     now.Add(-time.Hour),
+			Event:    audit.EventInteractiveLogin,
+			Grant:    "authcode",
+			Issuer:   "https://issuer.example.com",
+			ClientID: "YOUR_CLIENT_ID",
+			Subject:  "YOUR_SUBJECT",
+			Context:  "YOUR_CONTEXT",
+			Expiry:   &expiry,
+		},
+		{
+			Time:       now.Add(-time.Minute),
+			Event:      audit.EventFailure,
+			Grant:      "device-code",
+			Issuer:     "https://issuer.example.com",
+			ClientID:   "YOUR_CLIENT_ID",
+			ErrorClass: "oauth2:access_denied",
+		},
+	}
+
+	t.Run("Table", func(t *testing.T) {
+		mockAuditRepository := repository_mock.NewMockInterface(t)
+		mockAuditRepository.EXPECT().FindAll(config).Return(records, nil)
+		var stdout bytes.Buffer
+		u := AuditShow{
+			AuditRepository: mockAuditRepository,
+			Clock:           clock.Fake(now),
+			Stdout:          &stdout,
+		}
+		in := Input{
+			AuditConfig: config,
+			Filter:      Filter{Since: 24 * time.Hour},
+			Format:      FormatTable,
+		}
+		if err := u.Do(context.TODO(), in); err != nil {
+			t.Fatalf("Do error: %s", err)
+		}
+		want := `TIME                  EVENT              GRANT        ISSUER                      SUBJECT       CONTEXT       EXPIRY                ERROR
+2020-01-02T02:04:05Z  interactive-login  authcode     https://issuer.example.com  YOUR_SUBJECT  YOUR_CONTEXT  2020-01-02T04:04:05Z  
+2020-01-02T03:03:05Z  failure            device-code  https://issuer.example.com                                                    oauth2:access_denied
+`
+		if diff := cmp.Diff(want, stdout.String()); diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		mockAuditRepository := repository_mock.NewMockInterface(t)
+		mockAuditRepository.EXPECT().FindAll(config).Return(records, nil)
+		var stdout bytes.Buffer
+		u := AuditShow{
+			AuditRepository: mockAuditRepository,
+			Clock:           clock.Fake(now),
+			Stdout:          &stdout,
+		}
+		in := Input{
+			AuditConfig: config,
+			Filter:      Filter{Event: audit.EventFailure, Since: 24 * time.Hour},
+			Format:      FormatJSON,
+		}
+		if err := u.Do(context.TODO(), in); err != nil {
+			t.Fatalf("Do error: %s", err)
+		}
+		want := `{"time":"2020-01-02T03:03:05Z","event":"failure","grant":"device-code","issuer":"https://issuer.example.com","client_id":"YOUR_CLIENT_ID","error_class":"oauth2:access_denied"}
+`
+		if diff := cmp.Diff(want, stdout.String()); diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
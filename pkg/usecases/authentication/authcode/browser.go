@@ -22,6 +22,12 @@ type BrowserOption struct {
 	AuthRequestExtraParams     map[string]string
 	LocalServerCertFile        string
 	LocalServerKeyFile         string
+
+	// Templates of the pages of the local server. Default to the built-in templates.
+	LocalServerSuccessTemplateFile string
+	LocalServerErrorTemplateFile   string
+	// Cluster is shown in the pages of the local server.
+	Cluster string
 }
 
 // Browser provides the authentication code flow using the browser.
@@ -44,9 +50,14 @@ func (u *Browser) Do(ctx context.Context, o *BrowserOption, oidcClient client.In
 	if err != nil {
 		return nil, fmt.Errorf("could not generate the PKCE parameters: %w", err)
 	}
-	successHTML := BrowserSuccessHTML
+	templates, err := loadLocalServerTemplates(o)
+	if err != nil {
+		return nil, fmt.Errorf("could not load the template of the local server: %w", err)
+	}
+	successHTML := templates.successHTML
 	if o.OpenURLAfterAuthentication != "" {
-		successHTML = BrowserRedirectHTML(o.OpenURLAfterAuthentication)
+		redirectHTML := BrowserRedirectHTML(o.OpenURLAfterAuthentication)
+		successHTML = func(*oidc.TokenSet) string { return redirectHTML }
 	}
 	in := client.GetTokenByAuthCodeInput{
 		BindAddress:            o.BindAddress,
@@ -55,6 +66,7 @@ func (u *Browser) Do(ctx context.Context, o *BrowserOption, oidcClient client.In
 		PKCEParams:             pkceParams,
 		AuthRequestExtraParams: o.AuthRequestExtraParams,
 		LocalServerSuccessHTML: successHTML,
+		LocalServerErrorHTML:   templates.errorHTML,
 		LocalServerCertFile:    o.LocalServerCertFile,
 		LocalServerKeyFile:     o.LocalServerKeyFile,
 	}
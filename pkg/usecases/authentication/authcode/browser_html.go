@@ -1,60 +1,213 @@
 package authcode
 
 import (
+	"bytes"
 	"fmt"
+	"html/template"
 	"net/url"
+	"os"
+	"time"
+
+	"github.com/togethercomputer/together-kubelogin/pkg/jwt"
+	"github.com/togethercomputer/together-kubelogin/pkg/oidc"
+	"github.com/togethercomputer/together-kubelogin/pkg/oidc/client"
 )
 
-// BrowserSuccessHTML is the success page on browser based authentication.
-const BrowserSuccessHTML = `
-<!DOCTYPE html>
-<html lang="en">
-<head>
-	<meta charset="UTF-8">
-	<title>Authenticated</title>
-	<script>
-		window.close()
-	</script>
+// LocalServerTemplateData represents the data passed to the templates of the local server.
+type LocalServerTemplateData struct {
+	Subject string    // empty if unavailable
+	Email   string    // empty if unavailable
+	Expiry  time.Time // zero if unavailable
+	Cluster string    // kubeconfig context or cluster server, empty if unavailable
+
+	Error            string // error code such as access_denied
+	ErrorDescription string
+}
+
+const localServerStyle = `
 	<style>
 		body {
-			background-color: #eee;
+			background-color: #f5f5f7;
 			margin: 0;
 			padding: 0;
-			font-family: sans-serif;
+			font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif;
+			color: #0f0f10;
 		}
-		.placeholder {
-			margin: 2em;
+		.card {
+			max-width: 32em;
+			margin: 4em auto;
 			padding: 2em;
 			background-color: #fff;
 			border-radius: 1em;
+			box-shadow: 0 1px 4px rgba(0, 0, 0, 0.08);
+		}
+		.brand {
+			font-weight: 600;
+			color: #0f6fff;
+			letter-spacing: 0.02em;
+		}
+		.error {
+			color: #d92d20;
+		}
+		.note {
+			color: #6b6b73;
+			font-size: 0.9em;
 		}
-	</style>
+		code {
+			background-color: #f5f5f7;
+			padding: 0.1em 0.3em;
+			border-radius: 0.3em;
+		}
+	</style>`
+
+// DefaultSuccessTemplate is the default template of the success page on browser based authentication.
+const DefaultSuccessTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+	<meta charset="UTF-8">
+	<title>Authenticated</title>
+	<script>
+		setTimeout(function () { window.close() }, 3000)
+	</script>` + localServerStyle + `
 </head>
 <body>
-	<div class="placeholder">
+	<div class="card">
+		<p class="brand">Together AI</p>
 		<h1>Authenticated</h1>
-		<p>You have logged in to the cluster. You can close this window.</p>
+		<p>You have logged in{{if .Cluster}} to <code>{{.Cluster}}</code>{{else}} to the cluster{{end}}{{if .Email}} as <strong>{{.Email}}</strong>{{else if .Subject}} as <strong>{{.Subject}}</strong>{{end}}.</p>
+		{{- if not .Expiry.IsZero}}
+		<p>Your token is valid until {{.Expiry.Format "2006-01-02 15:04:05 MST"}}.</p>
+		{{- end}}
+		<p class="note">This window will close automatically. If not, you can close it.</p>
 	</div>
 </body>
 </html>
 `
 
-func BrowserRedirectHTML(target string) string {
-	targetURL, err := url.Parse(target)
+// DefaultErrorTemplate is the default template of the error page on browser based authentication.
+const DefaultErrorTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+	<meta charset="UTF-8">
+	<title>Authentication Failed</title>` + localServerStyle + `
+</head>
+<body>
+	<div class="card">
+		<p class="brand">Together AI</p>
+		<h1 class="error">Authentication Failed</h1>
+		<p>Could not log in{{if .Cluster}} to <code>{{.Cluster}}</code>{{end}}.</p>
+		<p><code>{{.Error}}</code>{{if .ErrorDescription}} {{.ErrorDescription}}{{end}}</p>
+		<p class="note">See the terminal for details. You can close this window.</p>
+	</div>
+</body>
+</html>
+`
+
+var (
+	defaultSuccessTemplate = template.Must(template.New("success").Parse(DefaultSuccessTemplate))
+	defaultErrorTemplate   = template.Must(template.New("error").Parse(DefaultErrorTemplate))
+)
+
+// localServerTemplates renders the pages of the local server.
+type localServerTemplates struct {
+	success *template.Template
+	error   *template.Template
+	cluster string
+}
+
+// loadLocalServerTemplates parses the template files of the option.
+// If a file is not set, it falls back to the default template.
+func loadLocalServerTemplates(o *BrowserOption) (*localServerTemplates, error) {
+	t := &localServerTemplates{
+		success: defaultSuccessTemplate,
+		error:   defaultErrorTemplate,
+		cluster: o.Cluster,
+	}
+	if o.LocalServerSuccessTemplateFile != "" {
+		tmpl, err := parseTemplateFile(o.LocalServerSuccessTemplateFile)
+		if err != nil {
+			return nil, fmt.Errorf("invalid success template: %w", err)
+		}
+		t.success = tmpl
+	}
+	if o.LocalServerErrorTemplateFile != "" {
+		tmpl, err := parseTemplateFile(o.LocalServerErrorTemplateFile)
+		if err != nil {
+			return nil, fmt.Errorf("invalid error template: %w", err)
+		}
+		t.error = tmpl
+	}
+	return t, nil
+}
+
+func parseTemplateFile(name string) (*template.Template, error) {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("read error: %w", err)
+	}
+	tmpl, err := template.New(name).Parse(string(b))
 	if err != nil {
-		return fmt.Sprintf(`invalid URL is set: %s`, err)
+		return nil, fmt.Errorf("parse error: %w", err)
 	}
-	return fmt.Sprintf(`
-<!DOCTYPE html>
+	return tmpl, nil
+}
+
+func (t *localServerTemplates) successHTML(tokenSet *oidc.TokenSet) string {
+	data := LocalServerTemplateData{Cluster: t.cluster}
+	if tokenSet != nil {
+		if claims, err := tokenSet.DecodeWithoutVerify(); err == nil {
+			data.Subject = claims.Subject
+			data.Expiry = claims.Expiry
+		}
+		if m, err := jwt.DecodePayloadAsMap(tokenSet.IDToken); err == nil {
+			data.Email, _ = m["email"].(string)
+		}
+	}
+	return render(t.success, data)
+}
+
+func (t *localServerTemplates) errorHTML(e client.LocalServerError) string {
+	return render(t.error, LocalServerTemplateData{
+		Cluster:          t.cluster,
+		Error:            e.Code,
+		ErrorDescription: e.Description,
+	})
+}
+
+func render(tmpl *template.Template, data LocalServerTemplateData) string {
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, data); err != nil {
+		return template.HTMLEscapeString(fmt.Sprintf("could not render the template: %s", err))
+	}
+	return b.String()
+}
+
+var browserRedirectTemplate = template.Must(template.New("redirect").Parse(`<!DOCTYPE html>
 <html lang="en">
 <head>
-	<meta http-equiv="refresh" content="0;URL=%s">
+	<meta http-equiv="refresh" content="0;URL={{.}}">
 	<meta charset="UTF-8">
 	<title>Authenticated</title>
 </head>
 <body>
-	<a href="%s">redirecting...</a>
+	<a href="{{.}}">redirecting...</a>
 </body>
 </html>
-`, targetURL, targetURL)
+`))
+
+// BrowserRedirectHTML returns the page to redirect to the target URL.
+// The target URL must be http or https.
+func BrowserRedirectHTML(target string) string {
+	targetURL, err := url.Parse(target)
+	if err != nil {
+		return template.HTMLEscapeString(fmt.Sprintf("invalid URL is set: %s", err))
+	}
+	if targetURL.Scheme != "http" && targetURL.Scheme != "https" {
+		return template.HTMLEscapeString(fmt.Sprintf("invalid URL is set: scheme must be http or https: %s", target))
+	}
+	var b bytes.Buffer
+	if err := browserRedirectTemplate.Execute(&b, targetURL.String()); err != nil {
+		return template.HTMLEscapeString(fmt.Sprintf("could not render the template: %s", err))
+	}
+	return b.String()
 }
@@ -0,0 +1,100 @@
+package authcode
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/togethercomputer/together-kubelogin/pkg/oidc"
+	"github.com/togethercomputer/together-kubelogin/pkg/oidc/client"
+	testingJWT "github.com/togethercomputer/together-kubelogin/pkg/testing/jwt"
+)
+
+func TestBrowserRedirectHTML(t *testing.T) {
+	t.Run("Escape", func(t *testing.T) {
+		got := BrowserRedirectHTML(`https://example.com/?a=1&b="><script>alert(1)</script>`)
+		if strings.Contains(got, "<script>") {
+			t.Errorf("URL must be escaped but was %s", got)
+		}
+		if !strings.Contains(got, `href="https://example.com/?a=1&amp;b=%22%3e%3cscript%3ealert%281%29%3c/script%3e"`) {
+			t.Errorf("href wants the escaped URL but was %s", got)
+		}
+	})
+	t.Run("InvalidScheme", func(t *testing.T) {
+		got := BrowserRedirectHTML("javascript:alert(1)")
+		if strings.Contains(got, "href") {
+			t.Errorf("javascript URL must be rejected but was %s", got)
+		}
+	})
+}
+
+func TestLocalServerTemplates(t *testing.T) {
+	expiry := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	idToken := testingJWT.EncodeF(t, func(claims *testingJWT.Claims) {
+		claims.Subject = "YOUR_SUBJECT"
+		claims.ExpiresAt = jwt.NewNumericDate(expiry)
+		claims.Email = "you@example.com"
+	})
+
+	t.Run("Default", func(t *testing.T) {
+		templates, err := loadLocalServerTemplates(&BrowserOption{Cluster: "YOUR_CONTEXT"})
+		if err != nil {
+			t.Fatalf("loadLocalServerTemplates error: %s", err)
+		}
+		got := templates.successHTML(&oidc.TokenSet{IDToken: idToken})
+		for _, want := range []string{"Authenticated", "YOUR_CONTEXT", "you@example.com", "2020-01-02 03:04:05 UTC", "window.close()"} {
+			if !strings.Contains(got, want) {
+				t.Errorf("success page wants %s but was %s", want, got)
+			}
+		}
+		got = templates.successHTML(nil)
+		if !strings.Contains(got, "Authenticated") {
+			t.Errorf("success page without token wants Authenticated but was %s", got)
+		}
+		got = templates.errorHTML(client.LocalServerError{Code: "access_denied", Description: "<denied>"})
+		for _, want := range []string{"access_denied", "&lt;denied&gt;", "YOUR_CONTEXT"} {
+			if !strings.Contains(got, want) {
+				t.Errorf("error page wants %s but was %s", want, got)
+			}
+		}
+	})
+
+	t.Run("File", func(t *testing.T) {
+		dir := t.TempDir()
+		successFile := filepath.Join(dir, "success.html")
+		if err := os.WriteFile(successFile, []byte(`OK {{.Subject}} {{.Cluster}}`), 0600); err != nil {
+			t.Fatalf("could not write the template: %s", err)
+		}
+		errorFile := filepath.Join(dir, "error.html")
+		if err := os.WriteFile(errorFile, []byte(`NG {{.Error}}: {{.ErrorDescription}}`), 0600); err != nil {
+			t.Fatalf("could not write the template: %s", err)
+		}
+		templates, err := loadLocalServerTemplates(&BrowserOption{
+			LocalServerSuccessTemplateFile: successFile,
+			LocalServerErrorTemplateFile:   errorFile,
+			Cluster:                        "https://api.example.com",
+		})
+		if err != nil {
+			t.Fatalf("loadLocalServerTemplates error: %s", err)
+		}
+		if got, want := templates.successHTML(&oidc.TokenSet{IDToken: idToken}), "OK YOUR_SUBJECT https://api.example.com"; got != want {
+			t.Errorf("success page wants %s but was %s", want, got)
+		}
+		if got, want := templates.errorHTML(client.LocalServerError{Code: "access_denied", Description: "denied"}), "NG access_denied: denied"; got != want {
+			t.Errorf("error page wants %s but was %s", want, got)
+		}
+	})
+
+	t.Run("InvalidFile", func(t *testing.T) {
+		filename := filepath.Join(t.TempDir(), "success.html")
+		if err := os.WriteFile(filename, []byte(`{{.Subject`), 0600); err != nil {
+			t.Fatalf("could not write the template: %s", err)
+		}
+		if _, err := loadLocalServerTemplates(&BrowserOption{LocalServerSuccessTemplateFile: filename}); err == nil {
+			t.Errorf("loadLocalServerTemplates wants error but was nil")
+		}
+	})
+}
@@ -25,22 +25,14 @@ type Keyboard struct {
 
 func (u *Keyboard) Do(ctx context.Context, o *KeyboardOption, oidcClient client.Interface) (*oidc.TokenSet, error) {
 	u.Logger.V(1).Infof("starting the authorization code flow with keyboard interactive")
-	state, err := oidc.NewState()
+	req, err := newAuthRequest(oidcClient)
 	if err != nil {
-		return nil, fmt.Errorf("could not generate a state: %w", err)
-	}
-	nonce, err := oidc.NewNonce()
-	if err != nil {
-		return nil, fmt.Errorf("could not generate a nonce: %w", err)
-	}
-	pkceParams, err := pkce.New(oidcClient.NegotiatedPKCEMethod())
-	if err != nil {
-		return nil, fmt.Errorf("could not generate the PKCE parameters: %w", err)
+		return nil, err
 	}
 	authCodeURL := oidcClient.GetAuthCodeURL(client.AuthCodeURLInput{
-		State:                  state,
-		Nonce:                  nonce,
-		PKCEParams:             pkceParams,
+		State:                  req.state,
+		Nonce:                  req.nonce,
+		PKCEParams:             req.pkceParams,
 		AuthRequestExtraParams: o.AuthRequestExtraParams,
 	})
 	u.Logger.Printf("Please visit the following URL in your browser: %s", authCodeURL)
@@ -52,8 +44,8 @@ func (u *Keyboard) Do(ctx context.Context, o *KeyboardOption, oidcClient client.
 	u.Logger.V(1).Infof("exchanging the code and token")
 	tokenSet, err := oidcClient.ExchangeAuthCode(ctx, client.ExchangeAuthCodeInput{
 		Code:       code,
-		PKCEParams: pkceParams,
-		Nonce:      nonce,
+		PKCEParams: req.pkceParams,
+		Nonce:      req.nonce,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("could not exchange the authorization code: %w", err)
@@ -61,3 +53,27 @@ func (u *Keyboard) Do(ctx context.Context, o *KeyboardOption, oidcClient client.
 	u.Logger.V(1).Infof("finished the authorization code flow with keyboard interactive")
 	return tokenSet, nil
 }
+
+// authRequest represents the parameters of an authorization request,
+// which are verified or sent again in the token request.
+type authRequest struct {
+	state      string
+	nonce      string
+	pkceParams pkce.Params
+}
+
+func newAuthRequest(oidcClient client.Interface) (*authRequest, error) {
+	state, err := oidc.NewState()
+	if err != nil {
+		return nil, fmt.Errorf("could not generate a state: %w", err)
+	}
+	nonce, err := oidc.NewNonce()
+	if err != nil {
+		return nil, fmt.Errorf("could not generate a nonce: %w", err)
+	}
+	pkceParams, err := pkce.New(oidcClient.NegotiatedPKCEMethod())
+	if err != nil {
+		return nil, fmt.Errorf("could not generate the PKCE parameters: %w", err)
+	}
+	return &authRequest{state: state, nonce: nonce, pkceParams: pkceParams}, nil
+}
@@ -0,0 +1,91 @@
+package authcode
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/logger"
+	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/reader"
+	"github.com/togethercomputer/together-kubelogin/pkg/oidc"
+	"github.com/togethercomputer/together-kubelogin/pkg/oidc/client"
+)
+
+const pastePrompt = "Enter the URL of the address bar: "
+
+type PasteOption struct {
+	// RedirectURL is sent if the provider has no redirect URL.
+	// It must be registered to the provider, but does not need to be reachable.
+	RedirectURL            string
+	AuthRequestExtraParams map[string]string
+}
+
+// Paste provides the authorization code flow by pasting the redirected URL.
+// This is useful on a remote host, where the browser cannot reach the local server.
+type Paste struct {
+	Reader reader.Interface
+	Logger logger.Interface
+}
+
+func (u *Paste) Do(ctx context.Context, o *PasteOption, oidcClient client.Interface) (*oidc.TokenSet, error) {
+	u.Logger.V(1).Infof("starting the authorization code flow by pasting the redirected URL")
+	req, err := newAuthRequest(oidcClient)
+	if err != nil {
+		return nil, err
+	}
+	authCodeURL := oidcClient.GetAuthCodeURL(client.AuthCodeURLInput{
+		State:                  req.state,
+		Nonce:                  req.nonce,
+		PKCEParams:             req.pkceParams,
+		AuthRequestExtraParams: o.AuthRequestExtraParams,
+		RedirectURL:            o.RedirectURL,
+	})
+	u.Logger.Printf("Please visit the following URL in your browser: %s", authCodeURL)
+	u.Logger.Printf("After authentication, the browser will fail to open the redirected page. Copy the URL of the address bar and paste it here.")
+	redirectedURL, err := u.Reader.ReadString(pastePrompt)
+	if err != nil {
+		return nil, fmt.Errorf("could not read the redirected URL: %w", err)
+	}
+	code, err := parseRedirectedURL(redirectedURL, req.state)
+	if err != nil {
+		return nil, err
+	}
+
+	u.Logger.V(1).Infof("exchanging the code and token")
+	tokenSet, err := oidcClient.ExchangeAuthCode(ctx, client.ExchangeAuthCodeInput{
+		Code:        code,
+		PKCEParams:  req.pkceParams,
+		Nonce:       req.nonce,
+		RedirectURL: o.RedirectURL,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not exchange the authorization code: %w", err)
+	}
+	u.Logger.V(1).Infof("finished the authorization code flow by pasting the redirected URL")
+	return tokenSet, nil
+}
+
+// parseRedirectedURL returns the authorization code in the redirected URL.
+// It verifies the state to prevent from pasting a URL of another authorization request.
+func parseRedirectedURL(s, state string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(s))
+	if err != nil {
+		return "", fmt.Errorf("invalid URL: %w", err)
+	}
+	q := u.Query()
+	if errorCode := q.Get("error"); errorCode != "" {
+		if description := q.Get("error_description"); description != "" {
+			return "", fmt.Errorf("authorization error: %s: %s", errorCode, description)
+		}
+		return "", fmt.Errorf("authorization error: %s", errorCode)
+	}
+	if q.Get("state") != state {
+		return "", fmt.Errorf("state does not match (you may have pasted a URL of another login)")
+	}
+	code := q.Get("code")
+	if code == "" {
+		return "", fmt.Errorf("code is missing in the URL")
+	}
+	return code, nil
+}
@@ -0,0 +1,147 @@
+package authcode
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/togethercomputer/together-kubelogin/mocks/github.com/togethercomputer/together-kubelogin/pkg/infrastructure/reader_mock"
+	"github.com/togethercomputer/together-kubelogin/mocks/github.com/togethercomputer/together-kubelogin/pkg/oidc/client_mock"
+	"github.com/togethercomputer/together-kubelogin/pkg/oidc"
+	"github.com/togethercomputer/together-kubelogin/pkg/oidc/client"
+	"github.com/togethercomputer/together-kubelogin/pkg/pkce"
+	"github.com/togethercomputer/together-kubelogin/pkg/testing/logger"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPaste_Do(t *testing.T) {
+	timeout := 5 * time.Second
+
+	t.Run("Success", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.TODO(), timeout)
+		defer cancel()
+		o := &PasteOption{
+			RedirectURL:            "http://localhost:8000",
+			AuthRequestExtraParams: map[string]string{"ttl": "86400"},
+		}
+		var state string
+		mockClient := client_mock.NewMockInterface(t)
+		mockClient.EXPECT().NegotiatedPKCEMethod().Return(pkce.NoMethod)
+		mockClient.EXPECT().
+			GetAuthCodeURL(mock.Anything).
+			Run(func(in client.AuthCodeURLInput) {
+				state = in.State
+				if in.RedirectURL != "http://localhost:8000" {
+					t.Errorf("RedirectURL wants http://localhost:8000 but was %s", in.RedirectURL)
+				}
+				if diff := cmp.Diff(o.AuthRequestExtraParams, in.AuthRequestExtraParams); diff != "" {
+					t.Errorf("AuthRequestExtraParams mismatch (-want +got):\n%s", diff)
+				}
+			}).
+			Return("https://issuer.example.com/auth")
+		mockClient.EXPECT().
+			ExchangeAuthCode(mock.Anything, mock.Anything).
+			Run(func(_ context.Context, in client.ExchangeAuthCodeInput) {
+				if in.Code != "YOUR_AUTH_CODE" {
+					t.Errorf("Code wants YOUR_AUTH_CODE but was %s", in.Code)
+				}
+				if in.RedirectURL != "http://localhost:8000" {
+					t.Errorf("RedirectURL wants http://localhost:8000 but was %s", in.RedirectURL)
+				}
+			}).
+			Return(&oidc.TokenSet{
+				IDToken:      "YOUR_ID_TOKEN",
+				RefreshToken: "YOUR_REFRESH_TOKEN",
+			}, nil)
+		mockReader := reader_mock.NewMockInterface(t)
+		mockReader.EXPECT().
+			ReadString(pastePrompt).
+			RunAndReturn(func(string) (string, error) {
+				return "http://localhost:8000/?code=YOUR_AUTH_CODE&state=" + state, nil
+			})
+		u := Paste{
+			Reader: mockReader,
+			Logger: logger.New(t),
+		}
+		got, err := u.Do(ctx, o, mockClient)
+		if err != nil {
+			t.Errorf("Do returned error: %+v", err)
+		}
+		want := &oidc.TokenSet{
+			IDToken:      "YOUR_ID_TOKEN",
+			RefreshToken: "YOUR_REFRESH_TOKEN",
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("StateMismatch", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.TODO(), timeout)
+		defer cancel()
+		mockClient := client_mock.NewMockInterface(t)
+		mockClient.EXPECT().NegotiatedPKCEMethod().Return(pkce.NoMethod)
+		mockClient.EXPECT().
+			GetAuthCodeURL(mock.Anything).
+			Return("https://issuer.example.com/auth")
+		mockReader := reader_mock.NewMockInterface(t)
+		mockReader.EXPECT().
+			ReadString(pastePrompt).
+			Return("http://localhost:8000/?code=YOUR_AUTH_CODE&state=ANOTHER_STATE", nil)
+		u := Paste{
+			Reader: mockReader,
+			Logger: logger.New(t),
+		}
+		_, err := u.Do(ctx, &PasteOption{}, mockClient)
+		if err == nil {
+			t.Errorf("Do wants error but was nil")
+		}
+	})
+}
+
+func Test_parseRedirectedURL(t *testing.T) {
+	tests := map[string]struct {
+		url     string
+		want    string
+		wantErr string
+	}{
+		"Code": {
+			url:  "http://localhost:8000/?state=STATE&code=CODE",
+			want: "CODE",
+		},
+		"Whitespace": {
+			url:  "  http://localhost:8000/callback?state=STATE&code=CODE \t",
+			want: "CODE",
+		},
+		"Error": {
+			url:     "http://localhost:8000/?state=STATE&error=access_denied&error_description=denied+by+user",
+			wantErr: "authorization error: access_denied: denied by user",
+		},
+		"StateMismatch": {
+			url:     "http://localhost:8000/?state=ANOTHER&code=CODE",
+			wantErr: "state does not match (you may have pasted a URL of another login)",
+		},
+		"NoCode": {
+			url:     "http://localhost:8000/?state=STATE",
+			wantErr: "code is missing in the URL",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := parseRedirectedURL(tc.url, "STATE")
+			if tc.wantErr != "" {
+				if err == nil || err.Error() != tc.wantErr {
+					t.Errorf("error wants %q but was %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseRedirectedURL error: %s", err)
+			}
+			if got != tc.want {
+				t.Errorf("code wants %s but was %s", tc.want, got)
+			}
+		})
+	}
+}
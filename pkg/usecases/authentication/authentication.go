@@ -2,10 +2,14 @@ package authentication
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/google/wire"
+	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/browser"
+	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/clock"
 	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/logger"
+	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/tracing"
 	"github.com/togethercomputer/together-kubelogin/pkg/oidc"
 	"github.com/togethercomputer/together-kubelogin/pkg/oidc/client"
 	"github.com/togethercomputer/together-kubelogin/pkg/tlsclientconfig"
@@ -13,6 +17,8 @@ import (
 	"github.com/togethercomputer/together-kubelogin/pkg/usecases/authentication/clientcredentials"
 	"github.com/togethercomputer/together-kubelogin/pkg/usecases/authentication/devicecode"
 	"github.com/togethercomputer/together-kubelogin/pkg/usecases/authentication/ropc"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Set provides the use-case of Authentication.
@@ -21,6 +27,7 @@ var Set = wire.NewSet(
 	wire.Bind(new(Interface), new(*Authentication)),
 	wire.Struct(new(authcode.Browser), "*"),
 	wire.Struct(new(authcode.Keyboard), "*"),
+	wire.Struct(new(authcode.Paste), "*"),
 	wire.Struct(new(ropc.ROPC), "*"),
 	wire.Struct(new(devicecode.DeviceCode), "*"),
 	wire.Struct(new(clientcredentials.ClientCredentials), "*"),
@@ -36,19 +43,76 @@ type Input struct {
 	GrantOptionSet  GrantOptionSet
 	CachedTokenSet  *oidc.TokenSet // optional
 	TLSClientConfig tlsclientconfig.Config
+	NonInteractive  bool // set if the standard input is not available
+	RefreshOnly     bool // if set, refresh the cached token and do not fall back to the grant
 }
 
 type GrantOptionSet struct {
 	AuthCodeBrowserOption   *authcode.BrowserOption
 	AuthCodeKeyboardOption  *authcode.KeyboardOption
+	AuthCodePasteOption     *authcode.PasteOption
 	ROPCOption              *ropc.Option
 	DeviceCodeOption        *devicecode.Option
 	ClientCredentialsOption *client.GetTokenByClientCredentialsInput
+
+	// Candidates is the ordered list of the grants for the auto grant type.
+	// If set, the first available grant is used and the above options are ignored.
+	Candidates []GrantOptionSet
+}
+
+// GrantTypeAuto is the grant type when the grant is selected from the candidates.
+const GrantTypeAuto = "auto"
+
+// GrantType returns the name of the grant type, such as authcode.
+func (s GrantOptionSet) GrantType() string {
+	switch {
+	case len(s.Candidates) > 0:
+		return GrantTypeAuto
+	case s.AuthCodeBrowserOption != nil:
+		return "authcode"
+	case s.AuthCodeKeyboardOption != nil:
+		return "authcode-keyboard"
+	case s.AuthCodePasteOption != nil:
+		return "authcode-paste"
+	case s.ROPCOption != nil:
+		return "password"
+	case s.DeviceCodeOption != nil:
+		return "device-code"
+	case s.ClientCredentialsOption != nil:
+		return "client-credentials"
+	}
+	return ""
 }
 
+// WithCluster returns a copy of the set with the cluster shown in the pages of the local server.
+func (s GrantOptionSet) WithCluster(cluster string) GrantOptionSet {
+	if s.AuthCodeBrowserOption != nil && cluster != "" {
+		o := *s.AuthCodeBrowserOption
+		o.Cluster = cluster
+		s.AuthCodeBrowserOption = &o
+	}
+	if len(s.Candidates) > 0 && cluster != "" {
+		candidates := make([]GrantOptionSet, len(s.Candidates))
+		for i, candidate := range s.Candidates {
+			candidates[i] = candidate.WithCluster(cluster)
+		}
+		s.Candidates = candidates
+	}
+	return s
+}
+
+// GrantRefreshToken is the grant type when the token is refreshed.
+const GrantRefreshToken = "refresh-token"
+
 // Output represents an output DTO of the Authentication use-case.
 type Output struct {
 	TokenSet oidc.TokenSet
+	Grant    string // grant type used, or GrantRefreshToken
+}
+
+// Refreshed returns true if the token is refreshed by the refresh token.
+func (o Output) Refreshed() bool {
+	return o.Grant == GrantRefreshToken
 }
 
 // Authentication provides the internal use-case of authentication.
@@ -58,71 +122,141 @@ type Output struct {
 // If the IDToken has expired and the RefreshToken is set, it refreshes the token.
 // If the RefreshToken has expired, it performs the authentication flow.
 //
-// The authentication flow is determined as:
-//
-// If the Username is not set, it performs the authorization code flow.
-// Otherwise, it performs the resource owner password credentials flow.
-// If the Password is not set, it asks a password by the prompt.
+// The authentication flow is determined by the GrantOptionSet.
+// If the candidates are set, it performs the first available grant
+// in the environment and the provider, and falls back to the next one on a runtime error.
 type Authentication struct {
 	ClientFactory     client.FactoryInterface
 	Logger            logger.Interface
+	Clock             clock.Interface
+	Browser           browser.Interface
 	AuthCodeBrowser   *authcode.Browser
 	AuthCodeKeyboard  *authcode.Keyboard
+	AuthCodePaste     *authcode.Paste
 	ROPC              *ropc.ROPC
 	DeviceCode        *devicecode.DeviceCode
 	ClientCredentials *clientcredentials.ClientCredentials
 }
 
 func (u *Authentication) Do(ctx context.Context, in Input) (*Output, error) {
+	startedAt := u.Clock.Now()
+	tokenSet, grant, err := u.do(ctx, in)
+	fields := logger.Fields{
+		"issuer":   in.Provider.IssuerURL,
+		"grant":    grant,
+		"duration": u.Clock.Now().Sub(startedAt),
+	}
+	if err != nil {
+		fields["error"] = err
+		u.Logger.Event("authentication_failed", fields)
+		return nil, err
+	}
+	u.Logger.Event("authenticated", fields)
+	return &Output{TokenSet: *tokenSet, Grant: grant}, nil
+}
+
+// do performs the authentication and returns the token with the grant type.
+func (u *Authentication) do(ctx context.Context, in Input) (*oidc.TokenSet, string, error) {
 	u.Logger.V(1).Infof("initializing an OpenID Connect client")
 	oidcClient, err := u.ClientFactory.New(ctx, in.Provider, in.TLSClientConfig)
 	if err != nil {
-		return nil, fmt.Errorf("oidc error: %w", err)
+		return nil, "", fmt.Errorf("oidc error: %w", err)
 	}
 
 	if in.CachedTokenSet != nil && in.CachedTokenSet.RefreshToken != "" {
-		u.Logger.V(1).Infof("refreshing the token")
-		tokenSet, err := oidcClient.Refresh(ctx, in.CachedTokenSet.RefreshToken)
-		if err == nil {
-			return &Output{TokenSet: *tokenSet}, nil
+		if err := in.Provider.VerifyCachedTokenSet(*in.CachedTokenSet, u.Clock.Now()); err != nil {
+			// a refreshed token has the same authentication, so log in again
+			u.Logger.Printf("The authentication policy requires a new login: %s", err)
+		} else {
+			tokenSet, err := u.refresh(ctx, oidcClient, in.CachedTokenSet.RefreshToken)
+			if err == nil {
+				return tokenSet, GrantRefreshToken, nil
+			}
+			if in.RefreshOnly {
+				return nil, GrantRefreshToken, fmt.Errorf("could not refresh the token: %w", err)
+			}
+			u.Logger.V(1).Infof("could not refresh the token: %s", err)
 		}
-		u.Logger.V(1).Infof("could not refresh the token: %s", err)
 	}
+	if in.RefreshOnly {
+		return nil, GrantRefreshToken, errors.New("could not refresh the token without a new login")
+	}
+
+	if len(in.GrantOptionSet.Candidates) > 0 {
+		return u.doCandidates(ctx, in, oidcClient)
+	}
+	grantType := in.GrantOptionSet.GrantType()
+	tokenSet, err := u.performGrant(ctx, in.GrantOptionSet, in.NonInteractive, oidcClient)
+	if err != nil {
+		return nil, grantType, err
+	}
+	return tokenSet, grantType, nil
+}
+
+// performGrant performs the grant with tracing.
+func (u *Authentication) performGrant(ctx context.Context, s GrantOptionSet, nonInteractive bool, oidcClient client.Interface) (*oidc.TokenSet, error) {
+	if s.ROPCOption != nil && nonInteractive {
+		o := *s.ROPCOption
+		o.NonInteractive = true
+		s.ROPCOption = &o
+	}
+	ctx, span := tracing.Start(ctx, "Grant.Do",
+		trace.WithAttributes(attribute.String("oauth2.grant_type", s.GrantType())))
+	tokenSet, err := u.doGrant(ctx, s, oidcClient)
+	tracing.End(span, err)
+	return tokenSet, err
+}
+
+func (u *Authentication) refresh(ctx context.Context, oidcClient client.Interface, refreshToken string) (*oidc.TokenSet, error) {
+	u.Logger.V(1).Infof("refreshing the token")
+	ctx, span := tracing.Start(ctx, "Client.Refresh")
+	tokenSet, err := oidcClient.Refresh(ctx, refreshToken)
+	tracing.End(span, err)
+	return tokenSet, err
+}
 
-	if in.GrantOptionSet.AuthCodeBrowserOption != nil {
-		tokenSet, err := u.AuthCodeBrowser.Do(ctx, in.GrantOptionSet.AuthCodeBrowserOption, oidcClient)
+func (u *Authentication) doGrant(ctx context.Context, s GrantOptionSet, oidcClient client.Interface) (*oidc.TokenSet, error) {
+	if s.AuthCodeBrowserOption != nil {
+		tokenSet, err := u.AuthCodeBrowser.Do(ctx, s.AuthCodeBrowserOption, oidcClient)
 		if err != nil {
 			return nil, fmt.Errorf("authcode-browser error: %w", err)
 		}
-		return &Output{TokenSet: *tokenSet}, nil
+		return tokenSet, nil
 	}
-	if in.GrantOptionSet.AuthCodeKeyboardOption != nil {
-		tokenSet, err := u.AuthCodeKeyboard.Do(ctx, in.GrantOptionSet.AuthCodeKeyboardOption, oidcClient)
+	if s.AuthCodeKeyboardOption != nil {
+		tokenSet, err := u.AuthCodeKeyboard.Do(ctx, s.AuthCodeKeyboardOption, oidcClient)
 		if err != nil {
 			return nil, fmt.Errorf("authcode-keyboard error: %w", err)
 		}
-		return &Output{TokenSet: *tokenSet}, nil
+		return tokenSet, nil
+	}
+	if s.AuthCodePasteOption != nil {
+		tokenSet, err := u.AuthCodePaste.Do(ctx, s.AuthCodePasteOption, oidcClient)
+		if err != nil {
+			return nil, fmt.Errorf("authcode-paste error: %w", err)
+		}
+		return tokenSet, nil
 	}
-	if in.GrantOptionSet.ROPCOption != nil {
-		tokenSet, err := u.ROPC.Do(ctx, in.GrantOptionSet.ROPCOption, oidcClient)
+	if s.ROPCOption != nil {
+		tokenSet, err := u.ROPC.Do(ctx, s.ROPCOption, oidcClient)
 		if err != nil {
 			return nil, fmt.Errorf("ropc error: %w", err)
 		}
-		return &Output{TokenSet: *tokenSet}, nil
+		return tokenSet, nil
 	}
-	if in.GrantOptionSet.DeviceCodeOption != nil {
-		tokenSet, err := u.DeviceCode.Do(ctx, in.GrantOptionSet.DeviceCodeOption, oidcClient)
+	if s.DeviceCodeOption != nil {
+		tokenSet, err := u.DeviceCode.Do(ctx, s.DeviceCodeOption, oidcClient)
 		if err != nil {
 			return nil, fmt.Errorf("device-code error: %w", err)
 		}
-		return &Output{TokenSet: *tokenSet}, nil
+		return tokenSet, nil
 	}
-	if in.GrantOptionSet.ClientCredentialsOption != nil {
-		tokenSet, err := u.ClientCredentials.Do(ctx, in.GrantOptionSet.ClientCredentialsOption, oidcClient)
+	if s.ClientCredentialsOption != nil {
+		tokenSet, err := u.ClientCredentials.Do(ctx, s.ClientCredentialsOption, oidcClient)
 		if err != nil {
 			return nil, fmt.Errorf("client-credentials error: %w", err)
 		}
-		return &Output{TokenSet: *tokenSet}, nil
+		return tokenSet, nil
 	}
 	return nil, fmt.Errorf("any authorization grant must be set")
 }
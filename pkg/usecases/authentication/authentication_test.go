@@ -12,6 +12,7 @@ import (
 	"github.com/togethercomputer/together-kubelogin/pkg/oidc"
 	"github.com/togethercomputer/together-kubelogin/pkg/oidc/client"
 	"github.com/togethercomputer/together-kubelogin/pkg/pkce"
+	"github.com/togethercomputer/together-kubelogin/pkg/testing/clock"
 	testingJWT "github.com/togethercomputer/together-kubelogin/pkg/testing/jwt"
 	testingLogger "github.com/togethercomputer/together-kubelogin/pkg/testing/logger"
 	"github.com/togethercomputer/together-kubelogin/pkg/tlsclientconfig"
@@ -63,6 +64,7 @@ func TestAuthentication_Do(t *testing.T) {
 		u := Authentication{
 			ClientFactory: mockClientFactory,
 			Logger:        testingLogger.New(t),
+			Clock:         clock.Fake(expiryTime.Add(-time.Hour)),
 		}
 		got, err := u.Do(ctx, in)
 		if err != nil {
@@ -73,6 +75,7 @@ func TestAuthentication_Do(t *testing.T) {
 				IDToken:      "NEW_ID_TOKEN",
 				RefreshToken: "NEW_REFRESH_TOKEN",
 			},
+			Grant: "refresh-token",
 		}
 		if diff := cmp.Diff(want, got); diff != "" {
 			t.Errorf("mismatch (-want +got):\n%s", diff)
@@ -118,6 +121,7 @@ func TestAuthentication_Do(t *testing.T) {
 		u := Authentication{
 			ClientFactory: mockClientFactory,
 			Logger:        testingLogger.New(t),
+			Clock:         clock.Fake(expiryTime.Add(-time.Hour)),
 			AuthCodeBrowser: &authcode.Browser{
 				Logger: testingLogger.New(t),
 			},
@@ -131,12 +135,97 @@ func TestAuthentication_Do(t *testing.T) {
 				IDToken:      "NEW_ID_TOKEN",
 				RefreshToken: "NEW_REFRESH_TOKEN",
 			},
+			Grant: "authcode",
 		}
 		if diff := cmp.Diff(want, got); diff != "" {
 			t.Errorf("mismatch (-want +got):\n%s", diff)
 		}
 	})
 
+	t.Run("HasExpiredRefreshToken/RefreshOnly", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.TODO(), timeout)
+		defer cancel()
+		in := Input{
+			Provider:        dummyProvider,
+			TLSClientConfig: dummyTLSClientConfig,
+			GrantOptionSet: GrantOptionSet{
+				AuthCodeBrowserOption: &authcode.BrowserOption{
+					BindAddress:     []string{"127.0.0.1:8000"},
+					SkipOpenBrowser: true,
+				},
+			},
+			CachedTokenSet: &oidc.TokenSet{
+				IDToken:      issuedIDToken,
+				RefreshToken: "EXPIRED_REFRESH_TOKEN",
+			},
+			RefreshOnly: true,
+		}
+		mockClient := client_mock.NewMockInterface(t)
+		mockClient.EXPECT().
+			Refresh(ctx, "EXPIRED_REFRESH_TOKEN").
+			Return(nil, errors.New("token has expired"))
+		mockClientFactory := client_mock.NewMockFactoryInterface(t)
+		mockClientFactory.EXPECT().
+			New(ctx, dummyProvider, dummyTLSClientConfig).
+			Return(mockClient, nil)
+		u := Authentication{
+			ClientFactory: mockClientFactory,
+			Logger:        testingLogger.New(t),
+			Clock:         clock.Fake(expiryTime.Add(-time.Hour)),
+		}
+		if _, err := u.Do(ctx, in); err == nil {
+			t.Errorf("Do wants an error but was nil")
+		}
+	})
+
+	t.Run("HasRefreshTokenNotSatisfyingPolicy", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.TODO(), timeout)
+		defer cancel()
+		provider := dummyProvider
+		provider.AuthenticationPolicy = oidc.AuthenticationPolicy{ACRValues: []string{"phr"}}
+		in := Input{
+			Provider:        provider,
+			TLSClientConfig: dummyTLSClientConfig,
+			GrantOptionSet: GrantOptionSet{
+				ROPCOption: &ropc.Option{
+					Username: "USER",
+					Password: "PASS",
+				},
+			},
+			CachedTokenSet: &oidc.TokenSet{
+				IDToken:      issuedIDToken,
+				RefreshToken: "VALID_REFRESH_TOKEN",
+			},
+		}
+		// it must not refresh the token, because the refreshed token has the same acr
+		mockClient := client_mock.NewMockInterface(t)
+		mockClient.EXPECT().
+			GetTokenByROPC(mock.Anything, client.GetTokenByROPCInput{Username: "USER", Password: "PASS"}).
+			Return(&oidc.TokenSet{
+				IDToken:      "YOUR_ID_TOKEN",
+				RefreshToken: "YOUR_REFRESH_TOKEN",
+			}, nil)
+		mockClientFactory := client_mock.NewMockFactoryInterface(t)
+		mockClientFactory.EXPECT().
+			New(ctx, provider, dummyTLSClientConfig).
+			Return(mockClient, nil)
+		u := Authentication{
+			ClientFactory: mockClientFactory,
+			Logger:        testingLogger.New(t),
+			Clock:         clock.Fake(expiryTime.Add(-time.Hour)),
+			ROPC: &ropc.ROPC{
+				Logger: testingLogger.New(t),
+			},
+		}
+		got, err := u.Do(ctx, in)
+		if err != nil {
+			t.Errorf("Do returned error: %+v", err)
+		}
+		if got.Grant != "password" {
+			t.Errorf("Grant wants password but got %s", got.Grant)
+		}
+	})
+
 	t.Run("NoToken/ROPC", func(t *testing.T) {
 		ctx, cancel := context.WithTimeout(context.TODO(), timeout)
 		defer cancel()
@@ -152,7 +241,7 @@ func TestAuthentication_Do(t *testing.T) {
 		}
 		mockClient := client_mock.NewMockInterface(t)
 		mockClient.EXPECT().
-			GetTokenByROPC(mock.Anything, "USER", "PASS").
+			GetTokenByROPC(mock.Anything, client.GetTokenByROPCInput{Username: "USER", Password: "PASS"}).
 			Return(&oidc.TokenSet{
 				IDToken:      "YOUR_ID_TOKEN",
 				RefreshToken: "YOUR_REFRESH_TOKEN",
@@ -164,6 +253,7 @@ func TestAuthentication_Do(t *testing.T) {
 		u := Authentication{
 			ClientFactory: mockClientFactory,
 			Logger:        testingLogger.New(t),
+			Clock:         clock.Fake(expiryTime.Add(-time.Hour)),
 			ROPC: &ropc.ROPC{
 				Logger: testingLogger.New(t),
 			},
@@ -177,6 +267,7 @@ func TestAuthentication_Do(t *testing.T) {
 				IDToken:      "YOUR_ID_TOKEN",
 				RefreshToken: "YOUR_REFRESH_TOKEN",
 			},
+			Grant: "password",
 		}
 		if diff := cmp.Diff(want, got); diff != "" {
 			t.Errorf("mismatch (-want +got):\n%s", diff)
@@ -206,6 +297,7 @@ func TestAuthentication_Do(t *testing.T) {
 		u := Authentication{
 			ClientFactory: mockClientFactory,
 			Logger:        testingLogger.New(t),
+			Clock:         clock.Fake(expiryTime.Add(-time.Hour)),
 			ClientCredentials: &clientcredentials.ClientCredentials{
 				Logger: testingLogger.New(t),
 			},
@@ -218,6 +310,7 @@ func TestAuthentication_Do(t *testing.T) {
 			TokenSet: oidc.TokenSet{
 				IDToken: "TEST_ID_TOKEN",
 			},
+			Grant: "client-credentials",
 		}
 		if diff := cmp.Diff(want, got); diff != "" {
 			t.Errorf("mismatch (-want +got):\n%s", diff)
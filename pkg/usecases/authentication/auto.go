@@ -0,0 +1,102 @@
+package authentication
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/togethercomputer/together-kubelogin/pkg/oidc"
+	"github.com/togethercomputer/together-kubelogin/pkg/oidc/client"
+	"github.com/int128/oauth2dev"
+	"golang.org/x/oauth2"
+)
+
+// doCandidates performs the first available grant of the candidates.
+// If a grant fails by a runtime error, it tries the next available grant.
+// It stops at an error of the user or the provider, because another grant would fail as well.
+func (u *Authentication) doCandidates(ctx context.Context, in Input, oidcClient client.Interface) (*oidc.TokenSet, string, error) {
+	candidates, errs := u.selectGrants(in, oidcClient)
+	for _, candidate := range candidates {
+		grantType := candidate.GrantType()
+		u.Logger.V(1).Infof("using the grant type %s", grantType)
+		tokenSet, err := u.performGrant(ctx, candidate, in.NonInteractive, oidcClient)
+		if err == nil {
+			return tokenSet, grantType, nil
+		}
+		if isUserError(ctx, err) {
+			return nil, grantType, err
+		}
+		u.Logger.Printf("could not authenticate by the grant type %s: %s", grantType, err)
+		errs = append(errs, err)
+	}
+	return nil, GrantTypeAuto, fmt.Errorf("no grant type succeeded: %w", errors.Join(errs...))
+}
+
+// selectGrants returns the available grants of the candidates in order,
+// and the errors of the skipped grants.
+// The last candidate is always returned, because the environment may be misdetected.
+func (u *Authentication) selectGrants(in Input, oidcClient client.Interface) ([]GrantOptionSet, []error) {
+	var available []GrantOptionSet
+	var skipped []error
+	candidates := in.GrantOptionSet.Candidates
+	for i, candidate := range candidates {
+		reason := u.unavailableReason(candidate, in.NonInteractive, oidcClient)
+		switch {
+		case reason == "":
+			available = append(available, candidate)
+		case i == len(candidates)-1:
+			u.Logger.V(1).Infof("trying the last grant type %s, although %s", candidate.GrantType(), reason)
+			available = append(available, candidate)
+		default:
+			u.Logger.V(1).Infof("skipped the grant type %s: %s", candidate.GrantType(), reason)
+			skipped = append(skipped, fmt.Errorf("skipped %s: %s", candidate.GrantType(), reason))
+		}
+	}
+	return available, skipped
+}
+
+// isUserError returns true if the error is caused by the user or the provider,
+// such as an interrupt, a wrong password or a denied request.
+func isUserError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return true
+	}
+	var retrieveError *oauth2.RetrieveError
+	var tokenError oauth2dev.TokenErrorResponse
+	return errors.As(err, &retrieveError) || errors.As(err, &tokenError)
+}
+
+// unavailableReason returns the reason if the grant is not available.
+// It returns an empty string if available.
+func (u *Authentication) unavailableReason(s GrantOptionSet, nonInteractive bool, oidcClient client.Interface) string {
+	switch {
+	case s.AuthCodeBrowserOption != nil:
+		if !oidcClient.SupportsGrantType(client.GrantTypeAuthorizationCode) {
+			return "authorization code grant is not supported by the provider"
+		}
+		o := s.AuthCodeBrowserOption
+		if !o.SkipOpenBrowser && o.BrowserCommand == "" && !u.Browser.IsAvailable() {
+			return "no browser is available"
+		}
+	case s.AuthCodeKeyboardOption != nil || s.AuthCodePasteOption != nil:
+		if !oidcClient.SupportsGrantType(client.GrantTypeAuthorizationCode) {
+			return "authorization code grant is not supported by the provider"
+		}
+		if nonInteractive {
+			return "standard input is not available"
+		}
+	case s.DeviceCodeOption != nil:
+		if !oidcClient.SupportsGrantType(client.GrantTypeDeviceCode) {
+			return "device authorization grant is not supported by the provider"
+		}
+	case s.ROPCOption != nil:
+		o := s.ROPCOption
+		// the standard input is not available for --password-stdin in the non-interactive mode
+		hasPassword := o.Password != "" || o.PasswordFile != "" || o.PasswordCommand != ""
+		needsOTP := o.OTPMode != "" && o.OTPCommand == ""
+		if nonInteractive && (o.Username == "" || !hasPassword || needsOTP) {
+			return "standard input is not available"
+		}
+	}
+	return ""
+}
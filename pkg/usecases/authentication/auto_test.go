@@ -0,0 +1,210 @@
+package authentication
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/togethercomputer/together-kubelogin/mocks/github.com/togethercomputer/together-kubelogin/pkg/infrastructure/browser_mock"
+	"github.com/togethercomputer/together-kubelogin/mocks/github.com/togethercomputer/together-kubelogin/pkg/oidc/client_mock"
+	"github.com/togethercomputer/together-kubelogin/pkg/oidc"
+	"github.com/togethercomputer/together-kubelogin/pkg/oidc/client"
+	testingLogger "github.com/togethercomputer/together-kubelogin/pkg/testing/logger"
+	"github.com/togethercomputer/together-kubelogin/pkg/usecases/authentication/authcode"
+	"github.com/togethercomputer/together-kubelogin/pkg/usecases/authentication/devicecode"
+	"github.com/togethercomputer/together-kubelogin/pkg/usecases/authentication/ropc"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/oauth2"
+)
+
+func TestAuthentication_selectGrants(t *testing.T) {
+	browserOptionSet := GrantOptionSet{AuthCodeBrowserOption: &authcode.BrowserOption{}}
+	deviceCodeOptionSet := GrantOptionSet{DeviceCodeOption: &devicecode.Option{}}
+	pasteOptionSet := GrantOptionSet{AuthCodePasteOption: &authcode.PasteOption{}}
+	candidates := GrantOptionSet{
+		Candidates: []GrantOptionSet{browserOptionSet, deviceCodeOptionSet, pasteOptionSet},
+	}
+
+	tests := map[string]struct {
+		browserAvailable    bool
+		deviceCodeSupported bool
+		nonInteractive      bool
+		want                []GrantOptionSet
+		wantSkipped         int
+	}{
+		"BrowserIsAvailable": {
+			browserAvailable:    true,
+			deviceCodeSupported: true,
+			want:                []GrantOptionSet{browserOptionSet, deviceCodeOptionSet, pasteOptionSet},
+		},
+		"NoBrowser": {
+			deviceCodeSupported: true,
+			want:                []GrantOptionSet{deviceCodeOptionSet, pasteOptionSet},
+			wantSkipped:         1,
+		},
+		"NoBrowser/DeviceCodeIsNotSupported": {
+			want:        []GrantOptionSet{pasteOptionSet},
+			wantSkipped: 2,
+		},
+		// the last candidate is always tried
+		"NoBrowser/DeviceCodeIsNotSupported/NonInteractive": {
+			nonInteractive: true,
+			want:           []GrantOptionSet{pasteOptionSet},
+			wantSkipped:    2,
+		},
+	}
+	for name, c := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockClient := client_mock.NewMockInterface(t)
+			mockClient.EXPECT().SupportsGrantType(client.GrantTypeAuthorizationCode).Return(true).Maybe()
+			mockClient.EXPECT().SupportsGrantType(client.GrantTypeDeviceCode).Return(c.deviceCodeSupported).Maybe()
+			mockBrowser := browser_mock.NewMockInterface(t)
+			mockBrowser.EXPECT().IsAvailable().Return(c.browserAvailable).Maybe()
+			u := Authentication{
+				Logger:  testingLogger.New(t),
+				Browser: mockBrowser,
+			}
+			got, skipped := u.selectGrants(Input{GrantOptionSet: candidates, NonInteractive: c.nonInteractive}, mockClient)
+			if diff := cmp.Diff(c.want, got); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+			if len(skipped) != c.wantSkipped {
+				t.Errorf("skipped wants %d errors but got %v", c.wantSkipped, skipped)
+			}
+		})
+	}
+}
+
+func TestAuthentication_unavailableReason_ROPC(t *testing.T) {
+	tests := map[string]struct {
+		option         ropc.Option
+		nonInteractive bool
+		wantAvailable  bool
+	}{
+		"Interactive": {
+			wantAvailable: true,
+		},
+		"NonInteractive/Password": {
+			option:         ropc.Option{Username: "USER", Password: "PASS"},
+			nonInteractive: true,
+			wantAvailable:  true,
+		},
+		"NonInteractive/PasswordFile": {
+			option:         ropc.Option{Username: "USER", PasswordFile: "/path/to/password"},
+			nonInteractive: true,
+			wantAvailable:  true,
+		},
+		"NonInteractive/PasswordCommand": {
+			option:         ropc.Option{Username: "USER", PasswordCommand: "pass show"},
+			nonInteractive: true,
+			wantAvailable:  true,
+		},
+		"NonInteractive/PasswordStdin": {
+			option:         ropc.Option{Username: "USER", PasswordStdin: true},
+			nonInteractive: true,
+		},
+		"NonInteractive/NoUsername": {
+			option:         ropc.Option{PasswordFile: "/path/to/password"},
+			nonInteractive: true,
+		},
+		"NonInteractive/OTPPrompt": {
+			option:         ropc.Option{Username: "USER", Password: "PASS", OTPMode: ropc.OTPModeAppend},
+			nonInteractive: true,
+		},
+		"NonInteractive/OTPCommand": {
+			option:         ropc.Option{Username: "USER", Password: "PASS", OTPMode: ropc.OTPModeAppend, OTPCommand: "otp"},
+			nonInteractive: true,
+			wantAvailable:  true,
+		},
+	}
+	for name, c := range tests {
+		t.Run(name, func(t *testing.T) {
+			var u Authentication
+			reason := u.unavailableReason(GrantOptionSet{ROPCOption: &c.option}, c.nonInteractive, client_mock.NewMockInterface(t))
+			if (reason == "") != c.wantAvailable {
+				t.Errorf("available wants %v but the reason was %q", c.wantAvailable, reason)
+			}
+		})
+	}
+}
+
+func TestAuthentication_doCandidates(t *testing.T) {
+	deviceCodeOptionSet := GrantOptionSet{DeviceCodeOption: &devicecode.Option{}}
+	ropcOptionSet := GrantOptionSet{ROPCOption: &ropc.Option{Username: "USER", Password: "PASS"}}
+	in := Input{
+		GrantOptionSet: GrantOptionSet{
+			Candidates: []GrantOptionSet{deviceCodeOptionSet, ropcOptionSet},
+		},
+	}
+	newAuthentication := func(t *testing.T) Authentication {
+		return Authentication{
+			Logger:     testingLogger.New(t),
+			DeviceCode: &devicecode.DeviceCode{Logger: testingLogger.New(t)},
+			ROPC:       &ropc.ROPC{Logger: testingLogger.New(t)},
+		}
+	}
+
+	t.Run("FallbackOnRuntimeError", func(t *testing.T) {
+		ctx := context.TODO()
+		mockClient := client_mock.NewMockInterface(t)
+		mockClient.EXPECT().SupportsGrantType(client.GrantTypeDeviceCode).Return(true)
+		mockClient.EXPECT().
+			GetDeviceAuthorization(ctx, mock.Anything).
+			Return(nil, errors.New("connection reset by peer"))
+		mockClient.EXPECT().
+			GetTokenByROPC(ctx, mock.Anything).
+			Return(&oidc.TokenSet{IDToken: "YOUR_ID_TOKEN"}, nil)
+		u := newAuthentication(t)
+		tokenSet, grantType, err := u.doCandidates(ctx, in, mockClient)
+		if err != nil {
+			t.Fatalf("doCandidates error: %s", err)
+		}
+		if grantType != "password" {
+			t.Errorf("grantType wants password but got %s", grantType)
+		}
+		if tokenSet.IDToken != "YOUR_ID_TOKEN" {
+			t.Errorf("IDToken wants YOUR_ID_TOKEN but got %s", tokenSet.IDToken)
+		}
+	})
+
+	t.Run("KeepAllErrors", func(t *testing.T) {
+		ctx := context.TODO()
+		mockClient := client_mock.NewMockInterface(t)
+		mockClient.EXPECT().SupportsGrantType(client.GrantTypeDeviceCode).Return(true)
+		mockClient.EXPECT().
+			GetDeviceAuthorization(ctx, mock.Anything).
+			Return(nil, errors.New("connection reset by peer"))
+		mockClient.EXPECT().
+			GetTokenByROPC(ctx, mock.Anything).
+			Return(nil, errors.New("connection refused"))
+		u := newAuthentication(t)
+		_, _, err := u.doCandidates(ctx, in, mockClient)
+		if err == nil {
+			t.Fatalf("doCandidates wants an error but was nil")
+		}
+		for _, want := range []string{"connection reset by peer", "connection refused"} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("error wants to contain %q but was %s", want, err)
+			}
+		}
+	})
+
+	t.Run("NoFallbackOnProviderError", func(t *testing.T) {
+		ctx := context.TODO()
+		mockClient := client_mock.NewMockInterface(t)
+		mockClient.EXPECT().SupportsGrantType(client.GrantTypeDeviceCode).Return(true)
+		mockClient.EXPECT().
+			GetDeviceAuthorization(ctx, mock.Anything).
+			Return(nil, &oauth2.RetrieveError{ErrorCode: "access_denied"})
+		u := newAuthentication(t)
+		_, grantType, err := u.doCandidates(ctx, in, mockClient)
+		if err == nil {
+			t.Fatalf("doCandidates wants an error but was nil")
+		}
+		if grantType != "device-code" {
+			t.Errorf("grantType wants device-code but got %s", grantType)
+		}
+	})
+}
@@ -0,0 +1,75 @@
+package devicecode
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/clock"
+	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/terminal"
+)
+
+// countdown shows the remaining time of the authorization in a line of the terminal.
+type countdown struct {
+	terminal terminal.Interface
+	clock    clock.Interface
+	expiry   time.Time // zero if unknown
+
+	mu   sync.Mutex
+	stop chan struct{}
+	done chan struct{}
+}
+
+func startCountdown(t terminal.Interface, c clock.Interface, expiry time.Time) *countdown {
+	cd := &countdown{
+		terminal: t,
+		clock:    c,
+		expiry:   expiry,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	cd.render()
+	go func() {
+		defer close(cd.done)
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				cd.render()
+			case <-cd.stop:
+				return
+			}
+		}
+	}()
+	return cd
+}
+
+func (cd *countdown) render() {
+	cd.mu.Lock()
+	defer cd.mu.Unlock()
+	if cd.expiry.IsZero() {
+		_, _ = fmt.Fprint(cd.terminal, "\r\x1b[KWaiting for the authorization...")
+		return
+	}
+	remaining := max(cd.expiry.Sub(cd.clock.Now()).Round(time.Second), 0)
+	_, _ = fmt.Fprintf(cd.terminal, "\r\x1b[KWaiting for the authorization... (%d:%02d left)",
+		int(remaining.Minutes()), int(remaining.Seconds())%60)
+}
+
+// println writes the message above the countdown.
+func (cd *countdown) println(message string) {
+	cd.mu.Lock()
+	_, _ = fmt.Fprintf(cd.terminal, "\r\x1b[K%s\n", message)
+	cd.mu.Unlock()
+	cd.render()
+}
+
+// Stop stops the countdown and clears the line.
+func (cd *countdown) Stop() {
+	close(cd.stop)
+	<-cd.done
+	cd.mu.Lock()
+	defer cd.mu.Unlock()
+	_, _ = fmt.Fprint(cd.terminal, "\r\x1b[K")
+}
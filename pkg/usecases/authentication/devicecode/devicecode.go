@@ -3,52 +3,113 @@ package devicecode
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/browser"
+	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/clock"
 	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/logger"
+	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/terminal"
 	"github.com/togethercomputer/together-kubelogin/pkg/oidc"
 	"github.com/togethercomputer/together-kubelogin/pkg/oidc/client"
 )
 
 type Option struct {
-	SkipOpenBrowser bool
-	BrowserCommand  string
+	SkipOpenBrowser         bool
+	BrowserCommand          string
+	AuthenticationTimeout   time.Duration // max wait for the authorization, default to the expiry of the code if zero
+	PollingInterval         time.Duration // minimum interval of polling, optional
+	AuthRequestExtraParams  map[string]string
+	TokenRequestExtraParams map[string]string
 }
 
 // DeviceCode provides the oauth2 device code flow.
 type DeviceCode struct {
-	Browser browser.Interface
-	Logger  logger.Interface
+	Browser  browser.Interface
+	Terminal terminal.Interface
+	Clock    clock.Interface
+	Logger   logger.Interface
 }
 
 func (u *DeviceCode) Do(ctx context.Context, in *Option, oidcClient client.Interface) (*oidc.TokenSet, error) {
 	u.Logger.V(1).Infof("starting the oauth2 device code flow")
 
-	authResponse, err := oidcClient.GetDeviceAuthorization(ctx)
+	var authRequestExtraParams map[string]string
+	if in != nil {
+		authRequestExtraParams = in.AuthRequestExtraParams
+	}
+	authResponse, err := oidcClient.GetDeviceAuthorization(ctx, client.GetDeviceAuthorizationInput{
+		AuthRequestExtraParams: authRequestExtraParams,
+	})
 	if err != nil {
 		return nil, fmt.Errorf("authorization error: %w", err)
 	}
 
-	if authResponse.VerificationURIComplete != "" {
-		u.openURL(ctx, in, authResponse.VerificationURIComplete)
-	} else if authResponse.VerificationURI != "" {
-		u.Logger.Printf("Please enter the following code when asked in your browser: %s", authResponse.UserCode)
-		u.openURL(ctx, in, authResponse.VerificationURI)
-	} else if authResponse.VerificationURL != "" {
-		u.Logger.Printf("Please enter the following code when asked in your browser: %s", authResponse.UserCode)
-		u.openURL(ctx, in, authResponse.VerificationURL)
-	} else {
+	verificationURL := authResponse.URL()
+	if verificationURL == "" {
 		return nil, fmt.Errorf("no verification URI in the authorization response")
 	}
+	if authResponse.VerificationURIComplete == "" {
+		u.Logger.Printf("Please enter the following code when asked in your browser: %s", authResponse.UserCode)
+	}
+	isTerminal := u.Terminal.IsTerminal()
+	if isTerminal {
+		u.showQRCode(verificationURL)
+	}
+	u.openURL(ctx, in, verificationURL)
 
-	tokenSet, err := oidcClient.ExchangeDeviceCode(ctx, authResponse)
+	exchangeInput := client.ExchangeDeviceCodeInput{AuthResponse: authResponse}
+	if in != nil {
+		exchangeInput.TokenRequestExtraParams = in.TokenRequestExtraParams
+		exchangeInput.MinInterval = in.PollingInterval
+	}
+	if timeout := authenticationTimeout(in, authResponse.ExpiresIn); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	println := func(message string) { u.Logger.Printf("%s", message) }
+	if isTerminal {
+		// show the earlier of the expiry of the code or the timeout
+		var expiry time.Time
+		if authResponse.ExpiresIn > 0 {
+			expiry = u.Clock.Now().Add(time.Duration(authResponse.ExpiresIn) * time.Second)
+		}
+		if deadline, ok := ctx.Deadline(); ok && (expiry.IsZero() || deadline.Before(expiry)) {
+			expiry = deadline
+		}
+		cd := startCountdown(u.Terminal, u.Clock, expiry)
+		defer cd.Stop()
+		println = cd.println
+	}
+	exchangeInput.OnSlowDown = func(interval time.Duration) {
+		println(fmt.Sprintf("The server asked to slow down, polling every %s", interval))
+	}
+	tokenSet, err := oidcClient.ExchangeDeviceCode(ctx, exchangeInput)
 	u.Logger.V(1).Infof("finished the oauth2 device code flow")
 	if err != nil {
-		return nil, fmt.Errorf("unable to exchange device code: %w", err)
+		return nil, wrapExchangeError(err)
 	}
 	return tokenSet, nil
 }
 
+// authenticationTimeout returns the timeout of the option,
+// or the expiry of the code if the timeout is not set.
+func authenticationTimeout(in *Option, expiresIn int) time.Duration {
+	if in != nil && in.AuthenticationTimeout > 0 {
+		return in.AuthenticationTimeout
+	}
+	return time.Duration(expiresIn) * time.Second
+}
+
+func (u *DeviceCode) showQRCode(text string) {
+	qrCode, err := renderQRCode(text)
+	if err != nil {
+		u.Logger.V(1).Infof("could not render the QR code: %s", err)
+		return
+	}
+	_, _ = fmt.Fprintf(u.Terminal, "Scan the QR code to log in from your phone:\n%s", qrCode)
+}
+
 func (u *DeviceCode) openURL(ctx context.Context, o *Option, url string) {
 	if o != nil && o.SkipOpenBrowser {
 		u.Logger.Printf("Please visit the following URL in your browser: %s", url)
@@ -3,11 +3,18 @@ package devicecode
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/togethercomputer/together-kubelogin/mocks/github.com/togethercomputer/together-kubelogin/pkg/infrastructure/browser_mock"
+	"github.com/togethercomputer/together-kubelogin/mocks/github.com/togethercomputer/together-kubelogin/pkg/infrastructure/terminal_mock"
 	"github.com/togethercomputer/together-kubelogin/mocks/github.com/togethercomputer/together-kubelogin/pkg/oidc/client_mock"
 	"github.com/togethercomputer/together-kubelogin/pkg/oidc"
+	"github.com/togethercomputer/together-kubelogin/pkg/oidc/client"
+	testingClock "github.com/togethercomputer/together-kubelogin/pkg/testing/clock"
 	"github.com/togethercomputer/together-kubelogin/pkg/testing/logger"
 	"github.com/int128/oauth2dev"
 	"github.com/stretchr/testify/mock"
@@ -19,11 +26,12 @@ func TestDeviceCode(t *testing.T) {
 	t.Run("Authorization error", func(t *testing.T) {
 		mockClient := client_mock.NewMockInterface(t)
 		dc := &DeviceCode{
-			Browser: browser_mock.NewMockInterface(t),
-			Logger:  logger.New(t),
+			Browser:  browser_mock.NewMockInterface(t),
+			Terminal: newNonTerminal(t),
+			Logger:   logger.New(t),
 		}
 		errTest := errors.New("test error")
-		mockClient.EXPECT().GetDeviceAuthorization(ctx).Return(nil, errTest).Once()
+		mockClient.EXPECT().GetDeviceAuthorization(ctx, client.GetDeviceAuthorizationInput{}).Return(nil, errTest).Once()
 		_, err := dc.Do(ctx, &Option{}, mockClient)
 		if !errors.Is(err, errTest) {
 			t.Errorf("returned error is not the test error: %v", err)
@@ -34,8 +42,9 @@ func TestDeviceCode(t *testing.T) {
 		mockBrowser := browser_mock.NewMockInterface(t)
 		mockClient := client_mock.NewMockInterface(t)
 		dc := &DeviceCode{
-			Browser: mockBrowser,
-			Logger:  logger.New(t),
+			Browser:  mockBrowser,
+			Terminal: newNonTerminal(t),
+			Logger:   logger.New(t),
 		}
 		mockResponse := &oauth2dev.AuthorizationResponse{
 			DeviceCode:              "device-code-1",
@@ -43,14 +52,14 @@ func TestDeviceCode(t *testing.T) {
 			ExpiresIn:               2,
 			Interval:                1,
 		}
-		mockClient.EXPECT().GetDeviceAuthorization(ctx).Return(&oauth2dev.AuthorizationResponse{
+		mockClient.EXPECT().GetDeviceAuthorization(ctx, client.GetDeviceAuthorizationInput{}).Return(&oauth2dev.AuthorizationResponse{
 			Interval:                1,
 			ExpiresIn:               2,
 			VerificationURIComplete: "https://example.com/verificationComplete?code=code123",
 			DeviceCode:              "device-code-1",
 		}, nil).Once()
 		mockBrowser.EXPECT().Open("https://example.com/verificationComplete?code=code123").Return(nil).Once()
-		mockClient.EXPECT().ExchangeDeviceCode(mock.Anything, mockResponse).Return(&oidc.TokenSet{
+		mockClient.EXPECT().ExchangeDeviceCode(mock.Anything, exchangeInputOf(mockResponse)).Return(&oidc.TokenSet{
 			IDToken: "test-id-token",
 		}, nil).Once()
 		ts, err := dc.Do(ctx, &Option{}, mockClient)
@@ -66,8 +75,9 @@ func TestDeviceCode(t *testing.T) {
 		mockBrowser := browser_mock.NewMockInterface(t)
 		mockClient := client_mock.NewMockInterface(t)
 		dc := &DeviceCode{
-			Browser: mockBrowser,
-			Logger:  logger.New(t),
+			Browser:  mockBrowser,
+			Terminal: newNonTerminal(t),
+			Logger:   logger.New(t),
 		}
 		mockResponseWithoutComplete := &oauth2dev.AuthorizationResponse{
 			DeviceCode:      "device-code-1",
@@ -75,14 +85,14 @@ func TestDeviceCode(t *testing.T) {
 			ExpiresIn:       2,
 			Interval:        1,
 		}
-		mockClient.EXPECT().GetDeviceAuthorization(ctx).Return(&oauth2dev.AuthorizationResponse{
+		mockClient.EXPECT().GetDeviceAuthorization(ctx, client.GetDeviceAuthorizationInput{}).Return(&oauth2dev.AuthorizationResponse{
 			Interval:        1,
 			ExpiresIn:       2,
 			VerificationURI: "https://example.com/verificationComplete",
 			DeviceCode:      "device-code-1",
 		}, nil).Once()
 		mockBrowser.EXPECT().Open("https://example.com/verificationComplete").Return(nil).Once()
-		mockClient.EXPECT().ExchangeDeviceCode(mock.Anything, mockResponseWithoutComplete).Return(&oidc.TokenSet{
+		mockClient.EXPECT().ExchangeDeviceCode(mock.Anything, exchangeInputOf(mockResponseWithoutComplete)).Return(&oidc.TokenSet{
 			IDToken: "test-id-token",
 		}, nil).Once()
 		ts, err := dc.Do(ctx, &Option{}, mockClient)
@@ -98,8 +108,9 @@ func TestDeviceCode(t *testing.T) {
 		mockBrowser := browser_mock.NewMockInterface(t)
 		mockClient := client_mock.NewMockInterface(t)
 		dc := &DeviceCode{
-			Browser: mockBrowser,
-			Logger:  logger.New(t),
+			Browser:  mockBrowser,
+			Terminal: newNonTerminal(t),
+			Logger:   logger.New(t),
 		}
 		mockResponse := &oauth2dev.AuthorizationResponse{
 			DeviceCode:      "device-code-1",
@@ -107,9 +118,9 @@ func TestDeviceCode(t *testing.T) {
 			ExpiresIn:       2,
 			Interval:        1,
 		}
-		mockClient.EXPECT().GetDeviceAuthorization(ctx).Return(mockResponse, nil).Once()
+		mockClient.EXPECT().GetDeviceAuthorization(ctx, client.GetDeviceAuthorizationInput{}).Return(mockResponse, nil).Once()
 		mockBrowser.EXPECT().Open("https://example.com/verificationCompleteURL").Return(nil).Once()
-		mockClient.EXPECT().ExchangeDeviceCode(mock.Anything, mockResponse).Return(&oidc.TokenSet{
+		mockClient.EXPECT().ExchangeDeviceCode(mock.Anything, exchangeInputOf(mockResponse)).Return(&oidc.TokenSet{
 			IDToken: "test-id-token",
 		}, nil).Once()
 		ts, err := dc.Do(ctx, &Option{}, mockClient)
@@ -125,8 +136,9 @@ func TestDeviceCode(t *testing.T) {
 		mockBrowser := browser_mock.NewMockInterface(t)
 		mockClient := client_mock.NewMockInterface(t)
 		dc := &DeviceCode{
-			Browser: mockBrowser,
-			Logger:  logger.New(t),
+			Browser:  mockBrowser,
+			Terminal: newNonTerminal(t),
+			Logger:   logger.New(t),
 		}
 		mockResponse := &oauth2dev.AuthorizationResponse{
 			DeviceCode:              "device-code-1",
@@ -134,19 +146,156 @@ func TestDeviceCode(t *testing.T) {
 			ExpiresIn:               2,
 			Interval:                1,
 		}
-		mockClient.EXPECT().GetDeviceAuthorization(ctx).Return(&oauth2dev.AuthorizationResponse{
+		mockClient.EXPECT().GetDeviceAuthorization(ctx, client.GetDeviceAuthorizationInput{}).Return(&oauth2dev.AuthorizationResponse{
 			Interval:                1,
 			ExpiresIn:               2,
 			VerificationURIComplete: "https://example.com/verificationComplete?code=code123",
 			DeviceCode:              "device-code-1",
 		}, nil).Once()
 		mockBrowser.EXPECT().Open("https://example.com/verificationComplete?code=code123").Return(nil).Once()
-		mockClient.EXPECT().ExchangeDeviceCode(mock.Anything, mockResponse).Return(nil, errors.New("test error")).Once()
+		mockClient.EXPECT().ExchangeDeviceCode(mock.Anything, exchangeInputOf(mockResponse)).Return(nil, errors.New("test error")).Once()
 		_, err := dc.Do(ctx, &Option{}, mockClient)
 		if err == nil {
 			t.Errorf("did not return error: %v", err)
 		}
 	})
+
+	t.Run("Access denied when exchanging the device code", func(t *testing.T) {
+		mockBrowser := browser_mock.NewMockInterface(t)
+		mockClient := client_mock.NewMockInterface(t)
+		dc := &DeviceCode{
+			Browser:  mockBrowser,
+			Terminal: newNonTerminal(t),
+			Logger:   logger.New(t),
+		}
+		mockResponse := &oauth2dev.AuthorizationResponse{
+			DeviceCode:              "device-code-1",
+			VerificationURIComplete: "https://example.com/verificationComplete?code=code123",
+		}
+		mockClient.EXPECT().GetDeviceAuthorization(ctx, client.GetDeviceAuthorizationInput{}).Return(mockResponse, nil).Once()
+		mockBrowser.EXPECT().Open("https://example.com/verificationComplete?code=code123").Return(nil).Once()
+		errDenied := fmt.Errorf("device-code: exchange failed: %w",
+			oauth2dev.TokenErrorResponse{ErrorCode: oauth2dev.TokenErrorAccessDenied})
+		mockClient.EXPECT().ExchangeDeviceCode(mock.Anything, exchangeInputOf(mockResponse)).Return(nil, errDenied).Once()
+		_, err := dc.Do(ctx, &Option{}, mockClient)
+		var deviceCodeError *Error
+		if !errors.As(err, &deviceCodeError) {
+			t.Fatalf("error wants Error but was %T: %v", err, err)
+		}
+		if deviceCodeError.Kind != ErrorKindAccessDenied {
+			t.Errorf("Kind wants %s but was %s", ErrorKindAccessDenied, deviceCodeError.Kind)
+		}
+	})
+
+	t.Run("Options are passed to the client", func(t *testing.T) {
+		mockClient := client_mock.NewMockInterface(t)
+		dc := &DeviceCode{
+			Browser:  browser_mock.NewMockInterface(t),
+			Terminal: newNonTerminal(t),
+			Logger:   logger.New(t),
+		}
+		o := &Option{
+			SkipOpenBrowser:         true,
+			AuthenticationTimeout:   time.Minute,
+			PollingInterval:         10 * time.Second,
+			AuthRequestExtraParams:  map[string]string{"audience": "https://api.example.com"},
+			TokenRequestExtraParams: map[string]string{"resource": "https://api.example.com"},
+		}
+		mockResponse := &oauth2dev.AuthorizationResponse{
+			DeviceCode:      "device-code-1",
+			VerificationURI: "https://example.com/verification",
+			UserCode:        "USER-CODE",
+		}
+		mockClient.EXPECT().GetDeviceAuthorization(ctx, client.GetDeviceAuthorizationInput{
+			AuthRequestExtraParams: map[string]string{"audience": "https://api.example.com"},
+		}).Return(mockResponse, nil).Once()
+		mockClient.EXPECT().ExchangeDeviceCode(
+			mock.MatchedBy(func(ctx context.Context) bool {
+				_, ok := ctx.Deadline()
+				return ok
+			}),
+			mock.MatchedBy(func(in client.ExchangeDeviceCodeInput) bool {
+				return in.AuthResponse == mockResponse &&
+					in.MinInterval == 10*time.Second &&
+					in.TokenRequestExtraParams["resource"] == "https://api.example.com"
+			}),
+		).Return(&oidc.TokenSet{IDToken: "test-id-token"}, nil).Once()
+		if _, err := dc.Do(ctx, o, mockClient); err != nil {
+			t.Errorf("returned unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Terminal shows the QR code and countdown", func(t *testing.T) {
+		mockBrowser := browser_mock.NewMockInterface(t)
+		mockClient := client_mock.NewMockInterface(t)
+		var term fakeTerminal
+		dc := &DeviceCode{
+			Browser:  mockBrowser,
+			Terminal: &term,
+			Clock:    testingClock.Fake(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)),
+			Logger:   logger.New(t),
+		}
+		mockResponse := &oauth2dev.AuthorizationResponse{
+			DeviceCode:              "device-code-1",
+			VerificationURIComplete: "https://example.com/verificationComplete?code=code123",
+			ExpiresIn:               600,
+		}
+		mockClient.EXPECT().GetDeviceAuthorization(ctx, client.GetDeviceAuthorizationInput{}).Return(mockResponse, nil).Once()
+		mockBrowser.EXPECT().Open("https://example.com/verificationComplete?code=code123").Return(nil).Once()
+		mockClient.EXPECT().ExchangeDeviceCode(mock.Anything, exchangeInputOf(mockResponse)).
+			RunAndReturn(func(ctx context.Context, in client.ExchangeDeviceCodeInput) (*oidc.TokenSet, error) {
+				in.OnSlowDown(10 * time.Second)
+				return &oidc.TokenSet{IDToken: "test-id-token"}, nil
+			}).Once()
+		if _, err := dc.Do(ctx, &Option{}, mockClient); err != nil {
+			t.Fatalf("returned unexpected error: %v", err)
+		}
+		out := term.String()
+		for _, want := range []string{
+			"Scan the QR code",
+			"▀",
+			"10:00 left",
+			"The server asked to slow down, polling every 10s\n",
+		} {
+			if !strings.Contains(out, want) {
+				t.Errorf("output wants %q but was:\n%s", want, out)
+			}
+		}
+		if !strings.HasSuffix(out, "\r\x1b[K") {
+			t.Errorf("countdown line is not cleared: %q", out)
+		}
+	})
+}
+
+func exchangeInputOf(authResponse *oauth2dev.AuthorizationResponse) any {
+	return mock.MatchedBy(func(in client.ExchangeDeviceCodeInput) bool {
+		return in.AuthResponse != nil && *in.AuthResponse == *authResponse && in.OnSlowDown != nil
+	})
+}
+
+func newNonTerminal(t *testing.T) *terminal_mock.MockInterface {
+	mockTerminal := terminal_mock.NewMockInterface(t)
+	mockTerminal.EXPECT().IsTerminal().Return(false).Maybe()
+	return mockTerminal
+}
+
+type fakeTerminal struct {
+	mu  sync.Mutex
+	buf strings.Builder
+}
+
+func (f *fakeTerminal) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.buf.Write(p)
+}
+
+func (f *fakeTerminal) IsTerminal() bool { return true }
+
+func (f *fakeTerminal) String() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.buf.String()
 }
 
 func TestDeviceCode_openURL(t *testing.T) {
@@ -183,3 +332,24 @@ func TestDeviceCode_openURL(t *testing.T) {
 		deviceCode.openURL(ctx, &Option{BrowserCommand: "test-command"}, url)
 	})
 }
+
+func Test_authenticationTimeout(t *testing.T) {
+	tests := map[string]struct {
+		in        *Option
+		expiresIn int
+		want      time.Duration
+	}{
+		"NoOption":            {expiresIn: 600, want: 600 * time.Second},
+		"NoTimeout":           {in: &Option{}, expiresIn: 600, want: 600 * time.Second},
+		"Timeout":             {in: &Option{AuthenticationTimeout: time.Minute}, expiresIn: 600, want: time.Minute},
+		"NoExpiryNoTimeout":   {in: &Option{}},
+		"TimeoutWithNoExpiry": {in: &Option{AuthenticationTimeout: time.Minute}, want: time.Minute},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := authenticationTimeout(tc.in, tc.expiresIn); got != tc.want {
+				t.Errorf("authenticationTimeout wants %s but was %s", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package devicecode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/int128/oauth2dev"
+)
+
+// ErrorKind represents a kind of error of the device code flow.
+type ErrorKind string
+
+const (
+	ErrorKindAccessDenied ErrorKind = oauth2dev.TokenErrorAccessDenied
+	ErrorKindExpiredToken ErrorKind = oauth2dev.TokenErrorExpiredToken
+	ErrorKindTimeout      ErrorKind = "timeout"
+)
+
+// Error represents an error of the device code flow with a message for the user.
+type Error struct {
+	Kind ErrorKind
+	Err  error
+}
+
+func (e *Error) Error() string {
+	switch e.Kind {
+	case ErrorKindAccessDenied:
+		return "the authorization request was denied in the browser"
+	case ErrorKindExpiredToken:
+		return "the code has expired before the authorization, please try again"
+	case ErrorKindTimeout:
+		return "timed out waiting for the authorization in the browser"
+	}
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// wrapExchangeError maps the error of the token request to an Error if possible.
+func wrapExchangeError(err error) error {
+	var eresp oauth2dev.TokenErrorResponse
+	if errors.As(err, &eresp) {
+		switch eresp.ErrorCode {
+		case oauth2dev.TokenErrorAccessDenied:
+			return &Error{Kind: ErrorKindAccessDenied, Err: err}
+		case oauth2dev.TokenErrorExpiredToken:
+			return &Error{Kind: ErrorKindExpiredToken, Err: err}
+		}
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return &Error{Kind: ErrorKindTimeout, Err: err}
+	}
+	return fmt.Errorf("unable to exchange device code: %w", err)
+}
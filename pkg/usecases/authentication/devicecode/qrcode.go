@@ -0,0 +1,43 @@
+package devicecode
+
+import (
+	"fmt"
+	"strings"
+
+	"rsc.io/qr"
+)
+
+// qrCodeQuietZone is the margin of the QR code in modules.
+const qrCodeQuietZone = 2
+
+// renderQRCode returns the QR code of the text for a terminal.
+// It draws 2 rows of modules in a line by the Unicode half blocks,
+// with black on white regardless of the color scheme of the terminal.
+func renderQRCode(text string) (string, error) {
+	code, err := qr.Encode(text, qr.L)
+	if err != nil {
+		return "", fmt.Errorf("could not encode the QR code: %w", err)
+	}
+	dark := func(x, y int) bool {
+		return x >= 0 && y >= 0 && x < code.Size && y < code.Size && code.Black(x, y)
+	}
+	var b strings.Builder
+	for y := -qrCodeQuietZone; y < code.Size+qrCodeQuietZone; y += 2 {
+		b.WriteString("\x1b[30;107m")
+		for x := -qrCodeQuietZone; x < code.Size+qrCodeQuietZone; x++ {
+			top, bottom := dark(x, y), dark(x, y+1)
+			switch {
+			case top && bottom:
+				b.WriteString("█")
+			case top:
+				b.WriteString("▀")
+			case bottom:
+				b.WriteString("▄")
+			default:
+				b.WriteString(" ")
+			}
+		}
+		b.WriteString("\x1b[0m\n")
+	}
+	return b.String(), nil
+}
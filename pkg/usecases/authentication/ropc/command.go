@@ -0,0 +1,35 @@
+package ropc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// runCommand runs the command by the shell and returns the standard output.
+// The standard error is passed through, so that the command can prompt the user.
+func runCommand(ctx context.Context, command string) ([]byte, error) {
+	name, _, _ := strings.Cut(strings.TrimSpace(command), " ")
+	if name == "" {
+		return nil, errors.New("command must not be blank")
+	}
+	var c *exec.Cmd
+	if runtime.GOOS == "windows" {
+		c = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		c = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	var stdout bytes.Buffer
+	c.Stdout = &stdout
+	c.Stderr = os.Stderr
+	if err := c.Run(); err != nil {
+		clear(stdout.Bytes())
+		return nil, fmt.Errorf("could not run %s: %w", name, err)
+	}
+	return stdout.Bytes(), nil
+}
@@ -1,49 +1,178 @@
 package ropc
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"os"
 
 	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/logger"
 	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/reader"
+	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/stdio"
 	"github.com/togethercomputer/together-kubelogin/pkg/oidc"
 	"github.com/togethercomputer/together-kubelogin/pkg/oidc/client"
 )
 
 const usernamePrompt = "Username: "
 const passwordPrompt = "Password: "
+const otpPrompt = "One-time password: "
+
+// OTPMode represents how to send a one-time password of the second factor.
+type OTPMode string
+
+const (
+	OTPModeNone   OTPMode = ""
+	OTPModeAppend OTPMode = "append" // append the OTP to the password
+	OTPModeParam  OTPMode = "param"  // send the OTP as a parameter of the token request
+)
 
 type Option struct {
 	Username string
-	Password string // If empty, read a password using Reader.ReadPassword()
+	// The password is read from the first source which is set.
+	// If none is set, read a password using Reader.ReadPassword().
+	Password        string
+	PasswordFile    string // optional, read the first line of the file
+	PasswordCommand string // optional, read the first line of the output of the command
+	PasswordStdin   bool   // optional, read the first line of the standard input
+	OTPMode         OTPMode
+	OTPParamName    string // name of the parameter for OTPModeParam
+	OTPCommand      string // optional, read the OTP from the command instead of prompting
+	// NonInteractive is set by the authentication use-case if the standard input is not available.
+	NonInteractive bool
 }
 
 // ROPC provides the resource owner password credentials flow.
 type ROPC struct {
 	Reader reader.Interface
+	Stdin  stdio.Stdin
 	Logger logger.Interface
 }
 
 func (u *ROPC) Do(ctx context.Context, in *Option, oidcClient client.Interface) (*oidc.TokenSet, error) {
 	u.Logger.V(1).Infof("starting the resource owner password credentials flow")
-	if in.Username == "" {
+	username := in.Username
+	if username == "" {
+		if in.NonInteractive {
+			return nil, errors.New("username is required in the non-interactive mode: set --username")
+		}
 		var err error
-		in.Username, err = u.Reader.ReadString(usernamePrompt)
+		username, err = u.Reader.ReadString(usernamePrompt)
 		if err != nil {
 			return nil, fmt.Errorf("could not read a username: %w", err)
 		}
 	}
-	if in.Password == "" {
-		var err error
-		in.Password, err = u.Reader.ReadPassword(passwordPrompt)
+	password, err := u.readPassword(ctx, in)
+	if err != nil {
+		return nil, fmt.Errorf("could not read a password: %w", err)
+	}
+	// Clear the password after the request.
+	// Note that a copy of string may remain in the memory until the garbage collection.
+	defer clear(password)
+
+	credential := password
+	var extraParams map[string]string
+	if in.OTPMode != OTPModeNone {
+		otp, err := u.readOTP(ctx, in)
 		if err != nil {
-			return nil, fmt.Errorf("could not read a password: %w", err)
+			return nil, fmt.Errorf("could not read a one-time password: %w", err)
+		}
+		defer clear(otp)
+		switch in.OTPMode {
+		case OTPModeAppend:
+			// Build the combined value in a new buffer,
+			// so that no copy of the password remains in a reallocated buffer.
+			credential = make([]byte, 0, len(password)+len(otp))
+			credential = append(credential, password...)
+			credential = append(credential, otp...)
+			defer clear(credential)
+		case OTPModeParam:
+			extraParams = map[string]string{in.OTPParamName: string(otp)}
+		default:
+			return nil, fmt.Errorf("unknown OTP mode: %s", in.OTPMode)
 		}
 	}
-	tokenSet, err := oidcClient.GetTokenByROPC(ctx, in.Username, in.Password)
+
+	tokenSet, err := oidcClient.GetTokenByROPC(ctx, client.GetTokenByROPCInput{
+		Username:                username,
+		Password:                string(credential),
+		TokenRequestExtraParams: extraParams,
+	})
 	if err != nil {
 		return nil, fmt.Errorf("resource owner password credentials flow error: %w", err)
 	}
 	u.Logger.V(1).Infof("finished the resource owner password credentials flow")
 	return tokenSet, nil
 }
+
+// readPassword returns the password from the first source which is set.
+// The caller should clear the returned slice after use.
+func (u *ROPC) readPassword(ctx context.Context, in *Option) ([]byte, error) {
+	switch {
+	case in.Password != "":
+		return []byte(in.Password), nil
+	case in.PasswordFile != "":
+		b, err := os.ReadFile(in.PasswordFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not read the password file: %w", err)
+		}
+		defer clear(b)
+		return firstLine(b, "the password file")
+	case in.PasswordCommand != "":
+		b, err := runCommand(ctx, in.PasswordCommand)
+		if err != nil {
+			return nil, fmt.Errorf("password command error: %w", err)
+		}
+		defer clear(b)
+		return firstLine(b, "the output of the password command")
+	case in.PasswordStdin:
+		if u.Stdin == nil {
+			return nil, errors.New("standard input is not available")
+		}
+		line, err := bufio.NewReader(u.Stdin).ReadBytes('\n')
+		if err != nil && len(line) == 0 {
+			return nil, fmt.Errorf("could not read the standard input: %w", err)
+		}
+		defer clear(line)
+		return firstLine(line, "the standard input")
+	case in.NonInteractive:
+		return nil, errors.New("no password source in the non-interactive mode: " +
+			"set --password-file, --password-command or --password-stdin")
+	}
+	s, err := u.Reader.ReadPassword(passwordPrompt)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(s), nil
+}
+
+func (u *ROPC) readOTP(ctx context.Context, in *Option) ([]byte, error) {
+	if in.OTPCommand != "" {
+		b, err := runCommand(ctx, in.OTPCommand)
+		if err != nil {
+			return nil, fmt.Errorf("OTP command error: %w", err)
+		}
+		defer clear(b)
+		return firstLine(b, "the output of the OTP command")
+	}
+	if in.NonInteractive {
+		return nil, errors.New("OTP is required in the non-interactive mode: set --otp-command")
+	}
+	s, err := u.Reader.ReadString(otpPrompt)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(s), nil
+}
+
+// firstLine returns a copy of the first line without the line break.
+// It returns an error if the first line is empty.
+func firstLine(b []byte, source string) ([]byte, error) {
+	line, _, _ := bytes.Cut(b, []byte("\n"))
+	line = bytes.TrimSuffix(line, []byte("\r"))
+	if len(line) == 0 {
+		return nil, fmt.Errorf("the first line of %s is empty", source)
+	}
+	return bytes.Clone(line), nil
+}
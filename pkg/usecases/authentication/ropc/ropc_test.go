@@ -3,6 +3,9 @@ package ropc
 import (
 	"context"
 	"errors"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -10,6 +13,7 @@ import (
 	"github.com/togethercomputer/together-kubelogin/mocks/github.com/togethercomputer/together-kubelogin/pkg/infrastructure/reader_mock"
 	"github.com/togethercomputer/together-kubelogin/mocks/github.com/togethercomputer/together-kubelogin/pkg/oidc/client_mock"
 	"github.com/togethercomputer/together-kubelogin/pkg/oidc"
+	"github.com/togethercomputer/together-kubelogin/pkg/oidc/client"
 	"github.com/togethercomputer/together-kubelogin/pkg/testing/logger"
 	"github.com/stretchr/testify/mock"
 )
@@ -23,7 +27,7 @@ func TestROPC_Do(t *testing.T) {
 		o := &Option{}
 		mockClient := client_mock.NewMockInterface(t)
 		mockClient.EXPECT().
-			GetTokenByROPC(mock.Anything, "USER", "PASS").
+			GetTokenByROPC(mock.Anything, client.GetTokenByROPCInput{Username: "USER", Password: "PASS"}).
 			Return(&oidc.TokenSet{
 				IDToken:      "YOUR_ID_TOKEN",
 				RefreshToken: "YOUR_REFRESH_TOKEN",
@@ -57,7 +61,7 @@ func TestROPC_Do(t *testing.T) {
 		}
 		mockClient := client_mock.NewMockInterface(t)
 		mockClient.EXPECT().
-			GetTokenByROPC(mock.Anything, "USER", "PASS").
+			GetTokenByROPC(mock.Anything, client.GetTokenByROPCInput{Username: "USER", Password: "PASS"}).
 			Return(&oidc.TokenSet{
 				IDToken:      "YOUR_ID_TOKEN",
 				RefreshToken: "YOUR_REFRESH_TOKEN",
@@ -86,7 +90,7 @@ func TestROPC_Do(t *testing.T) {
 		}
 		mockClient := client_mock.NewMockInterface(t)
 		mockClient.EXPECT().
-			GetTokenByROPC(mock.Anything, "USER", "PASS").
+			GetTokenByROPC(mock.Anything, client.GetTokenByROPCInput{Username: "USER", Password: "PASS"}).
 			Return(&oidc.TokenSet{
 				IDToken:      "YOUR_ID_TOKEN",
 				RefreshToken: "YOUR_REFRESH_TOKEN",
@@ -131,3 +135,107 @@ func TestROPC_Do(t *testing.T) {
 		}
 	})
 }
+
+func TestROPC_Do_sources(t *testing.T) {
+	passwordFile := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(passwordFile, []byte("PASS\n"), 0600); err != nil {
+		t.Fatalf("could not write the password file: %s", err)
+	}
+	tests := map[string]struct {
+		option Option
+		stdin  string
+		otp    string // returned by the reader
+		want   client.GetTokenByROPCInput
+	}{
+		"PasswordFile": {
+			option: Option{Username: "USER", PasswordFile: passwordFile},
+			want:   client.GetTokenByROPCInput{Username: "USER", Password: "PASS"},
+		},
+		"PasswordCommand": {
+			option: Option{Username: "USER", PasswordCommand: "echo PASS"},
+			want:   client.GetTokenByROPCInput{Username: "USER", Password: "PASS"},
+		},
+		"PasswordStdin": {
+			option: Option{Username: "USER", PasswordStdin: true},
+			stdin:  "PASS\r\nNEXT_LINE\n",
+			want:   client.GetTokenByROPCInput{Username: "USER", Password: "PASS"},
+		},
+		"OTPAppend": {
+			option: Option{Username: "USER", Password: "PASS", OTPMode: OTPModeAppend, OTPCommand: "echo 123456"},
+			want:   client.GetTokenByROPCInput{Username: "USER", Password: "PASS123456"},
+		},
+		"OTPParam": {
+			option: Option{Username: "USER", Password: "PASS", OTPMode: OTPModeParam, OTPParamName: "otp"},
+			otp:    "123456",
+			want: client.GetTokenByROPCInput{
+				Username:                "USER",
+				Password:                "PASS",
+				TokenRequestExtraParams: map[string]string{"otp": "123456"},
+			},
+		},
+	}
+	for name, c := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.TODO()
+			mockClient := client_mock.NewMockInterface(t)
+			mockClient.EXPECT().
+				GetTokenByROPC(mock.Anything, c.want).
+				Return(&oidc.TokenSet{IDToken: "YOUR_ID_TOKEN"}, nil)
+			mockReader := reader_mock.NewMockInterface(t)
+			if c.otp != "" {
+				mockReader.EXPECT().ReadString(otpPrompt).Return(c.otp, nil)
+			}
+			u := ROPC{
+				Reader: mockReader,
+				Stdin:  strings.NewReader(c.stdin),
+				Logger: logger.New(t),
+			}
+			if _, err := u.Do(ctx, &c.option, mockClient); err != nil {
+				t.Errorf("Do returned error: %+v", err)
+			}
+		})
+	}
+
+	t.Run("EmptyFirstLine", func(t *testing.T) {
+		emptyFile := filepath.Join(t.TempDir(), "empty")
+		if err := os.WriteFile(emptyFile, []byte("\nPASS\n"), 0600); err != nil {
+			t.Fatalf("could not write the password file: %s", err)
+		}
+		for name, c := range map[string]struct {
+			option Option
+			stdin  string
+		}{
+			"PasswordFile":    {option: Option{Username: "USER", PasswordFile: emptyFile}},
+			"PasswordCommand": {option: Option{Username: "USER", PasswordCommand: "echo"}},
+			"PasswordStdin":   {option: Option{Username: "USER", PasswordStdin: true}, stdin: "\r\n"},
+			"OTPCommand":      {option: Option{Username: "USER", Password: "PASS", OTPMode: OTPModeAppend, OTPCommand: "echo"}},
+			"BlankCommand":    {option: Option{Username: "USER", PasswordCommand: " "}},
+		} {
+			t.Run(name, func(t *testing.T) {
+				u := ROPC{
+					Reader: reader_mock.NewMockInterface(t),
+					Stdin:  strings.NewReader(c.stdin),
+					Logger: logger.New(t),
+				}
+				_, err := u.Do(context.TODO(), &c.option, client_mock.NewMockInterface(t))
+				if err == nil {
+					t.Fatalf("err wants non-nil but nil")
+				}
+				t.Logf("expected error: %s", err)
+			})
+		}
+	})
+
+	t.Run("NonInteractiveWithoutSource", func(t *testing.T) {
+		u := ROPC{
+			Reader: reader_mock.NewMockInterface(t),
+			Logger: logger.New(t),
+		}
+		o := &Option{Username: "USER", NonInteractive: true}
+		_, err := u.Do(context.TODO(), o, client_mock.NewMockInterface(t))
+		if err == nil {
+			t.Fatalf("err wants non-nil but nil")
+		}
+		t.Logf("expected error: %s", err)
+	})
+}
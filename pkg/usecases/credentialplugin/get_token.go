@@ -6,18 +6,26 @@ package credentialplugin
 import (
 	"context"
 	"fmt"
+	"io"
+	"os"
 
 	"github.com/google/wire"
+	"github.com/togethercomputer/together-kubelogin/pkg/audit"
+	auditrepository "github.com/togethercomputer/together-kubelogin/pkg/audit/repository"
+	"github.com/togethercomputer/together-kubelogin/pkg/claimpolicy"
 	"github.com/togethercomputer/together-kubelogin/pkg/credentialplugin"
 	credentialpluginreader "github.com/togethercomputer/together-kubelogin/pkg/credentialplugin/reader"
 	credentialpluginwriter "github.com/togethercomputer/together-kubelogin/pkg/credentialplugin/writer"
 	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/clock"
 	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/logger"
+	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/tracing"
+	"github.com/togethercomputer/together-kubelogin/pkg/jwt"
 	"github.com/togethercomputer/together-kubelogin/pkg/oidc"
 	"github.com/togethercomputer/together-kubelogin/pkg/tlsclientconfig"
 	"github.com/togethercomputer/together-kubelogin/pkg/tokencache"
 	"github.com/togethercomputer/together-kubelogin/pkg/tokencache/repository"
 	"github.com/togethercomputer/together-kubelogin/pkg/usecases/authentication"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 var Set = wire.NewSet(
@@ -27,6 +35,7 @@ var Set = wire.NewSet(
 
 type Interface interface {
 	Do(ctx context.Context, in Input) error
+	Token(ctx context.Context, in Input) (*credentialplugin.Output, error)
 }
 
 // Input represents an input DTO of the GetToken use-case.
@@ -36,6 +45,22 @@ type Input struct {
 	TokenCacheConfig tokencache.Config
 	GrantOptionSet   authentication.GrantOptionSet
 	TLSClientConfig  tlsclientconfig.Config
+	OutputFormat     credentialplugin.OutputFormat // default to ExecCredential
+	AuditConfig      audit.Config                  // disabled by default
+	ClaimPolicy      claimpolicy.Policy            // no rule by default
+}
+
+// TokenCacheKey returns the key of the token cache for the input.
+// The username is included for the password grant.
+func (in Input) TokenCacheKey() tokencache.Key {
+	key := tokencache.Key{
+		Provider:        in.Provider,
+		TLSClientConfig: in.TLSClientConfig,
+	}
+	if in.GrantOptionSet.ROPCOption != nil {
+		key.Username = in.GrantOptionSet.ROPCOption.Username
+	}
+	return key
 }
 
 type GetToken struct {
@@ -43,32 +68,51 @@ type GetToken struct {
 	TokenCacheRepository   repository.Interface
 	CredentialPluginReader credentialpluginreader.Interface
 	CredentialPluginWriter credentialpluginwriter.Interface
+	AuditRepository        auditrepository.Interface
 	Logger                 logger.Interface
 	Clock                  clock.Interface
 }
 
 func (u *GetToken) Do(ctx context.Context, in Input) error {
-	u.Logger.V(1).Infof("WARNING: log may contain your secrets such as token or password")
+	out, err := u.Token(ctx, in)
+	if err != nil {
+		return err
+	}
+	u.Logger.V(1).Infof("writing the token")
+	_, span := tracing.Start(ctx, "CredentialPluginWriter.Write")
+	err = u.CredentialPluginWriter.Write(*out)
+	tracing.End(span, err)
+	if err != nil {
+		return fmt.Errorf("could not write the token: %w", err)
+	}
+	return nil
+}
+
+// Token returns a valid token from the token cache or by authentication.
+// It does not write the token, so that other commands can use the token.
+func (u *GetToken) Token(ctx context.Context, in Input) (*credentialplugin.Output, error) {
+	if u.Logger.IsUnredacted() {
+		u.Logger.V(1).Infof("WARNING: log may contain your secrets such as token or password")
+	}
+	startedAt := u.Clock.Now()
 
 	credentialPluginInput, err := u.CredentialPluginReader.Read()
 	if err != nil {
-		return fmt.Errorf("could not read the input of credential plugin: %w", err)
+		return nil, fmt.Errorf("could not read the input of credential plugin: %w", err)
 	}
 	u.Logger.V(1).Infof("credential plugin is called with apiVersion: %s", credentialPluginInput.ClientAuthenticationAPIVersion)
 
 	u.Logger.V(1).Infof("finding a token cache")
-	tokenCacheKey := tokencache.Key{
-		Provider:        in.Provider,
-		TLSClientConfig: in.TLSClientConfig,
-	}
-	if in.GrantOptionSet.ROPCOption != nil {
-		tokenCacheKey.Username = in.GrantOptionSet.ROPCOption.Username
-	}
+	tokenCacheKey := in.TokenCacheKey()
 
 	u.Logger.V(1).Infof("acquiring the lock of token cache")
-	lock, err := u.TokenCacheRepository.Lock(in.TokenCacheConfig, tokenCacheKey)
+	_, lockSpan := tracing.Start(ctx, "Repository.Lock")
+	lock, err := u.lockWithProgress(ctx, in, func() (io.Closer, error) {
+		return u.TokenCacheRepository.Lock(in.TokenCacheConfig, tokenCacheKey)
+	})
+	tracing.End(lockSpan, err)
 	if err != nil {
-		return fmt.Errorf("could not lock the token cache: %w", err)
+		return nil, fmt.Errorf("could not lock the token cache: %w", err)
 	}
 	defer func() {
 		u.Logger.V(1).Infof("releasing the lock of token cache")
@@ -77,65 +121,212 @@ func (u *GetToken) Do(ctx context.Context, in Input) error {
 		}
 	}()
 
+	// Read the token cache after acquiring the lock,
+	// because another process may have written it while waiting for the lock.
+	_, findSpan := tracing.Start(ctx, "Repository.FindByKey")
 	cachedTokenSet, err := u.TokenCacheRepository.FindByKey(in.TokenCacheConfig, tokenCacheKey)
+	findSpan.SetAttributes(attribute.Bool("tokencache.hit", cachedTokenSet != nil))
+	tracing.End(findSpan, nil)
 	if err != nil {
 		u.Logger.V(1).Infof("could not find a token cache: %s", err)
 	}
+	// claimPolicyErr is set if the cached token is valid but rejected by the claim policy.
+	// It refreshes the token to update the claims, but does not log in again,
+	// because a new login would return the same claims.
+	var claimPolicyErr error
 	if cachedTokenSet != nil {
 		if in.ForceRefresh {
 			u.Logger.V(1).Infof("forcing refresh of the existing token")
 		} else {
-			u.Logger.V(1).Infof("checking expiration of the existing token")
-			// Skip verification of the token to reduce time of a discovery request.
-			// Here it trusts the signature and claims and checks only expiration,
-			// because the token has been verified before caching.
 			claims, err := cachedTokenSet.DecodeWithoutVerify()
 			if err != nil {
-				return fmt.Errorf("invalid token cache (you may need to remove): %w", err)
+				return nil, fmt.Errorf("invalid token cache (you may need to remove): %w", err)
 			}
-			if !claims.IsExpired(u.Clock) {
-				u.Logger.V(1).Infof("you already have a valid token until %s", claims.Expiry)
-				out := credentialplugin.Output{
-					Token:                          cachedTokenSet.IDToken,
-					Expiry:                         claims.Expiry,
-					ClientAuthenticationAPIVersion: credentialPluginInput.ClientAuthenticationAPIVersion,
-				}
-				if err := u.CredentialPluginWriter.Write(out); err != nil {
-					return fmt.Errorf("could not write the token to client-go: %w", err)
+			valid, err := u.checkCachedToken(in, *cachedTokenSet, claims)
+			if valid {
+				return u.cacheHit(in, credentialPluginInput, *cachedTokenSet, claims), nil
+			}
+			claimPolicyErr = err
+		}
+	}
+
+	// Acquire the lock of authentication for the provider,
+	// so that only one process opens the browser even if the token cache keys are different.
+	u.Logger.V(1).Infof("acquiring the lock of authentication")
+	authenticationLock, err := u.lockWithProgress(ctx, in, func() (io.Closer, error) {
+		return u.TokenCacheRepository.LockAuthentication(in.TokenCacheConfig, tokencache.AuthenticationMarker{
+			PID:       os.Getpid(),
+			IssuerURL: in.Provider.IssuerURL,
+			ClientID:  in.Provider.ClientID,
+			StartedAt: u.Clock.Now(),
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not lock the authentication: %w", err)
+	}
+	defer func() {
+		u.Logger.V(1).Infof("releasing the lock of authentication")
+		if err := authenticationLock.Close(); err != nil {
+			u.Logger.Printf("could not unlock the authentication: %s", err)
+		}
+	}()
+
+	// Another process may have authenticated while waiting for the lock,
+	// and saved the token with a different key, such as different TLS options.
+	// Reuse the token of the same key, or refresh by the token of a different key,
+	// instead of opening the browser again.
+	latest, err := u.TokenCacheRepository.FindLatestByProvider(in.TokenCacheConfig, tokenCacheKey, startedAt)
+	if err != nil {
+		u.Logger.V(1).Infof("could not find a token saved by another process: %s", err)
+	}
+	if latest != nil && (cachedTokenSet == nil || latest.TokenSet != *cachedTokenSet) {
+		u.Logger.V(1).Infof("found a token saved by another process")
+		latestTokenSet := latest.TokenSet
+		claims, err := latestTokenSet.DecodeWithoutVerify()
+		switch {
+		case err != nil:
+			u.Logger.V(1).Infof("invalid token saved by another process: %s", err)
+		case in.ForceRefresh || !latest.SameKey:
+			// A token of a different key may have different scopes,
+			// so use only the refresh token.
+			if latestTokenSet.RefreshToken != "" {
+				cachedTokenSet = &latestTokenSet
+			}
+		default:
+			valid, err := u.checkCachedToken(in, latestTokenSet, claims)
+			if valid {
+				if err := u.TokenCacheRepository.Save(in.TokenCacheConfig, tokenCacheKey, latestTokenSet); err != nil {
+					return nil, fmt.Errorf("could not write the token cache: %w", err)
 				}
-				return nil
+				return u.cacheHit(in, credentialPluginInput, latestTokenSet, claims), nil
+			}
+			if latestTokenSet.RefreshToken != "" {
+				cachedTokenSet = &latestTokenSet
+				claimPolicyErr = err
 			}
-			u.Logger.V(1).Infof("you have an expired token at %s", claims.Expiry)
 		}
 	}
+	if claimPolicyErr != nil && cachedTokenSet.RefreshToken == "" {
+		return nil, u.rejectByClaimPolicy(in, credentialPluginInput, "", claimPolicyErr)
+	}
 
 	authenticationInput := authentication.Input{
 		Provider:        in.Provider,
-		GrantOptionSet:  in.GrantOptionSet,
+		GrantOptionSet:  in.GrantOptionSet.WithCluster(credentialPluginInput.ClusterServer),
 		CachedTokenSet:  cachedTokenSet,
 		TLSClientConfig: in.TLSClientConfig,
+		NonInteractive:  credentialPluginInput.NonInteractive,
+		RefreshOnly:     claimPolicyErr != nil,
 	}
 	authenticationOutput, err := u.Authentication.Do(ctx, authenticationInput)
+	if err != nil && claimPolicyErr != nil {
+		u.Logger.V(1).Infof("could not refresh the token rejected by the claim policy: %s", err)
+		return nil, u.rejectByClaimPolicy(in, credentialPluginInput, authentication.GrantRefreshToken, claimPolicyErr)
+	}
 	if err != nil {
-		return fmt.Errorf("authentication error: %w", err)
+		u.audit(in, credentialPluginInput, audit.Record{
+			Event:      audit.EventFailure,
+			Grant:      in.GrantOptionSet.GrantType(),
+			ErrorClass: audit.ClassifyError(err),
+		})
+		return nil, fmt.Errorf("authentication error: %w", err)
 	}
 	idTokenClaims, err := authenticationOutput.TokenSet.DecodeWithoutVerify()
 	if err != nil {
-		return fmt.Errorf("you got an invalid token: %w", err)
+		return nil, fmt.Errorf("you got an invalid token: %w", err)
+	}
+	auditEvent := audit.EventInteractiveLogin
+	if authenticationOutput.Refreshed() {
+		auditEvent = audit.EventRefresh
 	}
+	u.audit(in, credentialPluginInput, audit.Record{
+		Event:   auditEvent,
+		Grant:   authenticationOutput.Grant,
+		Subject: idTokenClaims.Subject,
+		Expiry:  &idTokenClaims.Expiry,
+	})
 	u.Logger.V(1).Infof("you got a token: %s", idTokenClaims.Pretty)
 	u.Logger.V(1).Infof("you got a valid token until %s", idTokenClaims.Expiry)
-	if err := u.TokenCacheRepository.Save(in.TokenCacheConfig, tokenCacheKey, authenticationOutput.TokenSet); err != nil {
-		return fmt.Errorf("could not write the token cache: %w", err)
+	_, saveSpan := tracing.Start(ctx, "Repository.Save")
+	err = u.TokenCacheRepository.Save(in.TokenCacheConfig, tokenCacheKey, authenticationOutput.TokenSet)
+	tracing.End(saveSpan, err)
+	if err != nil {
+		return nil, fmt.Errorf("could not write the token cache: %w", err)
+	}
+	if err := in.ClaimPolicy.Evaluate(idTokenClaims); err != nil {
+		return nil, u.rejectByClaimPolicy(in, credentialPluginInput, authenticationOutput.Grant, err)
 	}
-	u.Logger.V(1).Infof("writing the token to client-go")
-	out := credentialplugin.Output{
+	return &credentialplugin.Output{
 		Token:                          authenticationOutput.TokenSet.IDToken,
 		Expiry:                         idTokenClaims.Expiry,
 		ClientAuthenticationAPIVersion: credentialPluginInput.ClientAuthenticationAPIVersion,
+		Format:                         in.OutputFormat,
+	}, nil
+}
+
+// checkCachedToken returns true if the cached token is not expired and satisfies the policies.
+// If the token is valid but rejected by the claim policy, it returns the error of the rule.
+func (u *GetToken) checkCachedToken(in Input, tokenSet oidc.TokenSet, claims *jwt.Claims) (bool, error) {
+	u.Logger.V(1).Infof("checking expiration of the existing token")
+	// Skip verification of the token to reduce time of a discovery request.
+	// Here it trusts the signature and claims and checks only expiration,
+	// because the token has been verified before caching.
+	if claims.IsExpired(u.Clock) {
+		u.Logger.V(1).Infof("you have an expired token at %s", claims.Expiry)
+		return false, nil
 	}
-	if err := u.CredentialPluginWriter.Write(out); err != nil {
-		return fmt.Errorf("could not write the token to client-go: %w", err)
+	if err := in.Provider.VerifyCachedTokenSet(tokenSet, u.Clock.Now()); err != nil {
+		u.Logger.V(1).Infof("the existing token does not satisfy the authentication policy: %s", err)
+		return false, nil
+	}
+	if err := in.ClaimPolicy.Evaluate(claims); err != nil {
+		u.Logger.V(1).Infof("the existing token is rejected by the claim policy: %s", err)
+		return false, err
+	}
+	return true, nil
+}
+
+// rejectByClaimPolicy returns the error of the rule which rejected the token.
+func (u *GetToken) rejectByClaimPolicy(in Input, credentialPluginInput credentialplugin.Input, grant string, err error) error {
+	u.audit(in, credentialPluginInput, audit.Record{
+		Event:      audit.EventFailure,
+		Grant:      grant,
+		ErrorClass: audit.ClassifyError(err),
+	})
+	return fmt.Errorf("the token is rejected by the claim policy: %w", err)
+}
+
+// cacheHit returns the output of the cached token.
+func (u *GetToken) cacheHit(in Input, credentialPluginInput credentialplugin.Input, tokenSet oidc.TokenSet, claims *jwt.Claims) *credentialplugin.Output {
+	u.Logger.V(1).Infof("you already have a valid token until %s", claims.Expiry)
+	u.Logger.Event("token_cache_hit", logger.Fields{
+		"issuer": in.Provider.IssuerURL,
+		"expiry": claims.Expiry,
+	})
+	u.audit(in, credentialPluginInput, audit.Record{
+		Event:   audit.EventCacheHit,
+		Subject: claims.Subject,
+		Expiry:  &claims.Expiry,
+	})
+	return &credentialplugin.Output{
+		Token:                          tokenSet.IDToken,
+		Expiry:                         claims.Expiry,
+		ClientAuthenticationAPIVersion: credentialPluginInput.ClientAuthenticationAPIVersion,
+		Format:                         in.OutputFormat,
+	}
+}
+
+// audit writes the record to the audit log if enabled.
+// It does not return an error, because the audit log should not block the authentication.
+func (u *GetToken) audit(in Input, credentialPluginInput credentialplugin.Input, record audit.Record) {
+	if !in.AuditConfig.Enabled() {
+		return
+	}
+	record.Time = u.Clock.Now()
+	record.Issuer = in.Provider.IssuerURL
+	record.ClientID = in.Provider.ClientID
+	record.Cluster = credentialPluginInput.ClusterServer
+	if err := u.AuditRepository.Append(in.AuditConfig, record); err != nil {
+		u.Logger.Printf("could not write the audit log: %s", err)
 	}
-	return nil
 }
@@ -3,15 +3,20 @@ package credentialplugin
 import (
 	"context"
 	"errors"
+	"io"
+	"os"
 	"testing"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	auditrepository_mock "github.com/togethercomputer/together-kubelogin/mocks/github.com/togethercomputer/together-kubelogin/pkg/audit/repository_mock"
 	"github.com/togethercomputer/together-kubelogin/mocks/github.com/togethercomputer/together-kubelogin/pkg/credentialplugin/reader_mock"
 	"github.com/togethercomputer/together-kubelogin/mocks/github.com/togethercomputer/together-kubelogin/pkg/credentialplugin/writer_mock"
 	"github.com/togethercomputer/together-kubelogin/mocks/github.com/togethercomputer/together-kubelogin/pkg/tokencache/repository_mock"
 	"github.com/togethercomputer/together-kubelogin/mocks/github.com/togethercomputer/together-kubelogin/pkg/usecases/authentication_mock"
 	"github.com/togethercomputer/together-kubelogin/mocks/io_mock"
+	"github.com/togethercomputer/together-kubelogin/pkg/audit"
+	"github.com/togethercomputer/together-kubelogin/pkg/claimpolicy"
 	"github.com/togethercomputer/together-kubelogin/pkg/credentialplugin"
 	"github.com/togethercomputer/together-kubelogin/pkg/testing/clock"
 	"github.com/togethercomputer/together-kubelogin/pkg/usecases/authentication/authcode"
@@ -19,9 +24,12 @@ import (
 	"github.com/togethercomputer/together-kubelogin/pkg/oidc"
 	testingJWT "github.com/togethercomputer/together-kubelogin/pkg/testing/jwt"
 	"github.com/togethercomputer/together-kubelogin/pkg/testing/logger"
+	"github.com/togethercomputer/together-kubelogin/pkg/tlsclientconfig"
 	"github.com/togethercomputer/together-kubelogin/pkg/tokencache"
+	"github.com/togethercomputer/together-kubelogin/pkg/tokencache/repository"
 	"github.com/togethercomputer/together-kubelogin/pkg/usecases/authentication"
 	"github.com/togethercomputer/together-kubelogin/pkg/usecases/authentication/ropc"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestGetToken_Do(t *testing.T) {
@@ -36,6 +44,12 @@ func TestGetToken_Do(t *testing.T) {
 		claims.Subject = "YOUR_SUBJECT"
 		claims.ExpiresAt = jwt.NewNumericDate(expiryTime)
 	})
+	authenticationMarker := tokencache.AuthenticationMarker{
+		PID:       os.Getpid(),
+		IssuerURL: "https://accounts.google.com",
+		ClientID:  "YOUR_CLIENT_ID",
+		StartedAt: expiryTime.Add(-time.Hour),
+	}
 	issuedTokenSet := oidc.TokenSet{
 		IDToken:      issuedIDToken,
 		RefreshToken: "YOUR_REFRESH_TOKEN",
@@ -82,6 +96,12 @@ func TestGetToken_Do(t *testing.T) {
 			Close().
 			Return(nil)
 		mockRepository := repository_mock.NewMockInterface(t)
+		mockRepository.EXPECT().
+			LockAuthentication(in.TokenCacheConfig, authenticationMarker).
+			Return(mockCloser, nil)
+		mockRepository.EXPECT().
+			FindLatestByProvider(in.TokenCacheConfig, tokenCacheKey, mock.Anything).
+			Return(nil, nil)
 		mockRepository.EXPECT().
 			Lock(in.TokenCacheConfig, tokenCacheKey).
 			Return(mockCloser, nil)
@@ -112,6 +132,88 @@ func TestGetToken_Do(t *testing.T) {
 		}
 	})
 
+	t.Run("AuditLog", func(t *testing.T) {
+		tokenCacheKey := tokencache.Key{
+			Provider: oidc.Provider{
+				IssuerURL:    "https://accounts.google.com",
+				ClientID:     "YOUR_CLIENT_ID",
+				ClientSecret: "YOUR_CLIENT_SECRET",
+			},
+		}
+		ctx := context.TODO()
+		in := Input{
+			Provider: dummyProvider,
+			TokenCacheConfig: tokencache.Config{
+				Directory: "/path/to/token-cache",
+			},
+			GrantOptionSet: grantOptionSet,
+			AuditConfig:    audit.Config{Filename: "/path/to/audit.log"},
+		}
+		mockAuthentication := authentication_mock.NewMockInterface(t)
+		mockAuthentication.EXPECT().
+			Do(ctx, authentication.Input{
+				Provider:       dummyProvider,
+				GrantOptionSet: grantOptionSet.WithCluster("https://api.example.com"),
+			}).
+			Return(&authentication.Output{TokenSet: issuedTokenSet, Grant: "authcode"}, nil)
+		mockCloser := io_mock.NewMockCloser(t)
+		mockCloser.EXPECT().
+			Close().
+			Return(nil)
+		mockRepository := repository_mock.NewMockInterface(t)
+		mockRepository.EXPECT().
+			LockAuthentication(in.TokenCacheConfig, authenticationMarker).
+			Return(mockCloser, nil)
+		mockRepository.EXPECT().
+			FindLatestByProvider(in.TokenCacheConfig, tokenCacheKey, mock.Anything).
+			Return(nil, nil)
+		mockRepository.EXPECT().
+			Lock(in.TokenCacheConfig, tokenCacheKey).
+			Return(mockCloser, nil)
+		mockRepository.EXPECT().
+			FindByKey(in.TokenCacheConfig, tokenCacheKey).
+			Return(nil, errors.New("file not found"))
+		mockRepository.EXPECT().
+			Save(in.TokenCacheConfig, tokenCacheKey, issuedTokenSet).
+			Return(nil)
+		mockAuditRepository := auditrepository_mock.NewMockInterface(t)
+		mockAuditRepository.EXPECT().
+			Append(in.AuditConfig, audit.Record{
+				Time:     expiryTime.Add(-time.Hour),
+				Event:    audit.EventInteractiveLogin,
+				Grant:    "authcode",
+				Issuer:   "https://accounts.google.com",
+				ClientID: "YOUR_CLIENT_ID",
+				Subject:  "YOUR_SUBJECT",
+				Cluster:  "https://api.example.com",
+				Expiry:   &expiryTime,
+			}).
+			Return(nil)
+		mockReader := reader_mock.NewMockInterface(t)
+		mockReader.EXPECT().
+			Read().
+			Return(credentialplugin.Input{
+				ClientAuthenticationAPIVersion: "client.authentication.k8s.io/v1",
+				ClusterServer:                  "https://api.example.com",
+			}, nil)
+		mockWriter := writer_mock.NewMockInterface(t)
+		mockWriter.EXPECT().
+			Write(issuedOutput).
+			Return(nil)
+		u := GetToken{
+			Authentication:         mockAuthentication,
+			TokenCacheRepository:   mockRepository,
+			CredentialPluginReader: mockReader,
+			CredentialPluginWriter: mockWriter,
+			AuditRepository:        mockAuditRepository,
+			Logger:                 logger.New(t),
+			Clock:                  clock.Fake(expiryTime.Add(-time.Hour)),
+		}
+		if err := u.Do(ctx, in); err != nil {
+			t.Errorf("Do returned error: %+v", err)
+		}
+	})
+
 	t.Run("TokenCacheNoneReturnsNone", func(t *testing.T) {
 		tokenCacheKey := tokencache.Key{
 			Provider: oidc.Provider{
@@ -140,6 +242,12 @@ func TestGetToken_Do(t *testing.T) {
 			Close().
 			Return(nil)
 		mockRepository := repository_mock.NewMockInterface(t)
+		mockRepository.EXPECT().
+			LockAuthentication(in.TokenCacheConfig, authenticationMarker).
+			Return(mockCloser, nil)
+		mockRepository.EXPECT().
+			FindLatestByProvider(in.TokenCacheConfig, tokenCacheKey, mock.Anything).
+			Return(nil, nil)
 		mockRepository.EXPECT().
 			Lock(in.TokenCacheConfig, tokenCacheKey).
 			Return(mockCloser, nil)
@@ -202,6 +310,12 @@ func TestGetToken_Do(t *testing.T) {
 			Close().
 			Return(nil)
 		mockRepository := repository_mock.NewMockInterface(t)
+		mockRepository.EXPECT().
+			LockAuthentication(in.TokenCacheConfig, authenticationMarker).
+			Return(mockCloser, nil)
+		mockRepository.EXPECT().
+			FindLatestByProvider(in.TokenCacheConfig, tokenCacheKey, mock.Anything).
+			Return(nil, nil)
 		mockRepository.EXPECT().
 			Lock(in.TokenCacheConfig, tokenCacheKey).
 			Return(mockCloser, nil)
@@ -287,6 +401,405 @@ func TestGetToken_Do(t *testing.T) {
 		}
 	})
 
+	t.Run("HasValidIDTokenNotSatisfyingPolicy", func(t *testing.T) {
+		provider := dummyProvider
+		provider.AuthenticationPolicy = oidc.AuthenticationPolicy{MaxAge: time.Hour}
+		tokenCacheKey := tokencache.Key{Provider: provider}
+		ctx := context.TODO()
+		in := Input{
+			Provider: provider,
+			TokenCacheConfig: tokencache.Config{
+				Directory: "/path/to/token-cache",
+			},
+			GrantOptionSet: grantOptionSet,
+		}
+		mockAuthentication := authentication_mock.NewMockInterface(t)
+		mockAuthentication.EXPECT().
+			Do(ctx, authentication.Input{
+				Provider:       provider,
+				GrantOptionSet: grantOptionSet,
+				CachedTokenSet: &issuedTokenSet,
+			}).
+			Return(&authentication.Output{TokenSet: issuedTokenSet}, nil)
+		mockCloser := io_mock.NewMockCloser(t)
+		mockCloser.EXPECT().
+			Close().
+			Return(nil)
+		mockRepository := repository_mock.NewMockInterface(t)
+		mockRepository.EXPECT().
+			LockAuthentication(in.TokenCacheConfig, authenticationMarker).
+			Return(mockCloser, nil)
+		mockRepository.EXPECT().
+			FindLatestByProvider(in.TokenCacheConfig, tokenCacheKey, mock.Anything).
+			Return(nil, nil)
+		mockRepository.EXPECT().
+			Lock(in.TokenCacheConfig, tokenCacheKey).
+			Return(mockCloser, nil)
+		mockRepository.EXPECT().
+			FindByKey(in.TokenCacheConfig, tokenCacheKey).
+			Return(&issuedTokenSet, nil)
+		mockRepository.EXPECT().
+			Save(in.TokenCacheConfig, tokenCacheKey, issuedTokenSet).
+			Return(nil)
+		mockReader := reader_mock.NewMockInterface(t)
+		mockReader.EXPECT().
+			Read().
+			Return(credentialpluginInput, nil)
+		mockWriter := writer_mock.NewMockInterface(t)
+		mockWriter.EXPECT().
+			Write(issuedOutput).
+			Return(nil)
+		u := GetToken{
+			Authentication:         mockAuthentication,
+			TokenCacheRepository:   mockRepository,
+			CredentialPluginReader: mockReader,
+			CredentialPluginWriter: mockWriter,
+			Logger:                 logger.New(t),
+			Clock:                  clock.Fake(expiryTime.Add(-time.Hour)),
+		}
+		if err := u.Do(ctx, in); err != nil {
+			t.Errorf("Do returned error: %+v", err)
+		}
+	})
+
+	t.Run("ClaimPolicy", func(t *testing.T) {
+		claimPolicy := claimpolicy.Policy{
+			RequiredClaims: []claimpolicy.RequiredClaim{{Claim: "groups", Value: "k8s-admins"}},
+		}
+		adminIDToken := testingJWT.EncodeF(t, func(claims *testingJWT.Claims) {
+			claims.Issuer = "https://accounts.google.com"
+			claims.Subject = "YOUR_SUBJECT"
+			claims.ExpiresAt = jwt.NewNumericDate(expiryTime)
+			claims.Groups = []string{"k8s-admins"}
+		})
+		adminTokenSet := oidc.TokenSet{IDToken: adminIDToken, RefreshToken: "YOUR_REFRESH_TOKEN"}
+		tokenCacheKey := tokencache.Key{Provider: dummyProvider}
+		in := Input{
+			Provider: dummyProvider,
+			TokenCacheConfig: tokencache.Config{
+				Directory: "/path/to/token-cache",
+			},
+			GrantOptionSet: grantOptionSet,
+			ClaimPolicy:    claimPolicy,
+		}
+		newGetToken := func(t *testing.T, cachedTokenSet *oidc.TokenSet, authenticatedTokenSet oidc.TokenSet) *GetToken {
+			ctx := context.TODO()
+			mockAuthentication := authentication_mock.NewMockInterface(t)
+			mockAuthentication.EXPECT().
+				Do(ctx, authentication.Input{
+					Provider:       dummyProvider,
+					GrantOptionSet: grantOptionSet,
+					CachedTokenSet: cachedTokenSet,
+					RefreshOnly:    true,
+				}).
+				Return(&authentication.Output{TokenSet: authenticatedTokenSet, Grant: authentication.GrantRefreshToken}, nil)
+			mockCloser := io_mock.NewMockCloser(t)
+			mockCloser.EXPECT().
+				Close().
+				Return(nil)
+			mockRepository := repository_mock.NewMockInterface(t)
+			mockRepository.EXPECT().
+				LockAuthentication(in.TokenCacheConfig, authenticationMarker).
+				Return(mockCloser, nil)
+			mockRepository.EXPECT().
+				FindLatestByProvider(in.TokenCacheConfig, tokenCacheKey, mock.Anything).
+				Return(nil, nil)
+			mockRepository.EXPECT().
+				Lock(in.TokenCacheConfig, tokenCacheKey).
+				Return(mockCloser, nil)
+			mockRepository.EXPECT().
+				FindByKey(in.TokenCacheConfig, tokenCacheKey).
+				Return(cachedTokenSet, nil)
+			mockRepository.EXPECT().
+				Save(in.TokenCacheConfig, tokenCacheKey, authenticatedTokenSet).
+				Return(nil)
+			mockReader := reader_mock.NewMockInterface(t)
+			mockReader.EXPECT().
+				Read().
+				Return(credentialpluginInput, nil)
+			return &GetToken{
+				Authentication:         mockAuthentication,
+				TokenCacheRepository:   mockRepository,
+				CredentialPluginReader: mockReader,
+				Logger:                 logger.New(t),
+				Clock:                  clock.Fake(expiryTime.Add(-time.Hour)),
+			}
+		}
+
+		t.Run("CachedTokenIsRejected", func(t *testing.T) {
+			u := newGetToken(t, &issuedTokenSet, adminTokenSet)
+			got, err := u.Token(context.TODO(), in)
+			if err != nil {
+				t.Fatalf("Token returned error: %+v", err)
+			}
+			if got.Token != adminIDToken {
+				t.Errorf("Token wants the refreshed token but got %s", got.Token)
+			}
+		})
+		t.Run("AuthenticatedTokenIsRejected", func(t *testing.T) {
+			u := newGetToken(t, &issuedTokenSet, issuedTokenSet)
+			_, err := u.Token(context.TODO(), in)
+			var ruleErr *claimpolicy.RuleError
+			if !errors.As(err, &ruleErr) {
+				t.Fatalf("error wants RuleError but got %v", err)
+			}
+			if ruleErr.Rule != "groups=k8s-admins" {
+				t.Errorf("Rule wants groups=k8s-admins but got %s", ruleErr.Rule)
+			}
+		})
+		// It must not start an interactive login for the claim policy.
+		newRejectingGetToken := func(t *testing.T, cachedTokenSet *oidc.TokenSet, mockAuthentication *authentication_mock.MockInterface) *GetToken {
+			mockCloser := io_mock.NewMockCloser(t)
+			mockCloser.EXPECT().
+				Close().
+				Return(nil)
+			mockRepository := repository_mock.NewMockInterface(t)
+			mockRepository.EXPECT().
+				LockAuthentication(in.TokenCacheConfig, authenticationMarker).
+				Return(mockCloser, nil)
+			mockRepository.EXPECT().
+				FindLatestByProvider(in.TokenCacheConfig, tokenCacheKey, mock.Anything).
+				Return(nil, nil)
+			mockRepository.EXPECT().
+				Lock(in.TokenCacheConfig, tokenCacheKey).
+				Return(mockCloser, nil)
+			mockRepository.EXPECT().
+				FindByKey(in.TokenCacheConfig, tokenCacheKey).
+				Return(cachedTokenSet, nil)
+			mockReader := reader_mock.NewMockInterface(t)
+			mockReader.EXPECT().
+				Read().
+				Return(credentialpluginInput, nil)
+			return &GetToken{
+				Authentication:         mockAuthentication,
+				TokenCacheRepository:   mockRepository,
+				CredentialPluginReader: mockReader,
+				Logger:                 logger.New(t),
+				Clock:                  clock.Fake(expiryTime.Add(-time.Hour)),
+			}
+		}
+		t.Run("CachedTokenWithoutRefreshTokenIsRejected", func(t *testing.T) {
+			cachedTokenSet := oidc.TokenSet{IDToken: issuedIDToken}
+			u := newRejectingGetToken(t, &cachedTokenSet, authentication_mock.NewMockInterface(t))
+			_, err := u.Token(context.TODO(), in)
+			var ruleErr *claimpolicy.RuleError
+			if !errors.As(err, &ruleErr) {
+				t.Fatalf("error wants RuleError but got %v", err)
+			}
+		})
+		t.Run("CachedTokenCouldNotBeRefreshed", func(t *testing.T) {
+			mockAuthentication := authentication_mock.NewMockInterface(t)
+			mockAuthentication.EXPECT().
+				Do(context.TODO(), authentication.Input{
+					Provider:       dummyProvider,
+					GrantOptionSet: grantOptionSet,
+					CachedTokenSet: &issuedTokenSet,
+					RefreshOnly:    true,
+				}).
+				Return(nil, errors.New("invalid_grant"))
+			u := newRejectingGetToken(t, &issuedTokenSet, mockAuthentication)
+			_, err := u.Token(context.TODO(), in)
+			var ruleErr *claimpolicy.RuleError
+			if !errors.As(err, &ruleErr) {
+				t.Fatalf("error wants RuleError but got %v", err)
+			}
+		})
+	})
+
+	t.Run("WaitForAnotherProcess", func(t *testing.T) {
+		defaultProgressDelay := progressDelay
+		progressDelay = time.Millisecond
+		t.Cleanup(func() { progressDelay = defaultProgressDelay })
+
+		tokenCacheKey := tokencache.Key{
+			Provider: oidc.Provider{
+				IssuerURL:    "https://accounts.google.com",
+				ClientID:     "YOUR_CLIENT_ID",
+				ClientSecret: "YOUR_CLIENT_SECRET",
+			},
+		}
+		ctx := context.TODO()
+		in := Input{
+			Provider: dummyProvider,
+			TokenCacheConfig: tokencache.Config{
+				Directory: "/path/to/token-cache",
+			},
+			GrantOptionSet: grantOptionSet,
+		}
+		mockCloser := io_mock.NewMockCloser(t)
+		mockCloser.EXPECT().
+			Close().
+			Return(nil)
+		// another process releases the lock after the progress is shown
+		progressShown := make(chan struct{})
+		mockRepository := repository_mock.NewMockInterface(t)
+		mockRepository.EXPECT().
+			Lock(in.TokenCacheConfig, tokenCacheKey).
+			RunAndReturn(func(tokencache.Config, tokencache.Key) (io.Closer, error) {
+				<-progressShown
+				return mockCloser, nil
+			})
+		mockRepository.EXPECT().
+			FindAuthenticationMarker(in.TokenCacheConfig, "https://accounts.google.com", "YOUR_CLIENT_ID").
+			RunAndReturn(func(tokencache.Config, string, string) (*tokencache.AuthenticationMarker, error) {
+				defer close(progressShown)
+				return &tokencache.AuthenticationMarker{
+					PID:       12345,
+					IssuerURL: "https://accounts.google.com",
+					ClientID:  "YOUR_CLIENT_ID",
+					StartedAt: expiryTime.Add(-time.Hour - time.Minute),
+				}, nil
+			}).
+			Once()
+		mockRepository.EXPECT().
+			FindByKey(in.TokenCacheConfig, tokenCacheKey).
+			Return(&issuedTokenSet, nil)
+		mockReader := reader_mock.NewMockInterface(t)
+		mockReader.EXPECT().
+			Read().
+			Return(credentialpluginInput, nil)
+		mockWriter := writer_mock.NewMockInterface(t)
+		mockWriter.EXPECT().
+			Write(issuedOutput).
+			Return(nil)
+		u := GetToken{
+			Authentication:         authentication_mock.NewMockInterface(t),
+			TokenCacheRepository:   mockRepository,
+			CredentialPluginReader: mockReader,
+			CredentialPluginWriter: mockWriter,
+			Logger:                 logger.New(t),
+			Clock:                  clock.Fake(expiryTime.Add(-time.Hour)),
+		}
+		if err := u.Do(ctx, in); err != nil {
+			t.Errorf("Do returned error: %+v", err)
+		}
+	})
+
+	t.Run("WaitForAnotherProcessWithDifferentKey", func(t *testing.T) {
+		// Both processes use the same provider but the different token cache keys.
+		// The second process has started before the first process saves the token.
+		// It must not reuse the token as-is, but refresh it by the refresh token.
+		withScopes := dummyProvider
+		withScopes.ExtraScopes = []string{"groups"}
+		tests := map[string][2]Input{
+			"DifferentTLSClientConfig": {
+				{Provider: dummyProvider, TLSClientConfig: tlsclientconfig.Config{CACertFilename: []string{"/path/to/cert1"}}},
+				{Provider: dummyProvider, TLSClientConfig: tlsclientconfig.Config{CACertFilename: []string{"/path/to/cert2"}}},
+			},
+			"DifferentScopes": {
+				{Provider: dummyProvider},
+				{Provider: withScopes},
+			},
+		}
+		refreshedIDToken := testingJWT.EncodeF(t, func(claims *testingJWT.Claims) {
+			claims.Issuer = "https://accounts.google.com"
+			claims.Subject = "YOUR_SUBJECT"
+			claims.ExpiresAt = jwt.NewNumericDate(expiryTime.Add(time.Minute))
+		})
+		refreshedTokenSet := oidc.TokenSet{IDToken: refreshedIDToken, RefreshToken: "NEW_REFRESH_TOKEN"}
+		for name, inputs := range tests {
+			t.Run(name, func(t *testing.T) {
+				ctx := context.TODO()
+				tokenCacheConfig := tokencache.Config{Directory: t.TempDir(), Storage: tokencache.StorageDisk}
+				mockAuthentication := authentication_mock.NewMockInterface(t)
+				mockAuthentication.EXPECT().
+					Do(ctx, mock.MatchedBy(func(in authentication.Input) bool { return in.CachedTokenSet == nil })).
+					Return(&authentication.Output{TokenSet: issuedTokenSet}, nil).
+					Once()
+				mockAuthentication.EXPECT().
+					Do(ctx, authentication.Input{
+						Provider:        inputs[1].Provider,
+						GrantOptionSet:  grantOptionSet,
+						CachedTokenSet:  &issuedTokenSet,
+						TLSClientConfig: inputs[1].TLSClientConfig,
+					}).
+					Return(&authentication.Output{TokenSet: refreshedTokenSet, Grant: authentication.GrantRefreshToken}, nil).
+					Once()
+				mockReader := reader_mock.NewMockInterface(t)
+				mockReader.EXPECT().
+					Read().
+					Return(credentialpluginInput, nil)
+				u := GetToken{
+					Authentication:         mockAuthentication,
+					TokenCacheRepository:   &repository.Repository{},
+					CredentialPluginReader: mockReader,
+					Logger:                 logger.New(t),
+					Clock:                  clock.Fake(expiryTime.Add(-time.Hour)),
+				}
+				for i, want := range []string{issuedIDToken, refreshedIDToken} {
+					in := inputs[i]
+					in.TokenCacheConfig = tokenCacheConfig
+					in.GrantOptionSet = grantOptionSet
+					got, err := u.Token(ctx, in)
+					if err != nil {
+						t.Fatalf("Token returned error: %+v", err)
+					}
+					if got.Token != want {
+						t.Errorf("Token of the process %d wants %s but got %s", i, want, got.Token)
+					}
+				}
+			})
+		}
+	})
+
+	t.Run("HasValidIDTokenWithOutputFormat", func(t *testing.T) {
+		tokenCacheKey := tokencache.Key{
+			Provider: oidc.Provider{
+				IssuerURL:    "https://accounts.google.com",
+				ClientID:     "YOUR_CLIENT_ID",
+				ClientSecret: "YOUR_CLIENT_SECRET",
+			},
+		}
+
+		ctx := context.TODO()
+		in := Input{
+			Provider: dummyProvider,
+			TokenCacheConfig: tokencache.Config{
+				Directory: "/path/to/token-cache",
+			},
+			GrantOptionSet: grantOptionSet,
+			OutputFormat:   credentialplugin.OutputFormatHeader,
+		}
+		mockCloser := io_mock.NewMockCloser(t)
+		mockCloser.EXPECT().
+			Close().
+			Return(nil)
+		mockRepository := repository_mock.NewMockInterface(t)
+		mockRepository.EXPECT().
+			Lock(in.TokenCacheConfig, tokenCacheKey).
+			Return(mockCloser, nil)
+		mockRepository.EXPECT().
+			FindByKey(in.TokenCacheConfig, tokencache.Key{
+				Provider: oidc.Provider{
+					IssuerURL:    "https://accounts.google.com",
+					ClientID:     "YOUR_CLIENT_ID",
+					ClientSecret: "YOUR_CLIENT_SECRET",
+				},
+			}).
+			Return(&issuedTokenSet, nil)
+		mockReader := reader_mock.NewMockInterface(t)
+		mockReader.EXPECT().
+			Read().
+			Return(credentialpluginInput, nil)
+		headerOutput := issuedOutput
+		headerOutput.Format = credentialplugin.OutputFormatHeader
+		mockWriter := writer_mock.NewMockInterface(t)
+		mockWriter.EXPECT().
+			Write(headerOutput).
+			Return(nil)
+		u := GetToken{
+			Authentication:         authentication_mock.NewMockInterface(t),
+			TokenCacheRepository:   mockRepository,
+			CredentialPluginReader: mockReader,
+			CredentialPluginWriter: mockWriter,
+			Logger:                 logger.New(t),
+			Clock:                  clock.Fake(expiryTime.Add(-time.Hour)),
+		}
+		if err := u.Do(ctx, in); err != nil {
+			t.Errorf("Do returned error: %+v", err)
+		}
+	})
+
 	t.Run("AuthenticationError", func(t *testing.T) {
 		tokenCacheKey := tokencache.Key{
 			Provider: oidc.Provider{
@@ -315,6 +828,12 @@ func TestGetToken_Do(t *testing.T) {
 			Close().
 			Return(nil)
 		mockRepository := repository_mock.NewMockInterface(t)
+		mockRepository.EXPECT().
+			LockAuthentication(in.TokenCacheConfig, authenticationMarker).
+			Return(mockCloser, nil)
+		mockRepository.EXPECT().
+			FindLatestByProvider(in.TokenCacheConfig, tokenCacheKey, mock.Anything).
+			Return(nil, nil)
 		mockRepository.EXPECT().
 			Lock(in.TokenCacheConfig, tokenCacheKey).
 			Return(mockCloser, nil)
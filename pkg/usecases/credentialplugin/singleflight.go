@@ -0,0 +1,62 @@
+package credentialplugin
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// progressDelay is the delay before showing the progress of waiting for another process.
+var progressDelay = 500 * time.Millisecond
+
+// progressInterval is the interval of showing the progress of waiting for another process.
+var progressInterval = 5 * time.Second
+
+type lockResult struct {
+	closer io.Closer
+	err    error
+}
+
+// lockWithProgress acquires a lock by the function.
+// If the lock is held by another process, it shows the authentication in progress.
+// If the context is canceled, it returns immediately and releases the lock when acquired.
+func (u *GetToken) lockWithProgress(ctx context.Context, in Input, lock func() (io.Closer, error)) (io.Closer, error) {
+	ch := make(chan lockResult, 1)
+	go func() {
+		closer, err := lock()
+		ch <- lockResult{closer, err}
+	}()
+	timer := time.NewTimer(progressDelay)
+	defer timer.Stop()
+	for {
+		select {
+		case r := <-ch:
+			return r.closer, r.err
+		case <-timer.C:
+			u.showProgress(in)
+			timer.Reset(progressInterval)
+		case <-ctx.Done():
+			go func() {
+				if r := <-ch; r.err == nil {
+					_ = r.closer.Close()
+				}
+			}()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (u *GetToken) showProgress(in Input) {
+	marker, err := u.TokenCacheRepository.FindAuthenticationMarker(in.TokenCacheConfig, in.Provider.IssuerURL, in.Provider.ClientID)
+	if err != nil {
+		u.Logger.V(1).Infof("could not find the authentication in progress: %s", err)
+		return
+	}
+	if marker == nil {
+		u.Logger.V(1).Infof("waiting for the lock of token cache")
+		return
+	}
+	elapsed := u.Clock.Now().Sub(marker.StartedAt).Round(time.Second)
+	u.Logger.Printf("Waiting for another kubelogin process (pid %d) authenticating to %s for %s...",
+		marker.PID, marker.IssuerURL, elapsed)
+}
@@ -0,0 +1,375 @@
+package doctor
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/togethercomputer/together-kubelogin/pkg/oidc"
+	"github.com/togethercomputer/together-kubelogin/pkg/oidc/client"
+	"github.com/togethercomputer/together-kubelogin/pkg/tlsclientconfig"
+	"github.com/togethercomputer/together-kubelogin/pkg/tokencache"
+	"github.com/togethercomputer/together-kubelogin/pkg/usecases/authentication"
+	"github.com/zalando/go-keyring"
+)
+
+const (
+	checkNameDiscovery     = "discovery"
+	checkNameIssuer        = "issuer"
+	checkNameTLS           = "tls"
+	checkNameJWKS          = "jwks"
+	checkNameGrantType     = "grant-type"
+	checkNamePKCE          = "pkce"
+	checkNameClock         = "clock"
+	checkNameListenAddress = "listen-address"
+	checkNameTokenCacheDir = "token-cache-dir"
+	checkNameKeyring       = "keyring"
+)
+
+const (
+	// certificateExpiryWarning is the remaining lifetime to warn the expiry of the server certificate.
+	certificateExpiryWarning = 14 * 24 * time.Hour
+	// clockOffsetWarning and clockOffsetFailure are the thresholds of the clock offset.
+	// The Date header has a resolution of a second.
+	clockOffsetWarning = 30 * time.Second
+	clockOffsetFailure = 5 * time.Minute
+)
+
+// discoveryDocument represents the fields of the OpenID Provider Metadata used by the checks.
+type discoveryDocument struct {
+	Issuer                        string   `json:"issuer"`
+	AuthorizationEndpoint         string   `json:"authorization_endpoint"`
+	TokenEndpoint                 string   `json:"token_endpoint"`
+	DeviceAuthorizationEndpoint   string   `json:"device_authorization_endpoint"`
+	JWKSURI                       string   `json:"jwks_uri"`
+	GrantTypesSupported           []string `json:"grant_types_supported"`
+	CodeChallengeMethodsSupported []string `json:"code_challenge_methods_supported"`
+}
+
+type discovery struct {
+	document discoveryDocument
+	date     time.Time // zero if the Date header is missing
+}
+
+func checkDiscovery(ctx context.Context, httpClient *http.Client, issuerURL string, tlsClientConfig tlsclientconfig.Config) (*discovery, []Result) {
+	discoveryURL := strings.TrimSuffix(issuerURL, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, discoveryURL, nil)
+	if err != nil {
+		return nil, []Result{{Check: checkNameDiscovery, Status: StatusFail, Message: fmt.Sprintf("invalid issuer URL: %s", err)}}
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, []Result{
+			checkTLSError(err, tlsClientConfig),
+			{Check: checkNameDiscovery, Status: StatusFail, Message: fmt.Sprintf("could not fetch %s: %s", discoveryURL, err)},
+		}
+	}
+	defer resp.Body.Close()
+	results := []Result{checkTLSConnection(resp, tlsClientConfig)}
+	if resp.StatusCode != http.StatusOK {
+		return nil, append(results, Result{Check: checkNameDiscovery, Status: StatusFail,
+			Message: fmt.Sprintf("%s returned %s", discoveryURL, resp.Status)})
+	}
+	var d discovery
+	if err := json.NewDecoder(resp.Body).Decode(&d.document); err != nil {
+		return nil, append(results, Result{Check: checkNameDiscovery, Status: StatusFail,
+			Message: fmt.Sprintf("invalid discovery document: %s", err)})
+	}
+	if date, err := http.ParseTime(resp.Header.Get("Date")); err == nil {
+		d.date = date
+	}
+	results = append(results,
+		Result{Check: checkNameDiscovery, Status: StatusPass, Message: fmt.Sprintf("fetched %s", discoveryURL)},
+		checkIssuer(issuerURL, d.document.Issuer),
+	)
+	return &d, results
+}
+
+// checkIssuer verifies the issuer in the same way as the ID token verification.
+// A trailing slash must be exactly the same.
+func checkIssuer(issuerURL, discoveredIssuer string) Result {
+	switch {
+	case issuerURL == discoveredIssuer:
+		return Result{Check: checkNameIssuer, Status: StatusPass, Message: fmt.Sprintf("issuer is %s", discoveredIssuer)}
+	case strings.TrimSuffix(issuerURL, "/") == strings.TrimSuffix(discoveredIssuer, "/"):
+		return Result{Check: checkNameIssuer, Status: StatusFail,
+			Message: fmt.Sprintf("trailing slash mismatch: set --oidc-issuer-url=%s", discoveredIssuer)}
+	default:
+		return Result{Check: checkNameIssuer, Status: StatusFail,
+			Message: fmt.Sprintf("the provider returned the issuer %s, which does not match --oidc-issuer-url", discoveredIssuer)}
+	}
+}
+
+func checkTLSConnection(resp *http.Response, tlsClientConfig tlsclientconfig.Config) Result {
+	if resp.TLS == nil {
+		return Result{Check: checkNameTLS, Status: StatusWarn, Message: "the issuer does not use TLS"}
+	}
+	if tlsClientConfig.SkipTLSVerify {
+		return Result{Check: checkNameTLS, Status: StatusWarn, Message: "the certificate is not verified due to --insecure-skip-tls-verify"}
+	}
+	if len(resp.TLS.PeerCertificates) == 0 {
+		return Result{Check: checkNameTLS, Status: StatusWarn, Message: "the server sent no certificate"}
+	}
+	leaf := resp.TLS.PeerCertificates[0]
+	remaining := time.Until(leaf.NotAfter)
+	message := fmt.Sprintf("%s issued by %s, expires at %s",
+		leaf.Subject, leaf.Issuer, leaf.NotAfter.Format(time.RFC3339))
+	if remaining < certificateExpiryWarning {
+		return Result{Check: checkNameTLS, Status: StatusWarn, Message: message}
+	}
+	return Result{Check: checkNameTLS, Status: StatusPass, Message: message}
+}
+
+func checkTLSError(err error, tlsClientConfig tlsclientconfig.Config) Result {
+	var unknownAuthorityError x509.UnknownAuthorityError
+	if errors.As(err, &unknownAuthorityError) {
+		hasCACert := len(tlsClientConfig.CACertFilename) > 0 || len(tlsClientConfig.CACertData) > 0
+		if hasCACert && !tlsClientConfig.UseSystemCertPool {
+			return Result{Check: checkNameTLS, Status: StatusFail,
+				Message: "certificate signed by unknown authority: set --certificate-authority-append-system if the issuer uses a public certificate"}
+		}
+		return Result{Check: checkNameTLS, Status: StatusFail,
+			Message: "certificate signed by unknown authority: set --certificate-authority or --certificate-authority-data"}
+	}
+	var hostnameError x509.HostnameError
+	if errors.As(err, &hostnameError) {
+		return Result{Check: checkNameTLS, Status: StatusFail, Message: hostnameError.Error()}
+	}
+	var certificateInvalidError x509.CertificateInvalidError
+	if errors.As(err, &certificateInvalidError) {
+		return Result{Check: checkNameTLS, Status: StatusFail, Message: certificateInvalidError.Error()}
+	}
+	var recordHeaderError tls.RecordHeaderError
+	if errors.As(err, &recordHeaderError) {
+		return Result{Check: checkNameTLS, Status: StatusFail, Message: "the server does not speak TLS"}
+	}
+	return Result{Check: checkNameTLS, Status: StatusSkip, Message: "could not connect to the issuer"}
+}
+
+func checkJWKS(ctx context.Context, httpClient *http.Client, jwksURI string) Result {
+	if jwksURI == "" {
+		return Result{Check: checkNameJWKS, Status: StatusFail, Message: "jwks_uri is missing in the discovery document"}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, jwksURI, nil)
+	if err != nil {
+		return Result{Check: checkNameJWKS, Status: StatusFail, Message: fmt.Sprintf("invalid jwks_uri: %s", err)}
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return Result{Check: checkNameJWKS, Status: StatusFail, Message: fmt.Sprintf("could not fetch %s: %s", jwksURI, err)}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Result{Check: checkNameJWKS, Status: StatusFail, Message: fmt.Sprintf("%s returned %s", jwksURI, resp.Status)}
+	}
+	var jwks struct {
+		Keys []json.RawMessage `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&jwks); err != nil {
+		return Result{Check: checkNameJWKS, Status: StatusFail, Message: fmt.Sprintf("invalid JWKS: %s", err)}
+	}
+	if len(jwks.Keys) == 0 {
+		return Result{Check: checkNameJWKS, Status: StatusFail, Message: "JWKS has no key"}
+	}
+	return Result{Check: checkNameJWKS, Status: StatusPass, Message: fmt.Sprintf("fetched %d keys", len(jwks.Keys))}
+}
+
+// checkGrantType verifies the provider supports the grant type of the options.
+// If grant_types_supported is omitted, the default is authorization_code and implicit.
+func checkGrantType(d discoveryDocument, s authentication.GrantOptionSet) Result {
+	if len(s.Candidates) > 0 {
+		return checkAutoGrantType(d, s.Candidates)
+	}
+	var grantType string
+	switch {
+	case s.AuthCodeBrowserOption != nil || s.AuthCodeKeyboardOption != nil || s.AuthCodePasteOption != nil:
+		grantType = "authorization_code"
+		if d.AuthorizationEndpoint == "" {
+			return Result{Check: checkNameGrantType, Status: StatusFail, Message: "authorization_endpoint is missing in the discovery document"}
+		}
+	case s.DeviceCodeOption != nil:
+		grantType = "urn:ietf:params:oauth:grant-type:device_code"
+		if d.DeviceAuthorizationEndpoint == "" {
+			return Result{Check: checkNameGrantType, Status: StatusFail, Message: "device_authorization_endpoint is missing in the discovery document"}
+		}
+	case s.ROPCOption != nil:
+		grantType = "password"
+	case s.ClientCredentialsOption != nil:
+		grantType = "client_credentials"
+	default:
+		return Result{Check: checkNameGrantType, Status: StatusSkip, Message: "no grant type"}
+	}
+	supported := d.GrantTypesSupported
+	if len(supported) == 0 {
+		supported = []string{"authorization_code", "implicit"}
+	}
+	if !slices.Contains(supported, grantType) {
+		return Result{Check: checkNameGrantType, Status: StatusWarn,
+			Message: fmt.Sprintf("%s is not in grant_types_supported (%s)", grantType, strings.Join(supported, ", "))}
+	}
+	return Result{Check: checkNameGrantType, Status: StatusPass, Message: fmt.Sprintf("%s is supported", grantType)}
+}
+
+// checkAutoGrantType verifies the provider supports any of the candidates.
+// The availability in the environment, such as a browser, is determined on login.
+func checkAutoGrantType(d discoveryDocument, candidates []authentication.GrantOptionSet) Result {
+	var unsupported []string
+	for _, candidate := range candidates {
+		r := checkGrantType(d, candidate)
+		if r.Status == StatusPass {
+			message := fmt.Sprintf("%s will be tried first: %s", candidate.GrantType(), r.Message)
+			if len(unsupported) > 0 {
+				message += fmt.Sprintf(" (skipped %s)", strings.Join(unsupported, ", "))
+			}
+			return Result{Check: checkNameGrantType, Status: StatusPass, Message: message}
+		}
+		unsupported = append(unsupported, fmt.Sprintf("%s: %s", candidate.GrantType(), r.Message))
+	}
+	return Result{Check: checkNameGrantType, Status: StatusFail,
+		Message: fmt.Sprintf("no grant type is supported: %s", strings.Join(unsupported, ", "))}
+}
+
+func checkPKCE(d discoveryDocument, method oidc.PKCEMethod) Result {
+	supportsS256 := slices.Contains(d.CodeChallengeMethodsSupported, "S256")
+	switch {
+	case method == oidc.PKCEMethodNo:
+		return Result{Check: checkNamePKCE, Status: StatusSkip, Message: "PKCE is disabled by --oidc-pkce-method"}
+	case supportsS256:
+		return Result{Check: checkNamePKCE, Status: StatusPass, Message: "S256 is supported"}
+	case method == oidc.PKCEMethodS256:
+		return Result{Check: checkNamePKCE, Status: StatusFail,
+			Message: "S256 is not in code_challenge_methods_supported, but --oidc-pkce-method=S256 is set"}
+	default:
+		return Result{Check: checkNamePKCE, Status: StatusWarn,
+			Message: "S256 is not in code_challenge_methods_supported, PKCE will not be used"}
+	}
+}
+
+func checkClock(date, now time.Time) Result {
+	if date.IsZero() {
+		return Result{Check: checkNameClock, Status: StatusSkip, Message: "the provider did not return the Date header"}
+	}
+	offset := now.Sub(date).Round(time.Second)
+	message := fmt.Sprintf("the local clock is %s ahead of the provider", offset)
+	if offset < 0 {
+		message = fmt.Sprintf("the local clock is %s behind the provider", -offset)
+	}
+	switch abs := max(offset, -offset); {
+	case abs >= clockOffsetFailure:
+		return Result{Check: checkNameClock, Status: StatusFail, Message: message}
+	case abs >= clockOffsetWarning:
+		return Result{Check: checkNameClock, Status: StatusWarn, Message: message}
+	default:
+		return Result{Check: checkNameClock, Status: StatusPass, Message: message}
+	}
+}
+
+// checkListenAddress verifies the local server can bind to one of the addresses.
+// The local server tries binding in order.
+// If the redirect URL is not set, it shows the redirect URIs to register to the provider.
+func checkListenAddress(s authentication.GrantOptionSet, redirectURL string) Result {
+	browserOption := s.AuthCodeBrowserOption
+	for _, candidate := range s.Candidates {
+		if candidate.AuthCodeBrowserOption != nil {
+			browserOption = candidate.AuthCodeBrowserOption
+			break
+		}
+	}
+	if browserOption == nil {
+		return Result{Check: checkNameListenAddress, Status: StatusSkip, Message: "the local server is not used"}
+	}
+	var registerHint string
+	if redirectURL == "" {
+		https := browserOption.LocalServerCertFile != "" && browserOption.LocalServerKeyFile != ""
+		redirectURLs, err := client.RedirectURLsToRegister(browserOption.BindAddress, https)
+		if err != nil {
+			return Result{Check: checkNameListenAddress, Status: StatusFail, Message: err.Error()}
+		}
+		registerHint = fmt.Sprintf("; register the redirect URIs: %s", strings.Join(redirectURLs, ", "))
+	}
+	var failures []string
+	for _, address := range browserOption.BindAddress {
+		l, err := net.Listen("tcp", address)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s (%s)", address, err))
+			continue
+		}
+		_ = l.Close()
+		if len(failures) > 0 {
+			return Result{Check: checkNameListenAddress, Status: StatusWarn,
+				Message: fmt.Sprintf("can bind to %s, but not to %s%s", address, strings.Join(failures, ", "), registerHint)}
+		}
+		return Result{Check: checkNameListenAddress, Status: StatusPass, Message: fmt.Sprintf("can bind to %s%s", address, registerHint)}
+	}
+	return Result{Check: checkNameListenAddress, Status: StatusFail,
+		Message: fmt.Sprintf("cannot bind to any address: %s", strings.Join(failures, ", "))}
+}
+
+func checkTokenCacheDir(c tokencache.Config) Result {
+	if c.Storage != tokencache.StorageDisk {
+		return Result{Check: checkNameTokenCacheDir, Status: StatusSkip, Message: "the token cache is not stored on disk"}
+	}
+	fi, err := os.Stat(c.Directory)
+	if errors.Is(err, os.ErrNotExist) {
+		return Result{Check: checkNameTokenCacheDir, Status: StatusPass,
+			Message: fmt.Sprintf("%s will be created on the first login", c.Directory)}
+	}
+	if err != nil {
+		return Result{Check: checkNameTokenCacheDir, Status: StatusFail, Message: err.Error()}
+	}
+	if !fi.IsDir() {
+		return Result{Check: checkNameTokenCacheDir, Status: StatusFail, Message: fmt.Sprintf("%s is not a directory", c.Directory)}
+	}
+	f, err := os.CreateTemp(c.Directory, ".doctor-")
+	if err != nil {
+		return Result{Check: checkNameTokenCacheDir, Status: StatusFail, Message: fmt.Sprintf("%s is not writable: %s", c.Directory, err)}
+	}
+	_ = f.Close()
+	_ = os.Remove(f.Name())
+	if perm := fi.Mode().Perm(); perm&0077 != 0 {
+		return Result{Check: checkNameTokenCacheDir, Status: StatusWarn,
+			Message: fmt.Sprintf("%s is accessible by other users (%s)", c.Directory, perm)}
+	}
+	entries, err := os.ReadDir(c.Directory)
+	if err != nil {
+		return Result{Check: checkNameTokenCacheDir, Status: StatusFail, Message: err.Error()}
+	}
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		if perm := info.Mode().Perm(); perm&0077 != 0 {
+			return Result{Check: checkNameTokenCacheDir, Status: StatusWarn,
+				Message: fmt.Sprintf("%s is accessible by other users (%s)", filepath.Join(c.Directory, e.Name()), perm)}
+		}
+	}
+	return Result{Check: checkNameTokenCacheDir, Status: StatusPass, Message: fmt.Sprintf("%s is writable", c.Directory)}
+}
+
+// keyringProbeItem is written and deleted to check the keyring.
+const keyringProbeItem = "kubelogin/doctor"
+
+func checkKeyring(c tokencache.Config) Result {
+	if c.Storage != tokencache.StorageKeyring {
+		return Result{Check: checkNameKeyring, Status: StatusSkip, Message: "the token cache is not stored in the keyring"}
+	}
+	if err := keyring.Set("kubelogin", keyringProbeItem, "ok"); err != nil {
+		return Result{Check: checkNameKeyring, Status: StatusFail, Message: fmt.Sprintf("could not write to the keyring: %s", err)}
+	}
+	if _, err := keyring.Get("kubelogin", keyringProbeItem); err != nil {
+		return Result{Check: checkNameKeyring, Status: StatusFail, Message: fmt.Sprintf("could not read from the keyring: %s", err)}
+	}
+	_ = keyring.Delete("kubelogin", keyringProbeItem)
+	return Result{Check: checkNameKeyring, Status: StatusPass, Message: "the keyring is available"}
+}
@@ -0,0 +1,163 @@
+// Package doctor provides the use-case of diagnosing a login configuration.
+package doctor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/wire"
+	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/clock"
+	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/logger"
+	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/stdio"
+	"github.com/togethercomputer/together-kubelogin/pkg/oidc"
+	"github.com/togethercomputer/together-kubelogin/pkg/oidc/client/transport"
+	"github.com/togethercomputer/together-kubelogin/pkg/tlsclientconfig"
+	"github.com/togethercomputer/together-kubelogin/pkg/tlsclientconfig/loader"
+	"github.com/togethercomputer/together-kubelogin/pkg/tokencache"
+	"github.com/togethercomputer/together-kubelogin/pkg/usecases/authentication"
+)
+
+var Set = wire.NewSet(
+	wire.Struct(new(Doctor), "*"),
+	wire.Bind(new(Interface), new(*Doctor)),
+)
+
+type Interface interface {
+	Do(ctx context.Context, in Input) error
+}
+
+// Format represents the output format of the checks.
+type Format string
+
+const (
+	FormatTable Format = "table"
+	FormatJSON  Format = "json"
+)
+
+// Input represents an input DTO of the Doctor use-case.
+type Input struct {
+	Provider         oidc.Provider
+	GrantOptionSet   authentication.GrantOptionSet
+	TLSClientConfig  tlsclientconfig.Config
+	TokenCacheConfig tokencache.Config
+	Format           Format
+}
+
+// Status represents the result of a check.
+type Status string
+
+const (
+	StatusPass Status = "pass"
+	StatusWarn Status = "warn"
+	StatusFail Status = "fail"
+	StatusSkip Status = "skip" // not applicable to the configuration
+)
+
+// Result represents the result of a check.
+type Result struct {
+	Check   string `json:"check"`
+	Status  Status `json:"status"`
+	Message string `json:"message"`
+}
+
+// Doctor runs the checks of a login configuration.
+// It does not authenticate, so it never opens the browser.
+type Doctor struct {
+	Loader loader.Interface
+	Clock  clock.Interface
+	Stdout stdio.Stdout
+	Logger logger.Interface
+}
+
+func (u *Doctor) Do(ctx context.Context, in Input) error {
+	results := u.check(ctx, in)
+	if err := u.print(in.Format, results); err != nil {
+		return fmt.Errorf("could not write the results: %w", err)
+	}
+	var failed int
+	for _, r := range results {
+		if r.Status == StatusFail {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, len(results))
+	}
+	return nil
+}
+
+// check runs the checks in order.
+// If the discovery fails, the checks depending on the discovery document are skipped.
+func (u *Doctor) check(ctx context.Context, in Input) []Result {
+	var results []Result
+	httpClient, err := u.newHTTPClient(in)
+	if err != nil {
+		return append(results, Result{Check: checkNameTLS, Status: StatusFail, Message: err.Error()})
+	}
+	d, discoveryResults := checkDiscovery(ctx, httpClient, in.Provider.IssuerURL, in.TLSClientConfig)
+	results = append(results, discoveryResults...)
+	if d != nil {
+		results = append(results,
+			checkJWKS(ctx, httpClient, d.document.JWKSURI),
+			checkGrantType(d.document, in.GrantOptionSet),
+			checkPKCE(d.document, in.Provider.PKCEMethod),
+			checkClock(d.date, u.Clock.Now()),
+		)
+	} else {
+		for _, name := range []string{checkNameJWKS, checkNameGrantType, checkNamePKCE, checkNameClock} {
+			results = append(results, Result{Check: name, Status: StatusSkip, Message: "discovery failed"})
+		}
+	}
+	results = append(results,
+		checkListenAddress(in.GrantOptionSet, in.Provider.RedirectURL),
+		checkTokenCacheDir(in.TokenCacheConfig),
+		checkKeyring(in.TokenCacheConfig),
+	)
+	return results
+}
+
+func (u *Doctor) newHTTPClient(in Input) (*http.Client, error) {
+	rawTLSClientConfig, err := u.Loader.Load(in.TLSClientConfig)
+	if err != nil {
+		return nil, fmt.Errorf("could not load the TLS client config: %w", err)
+	}
+	baseTransport, err := transport.ForIssuer(rawTLSClientConfig, in.Provider.IssuerURL)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{
+		Timeout: 30 * time.Second,
+		Transport: &transport.WithHeader{
+			Base: &transport.WithLogging{
+				Base: &transport.WithTracing{
+					Base: baseTransport,
+				},
+				Logger: u.Logger,
+			},
+			RequestHeaders: in.Provider.RequestHeaders,
+		},
+	}, nil
+}
+
+func (u *Doctor) print(format Format, results []Result) error {
+	switch format {
+	case FormatJSON:
+		e := json.NewEncoder(u.Stdout)
+		e.SetIndent("", "  ")
+		return e.Encode(struct {
+			Checks []Result `json:"checks"`
+		}{Checks: results})
+	default:
+		w := tabwriter.NewWriter(u.Stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "CHECK\tSTATUS\tMESSAGE")
+		for _, r := range results {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", r.Check, strings.ToUpper(string(r.Status)), r.Message)
+		}
+		return w.Flush()
+	}
+}
@@ -0,0 +1,251 @@
+package doctor
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/togethercomputer/together-kubelogin/pkg/oidc"
+	"github.com/togethercomputer/together-kubelogin/pkg/testing/clock"
+	"github.com/togethercomputer/together-kubelogin/pkg/testing/logger"
+	"github.com/togethercomputer/together-kubelogin/pkg/tlsclientconfig"
+	"github.com/togethercomputer/together-kubelogin/pkg/tlsclientconfig/loader"
+	"github.com/togethercomputer/together-kubelogin/pkg/tokencache"
+	"github.com/togethercomputer/together-kubelogin/pkg/usecases/authentication"
+	"github.com/togethercomputer/together-kubelogin/pkg/usecases/authentication/authcode"
+	"github.com/togethercomputer/together-kubelogin/pkg/usecases/authentication/devicecode"
+)
+
+func newProviderServer(t *testing.T, issuer func(serverURL string) string, date time.Time) *httptest.Server {
+	var serverURL string
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", date.Format(http.TimeFormat))
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"issuer":                           issuer(serverURL),
+			"authorization_endpoint":           serverURL + "/authorize",
+			"token_endpoint":                   serverURL + "/token",
+			"jwks_uri":                         serverURL + "/keys",
+			"code_challenge_methods_supported": []string{"plain"},
+		})
+	})
+	mux.HandleFunc("GET /keys", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"keys":[{"kty":"RSA","kid":"1"}]}`))
+	})
+	s := httptest.NewUnstartedServer(mux)
+	s.Config.ErrorLog = log.New(io.Discard, "", 0) // suppress the handshake errors
+	s.StartTLS()
+	t.Cleanup(s.Close)
+	serverURL = s.URL
+	return s
+}
+
+func serverCACertData(s *httptest.Server) string {
+	b := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.Certificate().Raw})
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// statuses returns the check names and statuses, because the messages contain the server address.
+func statuses(results []Result) map[string]Status {
+	m := make(map[string]Status)
+	for _, r := range results {
+		m[r.Check] = r.Status
+	}
+	return m
+}
+
+func TestDoctor_Do(t *testing.T) {
+	date := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	tokenCacheConfig := tokencache.Config{
+		Directory: filepath.Join(t.TempDir(), "oidc-login"),
+		Storage:   tokencache.StorageDisk,
+	}
+
+	t.Run("Healthy", func(t *testing.T) {
+		ctx := context.TODO()
+		s := newProviderServer(t, func(serverURL string) string { return serverURL }, date)
+		var stdout bytes.Buffer
+		u := Doctor{
+			Loader: &loader.Loader{},
+			Clock:  clock.Fake(date.Add(2 * time.Second)),
+			Stdout: &stdout,
+			Logger: logger.New(t),
+		}
+		in := Input{
+			Provider: oidc.Provider{IssuerURL: s.URL, ClientID: "YOUR_CLIENT_ID"},
+			GrantOptionSet: authentication.GrantOptionSet{
+				AuthCodeBrowserOption: &authcode.BrowserOption{BindAddress: []string{"127.0.0.1:0"}},
+			},
+			TLSClientConfig:  tlsclientconfig.Config{CACertData: []string{serverCACertData(s)}},
+			TokenCacheConfig: tokenCacheConfig,
+			Format:           FormatJSON,
+		}
+		if err := u.Do(ctx, in); err != nil {
+			t.Fatalf("Do returned error: %+v", err)
+		}
+		var got struct {
+			Checks []Result `json:"checks"`
+		}
+		if err := json.Unmarshal(stdout.Bytes(), &got); err != nil {
+			t.Fatalf("invalid json: %s", err)
+		}
+		want := map[string]Status{
+			"tls":             StatusPass,
+			"discovery":       StatusPass,
+			"issuer":          StatusPass,
+			"jwks":            StatusPass,
+			"grant-type":      StatusPass,
+			"pkce":            StatusWarn,
+			"clock":           StatusPass,
+			"listen-address":  StatusPass,
+			"token-cache-dir": StatusPass,
+			"keyring":         StatusSkip,
+		}
+		if diff := cmp.Diff(want, statuses(got.Checks)); diff != "" {
+			t.Errorf("statuses mismatch (-want +got):\n%s", diff)
+		}
+		wantClock := Result{Check: "clock", Status: StatusPass, Message: "the local clock is 2s ahead of the provider"}
+		if diff := cmp.Diff(wantClock, got.Checks[6]); diff != "" {
+			t.Errorf("clock mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("Misconfigured", func(t *testing.T) {
+		ctx := context.TODO()
+		s := newProviderServer(t, func(serverURL string) string { return serverURL + "/" }, date)
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Listen error: %s", err)
+		}
+		t.Cleanup(func() { _ = l.Close() })
+		var stdout bytes.Buffer
+		u := Doctor{
+			Loader: &loader.Loader{},
+			Clock:  clock.Fake(date.Add(-10 * time.Minute)),
+			Stdout: &stdout,
+			Logger: logger.New(t),
+		}
+		in := Input{
+			Provider: oidc.Provider{IssuerURL: s.URL, ClientID: "YOUR_CLIENT_ID", PKCEMethod: oidc.PKCEMethodS256},
+			GrantOptionSet: authentication.GrantOptionSet{
+				AuthCodeBrowserOption: &authcode.BrowserOption{BindAddress: []string{l.Addr().String()}},
+			},
+			TLSClientConfig:  tlsclientconfig.Config{CACertData: []string{serverCACertData(s)}},
+			TokenCacheConfig: tokenCacheConfig,
+			Format:           FormatJSON,
+		}
+		err = u.Do(ctx, in)
+		if want := "4 of 10 checks failed"; err == nil || err.Error() != want {
+			t.Errorf("err wants %s but was %v", want, err)
+		}
+		var got struct {
+			Checks []Result `json:"checks"`
+		}
+		if err := json.Unmarshal(stdout.Bytes(), &got); err != nil {
+			t.Fatalf("invalid json: %s", err)
+		}
+		want := []Result{
+			{Check: "issuer", Status: StatusFail, Message: "trailing slash mismatch: set --oidc-issuer-url=" + s.URL + "/"},
+			{Check: "pkce", Status: StatusFail, Message: "S256 is not in code_challenge_methods_supported, but --oidc-pkce-method=S256 is set"},
+			{Check: "clock", Status: StatusFail, Message: "the local clock is 10m0s behind the provider"},
+		}
+		filtered := cmpopts.IgnoreSliceElements(func(r Result) bool {
+			return r.Status != StatusFail || r.Check == "listen-address"
+		})
+		if diff := cmp.Diff(want, got.Checks, filtered); diff != "" {
+			t.Errorf("results mismatch (-want +got):\n%s", diff)
+		}
+		if got := statuses(got.Checks)["listen-address"]; got != StatusFail {
+			t.Errorf("listen-address wants fail but was %s", got)
+		}
+	})
+
+	t.Run("UnknownAuthority", func(t *testing.T) {
+		ctx := context.TODO()
+		s := newProviderServer(t, func(serverURL string) string { return serverURL }, date)
+		var stdout bytes.Buffer
+		u := Doctor{
+			Loader: &loader.Loader{},
+			Clock:  clock.Fake(date),
+			Stdout: &stdout,
+			Logger: logger.New(t),
+		}
+		in := Input{
+			Provider:         oidc.Provider{IssuerURL: s.URL, ClientID: "YOUR_CLIENT_ID"},
+			TokenCacheConfig: tokencache.Config{Storage: tokencache.StorageNone},
+			Format:           FormatTable,
+		}
+		if err := u.Do(ctx, in); err == nil {
+			t.Fatalf("Do wants error but was nil")
+		}
+		want := `CHECK            STATUS  MESSAGE
+tls              FAIL    certificate signed by unknown authority: set --certificate-authority or --certificate-authority-data
+discovery        FAIL    could not fetch ` + s.URL + `/.well-known/openid-configuration: Get "` + s.URL + `/.well-known/openid-configuration": tls: failed to verify certificate: x509: certificate signed by unknown authority
+jwks             SKIP    discovery failed
+grant-type       SKIP    discovery failed
+pkce             SKIP    discovery failed
+clock            SKIP    discovery failed
+listen-address   SKIP    the local server is not used
+token-cache-dir  SKIP    the token cache is not stored on disk
+keyring          SKIP    the token cache is not stored in the keyring
+`
+		if diff := cmp.Diff(want, stdout.String()); diff != "" {
+			t.Errorf("stdout mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
+
+func Test_checkGrantType_auto(t *testing.T) {
+	s := authentication.GrantOptionSet{
+		Candidates: []authentication.GrantOptionSet{
+			{DeviceCodeOption: &devicecode.Option{}},
+			{AuthCodePasteOption: &authcode.PasteOption{}},
+		},
+	}
+	t.Run("Supported", func(t *testing.T) {
+		d := discoveryDocument{AuthorizationEndpoint: "https://issuer.example.com/authorize"}
+		want := Result{Check: "grant-type", Status: StatusPass,
+			Message: "authcode-paste will be tried first: authorization_code is supported" +
+				" (skipped device-code: device_authorization_endpoint is missing in the discovery document)"}
+		if diff := cmp.Diff(want, checkGrantType(d, s)); diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+	})
+	t.Run("NotSupported", func(t *testing.T) {
+		got := checkGrantType(discoveryDocument{}, s)
+		if got.Status != StatusFail {
+			t.Errorf("Status wants %s but got %s", StatusFail, got.Status)
+		}
+	})
+}
+
+func Test_checkListenAddress(t *testing.T) {
+	s := authentication.GrantOptionSet{
+		AuthCodeBrowserOption: &authcode.BrowserOption{BindAddress: []string{"127.0.0.1:0", "[::1]:8000"}},
+	}
+	t.Run("RedirectURLNotSet", func(t *testing.T) {
+		want := Result{Check: "listen-address", Status: StatusPass,
+			Message: "can bind to 127.0.0.1:0; register the redirect URIs: http://127.0.0.1 (any port), http://[::1]:8000"}
+		if diff := cmp.Diff(want, checkListenAddress(s, "")); diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+	})
+	t.Run("RedirectURLSet", func(t *testing.T) {
+		want := Result{Check: "listen-address", Status: StatusPass, Message: "can bind to 127.0.0.1:0"}
+		if diff := cmp.Diff(want, checkListenAddress(s, "http://localhost:8000")); diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
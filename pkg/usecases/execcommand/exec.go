@@ -0,0 +1,259 @@
+// Package execcommand provides the use-case of running a command with a token.
+package execcommand
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/google/wire"
+	"github.com/togethercomputer/together-kubelogin/pkg/credentialplugin"
+	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/clock"
+	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/logger"
+	credentialpluginusecase "github.com/togethercomputer/together-kubelogin/pkg/usecases/credentialplugin"
+)
+
+var Set = wire.NewSet(
+	wire.Struct(new(Exec), "*"),
+	wire.Bind(new(Interface), new(*Exec)),
+)
+
+type Interface interface {
+	Do(ctx context.Context, in Input) error
+}
+
+// TokenFileEnvName is the environment variable of the path to the token file.
+const TokenFileEnvName = "KUBELOGIN_TOKEN_FILE"
+
+// RefreshAction represents the action to the command when the token is refreshed.
+type RefreshAction string
+
+const (
+	RefreshActionNone    RefreshAction = "none"    // rotate the token file only
+	RefreshActionSignal  RefreshAction = "signal"  // rotate the token file and send a signal
+	RefreshActionRestart RefreshAction = "restart" // terminate and start the command again
+)
+
+// Input represents an input DTO of the Exec use-case.
+type Input struct {
+	GetToken      credentialpluginusecase.Input
+	Command       []string
+	EnvName       string          // if set, pass the token by the environment variable
+	TokenFile     *TokenFileInput // if set, pass the token by the file
+	RefreshAction RefreshAction
+	RefreshSignal os.Signal     // for RefreshActionSignal
+	RefreshBefore time.Duration // refresh the token before the expiry
+}
+
+// TokenFileInput represents the file to write the token.
+type TokenFileInput struct {
+	Filename string // if empty, create a file in a private temporary directory and remove it on exit
+}
+
+// ExitError represents the exit code of the command.
+type ExitError struct {
+	Code int
+}
+
+func (e ExitError) Error() string {
+	return fmt.Sprintf("the command exited with code %d", e.Code)
+}
+
+// forwardedSignals are sent to the command when kubelogin receives them.
+var forwardedSignals = []os.Signal{os.Interrupt, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT}
+
+const (
+	// refreshRetryInterval is the interval to retry refreshing the token.
+	refreshRetryInterval = 30 * time.Second
+	// terminateTimeout is the time to wait for the command to exit before killing it.
+	terminateTimeout = 10 * time.Second
+)
+
+// Exec runs a command with a token.
+// It refreshes the token before the expiry, and then
+// rotates the token file, signals or restarts the command.
+type Exec struct {
+	GetToken credentialpluginusecase.Interface
+	Clock    clock.Interface
+	Logger   logger.Interface
+}
+
+func (u *Exec) Do(ctx context.Context, in Input) error {
+	if len(in.Command) == 0 {
+		return errors.New("no command is given")
+	}
+	out, err := u.GetToken.Token(ctx, in.GetToken)
+	if err != nil {
+		return fmt.Errorf("could not get a token: %w", err)
+	}
+	tokenFilename, cleanup, err := createTokenFile(in.TokenFile)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	if err := writeTokenFile(tokenFilename, out.Token); err != nil {
+		return err
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, forwardedSignals...)
+	defer signal.Stop(signals)
+
+	for {
+		cmd := exec.Command(in.Command[0], in.Command[1:]...)
+		cmd.Env = append(os.Environ(), commandEnv(in, out.Token, tokenFilename)...)
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+		u.Logger.V(1).Infof("starting the command %s", in.Command[0])
+		if err := cmd.Start(); err != nil {
+			return fmt.Errorf("could not start the command: %w", err)
+		}
+		done := make(chan error, 1)
+		go func() { done <- cmd.Wait() }()
+
+		restart, err := u.supervise(ctx, in, cmd, done, signals, &out, tokenFilename)
+		if !restart {
+			return err
+		}
+		u.Logger.V(1).Infof("restarting the command with the new token")
+	}
+}
+
+// supervise waits for the command, forwards the signals and refreshes the token.
+// It returns true if the command should be restarted.
+func (u *Exec) supervise(ctx context.Context, in Input, cmd *exec.Cmd, done <-chan error, signals <-chan os.Signal, out **credentialplugin.Output, tokenFilename string) (bool, error) {
+	timer := time.NewTimer(u.refreshDelay((*out).Expiry, in.RefreshBefore))
+	defer timer.Stop()
+	for {
+		select {
+		case err := <-done:
+			return false, exitError(err)
+
+		case sig := <-signals:
+			u.Logger.V(1).Infof("forwarding the signal %s to the command", sig)
+			if err := cmd.Process.Signal(sig); err != nil {
+				u.Logger.V(1).Infof("could not forward the signal: %s", err)
+			}
+
+		case <-timer.C:
+			u.Logger.V(1).Infof("refreshing the token before the expiry %s", (*out).Expiry)
+			getTokenInput := in.GetToken
+			getTokenInput.ForceRefresh = true
+			newOut, err := u.GetToken.Token(ctx, getTokenInput)
+			if err != nil {
+				u.Logger.Printf("could not refresh the token: %s", err)
+				timer.Reset(refreshRetryInterval)
+				continue
+			}
+			*out = newOut
+			if err := writeTokenFile(tokenFilename, newOut.Token); err != nil {
+				u.Logger.Printf("could not rotate the token file: %s", err)
+			}
+			switch in.RefreshAction {
+			case RefreshActionSignal:
+				u.Logger.V(1).Infof("sending the signal %s to the command", in.RefreshSignal)
+				if err := cmd.Process.Signal(in.RefreshSignal); err != nil {
+					u.Logger.Printf("could not send the signal: %s", err)
+				}
+			case RefreshActionRestart:
+				u.terminate(cmd, done)
+				return true, nil
+			}
+			timer.Reset(u.refreshDelay(newOut.Expiry, in.RefreshBefore))
+		}
+	}
+}
+
+// refreshDelay returns the duration until refreshing the token.
+// If the lifetime of the token is shorter than twice of refreshBefore,
+// it refreshes at the half of the remaining lifetime.
+func (u *Exec) refreshDelay(expiry time.Time, refreshBefore time.Duration) time.Duration {
+	if expiry.IsZero() {
+		return time.Duration(1<<63 - 1)
+	}
+	remaining := expiry.Sub(u.Clock.Now())
+	if remaining <= 0 {
+		// the provider returned an expired token
+		return refreshRetryInterval
+	}
+	return max(remaining-refreshBefore, remaining/2)
+}
+
+// terminate sends SIGTERM to the command and kills it after the timeout.
+func (u *Exec) terminate(cmd *exec.Cmd, done <-chan error) {
+	if err := cmd.Process.Signal(syscall.SIGTERM); err != nil {
+		_ = cmd.Process.Kill()
+	}
+	select {
+	case <-done:
+	case <-time.After(terminateTimeout):
+		u.Logger.Printf("killing the command after %s", terminateTimeout)
+		_ = cmd.Process.Kill()
+		<-done
+	}
+}
+
+func commandEnv(in Input, token, tokenFilename string) []string {
+	var env []string
+	if in.EnvName != "" {
+		env = append(env, in.EnvName+"="+token)
+	}
+	if tokenFilename != "" {
+		env = append(env, TokenFileEnvName+"="+tokenFilename)
+	}
+	return env
+}
+
+// createTokenFile returns the path to the token file and the function to remove it.
+// It removes only the private temporary directory created by itself,
+// and keeps the file at the explicit path, because it may be used by another process.
+func createTokenFile(in *TokenFileInput) (string, func(), error) {
+	if in == nil {
+		return "", func() {}, nil
+	}
+	if in.Filename != "" {
+		return in.Filename, func() {}, nil
+	}
+	// MkdirTemp creates the directory with 0700
+	dir, err := os.MkdirTemp("", "kubelogin-")
+	if err != nil {
+		return "", nil, fmt.Errorf("could not create a temporary directory: %w", err)
+	}
+	return dir + string(os.PathSeparator) + "token", func() { _ = os.RemoveAll(dir) }, nil
+}
+
+// writeTokenFile atomically replaces the token file with 0600.
+func writeTokenFile(filename, token string) error {
+	if filename == "" {
+		return nil
+	}
+	tmp := filename + ".tmp"
+	if err := os.WriteFile(tmp, []byte(token), 0600); err != nil {
+		return fmt.Errorf("could not write the token file: %w", err)
+	}
+	if err := os.Rename(tmp, filename); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("could not write the token file: %w", err)
+	}
+	return nil
+}
+
+// exitError converts the result of the command to ExitError.
+// If the command is terminated by a signal, the code is 128 + the signal number.
+func exitError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return fmt.Errorf("could not wait for the command: %w", err)
+	}
+	if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return ExitError{Code: 128 + int(status.Signal())}
+	}
+	return ExitError{Code: exitErr.ExitCode()}
+}
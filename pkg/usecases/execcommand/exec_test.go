@@ -0,0 +1,169 @@
+package execcommand
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/togethercomputer/together-kubelogin/mocks/github.com/togethercomputer/together-kubelogin/pkg/usecases/credentialplugin_mock"
+	"github.com/togethercomputer/together-kubelogin/pkg/credentialplugin"
+	"github.com/togethercomputer/together-kubelogin/pkg/oidc"
+	"github.com/togethercomputer/together-kubelogin/pkg/testing/clock"
+	"github.com/togethercomputer/together-kubelogin/pkg/testing/logger"
+	credentialpluginusecase "github.com/togethercomputer/together-kubelogin/pkg/usecases/credentialplugin"
+)
+
+func TestExec_Do(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires sh")
+	}
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	getTokenInput := credentialpluginusecase.Input{
+		Provider: oidc.Provider{
+			IssuerURL: "https://issuer.example.com",
+			ClientID:  "YOUR_CLIENT_ID",
+		},
+	}
+	refreshedGetTokenInput := getTokenInput
+	refreshedGetTokenInput.ForceRefresh = true
+
+	t.Run("EnvAndExitCode", func(t *testing.T) {
+		ctx := context.TODO()
+		getToken := credentialplugin_mock.NewMockInterface(t)
+		getToken.EXPECT().
+			Token(ctx, getTokenInput).
+			Return(&credentialplugin.Output{Token: "YOUR_TOKEN", Expiry: now.Add(time.Hour)}, nil)
+		u := Exec{
+			GetToken: getToken,
+			Clock:    clock.Fake(now),
+			Logger:   logger.New(t),
+		}
+		err := u.Do(ctx, Input{
+			GetToken: getTokenInput,
+			Command:  []string{"sh", "-c", `test "$KUBELOGIN_TOKEN" = YOUR_TOKEN && exit 3`},
+			EnvName:  "KUBELOGIN_TOKEN",
+		})
+		var exitError ExitError
+		if !errors.As(err, &exitError) {
+			t.Fatalf("err wants ExitError but was %+v", err)
+		}
+		if exitError.Code != 3 {
+			t.Errorf("Code wants 3 but was %d", exitError.Code)
+		}
+	})
+
+	t.Run("TokenFile", func(t *testing.T) {
+		ctx := context.TODO()
+		outputDir := t.TempDir()
+		getToken := credentialplugin_mock.NewMockInterface(t)
+		getToken.EXPECT().
+			Token(ctx, getTokenInput).
+			Return(&credentialplugin.Output{Token: "YOUR_TOKEN", Expiry: now.Add(time.Hour)}, nil)
+		u := Exec{
+			GetToken: getToken,
+			Clock:    clock.Fake(now),
+			Logger:   logger.New(t),
+		}
+		err := u.Do(ctx, Input{
+			GetToken:  getTokenInput,
+			Command:   []string{"sh", "-c", `find "$KUBELOGIN_TOKEN_FILE" -perm 0600 > "$0/perm" && cat "$KUBELOGIN_TOKEN_FILE" > "$0/token"`, outputDir},
+			TokenFile: &TokenFileInput{},
+		})
+		if err != nil {
+			t.Fatalf("Do returned error: %+v", err)
+		}
+		perm, err := os.ReadFile(filepath.Join(outputDir, "perm"))
+		if err != nil {
+			t.Fatalf("ReadFile error: %s", err)
+		}
+		tokenFilename := string(perm[:len(perm)-1])
+		if tokenFilename == "" {
+			t.Errorf("the token file wants 0600")
+		}
+		if _, err := os.Stat(filepath.Dir(tokenFilename)); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("the token directory wants to be removed but was %v", err)
+		}
+		token, err := os.ReadFile(filepath.Join(outputDir, "token"))
+		if err != nil {
+			t.Fatalf("ReadFile error: %s", err)
+		}
+		if diff := cmp.Diff("YOUR_TOKEN", string(token)); diff != "" {
+			t.Errorf("token mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("RestartOnRefresh", func(t *testing.T) {
+		ctx := context.TODO()
+		getToken := credentialplugin_mock.NewMockInterface(t)
+		getToken.EXPECT().
+			Token(ctx, getTokenInput).
+			Return(&credentialplugin.Output{Token: "YOUR_TOKEN", Expiry: now.Add(200 * time.Millisecond)}, nil)
+		getToken.EXPECT().
+			Token(ctx, refreshedGetTokenInput).
+			Return(&credentialplugin.Output{Token: "REFRESHED_TOKEN", Expiry: now.Add(2 * time.Hour)}, nil)
+		u := Exec{
+			GetToken: getToken,
+			Clock:    clock.Fake(now),
+			Logger:   logger.New(t),
+		}
+		err := u.Do(ctx, Input{
+			GetToken:      getTokenInput,
+			Command:       []string{"sh", "-c", `test "$KUBELOGIN_TOKEN" = REFRESHED_TOKEN && exit 0; exec sleep 10`},
+			EnvName:       "KUBELOGIN_TOKEN",
+			RefreshAction: RefreshActionRestart,
+			RefreshBefore: time.Hour,
+		})
+		if err != nil {
+			t.Errorf("Do returned error: %+v", err)
+		}
+	})
+
+	t.Run("SignalOnRefresh", func(t *testing.T) {
+		ctx := context.TODO()
+		outputDir := t.TempDir()
+		getToken := credentialplugin_mock.NewMockInterface(t)
+		getToken.EXPECT().
+			Token(ctx, getTokenInput).
+			Return(&credentialplugin.Output{Token: "YOUR_TOKEN", Expiry: now.Add(200 * time.Millisecond)}, nil)
+		getToken.EXPECT().
+			Token(ctx, refreshedGetTokenInput).
+			Return(&credentialplugin.Output{Token: "REFRESHED_TOKEN", Expiry: now.Add(2 * time.Hour)}, nil)
+		u := Exec{
+			GetToken: getToken,
+			Clock:    clock.Fake(now),
+			Logger:   logger.New(t),
+		}
+		err := u.Do(ctx, Input{
+			GetToken:      getTokenInput,
+			Command:       []string{"sh", "-c", `trap 'cat "$KUBELOGIN_TOKEN_FILE" > "$0/token"; exit 0' HUP; while :; do sleep 0.01; done`, outputDir},
+			TokenFile:     &TokenFileInput{Filename: filepath.Join(outputDir, "token-file")},
+			RefreshAction: RefreshActionSignal,
+			RefreshSignal: syscall.SIGHUP,
+			RefreshBefore: time.Hour,
+		})
+		if err != nil {
+			t.Errorf("Do returned error: %+v", err)
+		}
+		token, err := os.ReadFile(filepath.Join(outputDir, "token"))
+		if err != nil {
+			t.Fatalf("ReadFile error: %s", err)
+		}
+		if diff := cmp.Diff("REFRESHED_TOKEN", string(token)); diff != "" {
+			t.Errorf("token mismatch (-want +got):\n%s", diff)
+		}
+		// the token file at the explicit path is kept
+		tokenFile, err := os.ReadFile(filepath.Join(outputDir, "token-file"))
+		if err != nil {
+			t.Fatalf("the token file wants to be kept: %s", err)
+		}
+		if diff := cmp.Diff("REFRESHED_TOKEN", string(tokenFile)); diff != "" {
+			t.Errorf("token file mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
@@ -0,0 +1,254 @@
+// Package login provides the use-case of logging in to multiple contexts at once.
+package login
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/wire"
+	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/clock"
+	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/logger"
+	"github.com/togethercomputer/together-kubelogin/pkg/kubeconfig"
+	"github.com/togethercomputer/together-kubelogin/pkg/oidc"
+	"github.com/togethercomputer/together-kubelogin/pkg/oidc/client"
+	"github.com/togethercomputer/together-kubelogin/pkg/tokencache"
+	"github.com/togethercomputer/together-kubelogin/pkg/tokencache/repository"
+	"github.com/togethercomputer/together-kubelogin/pkg/usecases/authentication"
+	"github.com/togethercomputer/together-kubelogin/pkg/usecases/credentialplugin"
+)
+
+var Set = wire.NewSet(
+	wire.Struct(new(Login), "*"),
+	wire.Bind(new(Interface), new(*Login)),
+)
+
+type Interface interface {
+	Do(ctx context.Context, in Input) error
+}
+
+// Input represents an input DTO of the Login use-case.
+type Input struct {
+	Targets []Target
+}
+
+// Target represents a context and the options of its get-token command.
+type Target struct {
+	ContextName kubeconfig.ContextName
+	GetToken    credentialplugin.Input
+}
+
+// Status represents the result of a target.
+type Status string
+
+const (
+	StatusValid         Status = "valid"         // the token cache is valid
+	StatusAuthenticated Status = "authenticated" // refreshed or authenticated interactively
+	StatusExchanged     Status = "exchanged"     // exchanged the token of another client
+	StatusFailed        Status = "failed"
+)
+
+type result struct {
+	target Target
+	status Status
+	expiry time.Time
+	err    error
+}
+
+// group is the targets of the same token cache key, which share a login.
+type group struct {
+	key     tokencache.Key
+	results []*result
+}
+
+// subjectTokenKey is the key of the subject token of the token exchange.
+// The token of an issuer is shared between the clients of the same token type.
+type subjectTokenKey struct {
+	IssuerURL      string
+	UseAccessToken bool
+}
+
+func subjectTokenKeyOf(p oidc.Provider) subjectTokenKey {
+	return subjectTokenKey{IssuerURL: p.IssuerURL, UseAccessToken: p.UseAccessToken}
+}
+
+// Login logs in to the contexts at once.
+//
+// It groups the targets by the token cache key,
+// and authenticates once for each group.
+// If a group of the same issuer has already got a token,
+// it exchanges the token instead of the interactive authentication.
+// Finally, it writes the token to the token cache of every target.
+type Login struct {
+	Authentication       authentication.Interface
+	ClientFactory        client.FactoryInterface
+	TokenCacheRepository repository.Interface
+	Logger               logger.Interface
+	Clock                clock.Interface
+}
+
+func (u *Login) Do(ctx context.Context, in Input) error {
+	if len(in.Targets) == 0 {
+		return fmt.Errorf("no context uses get-token")
+	}
+	var groups []*group
+	for _, target := range in.Targets {
+		key := target.GetToken.TokenCacheKey()
+		i := slices.IndexFunc(groups, func(g *group) bool { return reflect.DeepEqual(g.key, key) })
+		if i < 0 {
+			groups = append(groups, &group{key: key})
+			i = len(groups) - 1
+		}
+		groups[i].results = append(groups[i].results, &result{target: target})
+	}
+
+	// token of each issuer and token type, used as the subject token of the token exchange
+	subjectTokens := make(map[subjectTokenKey]string)
+	for _, g := range groups {
+		u.loginGroup(ctx, g.results, subjectTokens)
+	}
+
+	var failed int
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "CONTEXT\tISSUER\tCLIENT ID\tSTATUS\tEXPIRY")
+	for _, target := range in.Targets {
+		r := findResult(groups, target.ContextName)
+		expiry := "-"
+		if !r.expiry.IsZero() {
+			expiry = r.expiry.Format(time.RFC3339)
+		}
+		status := string(r.status)
+		if r.err != nil {
+			failed++
+			status = fmt.Sprintf("%s: %s", r.status, r.err)
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			target.ContextName, target.GetToken.Provider.IssuerURL, target.GetToken.Provider.ClientID, status, expiry)
+	}
+	_ = w.Flush()
+	u.Logger.Printf("%s", strings.TrimSuffix(b.String(), "\n"))
+	if failed > 0 {
+		return fmt.Errorf("could not log in to %d of %d contexts", failed, len(in.Targets))
+	}
+	return nil
+}
+
+func findResult(groups []*group, contextName kubeconfig.ContextName) *result {
+	for _, g := range groups {
+		for _, r := range g.results {
+			if r.target.ContextName == contextName {
+				return r
+			}
+		}
+	}
+	return nil
+}
+
+func (u *Login) loginGroup(ctx context.Context, results []*result, subjectTokens map[subjectTokenKey]string) {
+	key := subjectTokenKeyOf(results[0].target.GetToken.Provider)
+	var pending []*result
+	var cachedTokenSet *oidc.TokenSet
+	for _, r := range results {
+		tokenSet, err := u.TokenCacheRepository.FindByKey(r.target.GetToken.TokenCacheConfig, r.target.GetToken.TokenCacheKey())
+		if err != nil {
+			u.Logger.V(1).Infof("%s: could not find a token cache: %s", r.target.ContextName, err)
+		}
+		if tokenSet != nil {
+			claims, err := tokenSet.DecodeWithoutVerify()
+			if err == nil && !claims.IsExpired(u.Clock) && !r.target.GetToken.ForceRefresh &&
+				r.target.GetToken.Provider.VerifyCachedTokenSet(*tokenSet, u.Clock.Now()) == nil {
+				r.status, r.expiry = StatusValid, claims.Expiry
+				if _, ok := subjectTokens[key]; !ok {
+					subjectTokens[key] = tokenSet.IDToken
+				}
+				continue
+			}
+			if cachedTokenSet == nil && tokenSet.RefreshToken != "" {
+				cachedTokenSet = tokenSet
+			}
+		}
+		pending = append(pending, r)
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	first := pending[0].target.GetToken
+	tokenSet, status, err := u.acquireToken(ctx, first, cachedTokenSet, subjectTokens[key])
+	if err != nil {
+		for _, r := range pending {
+			r.status, r.err = StatusFailed, err
+		}
+		return
+	}
+	claims, err := tokenSet.DecodeWithoutVerify()
+	if err != nil {
+		for _, r := range pending {
+			r.status, r.err = StatusFailed, fmt.Errorf("you got an invalid token: %w", err)
+		}
+		return
+	}
+	if _, ok := subjectTokens[key]; !ok {
+		subjectTokens[key] = tokenSet.IDToken
+	}
+	for _, r := range pending {
+		if err := u.saveTokenCache(r.target.GetToken, *tokenSet); err != nil {
+			r.status, r.err = StatusFailed, err
+			continue
+		}
+		r.status, r.expiry = status, claims.Expiry
+	}
+}
+
+// acquireToken returns a token by the cached refresh token, the token exchange or the authentication flow.
+func (u *Login) acquireToken(ctx context.Context, in credentialplugin.Input, cachedTokenSet *oidc.TokenSet, subjectToken string) (*oidc.TokenSet, Status, error) {
+	if cachedTokenSet == nil && subjectToken != "" {
+		u.Logger.V(1).Infof("exchanging the token for the client %s", in.Provider.ClientID)
+		tokenSet, err := u.exchangeToken(ctx, in, subjectToken)
+		if err == nil {
+			return tokenSet, StatusExchanged, nil
+		}
+		u.Logger.V(1).Infof("could not exchange the token: %s", err)
+	}
+	u.Logger.Printf("Logging in to %s as the client %s", in.Provider.IssuerURL, in.Provider.ClientID)
+	out, err := u.Authentication.Do(ctx, authentication.Input{
+		Provider:        in.Provider,
+		GrantOptionSet:  in.GrantOptionSet,
+		CachedTokenSet:  cachedTokenSet,
+		TLSClientConfig: in.TLSClientConfig,
+	})
+	if err != nil {
+		return nil, StatusFailed, fmt.Errorf("authentication error: %w", err)
+	}
+	return &out.TokenSet, StatusAuthenticated, nil
+}
+
+func (u *Login) exchangeToken(ctx context.Context, in credentialplugin.Input, subjectToken string) (*oidc.TokenSet, error) {
+	oidcClient, err := u.ClientFactory.New(ctx, in.Provider, in.TLSClientConfig)
+	if err != nil {
+		return nil, fmt.Errorf("oidc error: %w", err)
+	}
+	return oidcClient.ExchangeToken(ctx, subjectToken)
+}
+
+func (u *Login) saveTokenCache(in credentialplugin.Input, tokenSet oidc.TokenSet) error {
+	key := in.TokenCacheKey()
+	lock, err := u.TokenCacheRepository.Lock(in.TokenCacheConfig, key)
+	if err != nil {
+		return fmt.Errorf("could not lock the token cache: %w", err)
+	}
+	defer func() {
+		if err := lock.Close(); err != nil {
+			u.Logger.Printf("could not unlock the token cache: %s", err)
+		}
+	}()
+	if err := u.TokenCacheRepository.Save(in.TokenCacheConfig, key, tokenSet); err != nil {
+		return fmt.Errorf("could not write the token cache: %w", err)
+	}
+	return nil
+}
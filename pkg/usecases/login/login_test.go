@@ -0,0 +1,278 @@
+package login
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/togethercomputer/together-kubelogin/mocks/github.com/togethercomputer/together-kubelogin/pkg/oidc/client_mock"
+	"github.com/togethercomputer/together-kubelogin/mocks/github.com/togethercomputer/together-kubelogin/pkg/tokencache/repository_mock"
+	"github.com/togethercomputer/together-kubelogin/mocks/github.com/togethercomputer/together-kubelogin/pkg/usecases/authentication_mock"
+	"github.com/togethercomputer/together-kubelogin/mocks/io_mock"
+	"github.com/togethercomputer/together-kubelogin/pkg/oidc"
+	"github.com/togethercomputer/together-kubelogin/pkg/testing/clock"
+	testingJWT "github.com/togethercomputer/together-kubelogin/pkg/testing/jwt"
+	"github.com/togethercomputer/together-kubelogin/pkg/testing/logger"
+	"github.com/togethercomputer/together-kubelogin/pkg/tlsclientconfig"
+	"github.com/togethercomputer/together-kubelogin/pkg/tokencache"
+	"github.com/togethercomputer/together-kubelogin/pkg/usecases/authentication"
+	"github.com/togethercomputer/together-kubelogin/pkg/usecases/authentication/authcode"
+	"github.com/togethercomputer/together-kubelogin/pkg/usecases/credentialplugin"
+)
+
+func TestLogin_Do(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	tokenCacheConfig := tokencache.Config{Directory: "/path/to/token-cache"}
+	grantOptionSet := authentication.GrantOptionSet{
+		AuthCodeBrowserOption: &authcode.BrowserOption{BindAddress: []string{"127.0.0.1:0"}},
+	}
+	newInput := func(clientID string) credentialplugin.Input {
+		return credentialplugin.Input{
+			Provider: oidc.Provider{
+				IssuerURL: "https://issuer.example.com",
+				ClientID:  clientID,
+			},
+			TokenCacheConfig: tokenCacheConfig,
+			GrantOptionSet:   grantOptionSet,
+		}
+	}
+	newTokenSet := func(clientID string) oidc.TokenSet {
+		return oidc.TokenSet{
+			IDToken: testingJWT.EncodeF(t, func(claims *testingJWT.Claims) {
+				claims.Issuer = "https://issuer.example.com"
+				claims.Audience = []string{clientID}
+				claims.Subject = "YOUR_SUBJECT"
+				claims.ExpiresAt = jwt.NewNumericDate(now.Add(time.Hour))
+			}),
+			RefreshToken: "YOUR_REFRESH_TOKEN",
+		}
+	}
+
+	t.Run("SameProvider", func(t *testing.T) {
+		ctx := context.TODO()
+		in := Input{
+			Targets: []Target{
+				{ContextName: "dev", GetToken: newInput("YOUR_CLIENT_ID")},
+				{ContextName: "prod", GetToken: newInput("YOUR_CLIENT_ID")},
+			},
+		}
+		tokenSet := newTokenSet("YOUR_CLIENT_ID")
+		tokenCacheKey := tokencache.Key{Provider: in.Targets[0].GetToken.Provider}
+		mockRepository := repository_mock.NewMockInterface(t)
+		mockRepository.EXPECT().
+			FindByKey(tokenCacheConfig, tokenCacheKey).
+			Return(nil, errors.New("file not found")).
+			Twice()
+		mockCloser := io_mock.NewMockCloser(t)
+		mockCloser.EXPECT().Close().Return(nil).Twice()
+		mockRepository.EXPECT().
+			Lock(tokenCacheConfig, tokenCacheKey).
+			Return(mockCloser, nil).
+			Twice()
+		mockRepository.EXPECT().
+			Save(tokenCacheConfig, tokenCacheKey, tokenSet).
+			Return(nil).
+			Twice()
+		mockAuthentication := authentication_mock.NewMockInterface(t)
+		mockAuthentication.EXPECT().
+			Do(ctx, authentication.Input{
+				Provider:       in.Targets[0].GetToken.Provider,
+				GrantOptionSet: grantOptionSet,
+			}).
+			Return(&authentication.Output{TokenSet: tokenSet}, nil).
+			Once()
+		u := Login{
+			Authentication:       mockAuthentication,
+			ClientFactory:        client_mock.NewMockFactoryInterface(t),
+			TokenCacheRepository: mockRepository,
+			Logger:               logger.New(t),
+			Clock:                clock.Fake(now),
+		}
+		if err := u.Do(ctx, in); err != nil {
+			t.Errorf("Do returned error: %+v", err)
+		}
+	})
+
+	t.Run("SameProviderWithDifferentTLSClientConfig", func(t *testing.T) {
+		ctx := context.TODO()
+		prodInput := newInput("YOUR_CLIENT_ID")
+		prodInput.TLSClientConfig = tlsclientconfig.Config{CACertFilename: []string{"/path/to/ca.crt"}}
+		in := Input{
+			Targets: []Target{
+				{ContextName: "dev", GetToken: newInput("YOUR_CLIENT_ID")},
+				{ContextName: "prod", GetToken: prodInput},
+			},
+		}
+		tokenSet := newTokenSet("YOUR_CLIENT_ID")
+		mockRepository := repository_mock.NewMockInterface(t)
+		mockRepository.EXPECT().
+			FindByKey(tokenCacheConfig, mock.Anything).
+			Return(nil, errors.New("file not found")).
+			Twice()
+		mockCloser := io_mock.NewMockCloser(t)
+		mockCloser.EXPECT().Close().Return(nil).Twice()
+		for _, target := range in.Targets {
+			key := target.GetToken.TokenCacheKey()
+			mockRepository.EXPECT().
+				Lock(tokenCacheConfig, key).
+				Return(mockCloser, nil).
+				Once()
+			mockRepository.EXPECT().
+				Save(tokenCacheConfig, key, tokenSet).
+				Return(nil).
+				Once()
+		}
+		// the first group authenticates and the second group exchanges the token
+		mockAuthentication := authentication_mock.NewMockInterface(t)
+		mockAuthentication.EXPECT().
+			Do(ctx, authentication.Input{
+				Provider:       in.Targets[0].GetToken.Provider,
+				GrantOptionSet: grantOptionSet,
+			}).
+			Return(&authentication.Output{TokenSet: tokenSet}, nil).
+			Once()
+		mockClient := client_mock.NewMockInterface(t)
+		mockClient.EXPECT().
+			ExchangeToken(ctx, tokenSet.IDToken).
+			Return(&tokenSet, nil)
+		mockClientFactory := client_mock.NewMockFactoryInterface(t)
+		mockClientFactory.EXPECT().
+			New(ctx, in.Targets[1].GetToken.Provider, in.Targets[1].GetToken.TLSClientConfig).
+			Return(mockClient, nil)
+		u := Login{
+			Authentication:       mockAuthentication,
+			ClientFactory:        mockClientFactory,
+			TokenCacheRepository: mockRepository,
+			Logger:               logger.New(t),
+			Clock:                clock.Fake(now),
+		}
+		if err := u.Do(ctx, in); err != nil {
+			t.Errorf("Do returned error: %+v", err)
+		}
+	})
+
+	t.Run("TokenExchange", func(t *testing.T) {
+		ctx := context.TODO()
+		in := Input{
+			Targets: []Target{
+				{ContextName: "dev", GetToken: newInput("DEV_CLIENT_ID")},
+				{ContextName: "prod", GetToken: newInput("PROD_CLIENT_ID")},
+			},
+		}
+		devTokenSet := newTokenSet("DEV_CLIENT_ID")
+		prodTokenSet := newTokenSet("PROD_CLIENT_ID")
+		devTokenCacheKey := tokencache.Key{Provider: in.Targets[0].GetToken.Provider}
+		prodTokenCacheKey := tokencache.Key{Provider: in.Targets[1].GetToken.Provider}
+		mockRepository := repository_mock.NewMockInterface(t)
+		mockRepository.EXPECT().
+			FindByKey(tokenCacheConfig, devTokenCacheKey).
+			Return(&devTokenSet, nil)
+		mockRepository.EXPECT().
+			FindByKey(tokenCacheConfig, prodTokenCacheKey).
+			Return(nil, errors.New("file not found"))
+		mockCloser := io_mock.NewMockCloser(t)
+		mockCloser.EXPECT().Close().Return(nil)
+		mockRepository.EXPECT().
+			Lock(tokenCacheConfig, prodTokenCacheKey).
+			Return(mockCloser, nil)
+		mockRepository.EXPECT().
+			Save(tokenCacheConfig, prodTokenCacheKey, prodTokenSet).
+			Return(nil)
+		mockClient := client_mock.NewMockInterface(t)
+		mockClient.EXPECT().
+			ExchangeToken(ctx, devTokenSet.IDToken).
+			Return(&prodTokenSet, nil)
+		mockClientFactory := client_mock.NewMockFactoryInterface(t)
+		mockClientFactory.EXPECT().
+			New(ctx, in.Targets[1].GetToken.Provider, in.Targets[1].GetToken.TLSClientConfig).
+			Return(mockClient, nil)
+		u := Login{
+			Authentication:       authentication_mock.NewMockInterface(t),
+			ClientFactory:        mockClientFactory,
+			TokenCacheRepository: mockRepository,
+			Logger:               logger.New(t),
+			Clock:                clock.Fake(now),
+		}
+		if err := u.Do(ctx, in); err != nil {
+			t.Errorf("Do returned error: %+v", err)
+		}
+	})
+
+	t.Run("TokenExchangeOfDifferentTokenType", func(t *testing.T) {
+		ctx := context.TODO()
+		prodInput := newInput("PROD_CLIENT_ID")
+		prodInput.Provider.UseAccessToken = true
+		in := Input{
+			Targets: []Target{
+				{ContextName: "dev", GetToken: newInput("DEV_CLIENT_ID")},
+				{ContextName: "prod", GetToken: prodInput},
+			},
+		}
+		devTokenSet := newTokenSet("DEV_CLIENT_ID")
+		prodTokenSet := newTokenSet("PROD_CLIENT_ID")
+		devTokenCacheKey := tokencache.Key{Provider: in.Targets[0].GetToken.Provider}
+		prodTokenCacheKey := tokencache.Key{Provider: in.Targets[1].GetToken.Provider}
+		mockRepository := repository_mock.NewMockInterface(t)
+		mockRepository.EXPECT().
+			FindByKey(tokenCacheConfig, devTokenCacheKey).
+			Return(&devTokenSet, nil)
+		mockRepository.EXPECT().
+			FindByKey(tokenCacheConfig, prodTokenCacheKey).
+			Return(nil, errors.New("file not found"))
+		mockCloser := io_mock.NewMockCloser(t)
+		mockCloser.EXPECT().Close().Return(nil)
+		mockRepository.EXPECT().
+			Lock(tokenCacheConfig, prodTokenCacheKey).
+			Return(mockCloser, nil)
+		mockRepository.EXPECT().
+			Save(tokenCacheConfig, prodTokenCacheKey, prodTokenSet).
+			Return(nil)
+		mockAuthentication := authentication_mock.NewMockInterface(t)
+		mockAuthentication.EXPECT().
+			Do(ctx, authentication.Input{
+				Provider:       in.Targets[1].GetToken.Provider,
+				GrantOptionSet: grantOptionSet,
+			}).
+			Return(&authentication.Output{TokenSet: prodTokenSet}, nil)
+		u := Login{
+			Authentication:       mockAuthentication,
+			ClientFactory:        client_mock.NewMockFactoryInterface(t),
+			TokenCacheRepository: mockRepository,
+			Logger:               logger.New(t),
+			Clock:                clock.Fake(now),
+		}
+		if err := u.Do(ctx, in); err != nil {
+			t.Errorf("Do returned error: %+v", err)
+		}
+	})
+
+	t.Run("AuthenticationError", func(t *testing.T) {
+		ctx := context.TODO()
+		in := Input{
+			Targets: []Target{
+				{ContextName: "dev", GetToken: newInput("YOUR_CLIENT_ID")},
+			},
+		}
+		mockRepository := repository_mock.NewMockInterface(t)
+		mockRepository.EXPECT().
+			FindByKey(mock.Anything, mock.Anything).
+			Return(nil, errors.New("file not found"))
+		mockAuthentication := authentication_mock.NewMockInterface(t)
+		mockAuthentication.EXPECT().
+			Do(ctx, mock.Anything).
+			Return(nil, errors.New("authentication error"))
+		u := Login{
+			Authentication:       mockAuthentication,
+			ClientFactory:        client_mock.NewMockFactoryInterface(t),
+			TokenCacheRepository: mockRepository,
+			Logger:               logger.New(t),
+			Clock:                clock.Fake(now),
+		}
+		if err := u.Do(ctx, in); err == nil {
+			t.Errorf("Do wants an error but was nil")
+		}
+	})
+}
@@ -0,0 +1,164 @@
+package setup
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/togethercomputer/together-kubelogin/pkg/authnconfig"
+	"github.com/togethercomputer/together-kubelogin/pkg/tlsclientconfig"
+)
+
+// ClaimMappingInput represents how kube-apiserver maps the claims to a user.
+// If a claim is empty, it is suggested from the claims of the token.
+// A prefix follows the convention of --oidc-username-prefix,
+// i.e. an empty string means the default and "-" means no prefix.
+type ClaimMappingInput struct {
+	UsernameClaim  string
+	UsernamePrefix string
+	GroupsClaim    string
+	GroupsPrefix   string
+}
+
+// AuthenticationConfigInput represents the options to generate an AuthenticationConfiguration.
+type AuthenticationConfigInput struct {
+	Filename       string // If empty, show it in the instruction
+	ExtraAudiences []string
+}
+
+// claimMapping represents the resolved ClaimMappingInput.
+type claimMapping struct {
+	UsernameClaim  string
+	UsernamePrefix string
+	GroupsClaim    string // empty if the token has no groups
+	GroupsPrefix   string
+}
+
+// ignoredClaims are never suggested for the username or groups.
+var ignoredClaims = []string{"iss", "aud", "azp", "nonce", "at_hash", "c_hash", "jti", "sid", "typ"}
+
+func resolveClaimMapping(in ClaimMappingInput, issuerURL string, claims map[string]any) claimMapping {
+	var m claimMapping
+	m.UsernameClaim = in.UsernameClaim
+	if m.UsernameClaim == "" {
+		m.UsernameClaim = "sub"
+		if _, ok := claims["email"].(string); ok {
+			m.UsernameClaim = "email"
+		}
+	}
+	switch in.UsernamePrefix {
+	case "-":
+	case "":
+		// same as the default of --oidc-username-prefix
+		if m.UsernameClaim != "email" {
+			m.UsernamePrefix = issuerURL + "#"
+		}
+	default:
+		m.UsernamePrefix = in.UsernamePrefix
+	}
+	m.GroupsClaim = in.GroupsClaim
+	if m.GroupsClaim == "" && slices.Contains(groupsClaimCandidates(claims), "groups") {
+		m.GroupsClaim = "groups"
+	}
+	if in.GroupsPrefix != "-" {
+		m.GroupsPrefix = in.GroupsPrefix
+	}
+	return m
+}
+
+// usernameClaimCandidates returns the claims of a string value.
+func usernameClaimCandidates(claims map[string]any) []string {
+	var candidates []string
+	for name, value := range claims {
+		if _, ok := value.(string); ok && !slices.Contains(ignoredClaims, name) {
+			candidates = append(candidates, name)
+		}
+	}
+	sort.Strings(candidates)
+	return candidates
+}
+
+// groupsClaimCandidates returns the claims of a string array value.
+func groupsClaimCandidates(claims map[string]any) []string {
+	var candidates []string
+	for name, value := range claims {
+		values, ok := value.([]any)
+		if !ok || slices.Contains(ignoredClaims, name) {
+			continue
+		}
+		if slices.ContainsFunc(values, func(v any) bool { _, ok := v.(string); return !ok }) {
+			continue
+		}
+		candidates = append(candidates, name)
+	}
+	sort.Strings(candidates)
+	return candidates
+}
+
+func generateAuthenticationConfiguration(issuerURL string, audiences []string, certificateAuthority string, m claimMapping, claims map[string]any) authnconfig.AuthenticationConfiguration {
+	jwtAuthenticator := authnconfig.JWTAuthenticator{
+		Issuer: authnconfig.Issuer{
+			URL:                  issuerURL,
+			CertificateAuthority: certificateAuthority,
+			Audiences:            audiences,
+		},
+		ClaimMappings: authnconfig.ClaimMappings{
+			Username: authnconfig.PrefixedClaimOrExpression{
+				Claim:  m.UsernameClaim,
+				Prefix: &m.UsernamePrefix,
+			},
+			UID: authnconfig.ClaimOrExpression{
+				Claim: "sub",
+			},
+		},
+		UserValidationRules: []authnconfig.UserValidationRule{
+			{
+				Expression: "!user.username.startsWith('system:')",
+				Message:    "username cannot use the reserved system: prefix",
+			},
+		},
+	}
+	if len(audiences) > 1 {
+		jwtAuthenticator.Issuer.AudienceMatchPolicy = authnconfig.AudienceMatchPolicyMatchAny
+	}
+	if _, ok := claims["email_verified"]; ok && m.UsernameClaim == "email" {
+		jwtAuthenticator.ClaimValidationRules = append(jwtAuthenticator.ClaimValidationRules, authnconfig.ClaimValidationRule{
+			Expression: "claims.email_verified == true",
+			Message:    "email must be verified",
+		})
+	}
+	if m.GroupsClaim != "" {
+		jwtAuthenticator.ClaimMappings.Groups = authnconfig.PrefixedClaimOrExpression{
+			Claim:  m.GroupsClaim,
+			Prefix: &m.GroupsPrefix,
+		}
+	}
+	return authnconfig.AuthenticationConfiguration{
+		APIVersion: authnconfig.APIVersion,
+		Kind:       authnconfig.Kind,
+		JWT:        []authnconfig.JWTAuthenticator{jwtAuthenticator},
+	}
+}
+
+// readCertificateAuthority returns the concatenated PEM of the certificates for the provider.
+func readCertificateAuthority(c tlsclientconfig.Config) (string, error) {
+	var b strings.Builder
+	for _, f := range c.CACertFilename {
+		pem, err := os.ReadFile(f)
+		if err != nil {
+			return "", fmt.Errorf("could not read %s: %w", f, err)
+		}
+		b.Write(pem)
+	}
+	for _, d := range c.CACertData {
+		pem, err := base64.StdEncoding.DecodeString(d)
+		if err != nil {
+			return "", fmt.Errorf("could not decode base64: %w", err)
+		}
+		b.Write(pem)
+	}
+	return b.String(), nil
+}
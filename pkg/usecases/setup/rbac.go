@@ -0,0 +1,108 @@
+package setup
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// RBACInput represents the options to generate a role binding for the authenticated user.
+type RBACInput struct {
+	Role           string // Name of the ClusterRole, e.g. cluster-admin or view
+	Namespace      string // If set, generate a RoleBinding instead of a ClusterRoleBinding
+	BindGroupClaim string // If set, bind the groups instead of the user. Either "claim" or "claim=value"
+	Filename       string // If empty, show it in the instruction
+	Apply          bool
+	Kubeconfig     string // Kubeconfig to apply with. Default to the kubeconfig of kubectl
+}
+
+// groupsClaim returns the claim name of --bind-group.
+func (in RBACInput) groupsClaim() string {
+	claim, _, _ := strings.Cut(in.BindGroupClaim, "=")
+	return claim
+}
+
+var invalidBindingNameChars = regexp.MustCompile(`[^a-z0-9.-]+`)
+
+// generateRoleBinding returns a ClusterRoleBinding or RoleBinding for the subject of the token.
+// It follows the claim mapping of kube-apiserver, i.e. the same prefix and claim.
+func generateRoleBinding(in RBACInput, m claimMapping, claims map[string]any) (*unstructured.Unstructured, error) {
+	subjects, subjectName, err := roleBindingSubjects(in, m, claims)
+	if err != nil {
+		return nil, err
+	}
+	name := strings.Trim(invalidBindingNameChars.ReplaceAllString(strings.ToLower(fmt.Sprintf("oidc-%s-%s", in.Role, subjectName)), "-"), "-.")
+	roleRef := rbacv1.RoleRef{
+		APIGroup: rbacv1.GroupName,
+		Kind:     "ClusterRole",
+		Name:     in.Role,
+	}
+	var obj runtime.Object
+	if in.Namespace == "" {
+		obj = &rbacv1.ClusterRoleBinding{
+			TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "ClusterRoleBinding"},
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Subjects:   subjects,
+			RoleRef:    roleRef,
+		}
+	} else {
+		obj = &rbacv1.RoleBinding{
+			TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "RoleBinding"},
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: in.Namespace},
+			Subjects:   subjects,
+			RoleRef:    roleRef,
+		}
+	}
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, fmt.Errorf("could not convert the role binding: %w", err)
+	}
+	unstructured.RemoveNestedField(u, "metadata", "creationTimestamp")
+	return &unstructured.Unstructured{Object: u}, nil
+}
+
+func roleBindingSubjects(in RBACInput, m claimMapping, claims map[string]any) ([]rbacv1.Subject, string, error) {
+	if in.BindGroupClaim == "" {
+		username, ok := claims[m.UsernameClaim].(string)
+		if !ok || username == "" {
+			return nil, "", fmt.Errorf("the token has no %s claim for the username", m.UsernameClaim)
+		}
+		subject := rbacv1.Subject{Kind: rbacv1.UserKind, APIGroup: rbacv1.GroupName, Name: m.UsernamePrefix + username}
+		return []rbacv1.Subject{subject}, username, nil
+	}
+
+	claim, value, hasValue := strings.Cut(in.BindGroupClaim, "=")
+	var groups []string
+	switch v := claims[claim].(type) {
+	case string:
+		groups = []string{v}
+	case []any:
+		for _, e := range v {
+			if s, ok := e.(string); ok {
+				groups = append(groups, s)
+			}
+		}
+	}
+	if len(groups) == 0 {
+		return nil, "", fmt.Errorf("the token has no %s claim for the groups", claim)
+	}
+	if !hasValue {
+		// Do not bind all groups of the user, which may grant the role to unrelated groups.
+		if len(groups) > 1 {
+			return nil, "", fmt.Errorf("the %s claim has multiple groups: set --bind-group=%s=VALUE to choose one of %s",
+				claim, claim, strings.Join(groups, ", "))
+		}
+		value = groups[0]
+	}
+	if !slices.Contains(groups, value) {
+		return nil, "", fmt.Errorf("the %s claim does not contain %s (available: %s)", claim, value, strings.Join(groups, ", "))
+	}
+	subject := rbacv1.Subject{Kind: rbacv1.GroupKind, APIGroup: rbacv1.GroupName, Name: m.GroupsPrefix + value}
+	return []rbacv1.Subject{subject}, value, nil
+}
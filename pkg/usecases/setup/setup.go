@@ -4,6 +4,8 @@ package setup
 import (
 	"context"
 	"fmt"
+	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"text/template"
@@ -11,10 +13,18 @@ import (
 	_ "embed"
 
 	"github.com/google/wire"
+	"github.com/togethercomputer/together-kubelogin/pkg/authnconfig"
 	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/logger"
+	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/reader"
+	"github.com/togethercomputer/together-kubelogin/pkg/kubeconfig"
+	"github.com/togethercomputer/together-kubelogin/pkg/jwt"
+	"github.com/togethercomputer/together-kubelogin/pkg/kubeconfig/writer"
 	"github.com/togethercomputer/together-kubelogin/pkg/oidc"
+	"github.com/togethercomputer/together-kubelogin/pkg/oidc/client"
+	"github.com/togethercomputer/together-kubelogin/pkg/rbac/applier"
 	"github.com/togethercomputer/together-kubelogin/pkg/tlsclientconfig"
 	"github.com/togethercomputer/together-kubelogin/pkg/usecases/authentication"
+	"sigs.k8s.io/yaml"
 )
 
 var Set = wire.NewSet(
@@ -27,8 +37,11 @@ type Interface interface {
 }
 
 type Setup struct {
-	Authentication authentication.Interface
-	Logger         logger.Interface
+	Authentication   authentication.Interface
+	KubeconfigWriter writer.Interface
+	RBACApplier      applier.Interface
+	Reader           reader.Interface
+	Logger           logger.Interface
 }
 
 //go:embed setup.md
@@ -36,34 +49,110 @@ var setupMarkdown string
 
 var setupTemplate = template.Must(template.New("setup.md").Funcs(template.FuncMap{
 	"quote": strconv.Quote,
+	"join":  strings.Join,
 }).Parse(setupMarkdown))
 
 type Input struct {
-	IssuerURL       string
-	ClientID        string
-	ClientSecret    string
-	RedirectURL     string
-	ExtraScopes     []string
-	UseAccessToken  bool
-	RequestHeaders  map[string]string
-	PKCEMethod      oidc.PKCEMethod
-	GrantOptionSet  authentication.GrantOptionSet
-	TLSClientConfig tlsclientconfig.Config
-	ChangedFlags    []string
+	IssuerURL      string
+	ClientID       string
+	ClientSecret   string
+	RedirectURL    string
+	ExtraScopes    []string
+	UseAccessToken bool
+	RequestHeaders map[string]string
+	PKCEMethod     oidc.PKCEMethod
+	// AuthenticationPolicy, Prompt and LoginHint are passed to the provider.
+	AuthenticationPolicy oidc.AuthenticationPolicy
+	Prompt               string
+	LoginHint            string
+	GrantOptionSet       authentication.GrantOptionSet
+	TLSClientConfig      tlsclientconfig.Config
+	ChangedFlags         []string
+	ClaimMapping         ClaimMappingInput
+	// optional
+	WriteKubeconfig      *WriteKubeconfigInput
+	AuthenticationConfig *AuthenticationConfigInput
+	RBAC                 *RBACInput
+}
+
+// WriteKubeconfigInput represents the entries to write into the kubeconfig.
+// If ClusterServer is empty, it writes only the user.
+type WriteKubeconfigInput struct {
+	Filename          string // Default to the kubeconfig of kubectl
+	UserName          kubeconfig.UserName
+	ContextName       kubeconfig.ContextName // Also used as the cluster name
+	ClusterServer     string                 // optional
+	ClusterCAFilename string                 // optional
+}
+
+const execInstallHint = `kubelogin is required to authenticate with the OpenID Connect provider.
+See https://github.com/togethercomputer/together-kubelogin for the installation.`
+
+const overwritePrompt = "Overwrite them? [y/N] "
+
+// printRedirectURLsToRegister shows the redirect URIs derived from the listen addresses,
+// which must be registered to the provider.
+func (u Setup) printRedirectURLsToRegister(s authentication.GrantOptionSet) error {
+	var redirectURLs []string
+	appendURL := func(urls ...string) {
+		for _, url := range urls {
+			if !slices.Contains(redirectURLs, url) {
+				redirectURLs = append(redirectURLs, url)
+			}
+		}
+	}
+	for _, candidate := range append([]authentication.GrantOptionSet{s}, s.Candidates...) {
+		switch {
+		case candidate.AuthCodeBrowserOption != nil:
+			o := candidate.AuthCodeBrowserOption
+			https := o.LocalServerCertFile != "" && o.LocalServerKeyFile != ""
+			urls, err := client.RedirectURLsToRegister(o.BindAddress, https)
+			if err != nil {
+				return fmt.Errorf("invalid listen-address: %w", err)
+			}
+			appendURL(urls...)
+		case candidate.AuthCodePasteOption != nil:
+			appendURL(candidate.AuthCodePasteOption.RedirectURL)
+		}
+	}
+	if len(redirectURLs) == 0 {
+		return nil
+	}
+	u.Logger.Printf("Register the following redirect URIs to the OpenID Connect provider:\n  %s", strings.Join(redirectURLs, "\n  "))
+	return nil
 }
 
 func (u Setup) Do(ctx context.Context, in Input) error {
+	if in.RBAC != nil && in.RBAC.BindGroupClaim != "" {
+		// kube-apiserver maps the groups from the same claim as the binding
+		switch in.ClaimMapping.GroupsClaim {
+		case "":
+			in.ClaimMapping.GroupsClaim = in.RBAC.groupsClaim()
+		case in.RBAC.groupsClaim():
+		default:
+			return fmt.Errorf("the claim to bind (%s) must be the same as the groups claim (%s)", in.RBAC.groupsClaim(), in.ClaimMapping.GroupsClaim)
+		}
+	}
+
+	if in.RedirectURL == "" {
+		if err := u.printRedirectURLsToRegister(in.GrantOptionSet); err != nil {
+			return err
+		}
+	}
 	u.Logger.Printf("Authentication in progress...")
 	out, err := u.Authentication.Do(ctx, authentication.Input{
 		Provider: oidc.Provider{
-			IssuerURL:      in.IssuerURL,
-			ClientID:       in.ClientID,
-			ClientSecret:   in.ClientSecret,
-			RedirectURL:    in.RedirectURL,
-			ExtraScopes:    in.ExtraScopes,
-			PKCEMethod:     in.PKCEMethod,
-			UseAccessToken: in.UseAccessToken,
-			RequestHeaders: in.RequestHeaders,
+			IssuerURL:            in.IssuerURL,
+			ClientID:             in.ClientID,
+			ClientSecret:         in.ClientSecret,
+			RedirectURL:          in.RedirectURL,
+			ExtraScopes:          in.ExtraScopes,
+			PKCEMethod:           in.PKCEMethod,
+			UseAccessToken:       in.UseAccessToken,
+			RequestHeaders:       in.RequestHeaders,
+			AuthenticationPolicy: in.AuthenticationPolicy,
+			Prompt:               in.Prompt,
+			LoginHint:            in.LoginHint,
 		},
 		GrantOptionSet:  in.GrantOptionSet,
 		TLSClientConfig: in.TLSClientConfig,
@@ -76,13 +165,160 @@ func (u Setup) Do(ctx context.Context, in Input) error {
 		return fmt.Errorf("you got an invalid token: %w", err)
 	}
 
-	var b strings.Builder
-	if err := setupTemplate.Execute(&b, map[string]any{
+	claims, err := jwt.DecodePayloadAsMap(out.TokenSet.IDToken)
+	if err != nil {
+		return fmt.Errorf("you got an invalid token: %w", err)
+	}
+	issuerURL, ok := claims["iss"].(string)
+	if !ok {
+		issuerURL = in.IssuerURL
+	}
+	m := resolveClaimMapping(in.ClaimMapping, issuerURL, claims)
+
+	data := map[string]any{
 		"IDTokenPrettyJSON": idTokenClaims.Pretty,
 		"Flags":             in.ChangedFlags,
-	}); err != nil {
+	}
+	if in.AuthenticationConfig != nil {
+		if err := u.generateAuthenticationConfig(in, issuerURL, m, claims, data); err != nil {
+			return fmt.Errorf("could not generate the authentication configuration: %w", err)
+		}
+	}
+	if in.RBAC != nil {
+		if err := u.generateRBAC(ctx, in, m, claims, data); err != nil {
+			return fmt.Errorf("could not generate the role binding: %w", err)
+		}
+	}
+
+	var b strings.Builder
+	if err := setupTemplate.Execute(&b, data); err != nil {
 		return fmt.Errorf("render the template: %w", err)
 	}
 	u.Logger.Printf(b.String())
+
+	if in.WriteKubeconfig != nil {
+		if err := u.writeKubeconfig(in); err != nil {
+			return fmt.Errorf("could not write the kubeconfig: %w", err)
+		}
+	}
+	return nil
+}
+
+func (u Setup) generateAuthenticationConfig(in Input, issuerURL string, m claimMapping, claims map[string]any, data map[string]any) error {
+	audiences := []string{in.ClientID}
+	for _, audience := range in.AuthenticationConfig.ExtraAudiences {
+		if !slices.Contains(audiences, audience) {
+			audiences = append(audiences, audience)
+		}
+	}
+	certificateAuthority, err := readCertificateAuthority(in.TLSClientConfig)
+	if err != nil {
+		return fmt.Errorf("could not read the certificate authority: %w", err)
+	}
+	c := generateAuthenticationConfiguration(issuerURL, audiences, certificateAuthority, m, claims)
+	b, err := authnconfig.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if in.AuthenticationConfig.Filename != "" {
+		if err := os.WriteFile(in.AuthenticationConfig.Filename, b, 0644); err != nil {
+			return fmt.Errorf("could not write %s: %w", in.AuthenticationConfig.Filename, err)
+		}
+	}
+	data["AuthenticationConfigYAML"] = string(b)
+	data["AuthenticationConfigFilename"] = in.AuthenticationConfig.Filename
+	data["UsernameClaimCandidates"] = usernameClaimCandidates(claims)
+	data["GroupsClaimCandidates"] = groupsClaimCandidates(claims)
+	return nil
+}
+
+func (u Setup) generateRBAC(ctx context.Context, in Input, m claimMapping, claims map[string]any, data map[string]any) error {
+	obj, err := generateRoleBinding(*in.RBAC, m, claims)
+	if err != nil {
+		return err
+	}
+	b, err := yaml.Marshal(obj.Object)
+	if err != nil {
+		return fmt.Errorf("could not marshal the role binding: %w", err)
+	}
+	if in.RBAC.Filename != "" {
+		if err := os.WriteFile(in.RBAC.Filename, b, 0644); err != nil {
+			return fmt.Errorf("could not write %s: %w", in.RBAC.Filename, err)
+		}
+	}
+	if in.RBAC.Apply {
+		u.Logger.Printf("Applying the %s %s", obj.GetKind(), obj.GetName())
+		if err := u.RBACApplier.Apply(ctx, in.RBAC.Kubeconfig, obj); err != nil {
+			return err
+		}
+	}
+	data["RBACYAML"] = string(b)
+	data["RBACFilename"] = in.RBAC.Filename
+	data["RBACApplied"] = in.RBAC.Apply
+	data["RBACKind"] = obj.GetKind()
+	data["RBACName"] = obj.GetName()
 	return nil
 }
+
+func (u Setup) writeKubeconfig(in Input) error {
+	w := in.WriteKubeconfig
+	execConfig := kubeconfig.ExecConfig{
+		UserName:        w.UserName,
+		Command:         "kubectl",
+		Args:            append([]string{"oidc-login", "get-token"}, in.ChangedFlags...),
+		InstallHint:     execInstallHint,
+		InteractiveMode: execInteractiveMode(in.GrantOptionSet),
+	}
+	if w.ClusterServer != "" {
+		execConfig.ClusterName = string(w.ContextName)
+		execConfig.ContextName = w.ContextName
+		execConfig.ClusterServer = w.ClusterServer
+	}
+	if w.ClusterCAFilename != "" {
+		b, err := os.ReadFile(w.ClusterCAFilename)
+		if err != nil {
+			return fmt.Errorf("could not read the cluster CA: %w", err)
+		}
+		execConfig.ClusterCertificateAuthority = b
+	}
+
+	conflicts, err := u.KubeconfigWriter.FindExecConfigConflicts(w.Filename, execConfig)
+	if err != nil {
+		return err
+	}
+	if len(conflicts) > 0 {
+		u.Logger.Printf("The kubeconfig already has %s.", strings.Join(conflicts, ", "))
+		answer, err := u.Reader.ReadString(overwritePrompt)
+		if err != nil {
+			return fmt.Errorf("could not read the answer: %w", err)
+		}
+		if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+			return fmt.Errorf("cancelled by the user")
+		}
+	}
+	backupFilename, err := u.KubeconfigWriter.MergeExecConfig(w.Filename, execConfig)
+	if backupFilename != "" {
+		u.Logger.Printf("Backed up the kubeconfig to %s", backupFilename)
+	}
+	if err != nil {
+		return err
+	}
+	u.Logger.Printf("Wrote the user %s to the kubeconfig", w.UserName)
+	if execConfig.ContextName != "" {
+		u.Logger.Printf("Wrote the cluster and context %s to the kubeconfig", execConfig.ContextName)
+	}
+	return nil
+}
+
+// execInteractiveMode returns Never if the grant does not read the standard input.
+func execInteractiveMode(s authentication.GrantOptionSet) string {
+	if s.AuthCodeKeyboardOption != nil || s.AuthCodePasteOption != nil || s.ROPCOption != nil {
+		return "IfAvailable"
+	}
+	for _, candidate := range s.Candidates {
+		if execInteractiveMode(candidate) == "IfAvailable" {
+			return "IfAvailable"
+		}
+	}
+	return "Never"
+}
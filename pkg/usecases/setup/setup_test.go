@@ -2,16 +2,26 @@ package setup
 
 import (
 	"context"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/mock"
+	"github.com/togethercomputer/together-kubelogin/mocks/github.com/togethercomputer/together-kubelogin/pkg/infrastructure/reader_mock"
+	"github.com/togethercomputer/together-kubelogin/mocks/github.com/togethercomputer/together-kubelogin/pkg/kubeconfig/writer_mock"
+	"github.com/togethercomputer/together-kubelogin/mocks/github.com/togethercomputer/together-kubelogin/pkg/rbac/applier_mock"
 	"github.com/togethercomputer/together-kubelogin/mocks/github.com/togethercomputer/together-kubelogin/pkg/usecases/authentication_mock"
+	"github.com/togethercomputer/together-kubelogin/pkg/kubeconfig"
 	"github.com/togethercomputer/together-kubelogin/pkg/oidc"
 	testingJWT "github.com/togethercomputer/together-kubelogin/pkg/testing/jwt"
 	"github.com/togethercomputer/together-kubelogin/pkg/testing/logger"
 	"github.com/togethercomputer/together-kubelogin/pkg/tlsclientconfig"
 	"github.com/togethercomputer/together-kubelogin/pkg/usecases/authentication"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 func TestSetup_Do(t *testing.T) {
@@ -64,3 +74,390 @@ func TestSetup_Do(t *testing.T) {
 		t.Errorf("Do returned error: %+v", err)
 	}
 }
+
+func TestSetup_DoWithWriteKubeconfig(t *testing.T) {
+	issuedIDToken := testingJWT.EncodeF(t, func(claims *testingJWT.Claims) {
+		claims.Issuer = "https://issuer.example.com"
+		claims.Subject = "YOUR_SUBJECT"
+		claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(1 * time.Hour))
+	})
+	in := Input{
+		IssuerURL: "https://issuer.example.com",
+		ClientID:  "YOUR_CLIENT_ID",
+		ChangedFlags: []string{
+			"--oidc-issuer-url=https://issuer.example.com",
+			"--oidc-client-id=YOUR_CLIENT_ID",
+		},
+		WriteKubeconfig: &WriteKubeconfigInput{
+			Filename:      "/path/to/kubeconfig",
+			UserName:      "oidc",
+			ContextName:   "hello.k8s.local",
+			ClusterServer: "https://api.hello.k8s.local",
+		},
+	}
+	wantExecConfig := kubeconfig.ExecConfig{
+		UserName:      "oidc",
+		ContextName:   "hello.k8s.local",
+		ClusterName:   "hello.k8s.local",
+		ClusterServer: "https://api.hello.k8s.local",
+		Command:       "kubectl",
+		Args: []string{
+			"oidc-login",
+			"get-token",
+			"--oidc-issuer-url=https://issuer.example.com",
+			"--oidc-client-id=YOUR_CLIENT_ID",
+		},
+		InstallHint:     execInstallHint,
+		InteractiveMode: "Never",
+	}
+	newMockAuthentication := func(t *testing.T) *authentication_mock.MockInterface {
+		mockAuthentication := authentication_mock.NewMockInterface(t)
+		mockAuthentication.EXPECT().
+			Do(mock.Anything, mock.Anything).
+			Return(&authentication.Output{
+				TokenSet: oidc.TokenSet{IDToken: issuedIDToken},
+			}, nil)
+		return mockAuthentication
+	}
+
+	t.Run("NoConflict", func(t *testing.T) {
+		ctx := context.Background()
+		mockKubeconfigWriter := writer_mock.NewMockInterface(t)
+		mockKubeconfigWriter.EXPECT().
+			FindExecConfigConflicts("/path/to/kubeconfig", wantExecConfig).
+			Return(nil, nil)
+		mockKubeconfigWriter.EXPECT().
+			MergeExecConfig("/path/to/kubeconfig", wantExecConfig).
+			Return("", nil)
+		u := Setup{
+			Authentication:   newMockAuthentication(t),
+			KubeconfigWriter: mockKubeconfigWriter,
+			Logger:           logger.New(t),
+		}
+		if err := u.Do(ctx, in); err != nil {
+			t.Errorf("Do returned error: %+v", err)
+		}
+	})
+
+	t.Run("Overwrite", func(t *testing.T) {
+		ctx := context.Background()
+		mockKubeconfigWriter := writer_mock.NewMockInterface(t)
+		mockKubeconfigWriter.EXPECT().
+			FindExecConfigConflicts("/path/to/kubeconfig", wantExecConfig).
+			Return([]string{"user oidc"}, nil)
+		mockKubeconfigWriter.EXPECT().
+			MergeExecConfig("/path/to/kubeconfig", wantExecConfig).
+			Return("/path/to/kubeconfig.backup", nil)
+		mockReader := reader_mock.NewMockInterface(t)
+		mockReader.EXPECT().
+			ReadString(overwritePrompt).
+			Return("y", nil)
+		u := Setup{
+			Authentication:   newMockAuthentication(t),
+			KubeconfigWriter: mockKubeconfigWriter,
+			Reader:           mockReader,
+			Logger:           logger.New(t),
+		}
+		if err := u.Do(ctx, in); err != nil {
+			t.Errorf("Do returned error: %+v", err)
+		}
+	})
+
+	t.Run("Cancel", func(t *testing.T) {
+		ctx := context.Background()
+		mockKubeconfigWriter := writer_mock.NewMockInterface(t)
+		mockKubeconfigWriter.EXPECT().
+			FindExecConfigConflicts("/path/to/kubeconfig", wantExecConfig).
+			Return([]string{"user oidc"}, nil)
+		mockReader := reader_mock.NewMockInterface(t)
+		mockReader.EXPECT().
+			ReadString(overwritePrompt).
+			Return("", nil)
+		u := Setup{
+			Authentication:   newMockAuthentication(t),
+			KubeconfigWriter: mockKubeconfigWriter,
+			Reader:           mockReader,
+			Logger:           logger.New(t),
+		}
+		if err := u.Do(ctx, in); err == nil {
+			t.Errorf("Do wants error but got nil")
+		}
+	})
+}
+
+func TestSetup_DoWithAuthenticationConfig(t *testing.T) {
+	issuedIDToken := testingJWT.EncodeF(t, func(claims *testingJWT.Claims) {
+		claims.Issuer = "https://issuer.example.com"
+		claims.Subject = "YOUR_SUBJECT"
+		claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(1 * time.Hour))
+		claims.Email = "alice@example.com"
+		claims.EmailVerified = true
+		claims.Groups = []string{"admins", "developers"}
+	})
+
+	tests := map[string]struct {
+		claimMapping ClaimMappingInput
+		want         string
+	}{
+		"Suggested": {
+			want: `apiVersion: apiserver.config.k8s.io/v1
+jwt:
+- claimMappings:
+    groups:
+      claim: groups
+      prefix: ""
+    uid:
+      claim: sub
+    username:
+      claim: email
+      prefix: ""
+  claimValidationRules:
+  - expression: claims.email_verified == true
+    message: email must be verified
+  issuer:
+    audienceMatchPolicy: MatchAny
+    audiences:
+    - YOUR_CLIENT_ID
+    - kubernetes
+    url: https://issuer.example.com
+  userValidationRules:
+  - expression: '!user.username.startsWith(''system:'')'
+    message: 'username cannot use the reserved system: prefix'
+kind: AuthenticationConfiguration
+`,
+		},
+		"Explicit": {
+			claimMapping: ClaimMappingInput{
+				UsernameClaim: "sub",
+				GroupsPrefix:  "oidc:",
+			},
+			want: `apiVersion: apiserver.config.k8s.io/v1
+jwt:
+- claimMappings:
+    groups:
+      claim: groups
+      prefix: 'oidc:'
+    uid:
+      claim: sub
+    username:
+      claim: sub
+      prefix: https://issuer.example.com#
+  issuer:
+    audienceMatchPolicy: MatchAny
+    audiences:
+    - YOUR_CLIENT_ID
+    - kubernetes
+    url: https://issuer.example.com
+  userValidationRules:
+  - expression: '!user.username.startsWith(''system:'')'
+    message: 'username cannot use the reserved system: prefix'
+kind: AuthenticationConfiguration
+`,
+		},
+	}
+	for name, c := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			filename := filepath.Join(t.TempDir(), "authentication-config.yaml")
+			mockAuthentication := authentication_mock.NewMockInterface(t)
+			mockAuthentication.EXPECT().
+				Do(mock.Anything, mock.Anything).
+				Return(&authentication.Output{
+					TokenSet: oidc.TokenSet{IDToken: issuedIDToken},
+				}, nil)
+			u := Setup{
+				Authentication: mockAuthentication,
+				Logger:         logger.New(t),
+			}
+			if err := u.Do(ctx, Input{
+				IssuerURL:    "https://issuer.example.com",
+				ClientID:     "YOUR_CLIENT_ID",
+				ClaimMapping: c.claimMapping,
+				AuthenticationConfig: &AuthenticationConfigInput{
+					Filename:       filename,
+					ExtraAudiences: []string{"kubernetes"},
+				},
+			}); err != nil {
+				t.Fatalf("Do returned error: %+v", err)
+			}
+			got, err := os.ReadFile(filename)
+			if err != nil {
+				t.Fatalf("could not read the file: %s", err)
+			}
+			if diff := cmp.Diff(c.want, string(got)); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSetup_DoWithRBAC(t *testing.T) {
+	issuedIDToken := testingJWT.EncodeF(t, func(claims *testingJWT.Claims) {
+		claims.Issuer = "https://issuer.example.com"
+		claims.Subject = "YOUR_SUBJECT"
+		claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(1 * time.Hour))
+		claims.Email = "alice@example.com"
+		claims.Groups = []string{"admins", "developers"}
+	})
+
+	tests := map[string]struct {
+		claimMapping ClaimMappingInput
+		rbac         RBACInput
+		want         string
+	}{
+		"User": {
+			rbac: RBACInput{Role: "cluster-admin"},
+			want: `apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: oidc-cluster-admin-alice-example.com
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: cluster-admin
+subjects:
+- apiGroup: rbac.authorization.k8s.io
+  kind: User
+  name: alice@example.com
+`,
+		},
+		"UserWithPrefix": {
+			claimMapping: ClaimMappingInput{UsernameClaim: "sub"},
+			rbac:         RBACInput{Role: "view", Namespace: "default"},
+			want: `apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: oidc-view-your-subject
+  namespace: default
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: view
+subjects:
+- apiGroup: rbac.authorization.k8s.io
+  kind: User
+  name: https://issuer.example.com#YOUR_SUBJECT
+`,
+		},
+		"Group": {
+			claimMapping: ClaimMappingInput{GroupsPrefix: "oidc:"},
+			rbac:         RBACInput{Role: "view", BindGroupClaim: "groups=developers"},
+			want: `apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: oidc-view-developers
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: view
+subjects:
+- apiGroup: rbac.authorization.k8s.io
+  kind: Group
+  name: oidc:developers
+`,
+		},
+	}
+	for name, c := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			c.rbac.Filename = filepath.Join(t.TempDir(), "rbac.yaml")
+			mockAuthentication := authentication_mock.NewMockInterface(t)
+			mockAuthentication.EXPECT().
+				Do(mock.Anything, mock.Anything).
+				Return(&authentication.Output{
+					TokenSet: oidc.TokenSet{IDToken: issuedIDToken},
+				}, nil)
+			u := Setup{
+				Authentication: mockAuthentication,
+				Logger:         logger.New(t),
+			}
+			if err := u.Do(ctx, Input{
+				IssuerURL:    "https://issuer.example.com",
+				ClientID:     "YOUR_CLIENT_ID",
+				ClaimMapping: c.claimMapping,
+				RBAC:         &c.rbac,
+			}); err != nil {
+				t.Fatalf("Do returned error: %+v", err)
+			}
+			got, err := os.ReadFile(c.rbac.Filename)
+			if err != nil {
+				t.Fatalf("could not read the file: %s", err)
+			}
+			if diff := cmp.Diff(c.want, string(got)); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("Apply", func(t *testing.T) {
+		ctx := context.Background()
+		mockAuthentication := authentication_mock.NewMockInterface(t)
+		mockAuthentication.EXPECT().
+			Do(mock.Anything, mock.Anything).
+			Return(&authentication.Output{
+				TokenSet: oidc.TokenSet{IDToken: issuedIDToken},
+			}, nil)
+		mockApplier := applier_mock.NewMockInterface(t)
+		mockApplier.EXPECT().
+			Apply(ctx, "/path/to/kubeconfig", mock.MatchedBy(func(obj *unstructured.Unstructured) bool {
+				return obj.GetKind() == "ClusterRoleBinding" && obj.GetName() == "oidc-cluster-admin-alice-example.com"
+			})).
+			Return(nil)
+		u := Setup{
+			Authentication: mockAuthentication,
+			RBACApplier:    mockApplier,
+			Logger:         logger.New(t),
+		}
+		if err := u.Do(ctx, Input{
+			IssuerURL: "https://issuer.example.com",
+			ClientID:  "YOUR_CLIENT_ID",
+			RBAC: &RBACInput{
+				Role:       "cluster-admin",
+				Apply:      true,
+				Kubeconfig: "/path/to/kubeconfig",
+			},
+		}); err != nil {
+			t.Fatalf("Do returned error: %+v", err)
+		}
+	})
+
+	t.Run("GroupWithoutValueHavingMultipleGroups", func(t *testing.T) {
+		mockAuthentication := authentication_mock.NewMockInterface(t)
+		mockAuthentication.EXPECT().
+			Do(mock.Anything, mock.Anything).
+			Return(&authentication.Output{
+				TokenSet: oidc.TokenSet{IDToken: issuedIDToken},
+			}, nil)
+		u := Setup{
+			Authentication: mockAuthentication,
+			Logger:         logger.New(t),
+		}
+		err := u.Do(context.Background(), Input{
+			IssuerURL: "https://issuer.example.com",
+			ClientID:  "YOUR_CLIENT_ID",
+			RBAC:      &RBACInput{Role: "cluster-admin", BindGroupClaim: "groups"},
+		})
+		if err == nil {
+			t.Fatalf("Do wants an error but was nil")
+		}
+		if !strings.Contains(err.Error(), "admins, developers") {
+			t.Errorf("error wants the list of groups but was %s", err)
+		}
+	})
+
+	t.Run("GroupsClaimMismatch", func(t *testing.T) {
+		u := Setup{
+			Authentication: authentication_mock.NewMockInterface(t),
+			Logger:         logger.New(t),
+		}
+		err := u.Do(context.Background(), Input{
+			IssuerURL:    "https://issuer.example.com",
+			ClientID:     "YOUR_CLIENT_ID",
+			ClaimMapping: ClaimMappingInput{GroupsClaim: "roles"},
+			RBAC:         &RBACInput{Role: "view", BindGroupClaim: "groups"},
+		})
+		if err == nil {
+			t.Errorf("Do wants an error but was nil")
+		}
+	})
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 
 	"github.com/google/wire"
+	"github.com/togethercomputer/together-kubelogin/pkg/audit"
+	auditrepository "github.com/togethercomputer/together-kubelogin/pkg/audit/repository"
 	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/clock"
 	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/logger"
 	"github.com/togethercomputer/together-kubelogin/pkg/kubeconfig"
@@ -32,6 +34,7 @@ type Input struct {
 	KubeconfigUser     kubeconfig.UserName    // Default to the user of the context
 	GrantOptionSet     authentication.GrantOptionSet
 	TLSClientConfig    tlsclientconfig.Config
+	AuditConfig        audit.Config // disabled by default
 }
 
 const oidcConfigErrorMessage = `No configuration found.
@@ -52,12 +55,15 @@ type Standalone struct {
 	Authentication   authentication.Interface
 	KubeconfigLoader loader.Interface
 	KubeconfigWriter writer.Interface
+	AuditRepository  auditrepository.Interface
 	Logger           logger.Interface
 	Clock            clock.Interface
 }
 
 func (u *Standalone) Do(ctx context.Context, in Input) error {
-	u.Logger.V(1).Infof("WARNING: log may contain your secrets such as token or password")
+	if u.Logger.IsUnredacted() {
+		u.Logger.V(1).Infof("WARNING: log may contain your secrets such as token or password")
+	}
 
 	authProvider, err := u.KubeconfigLoader.GetCurrentAuthProvider(in.KubeconfigFilename, in.KubeconfigContext, in.KubeconfigUser)
 	if err != nil {
@@ -90,6 +96,11 @@ func (u *Standalone) Do(ctx context.Context, in Input) error {
 		}
 		if !claims.IsExpired(u.Clock) {
 			u.Logger.V(1).Infof("you already have a valid token until %s", claims.Expiry)
+			u.audit(in, authProvider, audit.Record{
+				Event:   audit.EventCacheHit,
+				Subject: claims.Subject,
+				Expiry:  &claims.Expiry,
+			})
 			return nil
 		}
 	}
@@ -101,12 +112,17 @@ func (u *Standalone) Do(ctx context.Context, in Input) error {
 			ClientSecret: authProvider.ClientSecret,
 			ExtraScopes:  authProvider.ExtraScopes,
 		},
-		GrantOptionSet:  in.GrantOptionSet,
+		GrantOptionSet:  in.GrantOptionSet.WithCluster(string(authProvider.ContextName)),
 		CachedTokenSet:  cachedTokenSet,
 		TLSClientConfig: in.TLSClientConfig,
 	}
 	authenticationOutput, err := u.Authentication.Do(ctx, authenticationInput)
 	if err != nil {
+		u.audit(in, authProvider, audit.Record{
+			Event:      audit.EventFailure,
+			Grant:      in.GrantOptionSet.GrantType(),
+			ErrorClass: audit.ClassifyError(err),
+		})
 		return fmt.Errorf("authentication error: %w", err)
 	}
 
@@ -114,6 +130,16 @@ func (u *Standalone) Do(ctx context.Context, in Input) error {
 	if err != nil {
 		return fmt.Errorf("you got an invalid token: %w", err)
 	}
+	auditEvent := audit.EventInteractiveLogin
+	if authenticationOutput.Refreshed() {
+		auditEvent = audit.EventRefresh
+	}
+	u.audit(in, authProvider, audit.Record{
+		Event:   auditEvent,
+		Grant:   authenticationOutput.Grant,
+		Subject: idTokenClaims.Subject,
+		Expiry:  &idTokenClaims.Expiry,
+	})
 	u.Logger.V(1).Infof("you got a token: %s", idTokenClaims.Pretty)
 	u.Logger.Printf("You got a valid token until %s", idTokenClaims.Expiry)
 	authProvider.IDToken = authenticationOutput.TokenSet.IDToken
@@ -124,3 +150,18 @@ func (u *Standalone) Do(ctx context.Context, in Input) error {
 	}
 	return nil
 }
+
+// audit writes the record to the audit log if enabled.
+// It does not return an error, because the audit log should not block the authentication.
+func (u *Standalone) audit(in Input, authProvider *kubeconfig.AuthProvider, record audit.Record) {
+	if !in.AuditConfig.Enabled() {
+		return
+	}
+	record.Time = u.Clock.Now()
+	record.Issuer = authProvider.IDPIssuerURL
+	record.ClientID = authProvider.ClientID
+	record.Context = string(authProvider.ContextName)
+	if err := u.AuditRepository.Append(in.AuditConfig, record); err != nil {
+		u.Logger.Printf("could not write the audit log: %s", err)
+	}
+}
@@ -0,0 +1,97 @@
+// Package verifyauthn provides the use-case of evaluating the token cache
+// against the structured authentication configuration of kube-apiserver.
+package verifyauthn
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/wire"
+	"github.com/togethercomputer/together-kubelogin/pkg/authnconfig"
+	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/clock"
+	"github.com/togethercomputer/together-kubelogin/pkg/infrastructure/logger"
+	"github.com/togethercomputer/together-kubelogin/pkg/jwt"
+	"github.com/togethercomputer/together-kubelogin/pkg/oidc"
+	"github.com/togethercomputer/together-kubelogin/pkg/tlsclientconfig"
+	"github.com/togethercomputer/together-kubelogin/pkg/tokencache"
+	"github.com/togethercomputer/together-kubelogin/pkg/tokencache/repository"
+)
+
+var Set = wire.NewSet(
+	wire.Struct(new(VerifyAuthn), "*"),
+	wire.Bind(new(Interface), new(*VerifyAuthn)),
+)
+
+type Interface interface {
+	Do(ctx context.Context, in Input) error
+}
+
+// Input represents an input DTO of the VerifyAuthn use-case.
+// The token cache is looked up in the same way as the get-token command.
+type Input struct {
+	AuthenticationConfigFilename string
+	Provider                     oidc.Provider
+	TokenCacheConfig             tokencache.Config
+	TLSClientConfig              tlsclientconfig.Config
+	Username                     string // set if the password grant is used
+}
+
+// VerifyAuthn evaluates the cached token offline.
+// It reports the user which kube-apiserver would map from the token,
+// or the rule which rejects the token.
+type VerifyAuthn struct {
+	TokenCacheRepository repository.Interface
+	Logger               logger.Interface
+	Clock                clock.Interface
+}
+
+func (u *VerifyAuthn) Do(ctx context.Context, in Input) error {
+	c, err := authnconfig.Load(in.AuthenticationConfigFilename)
+	if err != nil {
+		return err
+	}
+
+	u.Logger.V(1).Infof("finding a token cache")
+	tokenCacheKey := tokencache.Key{
+		Provider:        in.Provider,
+		TLSClientConfig: in.TLSClientConfig,
+		Username:        in.Username,
+	}
+	tokenSet, err := u.TokenCacheRepository.FindByKey(in.TokenCacheConfig, tokenCacheKey)
+	if err != nil {
+		return fmt.Errorf("could not find a token cache (run get-token first): %w", err)
+	}
+	if tokenSet == nil {
+		return fmt.Errorf("no token cache found (run get-token first)")
+	}
+	claims, err := jwt.DecodePayloadAsMap(tokenSet.IDToken)
+	if err != nil {
+		return fmt.Errorf("invalid token cache: %w", err)
+	}
+
+	userInfo, err := authnconfig.Evaluate(*c, claims, u.Clock.Now())
+	if err != nil {
+		return fmt.Errorf("the token is rejected by %s: %w", in.AuthenticationConfigFilename, err)
+	}
+	u.Logger.Printf("The token is accepted by %s", in.AuthenticationConfigFilename)
+	u.Logger.Printf("%s", formatUserInfo(*userInfo))
+	return nil
+}
+
+func formatUserInfo(userInfo authnconfig.UserInfo) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "username: %s\n", userInfo.Username)
+	fmt.Fprintf(&b, "uid: %s\n", userInfo.UID)
+	fmt.Fprintf(&b, "groups: [%s]", strings.Join(userInfo.Groups, ", "))
+	keys := make([]string, 0, len(userInfo.Extra))
+	for k := range userInfo.Extra {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&b, "\nextra[%s]: [%s]", k, strings.Join(userInfo.Extra[k], ", "))
+	}
+	return b.String()
+}
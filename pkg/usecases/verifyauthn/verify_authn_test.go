@@ -0,0 +1,102 @@
+package verifyauthn
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/togethercomputer/together-kubelogin/mocks/github.com/togethercomputer/together-kubelogin/pkg/tokencache/repository_mock"
+	"github.com/togethercomputer/together-kubelogin/pkg/oidc"
+	"github.com/togethercomputer/together-kubelogin/pkg/testing/clock"
+	testingJWT "github.com/togethercomputer/together-kubelogin/pkg/testing/jwt"
+	"github.com/togethercomputer/together-kubelogin/pkg/testing/logger"
+	"github.com/togethercomputer/together-kubelogin/pkg/tokencache"
+)
+
+const authenticationConfigYAML = `apiVersion: apiserver.config.k8s.io/v1
+kind: AuthenticationConfiguration
+jwt:
+- issuer:
+    url: https://issuer.example.com
+    audiences:
+    - YOUR_CLIENT_ID
+  claimMappings:
+    username:
+      claim: email
+      prefix: ""
+  userValidationRules:
+  - expression: "!user.username.startsWith('system:')"
+    message: "username cannot use reserved system: prefix"
+`
+
+func TestVerifyAuthn_Do(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	filename := filepath.Join(t.TempDir(), "authentication-config.yaml")
+	if err := os.WriteFile(filename, []byte(authenticationConfigYAML), 0644); err != nil {
+		t.Fatalf("WriteFile error: %s", err)
+	}
+	in := Input{
+		AuthenticationConfigFilename: filename,
+		Provider: oidc.Provider{
+			IssuerURL: "https://issuer.example.com",
+			ClientID:  "YOUR_CLIENT_ID",
+		},
+		TokenCacheConfig: tokencache.Config{Directory: "/path/to/token-cache"},
+	}
+	tokenCacheKey := tokencache.Key{Provider: in.Provider}
+
+	t.Run("Accepted", func(t *testing.T) {
+		ctx := context.TODO()
+		idToken := testingJWT.EncodeF(t, func(claims *testingJWT.Claims) {
+			claims.Issuer = "https://issuer.example.com"
+			claims.Audience = []string{"YOUR_CLIENT_ID"}
+			claims.Subject = "YOUR_SUBJECT"
+			claims.Email = "alice@example.com"
+			claims.ExpiresAt = jwt.NewNumericDate(now.Add(time.Hour))
+		})
+		mockRepository := repository_mock.NewMockInterface(t)
+		mockRepository.EXPECT().
+			FindByKey(in.TokenCacheConfig, tokenCacheKey).
+			Return(&oidc.TokenSet{IDToken: idToken}, nil)
+		u := VerifyAuthn{
+			TokenCacheRepository: mockRepository,
+			Logger:               logger.New(t),
+			Clock:                clock.Fake(now),
+		}
+		if err := u.Do(ctx, in); err != nil {
+			t.Errorf("Do returned error: %+v", err)
+		}
+	})
+
+	t.Run("Rejected", func(t *testing.T) {
+		ctx := context.TODO()
+		idToken := testingJWT.EncodeF(t, func(claims *testingJWT.Claims) {
+			claims.Issuer = "https://issuer.example.com"
+			claims.Audience = []string{"YOUR_CLIENT_ID"}
+			claims.Subject = "YOUR_SUBJECT"
+			claims.Email = "system:admin"
+			claims.ExpiresAt = jwt.NewNumericDate(now.Add(time.Hour))
+		})
+		mockRepository := repository_mock.NewMockInterface(t)
+		mockRepository.EXPECT().
+			FindByKey(in.TokenCacheConfig, tokenCacheKey).
+			Return(&oidc.TokenSet{IDToken: idToken}, nil)
+		u := VerifyAuthn{
+			TokenCacheRepository: mockRepository,
+			Logger:               logger.New(t),
+			Clock:                clock.Fake(now),
+		}
+		err := u.Do(ctx, in)
+		if err == nil {
+			t.Fatalf("Do wants an error but was nil")
+		}
+		const want = "the token is rejected by %s: jwt[0].userValidationRules[0] (!user.username.startsWith('system:')): username cannot use reserved system: prefix"
+		if got := err.Error(); got != fmt.Sprintf(want, filename) {
+			t.Errorf("error wants %q but was %q", fmt.Sprintf(want, filename), got)
+		}
+	})
+}